@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL, -- sha256 of the plain token, the plain token is never stored
+    token_prefix VARCHAR(16) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    user_id UUID NULL,
+    service_account_id UUID NULL,
+    expires_at TIMESTAMP NULL,
+    last_used_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    modified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_by UUID NULL,
+    modified_by UUID NULL,
+    -- A token belongs either to a user or to a service account, never both
+    CONSTRAINT chk_token_owner CHECK ((user_id IS NULL) <> (service_account_id IS NULL))
+);
+
+ALTER TABLE personal_access_tokens
+    ADD CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+-- fk_service_account_id is added by the service accounts migration, which runs after this one
+
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id ON personal_access_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_service_account_id ON personal_access_tokens (service_account_id);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS service_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    team_id UUID NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    modified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_by UUID NULL,
+    modified_by UUID NULL
+);
+
+ALTER TABLE service_accounts
+    ADD CONSTRAINT fk_team_id FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE;
+
+ALTER TABLE service_accounts
+    ADD CONSTRAINT unique_service_account_name UNIQUE (team_id, name);
+
+-- The tokens of a service account are created in the personal access tokens table
+ALTER TABLE personal_access_tokens
+    ADD CONSTRAINT fk_service_account_id FOREIGN KEY (service_account_id) REFERENCES service_accounts(id) ON DELETE CASCADE;
@@ -0,0 +1,9 @@
+ALTER TABLE user_teams
+    ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'MEMBER' CHECK (role IN ('OWNER', 'ADMIN', 'MEMBER'));
+
+-- Existing teams are owned by their creator when it is known
+UPDATE user_teams ut
+SET role = 'OWNER'
+FROM teams t
+WHERE t.id = ut.team_id
+  AND t.created_by = ut.user_id;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS team_invitations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    team_id UUID NOT NULL,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT 'MEMBER' CHECK (role IN ('OWNER', 'ADMIN', 'MEMBER')),
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'ACCEPTED', 'DECLINED', 'REVOKED')),
+    token_hash TEXT UNIQUE NOT NULL, -- sha256 of the token sent by email
+    expires_at TIMESTAMP NOT NULL,
+    responded_at TIMESTAMP NULL,
+    responded_by UUID NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    modified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_by UUID NULL,
+    modified_by UUID NULL
+);
+
+ALTER TABLE team_invitations
+    ADD CONSTRAINT fk_team_id FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_responded_by FOREIGN KEY (responded_by) REFERENCES users(id) ON DELETE SET NULL;
+
+-- Only one pending invitation per email and team
+CREATE UNIQUE INDEX IF NOT EXISTS unique_pending_team_invitation ON team_invitations (team_id, LOWER(email)) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_team_invitations_email ON team_invitations (LOWER(email));
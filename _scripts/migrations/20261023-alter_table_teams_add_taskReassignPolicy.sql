@@ -0,0 +1,15 @@
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS task_reassign_policy TEXT NOT NULL DEFAULT 'UNASSIGN' CHECK (task_reassign_policy IN ('UNASSIGN', 'REASSIGN_TO_OWNER'));
+
+-- Removing a member from a team or deleting a user must not delete the tasks assigned to them
+ALTER TABLE tasks
+    DROP CONSTRAINT IF EXISTS fk_assigned_to;
+
+ALTER TABLE tasks
+    ADD CONSTRAINT fk_assigned_to FOREIGN KEY (assigned_to) REFERENCES users(id) ON DELETE SET NULL;
+
+-- Before roles every member could manage the team, keep that for teams without an owner
+UPDATE user_teams ut
+SET role = 'ADMIN'
+WHERE ut.role = 'MEMBER'
+  AND NOT EXISTS (SELECT 1 FROM user_teams o WHERE o.team_id = ut.team_id AND o.role = 'OWNER');
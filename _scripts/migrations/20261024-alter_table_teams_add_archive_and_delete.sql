@@ -0,0 +1,5 @@
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP NULL,
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS idx_teams_deleted_at ON teams (deleted_at) WHERE deleted_at IS NOT NULL;
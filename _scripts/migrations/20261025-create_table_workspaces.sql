@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS workspaces (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    modified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_by UUID NULL,
+    modified_by UUID NULL
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    role TEXT NOT NULL DEFAULT 'MEMBER' CHECK (role IN ('ADMIN', 'MEMBER')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+ALTER TABLE workspace_members
+    ADD CONSTRAINT fk_workspace_id FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user_id ON workspace_members (user_id);
+
+-- Existing data moves to a default workspace, team owners become its admins
+INSERT INTO workspaces (id, name)
+VALUES ('00000000-0000-0000-0000-000000000001', 'Default')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT
+    '00000000-0000-0000-0000-000000000001',
+    u.id,
+    CASE WHEN EXISTS (SELECT 1 FROM user_teams ut WHERE ut.user_id = u.id AND ut.role = 'OWNER') THEN 'ADMIN' ELSE 'MEMBER' END
+FROM users u
+ON CONFLICT (workspace_id, user_id) DO NOTHING;
+
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS workspace_id UUID NULL;
+
+UPDATE teams SET workspace_id = '00000000-0000-0000-0000-000000000001' WHERE workspace_id IS NULL;
+
+ALTER TABLE teams
+    ALTER COLUMN workspace_id SET NOT NULL,
+    ADD CONSTRAINT fk_workspace_id FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_teams_workspace_id ON teams (workspace_id);
@@ -0,0 +1,34 @@
+-- The API sets app.workspace_id on every connection it acquires from the workspace of the authenticated request.
+-- app.bypass_rls is only turned on by background jobs and by flows crossing workspaces such as accepting an invitation.
+-- FORCE makes the policies apply to the table owner the API connects with.
+CREATE OR REPLACE FUNCTION app_current_workspace() RETURNS UUID
+    LANGUAGE sql STABLE AS
+$$ SELECT NULLIF(current_setting('app.workspace_id', true), '')::UUID $$;
+
+CREATE OR REPLACE FUNCTION app_rls_bypassed() RETURNS BOOLEAN
+    LANGUAGE sql STABLE AS
+$$ SELECT COALESCE(current_setting('app.bypass_rls', true), '') = 'on' $$;
+
+ALTER TABLE teams ENABLE ROW LEVEL SECURITY;
+ALTER TABLE teams FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON teams
+    USING (app_rls_bypassed() OR workspace_id = app_current_workspace())
+    WITH CHECK (app_rls_bypassed() OR workspace_id = app_current_workspace());
+
+ALTER TABLE tasks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE tasks FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON tasks
+    USING (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()))
+    WITH CHECK (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()));
+
+ALTER TABLE user_teams ENABLE ROW LEVEL SECURITY;
+ALTER TABLE user_teams FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON user_teams
+    USING (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()))
+    WITH CHECK (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()));
+
+ALTER TABLE team_invitations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE team_invitations FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON team_invitations
+    USING (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()))
+    WITH CHECK (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()));
@@ -0,0 +1,11 @@
+-- Subtasks point to their parent task, deleting a parent promotes its subtasks to top level tasks
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS parent_id UUID NULL;
+
+ALTER TABLE tasks
+    ADD CONSTRAINT fk_parent_id FOREIGN KEY (parent_id) REFERENCES tasks(id) ON DELETE SET NULL;
+
+ALTER TABLE tasks
+    ADD CONSTRAINT chk_parent_id CHECK (parent_id <> id);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_id ON tasks (parent_id) WHERE parent_id IS NOT NULL;
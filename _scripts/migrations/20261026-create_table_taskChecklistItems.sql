@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS task_checklist_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL,
+    text TEXT NOT NULL,
+    is_done BOOLEAN DEFAULT FALSE NOT NULL,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    modified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_by UUID NULL,
+    modified_by UUID NULL
+);
+
+ALTER TABLE task_checklist_items
+    ADD CONSTRAINT fk_task_id FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_task_checklist_items_task_id ON task_checklist_items (task_id, position);
+
+-- Same workspace isolation as the tasks the items belong to
+ALTER TABLE task_checklist_items ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_checklist_items FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON task_checklist_items
+    USING (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks))
+    WITH CHECK (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks));
@@ -0,0 +1,24 @@
+-- task_id cannot start until blocked_by_task_id is done, both tasks belong to the same team
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    task_id UUID NOT NULL,
+    blocked_by_task_id UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_by UUID NULL,
+    PRIMARY KEY (task_id, blocked_by_task_id),
+    CHECK (task_id <> blocked_by_task_id)
+);
+
+ALTER TABLE task_dependencies
+    ADD CONSTRAINT fk_task_id FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+ALTER TABLE task_dependencies
+    ADD CONSTRAINT fk_blocked_by_task_id FOREIGN KEY (blocked_by_task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_task_dependencies_blocked_by_task_id ON task_dependencies (blocked_by_task_id);
+
+-- Same workspace isolation as the tasks the dependencies link
+ALTER TABLE task_dependencies ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_dependencies FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON task_dependencies
+    USING (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks))
+    WITH CHECK (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks));
@@ -0,0 +1,45 @@
+-- Labels are defined per team, names are unique within a team regardless of case
+CREATE TABLE IF NOT EXISTS labels (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    team_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    color VARCHAR(7) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    modified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_by UUID NULL,
+    modified_by UUID NULL
+);
+
+ALTER TABLE labels
+    ADD CONSTRAINT fk_team_id FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_labels_team_id_name ON labels (team_id, lower(name));
+
+CREATE TABLE IF NOT EXISTS task_labels (
+    task_id UUID NOT NULL,
+    label_id UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_by UUID NULL,
+    PRIMARY KEY (task_id, label_id)
+);
+
+ALTER TABLE task_labels
+    ADD CONSTRAINT fk_task_id FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+ALTER TABLE task_labels
+    ADD CONSTRAINT fk_label_id FOREIGN KEY (label_id) REFERENCES labels(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_task_labels_label_id ON task_labels (label_id);
+
+-- Same workspace isolation as the teams and tasks they belong to
+ALTER TABLE labels ENABLE ROW LEVEL SECURITY;
+ALTER TABLE labels FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON labels
+    USING (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()))
+    WITH CHECK (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()));
+
+ALTER TABLE task_labels ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_labels FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON task_labels
+    USING (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks))
+    WITH CHECK (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks));
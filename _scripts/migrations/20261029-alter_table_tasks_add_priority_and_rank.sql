@@ -0,0 +1,17 @@
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS priority TEXT NOT NULL DEFAULT 'NONE' CHECK (priority IN ('NONE', 'LOW', 'MEDIUM', 'HIGH', 'URGENT')),
+    ADD COLUMN IF NOT EXISTS rank TEXT COLLATE "C" NULL;
+
+-- Keep the current order of every column (newest first), keys are base 62 fractions that never end with 0
+UPDATE tasks t
+SET rank = r.rank
+FROM (
+    SELECT id, lpad(row_number() OVER (PARTITION BY team_id, status ORDER BY created_at DESC, id)::TEXT, 8, '0') || 'V' AS rank
+    FROM tasks
+) r
+WHERE t.id = r.id;
+
+ALTER TABLE tasks
+    ALTER COLUMN rank SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_team_id_status_rank ON tasks (team_id, status, rank);
@@ -0,0 +1,23 @@
+-- WARN lets a move exceed the WIP limit of a column and reports it, REJECT refuses the move
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS wip_limit_policy TEXT NOT NULL DEFAULT 'WARN' CHECK (wip_limit_policy IN ('WARN', 'REJECT'));
+
+-- Maximum number of tasks a board column of the team may hold, columns without a row are unlimited
+CREATE TABLE IF NOT EXISTS team_wip_limits (
+    team_id UUID NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('To Do', 'In Progress', 'Done')),
+    wip_limit INTEGER NOT NULL CHECK (wip_limit > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_by UUID NULL,
+    PRIMARY KEY (team_id, status)
+);
+
+ALTER TABLE team_wip_limits
+    ADD CONSTRAINT fk_team_id FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE;
+
+-- Same workspace isolation as the teams they belong to
+ALTER TABLE team_wip_limits ENABLE ROW LEVEL SECURITY;
+ALTER TABLE team_wip_limits FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON team_wip_limits
+    USING (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()))
+    WITH CHECK (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()));
@@ -0,0 +1,65 @@
+-- Time boxes of a team, a team runs at most one active sprint at a time
+CREATE TABLE IF NOT EXISTS sprints (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    team_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    goal TEXT NULL,
+    start_date TIMESTAMP NOT NULL,
+    end_date TIMESTAMP NOT NULL,
+    state TEXT NOT NULL DEFAULT 'PLANNED' CHECK (state IN ('PLANNED', 'ACTIVE', 'COMPLETED')),
+    started_at TIMESTAMP NULL,
+    completed_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    modified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_by UUID NULL,
+    modified_by UUID NULL,
+    CONSTRAINT chk_sprints_dates CHECK (end_date > start_date)
+);
+
+ALTER TABLE sprints
+    ADD CONSTRAINT fk_team_id FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_sprints_team_id_start_date ON sprints (team_id, start_date);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_sprints_team_id_active ON sprints (team_id) WHERE state = 'ACTIVE';
+
+-- Tasks without sprint are in the backlog of their team
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS sprint_id UUID NULL;
+
+ALTER TABLE tasks
+    ADD CONSTRAINT fk_sprint_id FOREIGN KEY (sprint_id) REFERENCES sprints(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_sprint_id ON tasks (sprint_id);
+
+-- History of the sprint scope used by the sprint report:
+-- COMMITTED when the sprint starts, ADDED and REMOVED while it runs, COMPLETED and CARRIED_OVER when it ends
+CREATE TABLE IF NOT EXISTS sprint_task_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sprint_id UUID NOT NULL,
+    task_id UUID NOT NULL,
+    event TEXT NOT NULL CHECK (event IN ('COMMITTED', 'ADDED', 'REMOVED', 'COMPLETED', 'CARRIED_OVER')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_by UUID NULL
+);
+
+ALTER TABLE sprint_task_events
+    ADD CONSTRAINT fk_sprint_id FOREIGN KEY (sprint_id) REFERENCES sprints(id) ON DELETE CASCADE;
+
+ALTER TABLE sprint_task_events
+    ADD CONSTRAINT fk_task_id FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_sprint_task_events_sprint_id ON sprint_task_events (sprint_id, created_at);
+
+-- Same workspace isolation as the teams and tasks they belong to
+ALTER TABLE sprints ENABLE ROW LEVEL SECURITY;
+ALTER TABLE sprints FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON sprints
+    USING (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()))
+    WITH CHECK (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()));
+
+ALTER TABLE sprint_task_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE sprint_task_events FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON sprint_task_events
+    USING (app_rls_bypassed() OR sprint_id IN (SELECT id FROM sprints))
+    WITH CHECK (app_rls_bypassed() OR sprint_id IN (SELECT id FROM sprints));
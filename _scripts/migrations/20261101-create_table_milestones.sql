@@ -0,0 +1,44 @@
+-- Milestones group the tasks of a team towards a target date
+CREATE TABLE IF NOT EXISTS milestones (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    team_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NULL,
+    target_date TIMESTAMP NOT NULL,
+    state TEXT NOT NULL DEFAULT 'OPEN' CHECK (state IN ('OPEN', 'COMPLETED')),
+    completed_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    modified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_by UUID NULL,
+    modified_by UUID NULL
+);
+
+ALTER TABLE milestones
+    ADD CONSTRAINT fk_team_id FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_milestones_team_id_target_date ON milestones (team_id, target_date);
+
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS milestone_id UUID NULL;
+
+ALTER TABLE tasks
+    ADD CONSTRAINT fk_milestone_id FOREIGN KEY (milestone_id) REFERENCES milestones(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_milestone_id ON tasks (milestone_id);
+
+-- Moment the task last reached "Done", the team throughput is measured from it
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP NULL;
+
+UPDATE tasks
+SET completed_at = modified_at
+WHERE status = 'Done' AND completed_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_team_id_completed_at ON tasks (team_id, completed_at) WHERE completed_at IS NOT NULL;
+
+-- Same workspace isolation as the team they belong to
+ALTER TABLE milestones ENABLE ROW LEVEL SECURITY;
+ALTER TABLE milestones FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON milestones
+    USING (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()))
+    WITH CHECK (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()));
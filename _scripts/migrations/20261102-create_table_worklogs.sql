@@ -0,0 +1,36 @@
+-- Estimates of a task, story points and the expected effort in seconds
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS story_points INT NULL CHECK (story_points >= 0),
+    ADD COLUMN IF NOT EXISTS original_estimate INT NULL CHECK (original_estimate >= 0);
+
+-- Time spent on tasks, a worklog without duration is a running timer
+CREATE TABLE IF NOT EXISTS worklogs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    duration INT NULL CHECK (duration >= 0), -- seconds
+    note TEXT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    modified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+ALTER TABLE worklogs
+    ADD CONSTRAINT fk_task_id FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+ALTER TABLE worklogs
+    ADD CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_worklogs_task_id ON worklogs (task_id);
+
+CREATE INDEX IF NOT EXISTS idx_worklogs_user_id_started_at ON worklogs (user_id, started_at);
+
+-- A user runs one timer at a time
+CREATE UNIQUE INDEX IF NOT EXISTS uq_worklogs_user_id_running ON worklogs (user_id) WHERE duration IS NULL;
+
+-- Same workspace isolation as the tasks they are logged on
+ALTER TABLE worklogs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE worklogs FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON worklogs
+    USING (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks))
+    WITH CHECK (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks));
@@ -0,0 +1,50 @@
+-- Recurring tasks, a series holds the task template and the iCalendar RRULE its occurrences follow
+CREATE TABLE IF NOT EXISTS task_series (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    team_id UUID NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NULL,
+    assigned_to UUID NULL,
+    priority TEXT NOT NULL DEFAULT 'NONE' CHECK (priority IN ('NONE', 'LOW', 'MEDIUM', 'HIGH', 'URGENT')),
+    story_points INT NULL CHECK (story_points >= 0),
+    original_estimate INT NULL CHECK (original_estimate >= 0),
+    rrule TEXT NOT NULL,
+    start_at TIMESTAMP NOT NULL,
+    last_occurrence_at TIMESTAMP NULL, -- latest occurrence a task was generated for
+    next_occurrence_at TIMESTAMP NULL, -- NULL once the rule has no occurrence left
+    ended_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    modified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_by UUID NULL,
+    modified_by UUID NULL
+);
+
+ALTER TABLE task_series
+    ADD CONSTRAINT fk_team_id FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE;
+
+ALTER TABLE task_series
+    ADD CONSTRAINT fk_assigned_to FOREIGN KEY (assigned_to) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_task_series_team_id ON task_series (team_id);
+
+-- The generator scans the series still running
+CREATE INDEX IF NOT EXISTS idx_task_series_next_occurrence_at ON task_series (next_occurrence_at) WHERE ended_at IS NULL;
+
+-- Tasks generated by a series, an exception was edited on its own and no longer follows the series edits
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS series_id UUID NULL,
+    ADD COLUMN IF NOT EXISTS occurrence_at TIMESTAMP NULL,
+    ADD COLUMN IF NOT EXISTS series_exception BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE tasks
+    ADD CONSTRAINT fk_series_id FOREIGN KEY (series_id) REFERENCES task_series(id) ON DELETE SET NULL;
+
+-- One task per occurrence, replicas generating the same occurrence insert it once
+CREATE UNIQUE INDEX IF NOT EXISTS uq_tasks_series_id_occurrence_at ON tasks (series_id, occurrence_at);
+
+-- Same workspace isolation as the team they belong to
+ALTER TABLE task_series ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_series FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON task_series
+    USING (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()))
+    WITH CHECK (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()));
@@ -0,0 +1,57 @@
+-- Reusable blueprints of the tasks of a team
+CREATE TABLE IF NOT EXISTS task_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    team_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    title_pattern TEXT NOT NULL, -- placeholders such as {date} are filled when a task is created
+    description TEXT NULL,
+    checklist TEXT[] NOT NULL DEFAULT '{}', -- texts of the checklist items in order
+    assigned_to UUID NULL,
+    due_offset INT NULL CHECK (due_offset >= 0), -- seconds between the creation and the due date of the task
+    priority TEXT NOT NULL DEFAULT 'NONE' CHECK (priority IN ('NONE', 'LOW', 'MEDIUM', 'HIGH', 'URGENT')),
+    story_points INT NULL CHECK (story_points >= 0),
+    original_estimate INT NULL CHECK (original_estimate >= 0),
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    modified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_by UUID NULL,
+    modified_by UUID NULL
+);
+
+ALTER TABLE task_templates
+    ADD CONSTRAINT fk_team_id FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE;
+
+ALTER TABLE task_templates
+    ADD CONSTRAINT fk_assigned_to FOREIGN KEY (assigned_to) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_task_templates_team_id_name ON task_templates (team_id, lower(name));
+
+-- A team has at most one default template
+CREATE UNIQUE INDEX IF NOT EXISTS uq_task_templates_team_id_default ON task_templates (team_id) WHERE is_default;
+
+CREATE TABLE IF NOT EXISTS task_template_labels (
+    template_id UUID NOT NULL,
+    label_id UUID NOT NULL,
+    PRIMARY KEY (template_id, label_id)
+);
+
+ALTER TABLE task_template_labels
+    ADD CONSTRAINT fk_template_id FOREIGN KEY (template_id) REFERENCES task_templates(id) ON DELETE CASCADE;
+
+ALTER TABLE task_template_labels
+    ADD CONSTRAINT fk_label_id FOREIGN KEY (label_id) REFERENCES labels(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_task_template_labels_label_id ON task_template_labels (label_id);
+
+-- Same workspace isolation as the team they belong to
+ALTER TABLE task_templates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_templates FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON task_templates
+    USING (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()))
+    WITH CHECK (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()));
+
+ALTER TABLE task_template_labels ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_template_labels FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON task_template_labels
+    USING (app_rls_bypassed() OR template_id IN (SELECT id FROM task_templates))
+    WITH CHECK (app_rls_bypassed() OR template_id IN (SELECT id FROM task_templates));
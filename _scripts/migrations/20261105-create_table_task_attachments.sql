@@ -0,0 +1,47 @@
+-- Files attached to tasks, the content is kept in the blob store under storage_key
+CREATE TABLE IF NOT EXISTS task_attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL, -- sniffed from the content, not taken from the client
+    size INT NOT NULL CHECK (size >= 0), -- bytes
+    storage_key TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_by UUID NULL
+);
+
+ALTER TABLE task_attachments
+    ADD CONSTRAINT fk_task_id FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_task_attachments_storage_key ON task_attachments (storage_key);
+
+CREATE INDEX IF NOT EXISTS idx_task_attachments_task_id ON task_attachments (task_id, created_at);
+
+-- Same workspace isolation as the tasks they are attached to
+ALTER TABLE task_attachments ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_attachments FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON task_attachments
+    USING (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks))
+    WITH CHECK (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks));
+
+-- Blobs left behind by deleted attachments, removed from the blob store by the attachment cleanup job.
+-- The trigger also queues the attachments deleted along with their task or team.
+-- Only opaque keys are stored, the table is not restricted by workspace
+CREATE TABLE IF NOT EXISTS blob_deletions (
+    storage_key TEXT PRIMARY KEY,
+    queued_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    attempts INT NOT NULL DEFAULT 0
+);
+
+CREATE OR REPLACE FUNCTION queue_attachment_blob_deletion() RETURNS TRIGGER
+    LANGUAGE plpgsql AS
+$$
+BEGIN
+    INSERT INTO blob_deletions (storage_key) VALUES (OLD.storage_key) ON CONFLICT DO NOTHING;
+    RETURN OLD;
+END;
+$$;
+
+CREATE TRIGGER trg_task_attachments_blob_deletion
+    AFTER DELETE ON task_attachments
+    FOR EACH ROW EXECUTE FUNCTION queue_attachment_blob_deletion();
@@ -0,0 +1,86 @@
+-- Discussion on a task, the author is kept as NULL once their account is removed
+CREATE TABLE IF NOT EXISTS task_comments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL,
+    user_id UUID NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    modified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+ALTER TABLE task_comments
+    ADD CONSTRAINT fk_task_id FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+ALTER TABLE task_comments
+    ADD CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_task_comments_task_id ON task_comments (task_id, created_at);
+
+-- Team members referenced with @name or @email in the description of a task or in one of its comments
+CREATE TABLE IF NOT EXISTS task_mentions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL,
+    comment_id UUID NULL, -- NULL for the description
+    user_id UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_by UUID NULL
+);
+
+ALTER TABLE task_mentions
+    ADD CONSTRAINT fk_task_id FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+ALTER TABLE task_mentions
+    ADD CONSTRAINT fk_comment_id FOREIGN KEY (comment_id) REFERENCES task_comments(id) ON DELETE CASCADE;
+
+ALTER TABLE task_mentions
+    ADD CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+-- A user is mentioned once per description and once per comment
+CREATE UNIQUE INDEX IF NOT EXISTS uq_task_mentions_description ON task_mentions (task_id, user_id) WHERE comment_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_task_mentions_comment ON task_mentions (comment_id, user_id) WHERE comment_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_task_mentions_user_id ON task_mentions (user_id, created_at DESC);
+
+-- In-app notifications, one row per recipient
+CREATE TABLE IF NOT EXISTS notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    kind VARCHAR(30) NOT NULL CHECK (kind IN ('MENTIONED')),
+    task_id UUID NOT NULL,
+    comment_id UUID NULL,
+    actor_id UUID NULL, -- user whose action triggered the notification
+    read_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+ALTER TABLE notifications
+    ADD CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT fk_task_id FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT fk_comment_id FOREIGN KEY (comment_id) REFERENCES task_comments(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications (user_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id_unread ON notifications (user_id) WHERE read_at IS NULL;
+
+-- Same workspace isolation as the tasks they belong to
+ALTER TABLE task_comments ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_comments FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON task_comments
+    USING (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks))
+    WITH CHECK (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks));
+
+ALTER TABLE task_mentions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_mentions FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON task_mentions
+    USING (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks))
+    WITH CHECK (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks));
+
+ALTER TABLE notifications ENABLE ROW LEVEL SECURITY;
+ALTER TABLE notifications FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON notifications
+    USING (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks))
+    WITH CHECK (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks));
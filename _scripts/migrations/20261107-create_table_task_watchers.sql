@@ -0,0 +1,56 @@
+-- Users notified of the changes of a task
+CREATE TABLE IF NOT EXISTS task_watchers (
+    task_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (task_id, user_id)
+);
+
+ALTER TABLE task_watchers
+    ADD CONSTRAINT fk_task_id FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+ALTER TABLE task_watchers
+    ADD CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_task_watchers_user_id ON task_watchers (user_id);
+
+-- Existing tasks are watched by the people involved so far
+INSERT INTO task_watchers (task_id, user_id)
+SELECT t.id, t.created_by FROM tasks t JOIN users u ON u.id = t.created_by
+UNION
+SELECT t.id, t.assigned_to FROM tasks t WHERE t.assigned_to IS NOT NULL
+UNION
+SELECT c.task_id, c.user_id FROM task_comments c WHERE c.user_id IS NOT NULL
+UNION
+SELECT m.task_id, m.user_id FROM task_mentions m
+ON CONFLICT DO NOTHING;
+
+-- Channels of each kind of notification chosen by a user, kinds without a row use the defaults of the API
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id UUID NOT NULL,
+    kind VARCHAR(30) NOT NULL,
+    in_app BOOLEAN NOT NULL,
+    email BOOLEAN NOT NULL,
+    modified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, kind)
+);
+
+ALTER TABLE notification_preferences
+    ADD CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+-- Watchers are notified of updates, moves and comments
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_kind_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_kind_check CHECK (kind IN ('MENTIONED', 'TASK_UPDATED', 'TASK_MOVED', 'COMMENTED'));
+
+ALTER TABLE notification_preferences
+    ADD CONSTRAINT notification_preferences_kind_check CHECK (kind IN ('MENTIONED', 'TASK_UPDATED', 'TASK_MOVED', 'COMMENTED'));
+
+-- Same workspace isolation as the tasks they belong to
+ALTER TABLE task_watchers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_watchers FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON task_watchers
+    USING (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks))
+    WITH CHECK (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks));
@@ -0,0 +1,6 @@
+-- Deleted tasks stay in the trash of their team until restored or purged by the retention job
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL,
+    ADD COLUMN IF NOT EXISTS deleted_by UUID NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_trash ON tasks (team_id, deleted_at) WHERE deleted_at IS NOT NULL;
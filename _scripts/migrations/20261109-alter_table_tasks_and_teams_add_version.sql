@@ -0,0 +1,6 @@
+-- Incremented on each write, clients send the version they edited to detect concurrent changes
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS version INT DEFAULT 1 NOT NULL;
+
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS version INT DEFAULT 1 NOT NULL;
@@ -0,0 +1,11 @@
+-- Responses of the mutations sent with an Idempotency-Key, replayed to the retries of the same request
+-- until they expire. The response stays NULL while the first request is running
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key TEXT PRIMARY KEY,
+    fingerprint VARCHAR(64) NOT NULL,
+    response BYTEA,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
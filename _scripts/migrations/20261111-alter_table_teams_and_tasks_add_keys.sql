@@ -0,0 +1,73 @@
+-- Human-readable task keys such as ENG-142, the key prefix of the team followed by the number of the task in the team.
+-- task_counter holds the last number given, creates increment it under the row lock of the team
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS key VARCHAR(10) NULL,
+    ADD COLUMN IF NOT EXISTS task_counter INT DEFAULT 0 NOT NULL;
+
+-- Existing teams get the first three letters of their name like the API does for new teams,
+-- teams of a workspace sharing them are numbered
+UPDATE teams t
+SET key = k.key
+FROM (
+    SELECT id, prefix || CASE WHEN row_number() OVER w > 1 THEN (row_number() OVER w)::TEXT ELSE '' END AS key
+    FROM (
+        SELECT id, workspace_id, created_at,
+               CASE WHEN length(letters) >= 2 THEN LEFT(letters, 3) ELSE 'TEAM' END AS prefix
+        FROM (SELECT id, workspace_id, created_at, regexp_replace(UPPER("name"), '[^A-Z]', '', 'g') AS letters FROM teams) l
+    ) p
+    WINDOW w AS (PARTITION BY workspace_id, prefix ORDER BY created_at, id)
+) k
+WHERE t.id = k.id;
+
+ALTER TABLE teams
+    ALTER COLUMN key SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_teams_workspace_id_key ON teams (workspace_id, key);
+
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS number INT NULL;
+
+-- Keep the creation order of the existing tasks of every team
+UPDATE tasks t
+SET number = n.number
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY team_id ORDER BY created_at, id) AS number
+    FROM tasks
+) n
+WHERE t.id = n.id;
+
+UPDATE teams t
+SET task_counter = c.task_counter
+FROM (
+    SELECT team_id, MAX(number) AS task_counter FROM tasks GROUP BY team_id
+) c
+WHERE t.id = c.team_id;
+
+ALTER TABLE tasks
+    ALTER COLUMN number SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_team_id_number ON tasks (team_id, number);
+
+-- Former keys of the tasks moved to another team, so links to the old key keep working
+CREATE TABLE IF NOT EXISTS task_key_aliases (
+    team_id UUID NOT NULL,
+    number INT NOT NULL,
+    task_id UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (team_id, number)
+);
+
+ALTER TABLE task_key_aliases
+    ADD CONSTRAINT fk_team_id FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE;
+
+ALTER TABLE task_key_aliases
+    ADD CONSTRAINT fk_task_id FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_task_key_aliases_task_id ON task_key_aliases (task_id);
+
+-- Same workspace isolation as the tasks they belong to
+ALTER TABLE task_key_aliases ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_key_aliases FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON task_key_aliases
+    USING (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks))
+    WITH CHECK (app_rls_bypassed() OR task_id IN (SELECT id FROM tasks));
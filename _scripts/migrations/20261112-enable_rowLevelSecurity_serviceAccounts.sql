@@ -0,0 +1,13 @@
+-- Service accounts belong to a team, their tokens follow the workspace of that team.
+-- Tokens of users are not tied to a workspace, they are always looked up by their owner or by their hash.
+ALTER TABLE service_accounts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE service_accounts FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON service_accounts
+    USING (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()))
+    WITH CHECK (app_rls_bypassed() OR team_id IN (SELECT id FROM teams WHERE workspace_id = app_current_workspace()));
+
+ALTER TABLE personal_access_tokens ENABLE ROW LEVEL SECURITY;
+ALTER TABLE personal_access_tokens FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON personal_access_tokens
+    USING (app_rls_bypassed() OR user_id IS NOT NULL OR service_account_id IN (SELECT id FROM service_accounts))
+    WITH CHECK (app_rls_bypassed() OR user_id IS NOT NULL OR service_account_id IN (SELECT id FROM service_accounts));
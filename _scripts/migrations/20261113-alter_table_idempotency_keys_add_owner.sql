@@ -0,0 +1,4 @@
+-- Token of the request holding the key, a request taken over after the pending timeout can no longer
+-- complete or release the key of the request that replaced it
+ALTER TABLE idempotency_keys
+    ADD COLUMN IF NOT EXISTS owner VARCHAR(64) NOT NULL DEFAULT '';
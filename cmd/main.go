@@ -1,22 +1,28 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	_config "bitbucket.org/edts/go-task-management/config"
 	_db "bitbucket.org/edts/go-task-management/internal/db"
 	"bitbucket.org/edts/go-task-management/internal/graph/_generated"
 	_directives "bitbucket.org/edts/go-task-management/internal/graph/directives"
+	_extensions "bitbucket.org/edts/go-task-management/internal/graph/extensions"
 	_dl "bitbucket.org/edts/go-task-management/internal/graph/loaders"
 	_resolver "bitbucket.org/edts/go-task-management/internal/graph/resolver"
+	_handler "bitbucket.org/edts/go-task-management/internal/handler"
 	_mw "bitbucket.org/edts/go-task-management/internal/middleware"
 	_pubsub "bitbucket.org/edts/go-task-management/internal/pubsub"
 	_repo "bitbucket.org/edts/go-task-management/internal/repository"
 	_usecase "bitbucket.org/edts/go-task-management/internal/usecase"
 	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"bitbucket.org/edts/go-task-management/pkg/idempotency"
 	_logger "bitbucket.org/edts/go-task-management/pkg/logger"
+	"bitbucket.org/edts/go-task-management/pkg/ratelimit"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/extension"
 	"github.com/99designs/gqlgen/graphql/handler/lru"
@@ -42,6 +48,149 @@ func defaultTranslation() {
 	//_directives.ValidateAddTranslation("email", " not a valid email (custom message)")
 }
 
+// newRateLimiter builds the limiter on the configured store, the postgres store is shared between replicas
+func newRateLimiter(dbConn *_db.Database) *ratelimit.Limiter {
+	cfg := _config.AppConfigInstance.RateLimit
+
+	rules := make(map[string]ratelimit.Rule, len(cfg.Rules))
+	for name, rule := range cfg.Rules {
+		rules[name] = ratelimit.PerMinute(rule.RequestsPerMinute, rule.Burst)
+	}
+
+	var store ratelimit.Store
+	switch cfg.Store {
+	case "postgres":
+		pgStore := ratelimit.NewPostgresStore(dbConn.Pool)
+		// Drop idle buckets once in a while so the table does not grow forever
+		go func() {
+			for range time.Tick(time.Hour) {
+				if err := pgStore.Purge(context.Background(), 24*time.Hour); err != nil {
+					logs.Errorf("newRateLimiter:: Error purging rate limit buckets: %v", err)
+				}
+			}
+		}()
+		store = pgStore
+	default:
+		store = ratelimit.NewMemoryStore()
+	}
+
+	return ratelimit.NewLimiter(store, rules)
+}
+
+// newIdempotencyKeeper builds the Idempotency-Key keeper on the configured store, the postgres store is shared between replicas
+func newIdempotencyKeeper(dbConn *_db.Database) *idempotency.Keeper {
+	cfg := _config.AppConfigInstance.Idempotency
+
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	var store idempotency.Store
+	switch cfg.Store {
+	case "postgres":
+		pgStore := idempotency.NewPostgresStore(dbConn.Pool)
+		// Drop expired keys once in a while so the table does not grow forever
+		go func() {
+			for range time.Tick(time.Hour) {
+				if err := pgStore.Purge(context.Background()); err != nil {
+					logs.Errorf("newIdempotencyKeeper:: Error purging idempotency keys: %v", err)
+				}
+			}
+		}()
+		store = pgStore
+	default:
+		store = idempotency.NewMemoryStore()
+	}
+
+	return idempotency.NewKeeper(store, ttl)
+}
+
+// startTeamPurge hard deletes the teams whose deletion grace period is over,
+// each replica runs it and the repository skips teams another replica is purging
+func startTeamPurge(teamUc _usecase.TeamUsecaseInterface) {
+	interval := _config.AppConfigInstance.Team.PurgeInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	go func() {
+		for range time.Tick(interval) {
+			if _, err := teamUc.PurgeDeletedTeams(context.Background()); err != nil {
+				logs.Errorf("startTeamPurge:: Error purging deleted teams: %v", err)
+			}
+		}
+	}()
+}
+
+// startRankRebalance respaces the task columns whose rank keys grew too long,
+// replicas running it at the same time wait on the column lock and respacing twice is harmless
+func startRankRebalance(taskUc _usecase.TaskUsecaseInterface) {
+	interval := _config.AppConfigInstance.Task.RankRebalanceInterval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	go func() {
+		for range time.Tick(interval) {
+			if _, err := taskUc.RebalanceTaskRanks(context.Background()); err != nil {
+				logs.Errorf("startRankRebalance:: Error rebalancing task ranks: %v", err)
+			}
+		}
+	}()
+}
+
+// startRecurringTasks creates the due occurrences of the recurring task series,
+// replicas skip the series another replica is generating and an occurrence is never created twice
+func startRecurringTasks(taskSeriesUc _usecase.TaskSeriesUsecaseInterface) {
+	interval := _config.AppConfigInstance.Task.RecurrenceInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	go func() {
+		for range time.Tick(interval) {
+			if _, err := taskSeriesUc.GenerateTaskOccurrences(context.Background()); err != nil {
+				logs.Errorf("startRecurringTasks:: Error generating recurring tasks: %v", err)
+			}
+		}
+	}()
+}
+
+// startTrashPurge hard deletes the tasks whose trash retention is over,
+// replicas skip the tasks another replica is purging
+func startTrashPurge(taskUc _usecase.TaskUsecaseInterface) {
+	interval := _config.AppConfigInstance.Task.TrashPurgeInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	go func() {
+		for range time.Tick(interval) {
+			if _, err := taskUc.PurgeTrash(context.Background()); err != nil {
+				logs.Errorf("startTrashPurge:: Error purging the task trash: %v", err)
+			}
+		}
+	}()
+}
+
+// startAttachmentCleanup removes from the blob store the content of the deleted attachments,
+// replicas skip the blobs another replica is removing
+func startAttachmentCleanup(attachmentUc _usecase.AttachmentUsecaseInterface) {
+	interval := _config.AppConfigInstance.Attachment.CleanupInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	go func() {
+		for range time.Tick(interval) {
+			if _, err := attachmentUc.CleanupDeletedBlobs(context.Background()); err != nil {
+				logs.Errorf("startAttachmentCleanup:: Error removing deleted attachments: %v", err)
+			}
+		}
+	}()
+}
+
 func main() {
 	// Init db connection
 	dbConn, err := _db.NewDatabase(&_config.AppConfigInstance.Database)
@@ -62,12 +211,25 @@ func main() {
 	dataloader := _dl.NewLoaders(repo)
 	resolver := _resolver.NewResolver(uc, dataloader)
 
+	// Background jobs
+	startTeamPurge(uc.TeamUsecase)
+	startRankRebalance(uc.TaskUsecase)
+	startRecurringTasks(uc.TaskSeriesUsecase)
+	startTrashPurge(uc.TaskUsecase)
+	startAttachmentCleanup(uc.AttachmentUsecase)
+
 	// Generated config
 	genConf := _generated.Config{Resolvers: resolver}
 	// Use directives binding for validator
 	genConf.Directives.Binding = _directives.Binding
-
-	//TODO: add AuthDirective
+	// Use auth directive, accepts JWTs and personal access tokens
+	genConf.Directives.Auth = _directives.AuthDirective(uc.AccessTokenUsecase, uc.WorkspaceUsecase)
+	// Use rate limit directive for the unauthenticated mutations
+	trustedProxies, err := _mw.ParseTrustedProxies(_config.AppConfigInstance.RateLimit.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Rate limit configuration error: %v", err)
+	}
+	genConf.Directives.RateLimit = _directives.RateLimitDirective(newRateLimiter(dbConn), trustedProxies)
 
 	// Init GraphQL server
 	srv := handler.New(_generated.NewExecutableSchema(genConf))
@@ -78,6 +240,11 @@ func main() {
 	srv.AddTransport(transport.Options{})
 	srv.AddTransport(transport.GET{})
 	srv.AddTransport(transport.POST{})
+	// File uploads, with room for the operations and map fields. The attachment usecase checks the size of the file itself
+	srv.AddTransport(transport.MultipartForm{
+		MaxUploadSize: _usecase.GetAttachmentMaxSize() + 1<<20,
+		MaxMemory:     32 << 20,
+	})
 
 	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
 
@@ -85,6 +252,8 @@ func main() {
 	srv.Use(extension.AutomaticPersistedQuery{
 		Cache: lru.New[string](100),
 	})
+	// Replay the create mutations retried with the same Idempotency-Key
+	srv.Use(_extensions.NewIdempotency(newIdempotencyKeeper(dbConn), uc.AccessTokenUsecase))
 
 	// Subscriptions handler
 	subscriptionSrv := handler.New(_generated.NewExecutableSchema(genConf))
@@ -93,6 +262,7 @@ func main() {
 		Upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool { return true },
 		},
+		InitFunc: websocketInit,
 	})
 	subscriptionSrv.AddTransport(transport.SSE{})
 
@@ -107,6 +277,34 @@ func main() {
 	http.Handle("/graphql", _mw.RequestMiddleware(_mw.CORSHandler(_dl.Middleware(repo, srv))))
 	http.Handle("/subscription", _mw.RequestMiddleware(_mw.CORSHandler(_dl.Middleware(repo, subscriptionSrv))))
 
+	// Downloads of the attachments kept by stores that do not sign their own URLs
+	attachmentHandler := _handler.NewAttachmentHandler(uc.AttachmentUsecase)
+	http.HandleFunc("/attachments/download", attachmentHandler.Download)
+
+	// OIDC single sign-on routes
+	if oidcCfg := _config.AppConfigInstance.OIDC; oidcCfg.Enabled {
+		oidcHandler := _handler.NewOIDCHandler(uc.OIDCUsecase, oidcCfg.SuccessRedirectURL, strings.HasPrefix(oidcCfg.RedirectURL, "https://"))
+		http.HandleFunc("/auth/oidc/login", oidcHandler.Login)
+		http.HandleFunc("/auth/oidc/callback", oidcHandler.Callback)
+	}
+
 	logs.Infof("connect to http://localhost:%s/ for GraphQL playground", port)
 	log.Fatal(http.ListenAndServe(":"+port, nil))
 }
+
+// websocketInit lets browser clients, which cannot set headers on a websocket, send the
+// credentials and the workspace in the connection_init payload instead
+func websocketInit(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+	req, ok := ctx.Value("httpRequest").(*http.Request)
+	if !ok {
+		return ctx, &payload, nil
+	}
+
+	if authorization := payload.Authorization(); authorization != "" && req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	if workspaceID := payload.GetString(_directives.WorkspaceHeader); workspaceID != "" && req.Header.Get(_directives.WorkspaceHeader) == "" {
+		req.Header.Set(_directives.WorkspaceHeader, workspaceID)
+	}
+	return ctx, &payload, nil
+}
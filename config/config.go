@@ -3,6 +3,7 @@ package config
 import (
 	_logger "bitbucket.org/edts/go-task-management/pkg/logger"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -12,9 +13,18 @@ var logs = _logger.GetContextLoggerf(nil)
 
 // Config structure for the application
 type Config struct {
-	App      AppConfig      `mapstructure:"app"`
-	Database DatabaseConfig `mapstructure:"database"`
-	JWT      JWT            `mapstructure:"jwt"`
+	App         AppConfig         `mapstructure:"app"`
+	Database    DatabaseConfig    `mapstructure:"database"`
+	JWT         JWT               `mapstructure:"jwt"`
+	Login       LoginConfig       `mapstructure:"login"`
+	RateLimit   RateLimitConfig   `mapstructure:"rate_limit"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+	OIDC        OIDCConfig        `mapstructure:"oidc"`
+	Mail        MailConfig        `mapstructure:"mail"`
+	Invitation  InvitationConfig  `mapstructure:"invitation"`
+	Team        TeamConfig        `mapstructure:"team"`
+	Task        TaskConfig        `mapstructure:"task"`
+	Attachment  AttachmentConfig  `mapstructure:"attachment"`
 }
 
 // AppConfig holds application-related settings
@@ -38,6 +48,100 @@ type JWT struct {
 	RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl"`
 }
 
+// LoginConfig holds brute-force protection settings
+type LoginConfig struct {
+	MaxFailedAttempts int           `mapstructure:"max_failed_attempts"`
+	LockoutDuration   time.Duration `mapstructure:"lockout_duration"`
+}
+
+// RateLimitConfig holds per-IP rate limiting settings
+type RateLimitConfig struct {
+	Store          string                   `mapstructure:"store"`           // "memory" or "postgres"
+	TrustedProxies []string                 `mapstructure:"trusted_proxies"` // CIDR ranges of the proxies allowed to set X-Forwarded-For
+	Rules          map[string]RateLimitRule `mapstructure:"rules"`
+}
+
+// RateLimitRule holds a token bucket setting
+type RateLimitRule struct {
+	RequestsPerMinute float64 `mapstructure:"requests_per_minute"`
+	Burst             int     `mapstructure:"burst"`
+}
+
+// IdempotencyConfig holds the settings of the Idempotency-Key replays
+type IdempotencyConfig struct {
+	Store string        `mapstructure:"store"` // "memory" or "postgres"
+	TTL   time.Duration `mapstructure:"ttl"`   // how long a response is replayed to the retries of its request
+}
+
+// OIDCConfig holds OpenID Connect single sign-on settings
+type OIDCConfig struct {
+	Enabled            bool     `mapstructure:"enabled"`
+	IssuerURL          string   `mapstructure:"issuer_url"`
+	ClientID           string   `mapstructure:"client_id"`
+	ClientSecret       string   `mapstructure:"client_secret"`
+	RedirectURL        string   `mapstructure:"redirect_url"`
+	Scopes             []string `mapstructure:"scopes"`
+	SuccessRedirectURL string   `mapstructure:"success_redirect_url"`
+}
+
+// MailConfig holds outgoing email settings
+type MailConfig struct {
+	Driver   string `mapstructure:"driver"` // "log" or "smtp"
+	From     string `mapstructure:"from"`
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// InvitationConfig holds team invitation settings
+type InvitationConfig struct {
+	TTL       time.Duration `mapstructure:"ttl"`
+	AcceptURL string        `mapstructure:"accept_url"`
+}
+
+// TeamConfig holds team lifecycle settings
+type TeamConfig struct {
+	DeletionGracePeriod time.Duration `mapstructure:"deletion_grace_period"`
+	PurgeInterval       time.Duration `mapstructure:"purge_interval"`
+}
+
+// TaskConfig holds task ordering, planning, recurrence and trash settings
+type TaskConfig struct {
+	RankMaxLength         int           `mapstructure:"rank_max_length"`
+	RankRebalanceInterval time.Duration `mapstructure:"rank_rebalance_interval"`
+	ThroughputWindow      time.Duration `mapstructure:"throughput_window"`
+	RecurrenceInterval    time.Duration `mapstructure:"recurrence_interval"`
+	TrashRetention        time.Duration `mapstructure:"trash_retention"`
+	TrashPurgeInterval    time.Duration `mapstructure:"trash_purge_interval"`
+}
+
+// AttachmentConfig holds task attachment storage and limits settings
+type AttachmentConfig struct {
+	Driver          string        `mapstructure:"driver"` // "local" or "s3"
+	LocalDir        string        `mapstructure:"local_dir"`
+	S3              S3Config      `mapstructure:"s3"`
+	MaxSize         int64         `mapstructure:"max_size"` // bytes
+	AllowedTypes    []string      `mapstructure:"allowed_types"`
+	URLTTL          time.Duration `mapstructure:"url_ttl"`
+	SigningKey      string        `mapstructure:"signing_key"` // required, shared by every replica
+	DownloadURL     string        `mapstructure:"download_url"`
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`
+}
+
+// S3Config holds the settings of an S3 compatible bucket
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	PathStyle bool   `mapstructure:"path_style"`
+}
+
+// minSigningKeyLength matches the size of the HMAC-SHA256 output
+const minSigningKeyLength = 32
+
 // Global variable to store the loaded config
 var AppConfigInstance Config
 
@@ -48,7 +152,8 @@ func LoadConfig() {
 	// Search for config.yaml in the config root directory
 	viper.AddConfigPath(".")
 
-	// Override settings with env var if available
+	// Override settings with env var if available, nested keys use underscores such as ATTACHMENT_SIGNING_KEY
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
 
 	if err := viper.ReadInConfig(); err != nil {
@@ -59,5 +164,10 @@ func LoadConfig() {
 		log.Fatalf("Error parsing config file: %v", err)
 	}
 
+	// Download links signed with a key only one process knows would break on restart and across replicas
+	if len(AppConfigInstance.Attachment.SigningKey) < minSigningKeyLength {
+		log.Fatalf("Error in config file: attachment.signing_key must be set to at least %d characters", minSigningKeyLength)
+	}
+
 	logs.Info("✅ Config loaded successfully!")
 }
@@ -4,4 +4,6 @@ const (
 	CREATED = "created"
 	UPDATED = "updated"
 	DELETED = "deleted"
+	PURGED  = "purged" // Task deleted permanently
+	BATCH   = "batch"  // Tasks changed by a bulk operation
 )
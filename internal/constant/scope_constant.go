@@ -0,0 +1,16 @@
+package constant
+
+// Personal access token scopes
+const (
+	SCOPE_READ_TASKS  = "read:tasks"
+	SCOPE_WRITE_TASKS = "write:tasks"
+	SCOPE_ADMIN_TEAM  = "admin:team"
+	// SCOPE_READ_TEAM lists the pending invitations, SCOPE_READ_WORKSPACE the workspaces and their members
+	SCOPE_READ_TEAM      = "read:team"
+	SCOPE_READ_WORKSPACE = "read:workspace"
+	// SCOPE_ADMIN_WORKSPACE creates workspaces and manages their members
+	SCOPE_ADMIN_WORKSPACE = "admin:workspace"
+)
+
+// AllowedScopes lists the scopes that can be granted to a personal access token
+var AllowedScopes = []string{SCOPE_READ_TASKS, SCOPE_WRITE_TASKS, SCOPE_ADMIN_TEAM, SCOPE_READ_TEAM, SCOPE_READ_WORKSPACE, SCOPE_ADMIN_WORKSPACE}
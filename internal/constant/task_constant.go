@@ -0,0 +1,8 @@
+package constant
+
+// Task statuses
+const (
+	TASK_STATUS_TODO        = "To Do"
+	TASK_STATUS_IN_PROGRESS = "In Progress"
+	TASK_STATUS_DONE        = "Done"
+)
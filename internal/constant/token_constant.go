@@ -0,0 +1,8 @@
+package constant
+
+// Prefixes of the generated secret tokens, they make a leaked token recognizable
+const (
+	// PERSONAL_ACCESS_TOKEN_PREFIX also lets the auth directive tell them apart from JWTs
+	PERSONAL_ACCESS_TOKEN_PREFIX = "tmpat_"
+	TEAM_INVITATION_TOKEN_PREFIX = "tminv_"
+)
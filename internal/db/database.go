@@ -28,6 +28,8 @@ func NewDatabase(cfg *_config.DatabaseConfig) (*Database, error) {
 	config.MinConns = cfg.MinConnections
 	config.MaxConnIdleTime = cfg.MaxIdleTime
 	config.HealthCheckPeriod = cfg.HealthCheckPeriod
+	// Row level security policies read the workspace of the request from the session
+	config.BeforeAcquire = applySessionScope
 
 	// Initialize database connection pool
 	pool, err := pgxpool.NewWithConfig(context.Background(), config)
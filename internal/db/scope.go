@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type scopeKey struct{}
+type bypassKey struct{}
+
+// Scope is the workspace a request is allowed to see. It is installed empty when the request
+// arrives and filled once the caller is authenticated, so every connection acquired for the request,
+// including the ones used by dataloaders, is restricted by the row level security policies
+type Scope struct {
+	mu          sync.RWMutex
+	workspaceID string
+}
+
+// WithScope installs an empty scope in the request context
+func WithScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &Scope{})
+}
+
+// ScopeFrom returns the request scope, nil outside of a request
+func ScopeFrom(ctx context.Context) *Scope {
+	scope, _ := ctx.Value(scopeKey{}).(*Scope)
+	return scope
+}
+
+func (s *Scope) SetWorkspace(workspaceID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workspaceID = workspaceID
+}
+
+func (s *Scope) WorkspaceID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.workspaceID
+}
+
+// WithoutRowLevelSecurity lets background jobs and cross workspace flows such as
+// accepting an invitation see every workspace
+func WithoutRowLevelSecurity(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// applySessionScope runs before a connection is handed out and overwrites the session variables
+// read by the policies, a connection never keeps the scope of the previous request
+func applySessionScope(ctx context.Context, conn *pgx.Conn) bool {
+	workspaceID := ""
+	if scope := ScopeFrom(ctx); scope != nil {
+		workspaceID = scope.WorkspaceID()
+	}
+	bypass := "off"
+	if ok, _ := ctx.Value(bypassKey{}).(bool); ok {
+		bypass = "on"
+	}
+
+	_, err := conn.Exec(ctx, `SELECT set_config('app.workspace_id', $1, false), set_config('app.bypass_rls', $2, false)`, workspaceID, bypass)
+	if err != nil {
+		logs.Errorf("applySessionScope:: unable to set the session scope: %v", err)
+		// Destroy the connection rather than hand it out with an unknown scope
+		return false
+	}
+	return true
+}
@@ -10,14 +10,47 @@ import (
 	"strings"
 )
 
+// WorkspaceHeader selects the workspace a request works in
+const WorkspaceHeader = "X-Workspace-ID"
+
 // AuthDirective will be used as auth middleware
-func AuthDirective(ctx context.Context, obj interface{}, next graphql.Resolver) (res interface{}, err error) {
-	// Extract HTTP request from the context
-	req, ok := ctx.Value("httpRequest").(*http.Request)
-	if !ok {
-		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "unable to extract request from context")
+// It accepts both JWT access tokens and personal access tokens, the optional scope
+// argument is only enforced on personal access tokens. The workspace of the request is
+// taken from the X-Workspace-ID header once the caller is known
+func AuthDirective(accessTokenUc usecase.AccessTokenUsecaseInterface, workspaceUc usecase.WorkspaceUsecaseInterface) func(ctx context.Context, obj interface{}, next graphql.Resolver, scope *string) (res interface{}, err error) {
+	return func(ctx context.Context, obj interface{}, next graphql.Resolver, scope *string) (res interface{}, err error) {
+		// Extract HTTP request from the context
+		req, ok := ctx.Value("httpRequest").(*http.Request)
+		if !ok {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "unable to extract request from context")
+		}
+
+		// Verify the token of the caller
+		userCtx, err := Authenticate(ctx, accessTokenUc, req)
+		if err != nil {
+			return nil, err
+		}
+
+		// Check the token has been granted the scope required by the field
+		if scope != nil && !userCtx.HasScope(*scope) {
+			return nil, _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: token is missing scope "+*scope)
+		}
+
+		// Restrict the request to the workspace of the caller
+		if err = workspaceUc.ResolveWorkspace(ctx, userCtx, req.Header.Get(WorkspaceHeader)); err != nil {
+			return nil, err
+		}
+
+		// Add user info to context
+		ctx = context.WithValue(ctx, "user", userCtx)
+
+		// Proceed to the resolver
+		return next(ctx)
 	}
+}
 
+// Authenticate verifies the bearer token of the request, either a JWT access token or a personal access token
+func Authenticate(ctx context.Context, accessTokenUc usecase.AccessTokenUsecaseInterface, req *http.Request) (*_projection.UserContext, error) {
 	// Retrieve Authorization header
 	authHeader := req.Header.Get("Authorization")
 	if authHeader == "" {
@@ -32,17 +65,15 @@ func AuthDirective(ctx context.Context, obj interface{}, next graphql.Resolver)
 
 	// Verify the token
 	token := parts[1]
+	if usecase.IsPersonalAccessToken(token) {
+		return accessTokenUc.VerifyPersonalAccessToken(ctx, token)
+	}
 	user, err := usecase.VerifyToken(token)
 	if err != nil {
 		return nil, _customErr.NewGraphQLError(http.StatusUnauthorized, "unauthorized: invalid token")
 	}
-
-	// Add user info to context
-	ctx = context.WithValue(ctx, "user", &_projection.UserContext{
+	return &_projection.UserContext{
 		Email:  user["email"],
 		UserID: user["userId"],
-	})
-
-	// Proceed to the resolver
-	return next(ctx)
+	}, nil
 }
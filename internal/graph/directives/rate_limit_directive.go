@@ -0,0 +1,42 @@
+package directives
+
+import (
+	_mw "bitbucket.org/edts/go-task-management/internal/middleware"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	_logger "bitbucket.org/edts/go-task-management/pkg/logger"
+	"bitbucket.org/edts/go-task-management/pkg/ratelimit"
+	"context"
+	"fmt"
+	"github.com/99designs/gqlgen/graphql"
+	"math"
+	"net/http"
+)
+
+var logs = _logger.GetContextLoggerf(nil)
+
+// RateLimitDirective limits the field per client IP using the named bucket rule
+func RateLimitDirective(limiter *ratelimit.Limiter, trustedProxies _mw.TrustedProxies) func(ctx context.Context, obj interface{}, next graphql.Resolver, bucket string) (res interface{}, err error) {
+	return func(ctx context.Context, obj interface{}, next graphql.Resolver, bucket string) (res interface{}, err error) {
+		// Extract HTTP request from the context
+		req, ok := ctx.Value("httpRequest").(*http.Request)
+		if !ok {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "unable to extract request from context")
+		}
+
+		result, err := limiter.Allow(ctx, bucket, _mw.ClientIP(req, trustedProxies))
+		if err != nil {
+			// Fail open, an unavailable limiter store must not lock everybody out
+			logs.Errorf("RateLimitDirective:: Error taking token of bucket %s: %v", bucket, err)
+			return next(ctx)
+		}
+
+		if !result.Allowed {
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			gqlErr := _customErr.NewGraphQLError(http.StatusTooManyRequests, fmt.Sprintf("too many requests, retry in %d seconds", retryAfter))
+			gqlErr.Extensions["retryAfter"] = retryAfter
+			return nil, gqlErr
+		}
+
+		return next(ctx)
+	}
+}
@@ -0,0 +1,270 @@
+package extensions
+
+import (
+	_directives "bitbucket.org/edts/go-task-management/internal/graph/directives"
+	"bitbucket.org/edts/go-task-management/internal/usecase"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"bitbucket.org/edts/go-task-management/pkg/idempotency"
+	_logger "bitbucket.org/edts/go-task-management/pkg/logger"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"io"
+	"net/http"
+)
+
+var logs = _logger.GetContextLoggerf(nil)
+
+const (
+	// IdempotencyKeyHeader carries the key of a create mutation
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// idempotencyKeyField is the input field alternative to the header
+	idempotencyKeyField = "idempotencyKey"
+	// idempotentDirective marks the mutations honoring the key
+	idempotentDirective = "idempotent"
+)
+
+// Idempotency replays the response of a mutation sent again with the same Idempotency-Key,
+// so a client can safely retry a create it did not get the response of. Keys are scoped to the
+// caller, the same key sent with a different request is rejected
+type Idempotency struct {
+	keeper        *idempotency.Keeper
+	accessTokenUc usecase.AccessTokenUsecaseInterface
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = Idempotency{}
+
+func NewIdempotency(keeper *idempotency.Keeper, accessTokenUc usecase.AccessTokenUsecaseInterface) Idempotency {
+	return Idempotency{
+		keeper:        keeper,
+		accessTokenUc: accessTokenUc,
+	}
+}
+
+func (Idempotency) ExtensionName() string {
+	return "Idempotency"
+}
+
+func (Idempotency) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (i Idempotency) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation != ast.Mutation {
+		return next(ctx)
+	}
+
+	req, ok := ctx.Value("httpRequest").(*http.Request)
+	if !ok {
+		return next(ctx)
+	}
+
+	fields := graphql.CollectFields(oc, oc.Operation.SelectionSet, []string{"Mutation"})
+	key, keyErr := idempotencyKey(req, fields, oc.Variables)
+	if keyErr != nil {
+		return errorResponse(keyErr)
+	}
+	if key == "" {
+		return next(ctx)
+	}
+
+	// Only create mutations can be replayed, the others are expected to be retried as they are
+	for _, field := range fields {
+		if field.Definition == nil || field.Definition.Directives.ForName(idempotentDirective) == nil {
+			return errorResponse(_customErr.NewGraphQLError(http.StatusBadRequest, "idempotency key is not supported by "+field.Name))
+		}
+	}
+
+	// Scope the key to the caller, the auth directive reports the requests without a valid token
+	userCtx, err := _directives.Authenticate(ctx, i.accessTokenUc, req)
+	if err != nil {
+		return next(ctx)
+	}
+	scopedKey := userCtx.UserID + ":" + key
+
+	fingerprint, err := requestFingerprint(req, oc, fields)
+	if err != nil {
+		logs.Errorf("Idempotency:: Error fingerprinting request: %v", err)
+		return errorResponse(_customErr.NewGraphQLError(http.StatusInternalServerError, "failed to process idempotency key"))
+	}
+
+	stored, owner, err := i.keeper.Begin(ctx, scopedKey, fingerprint)
+	if errors.Is(err, idempotency.ErrKeyReused) {
+		return errorResponse(_customErr.NewGraphQLError(http.StatusUnprocessableEntity, "idempotency key was already used with a different request"))
+	} else if errors.Is(err, idempotency.ErrKeyInProgress) {
+		return errorResponse(_customErr.NewGraphQLError(http.StatusConflict, "a request with this idempotency key is still in progress"))
+	} else if err != nil {
+		logs.Errorf("Idempotency:: Error reserving key: %v", err)
+		return errorResponse(_customErr.NewGraphQLError(http.StatusInternalServerError, "failed to process idempotency key"))
+	}
+
+	// Replay the response of the original request
+	if stored != nil {
+		var resp graphql.Response
+		if err = json.Unmarshal(stored, &resp); err != nil {
+			logs.Errorf("Idempotency:: Error decoding stored response: %v", err)
+			return errorResponse(_customErr.NewGraphQLError(http.StatusInternalServerError, "failed to process idempotency key"))
+		}
+		if resp.Extensions == nil {
+			resp.Extensions = map[string]interface{}{}
+		}
+		resp.Extensions["idempotentReplay"] = true
+		return &resp
+	}
+
+	resp := next(ctx)
+
+	// Requests that created nothing free the key, the client retries them with the same key once fixed.
+	// The others are kept even with errors, a retry must not create the same thing again
+	if !mutationApplied(resp, fields) {
+		if err = i.keeper.Release(ctx, scopedKey, owner); err != nil {
+			logs.Errorf("Idempotency:: Error releasing key: %v", err)
+		}
+		return resp
+	}
+
+	encoded, err := json.Marshal(resp)
+	if err == nil {
+		err = i.keeper.Complete(ctx, scopedKey, owner, encoded)
+	}
+	if err != nil {
+		logs.Errorf("Idempotency:: Error storing response: %v", err)
+	}
+	return resp
+}
+
+// mutationApplied reports whether a root field of the mutation resolved. A failing nested field, such as the key of
+// a created task, can null the whole data by null propagation while the mutation itself went through
+func mutationApplied(resp *graphql.Response, fields []graphql.CollectedField) bool {
+	if resp == nil {
+		return false
+	}
+
+	var data map[string]json.RawMessage
+	if len(resp.Data) > 0 {
+		if err := json.Unmarshal(resp.Data, &data); err != nil {
+			return false
+		}
+	}
+
+	for _, field := range fields {
+		if value, ok := data[field.Alias]; ok && string(value) != "null" {
+			return true
+		}
+		for _, err := range resp.Errors {
+			if len(err.Path) > 1 && err.Path[0] == ast.PathName(field.Alias) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// idempotencyKey reads the key from the header, or from the idempotencyKey field of the mutation inputs
+func idempotencyKey(req *http.Request, fields []graphql.CollectedField, variables map[string]interface{}) (string, *gqlerror.Error) {
+	key := req.Header.Get(IdempotencyKeyHeader)
+	for _, field := range fields {
+		for _, arg := range field.ArgumentMap(variables) {
+			input, ok := arg.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			fieldKey, ok := input[idempotencyKeyField].(string)
+			if !ok || fieldKey == "" {
+				continue
+			}
+			if key != "" && key != fieldKey {
+				return "", _customErr.NewGraphQLError(http.StatusBadRequest, "conflicting idempotency keys in request")
+			}
+			key = fieldKey
+		}
+	}
+	return key, nil
+}
+
+// requestFingerprint hashes what makes two requests the same: the workspace, the operation name and the root fields
+// with their arguments, variables substituted and maps encoded with sorted keys. The query text is left out, a retry
+// sent by another client build formats it differently
+func requestFingerprint(req *http.Request, oc *graphql.OperationContext, fields []graphql.CollectedField) (string, error) {
+	hash := sha256.New()
+	write := func(part string) {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+
+	write(req.Header.Get(_directives.WorkspaceHeader))
+	write(oc.OperationName)
+	for _, field := range fields {
+		arguments, err := fingerprintValue(field.ArgumentMap(oc.Variables))
+		if err != nil {
+			return "", err
+		}
+		encoded, err := json.Marshal(arguments)
+		if err != nil {
+			return "", err
+		}
+		write(field.Name)
+		write(string(encoded))
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fingerprintValue replaces the uploaded files of an argument by the hash of their content
+func fingerprintValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case *graphql.Upload:
+		if v == nil {
+			return nil, nil
+		}
+		return fingerprintValue(*v)
+	case graphql.Upload:
+		hash := sha256.New()
+		if _, err := io.Copy(hash, v.File); err != nil {
+			return nil, err
+		}
+		// The resolver reads the file after the fingerprint
+		if _, err := v.File.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"filename":    v.Filename,
+			"contentType": v.ContentType,
+			"size":        v.Size,
+			"sha256":      hex.EncodeToString(hash.Sum(nil)),
+		}, nil
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			item, err := fingerprintValue(item)
+			if err != nil {
+				return nil, err
+			}
+			normalized[name] = item
+		}
+		return normalized, nil
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, item := range v {
+			item, err := fingerprintValue(item)
+			if err != nil {
+				return nil, err
+			}
+			normalized[i] = item
+		}
+		return normalized, nil
+	}
+	return value, nil
+}
+
+func errorResponse(err *gqlerror.Error) *graphql.Response {
+	return &graphql.Response{Errors: gqlerror.List{err}}
+}
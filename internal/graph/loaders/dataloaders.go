@@ -1,6 +1,7 @@
 package loaders
 
 import (
+	_config "bitbucket.org/edts/go-task-management/config"
 	_model "bitbucket.org/edts/go-task-management/internal/model"
 	_repo "bitbucket.org/edts/go-task-management/internal/repository"
 	_logger "bitbucket.org/edts/go-task-management/pkg/logger"
@@ -12,9 +13,45 @@ import (
 
 var logs = _logger.GetContextLoggerf(nil)
 
+// defaultThroughputWindow is the period the team throughput is measured over when none is configured
+const defaultThroughputWindow = 28 * 24 * time.Hour
+
 type DataLoaders struct {
 	UserLoader *dataloadgen.Loader[string, *_model.User]
 	TeamLoader *dataloadgen.Loader[string, *_model.Team]
+	TaskLoader *dataloadgen.Loader[string, *_model.Task]
+	// SubtasksLoader and ChecklistLoader are keyed by the parent task ID
+	SubtasksLoader  *dataloadgen.Loader[string, []*_model.Task]
+	ChecklistLoader *dataloadgen.Loader[string, []*_model.ChecklistItem]
+	// BlockedByLoader returns the tasks blocking a task, BlocksLoader the tasks it blocks
+	BlockedByLoader *dataloadgen.Loader[string, []*_model.Task]
+	BlocksLoader    *dataloadgen.Loader[string, []*_model.Task]
+	// TaskLabelsLoader is keyed by the task ID
+	TaskLabelsLoader *dataloadgen.Loader[string, []*_model.Label]
+	// TeamWipLimitsLoader is keyed by the team ID
+	TeamWipLimitsLoader *dataloadgen.Loader[string, []*_model.WipLimit]
+	SprintLoader        *dataloadgen.Loader[string, *_model.Sprint]
+	// SprintTasksLoader is keyed by the sprint ID
+	SprintTasksLoader *dataloadgen.Loader[string, []*_model.Task]
+	MilestoneLoader   *dataloadgen.Loader[string, *_model.Milestone]
+	// MilestoneTasksLoader and MilestoneTaskCountLoader are keyed by the milestone ID
+	MilestoneTasksLoader     *dataloadgen.Loader[string, []*_model.Task]
+	MilestoneTaskCountLoader *dataloadgen.Loader[string, *_model.MilestoneTaskCount]
+	// TeamThroughputLoader is keyed by the team ID
+	TeamThroughputLoader *dataloadgen.Loader[string, *_model.TeamThroughput]
+	// TaskWorklogsLoader is keyed by the task ID
+	TaskWorklogsLoader *dataloadgen.Loader[string, []*_model.Worklog]
+	TaskSeriesLoader   *dataloadgen.Loader[string, *_model.TaskSeries]
+	// TaskTemplateLabelsLoader is keyed by the template ID
+	TaskTemplateLabelsLoader *dataloadgen.Loader[string, []*_model.Label]
+	// TaskAttachmentsLoader is keyed by the task ID
+	TaskAttachmentsLoader *dataloadgen.Loader[string, []*_model.Attachment]
+	// TaskCommentsLoader is keyed by the task ID
+	TaskCommentsLoader *dataloadgen.Loader[string, []*_model.Comment]
+	// TaskMentionsLoader is keyed by the task ID
+	TaskMentionsLoader *dataloadgen.Loader[string, []*_model.Mention]
+	// TaskWatchersLoader is keyed by the task ID
+	TaskWatchersLoader *dataloadgen.Loader[string, []*_model.TaskWatcher]
 }
 
 func NewLoaders(repo *_repo.Repository) *DataLoaders {
@@ -68,6 +105,516 @@ func NewLoaders(repo *_repo.Repository) *DataLoaders {
 			// Short wait for batching
 			dataloadgen.WithWait(1*time.Millisecond),
 		),
+		TaskLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([]*_model.Task, []error) {
+			tasks, err := repo.TaskRepo.GetTasksByIDs(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetTasksByIDs in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([]*_model.Task, len(keys)), errs
+			}
+
+			taskMap := make(map[string]*_model.Task)
+			for _, t := range tasks {
+				taskMap[t.ID] = t
+			}
+
+			results := make([]*_model.Task, len(keys))
+			for i, k := range keys {
+				results[i] = taskMap[k] // nil if not found
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		SubtasksLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([][]*_model.Task, []error) {
+			subtasks, err := repo.TaskRepo.GetSubtasksByParentIDs(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetSubtasksByParentIDs in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([][]*_model.Task, len(keys)), errs
+			}
+
+			subtaskMap := make(map[string][]*_model.Task)
+			for _, t := range subtasks {
+				subtaskMap[*t.ParentID] = append(subtaskMap[*t.ParentID], t)
+			}
+
+			results := make([][]*_model.Task, len(keys))
+			for i, k := range keys {
+				results[i] = subtaskMap[k] // nil if there are no subtasks
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		ChecklistLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([][]*_model.ChecklistItem, []error) {
+			items, err := repo.ChecklistItemRepo.GetChecklistItemsByTaskIDs(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetChecklistItemsByTaskIDs in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([][]*_model.ChecklistItem, len(keys)), errs
+			}
+
+			itemMap := make(map[string][]*_model.ChecklistItem)
+			for _, item := range items {
+				itemMap[item.TaskID] = append(itemMap[item.TaskID], item)
+			}
+
+			results := make([][]*_model.ChecklistItem, len(keys))
+			for i, k := range keys {
+				results[i] = itemMap[k] // nil if the checklist is empty
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		BlockedByLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([][]*_model.Task, []error) {
+			dependencies, err := repo.TaskDependencyRepo.GetBlockingTasks(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetBlockingTasks in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([][]*_model.Task, len(keys)), errs
+			}
+
+			blockerMap := make(map[string][]*_model.Task)
+			for _, d := range dependencies {
+				blockerMap[d.TaskID] = append(blockerMap[d.TaskID], d.BlockedBy)
+			}
+
+			results := make([][]*_model.Task, len(keys))
+			for i, k := range keys {
+				results[i] = blockerMap[k] // nil if the task is not blocked
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		BlocksLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([][]*_model.Task, []error) {
+			dependencies, err := repo.TaskDependencyRepo.GetBlockedTasks(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetBlockedTasks in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([][]*_model.Task, len(keys)), errs
+			}
+
+			blockedMap := make(map[string][]*_model.Task)
+			for _, d := range dependencies {
+				blockedMap[d.BlockedByTaskID] = append(blockedMap[d.BlockedByTaskID], d.Task)
+			}
+
+			results := make([][]*_model.Task, len(keys))
+			for i, k := range keys {
+				results[i] = blockedMap[k] // nil if the task blocks nothing
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		TaskLabelsLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([][]*_model.Label, []error) {
+			taskLabels, err := repo.LabelRepo.GetTaskLabelsByTaskIDs(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetTaskLabelsByTaskIDs in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([][]*_model.Label, len(keys)), errs
+			}
+
+			labelMap := make(map[string][]*_model.Label)
+			for _, tl := range taskLabels {
+				labelMap[tl.TaskID] = append(labelMap[tl.TaskID], tl.Label)
+			}
+
+			results := make([][]*_model.Label, len(keys))
+			for i, k := range keys {
+				results[i] = labelMap[k] // nil if the task has no labels
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		TeamWipLimitsLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([][]*_model.WipLimit, []error) {
+			limits, err := repo.TeamRepo.GetTeamWipLimits(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetTeamWipLimits in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([][]*_model.WipLimit, len(keys)), errs
+			}
+
+			limitMap := make(map[string][]*_model.WipLimit)
+			for _, l := range limits {
+				limitMap[l.TeamID] = append(limitMap[l.TeamID], l)
+			}
+
+			results := make([][]*_model.WipLimit, len(keys))
+			for i, k := range keys {
+				results[i] = limitMap[k] // nil if the team has no limits
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		SprintLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([]*_model.Sprint, []error) {
+			sprints, err := repo.SprintRepo.GetSprintsByIDs(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetSprintsByIDs in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([]*_model.Sprint, len(keys)), errs
+			}
+
+			sprintMap := make(map[string]*_model.Sprint)
+			for _, s := range sprints {
+				sprintMap[s.ID] = s
+			}
+
+			results := make([]*_model.Sprint, len(keys))
+			for i, k := range keys {
+				results[i] = sprintMap[k] // nil if not found
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		SprintTasksLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([][]*_model.Task, []error) {
+			tasks, err := repo.SprintRepo.GetTasksBySprintIDs(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetTasksBySprintIDs in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([][]*_model.Task, len(keys)), errs
+			}
+
+			taskMap := make(map[string][]*_model.Task)
+			for _, t := range tasks {
+				taskMap[*t.SprintID] = append(taskMap[*t.SprintID], t)
+			}
+
+			results := make([][]*_model.Task, len(keys))
+			for i, k := range keys {
+				results[i] = taskMap[k] // nil if the sprint has no tasks
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		MilestoneLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([]*_model.Milestone, []error) {
+			milestones, err := repo.MilestoneRepo.GetMilestonesByIDs(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetMilestonesByIDs in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([]*_model.Milestone, len(keys)), errs
+			}
+
+			milestoneMap := make(map[string]*_model.Milestone)
+			for _, m := range milestones {
+				milestoneMap[m.ID] = m
+			}
+
+			results := make([]*_model.Milestone, len(keys))
+			for i, k := range keys {
+				results[i] = milestoneMap[k] // nil if not found
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		MilestoneTasksLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([][]*_model.Task, []error) {
+			tasks, err := repo.MilestoneRepo.GetTasksByMilestoneIDs(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetTasksByMilestoneIDs in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([][]*_model.Task, len(keys)), errs
+			}
+
+			taskMap := make(map[string][]*_model.Task)
+			for _, t := range tasks {
+				taskMap[*t.MilestoneID] = append(taskMap[*t.MilestoneID], t)
+			}
+
+			results := make([][]*_model.Task, len(keys))
+			for i, k := range keys {
+				results[i] = taskMap[k] // nil if the milestone has no tasks
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		MilestoneTaskCountLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([]*_model.MilestoneTaskCount, []error) {
+			counts, err := repo.MilestoneRepo.GetMilestoneTaskCounts(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetMilestoneTaskCounts in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([]*_model.MilestoneTaskCount, len(keys)), errs
+			}
+
+			countMap := make(map[string]*_model.MilestoneTaskCount)
+			for _, c := range counts {
+				countMap[c.MilestoneID] = c
+			}
+
+			results := make([]*_model.MilestoneTaskCount, len(keys))
+			for i, k := range keys {
+				results[i] = countMap[k] // nil if the milestone has no tasks
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		TeamThroughputLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([]*_model.TeamThroughput, []error) {
+			window := _config.AppConfigInstance.Task.ThroughputWindow
+			if window <= 0 {
+				window = defaultThroughputWindow
+			}
+			since := time.Now().Add(-window)
+
+			throughputs, err := repo.TaskRepo.GetTeamThroughputs(ctx, keys, since)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetTeamThroughputs in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([]*_model.TeamThroughput, len(keys)), errs
+			}
+
+			throughputMap := make(map[string]*_model.TeamThroughput)
+			for _, t := range throughputs {
+				throughputMap[t.TeamID] = t
+			}
+
+			results := make([]*_model.TeamThroughput, len(keys))
+			for i, k := range keys {
+				results[i] = throughputMap[k]
+				if results[i] == nil {
+					// The team completed nothing over the window
+					results[i] = &_model.TeamThroughput{TeamID: k, Since: since}
+				}
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		TaskWorklogsLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([][]*_model.Worklog, []error) {
+			worklogs, err := repo.WorklogRepo.GetWorklogsByTaskIDs(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetWorklogsByTaskIDs in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([][]*_model.Worklog, len(keys)), errs
+			}
+
+			worklogMap := make(map[string][]*_model.Worklog)
+			for _, w := range worklogs {
+				worklogMap[w.TaskID] = append(worklogMap[w.TaskID], w)
+			}
+
+			results := make([][]*_model.Worklog, len(keys))
+			for i, k := range keys {
+				results[i] = worklogMap[k] // nil if no time was logged
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		TaskSeriesLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([]*_model.TaskSeries, []error) {
+			seriesList, err := repo.TaskSeriesRepo.GetTaskSeriesByIDs(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetTaskSeriesByIDs in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([]*_model.TaskSeries, len(keys)), errs
+			}
+
+			seriesMap := make(map[string]*_model.TaskSeries)
+			for _, s := range seriesList {
+				seriesMap[s.ID] = s
+			}
+
+			results := make([]*_model.TaskSeries, len(keys))
+			for i, k := range keys {
+				results[i] = seriesMap[k] // nil if not found
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		TaskTemplateLabelsLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([][]*_model.Label, []error) {
+			templateLabels, err := repo.TaskTemplateRepo.GetTemplateLabelsByTemplateIDs(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetTemplateLabelsByTemplateIDs in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([][]*_model.Label, len(keys)), errs
+			}
+
+			labelMap := make(map[string][]*_model.Label)
+			for _, tl := range templateLabels {
+				labelMap[tl.TemplateID] = append(labelMap[tl.TemplateID], tl.Label)
+			}
+
+			results := make([][]*_model.Label, len(keys))
+			for i, k := range keys {
+				results[i] = labelMap[k] // nil if the template has no labels
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		TaskAttachmentsLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([][]*_model.Attachment, []error) {
+			attachments, err := repo.AttachmentRepo.GetAttachmentsByTaskIDs(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetAttachmentsByTaskIDs in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([][]*_model.Attachment, len(keys)), errs
+			}
+
+			attachmentMap := make(map[string][]*_model.Attachment)
+			for _, a := range attachments {
+				attachmentMap[a.TaskID] = append(attachmentMap[a.TaskID], a)
+			}
+
+			results := make([][]*_model.Attachment, len(keys))
+			for i, k := range keys {
+				results[i] = attachmentMap[k] // nil if the task has no attachments
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		TaskCommentsLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([][]*_model.Comment, []error) {
+			comments, err := repo.CommentRepo.GetCommentsByTaskIDs(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetCommentsByTaskIDs in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([][]*_model.Comment, len(keys)), errs
+			}
+
+			commentMap := make(map[string][]*_model.Comment)
+			for _, c := range comments {
+				commentMap[c.TaskID] = append(commentMap[c.TaskID], c)
+			}
+
+			results := make([][]*_model.Comment, len(keys))
+			for i, k := range keys {
+				results[i] = commentMap[k] // nil if the task has no comments
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		TaskMentionsLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([][]*_model.Mention, []error) {
+			mentions, err := repo.MentionRepo.GetMentionsByTaskIDs(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetMentionsByTaskIDs in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([][]*_model.Mention, len(keys)), errs
+			}
+
+			mentionMap := make(map[string][]*_model.Mention)
+			for _, m := range mentions {
+				mentionMap[m.TaskID] = append(mentionMap[m.TaskID], m)
+			}
+
+			results := make([][]*_model.Mention, len(keys))
+			for i, k := range keys {
+				results[i] = mentionMap[k] // nil if the task has no mentions
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
+		TaskWatchersLoader: dataloadgen.NewLoader(func(ctx context.Context, keys []string) ([][]*_model.TaskWatcher, []error) {
+			watchers, err := repo.WatcherRepo.GetWatchersByTaskIDs(ctx, keys)
+			if err != nil {
+				logs.Errorf("ERROR fetching GetWatchersByTaskIDs in loader: %s", err.Error())
+				errs := make([]error, len(keys))
+				for i := range errs {
+					errs[i] = err
+				}
+				return make([][]*_model.TaskWatcher, len(keys)), errs
+			}
+
+			watcherMap := make(map[string][]*_model.TaskWatcher)
+			for _, w := range watchers {
+				watcherMap[w.TaskID] = append(watcherMap[w.TaskID], w)
+			}
+
+			results := make([][]*_model.TaskWatcher, len(keys))
+			for i, k := range keys {
+				results[i] = watcherMap[k] // nil if the task has no watchers
+			}
+			return results, nil
+		},
+			// Short wait for batching
+			dataloadgen.WithWait(1*time.Millisecond),
+		),
 	}
 }
 
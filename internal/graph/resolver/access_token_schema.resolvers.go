@@ -0,0 +1,42 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.67
+
+import (
+	"context"
+
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+)
+
+// CreatePersonalAccessToken is the resolver for the createPersonalAccessToken field.
+func (r *mutationResolver) CreatePersonalAccessToken(ctx context.Context, input _genModel.CreatePersonalAccessTokenInput) (*_genModel.CreatedAccessToken, error) {
+	// Call the usecase
+	createdToken, err := r.Usecase.AccessTokenUsecase.CreatePersonalAccessToken(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return createdToken, nil
+}
+
+// RevokePersonalAccessToken is the resolver for the revokePersonalAccessToken field.
+func (r *mutationResolver) RevokePersonalAccessToken(ctx context.Context, id string) (bool, error) {
+	// Call the usecase
+	isRevoked, err := r.Usecase.AccessTokenUsecase.RevokePersonalAccessToken(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return isRevoked, nil
+}
+
+// PersonalAccessTokens is the resolver for the personalAccessTokens field.
+func (r *queryResolver) PersonalAccessTokens(ctx context.Context) ([]*_model.PersonalAccessToken, error) {
+	// Call the usecase
+	tokens, err := r.Usecase.AccessTokenUsecase.GetPersonalAccessTokens(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
@@ -0,0 +1,54 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.67
+
+import (
+	"context"
+
+	"bitbucket.org/edts/go-task-management/internal/graph/_generated"
+	_dl "bitbucket.org/edts/go-task-management/internal/graph/loaders"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// URL is the resolver for the url field.
+func (r *attachmentResolver) URL(ctx context.Context, obj *_model.Attachment) (string, error) {
+	// Call the usecase
+	return r.Usecase.AttachmentUsecase.AttachmentURL(ctx, obj)
+}
+
+// UploadedBy is the resolver for the uploadedBy field.
+func (r *attachmentResolver) UploadedBy(ctx context.Context, obj *_model.Attachment) (*_model.User, error) {
+	if obj.CreatedBy == nil {
+		return nil, nil
+	}
+	return _dl.For(ctx).UserLoader.Load(ctx, obj.CreatedBy.String())
+}
+
+// UploadAttachment is the resolver for the uploadAttachment field.
+func (r *mutationResolver) UploadAttachment(ctx context.Context, taskID string, file graphql.Upload) (*_model.Attachment, error) {
+	// Call the usecase
+	attachment, err := r.Usecase.AttachmentUsecase.UploadAttachment(ctx, taskID, file)
+	if err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// DeleteAttachment is the resolver for the deleteAttachment field.
+func (r *mutationResolver) DeleteAttachment(ctx context.Context, id string) (bool, error) {
+	// Call the usecase
+	return r.Usecase.AttachmentUsecase.DeleteAttachment(ctx, id)
+}
+
+// Attachments is the resolver for the attachments field.
+func (r *taskResolver) Attachments(ctx context.Context, obj *_model.Task) ([]*_model.Attachment, error) {
+	return _dl.For(ctx).TaskAttachmentsLoader.Load(ctx, obj.ID)
+}
+
+// Attachment returns _generated.AttachmentResolver implementation.
+func (r *Resolver) Attachment() _generated.AttachmentResolver { return &attachmentResolver{r} }
+
+type attachmentResolver struct{ *Resolver }
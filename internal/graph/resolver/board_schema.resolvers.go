@@ -0,0 +1,46 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.67
+
+import (
+	"context"
+
+	"bitbucket.org/edts/go-task-management/internal/graph/_generated"
+	_dl "bitbucket.org/edts/go-task-management/internal/graph/loaders"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+)
+
+// Assignee is the resolver for the assignee field.
+func (r *boardLaneResolver) Assignee(ctx context.Context, obj *_model.BoardLane) (*_model.User, error) {
+	if obj.Swimlane != _model.BoardSwimlaneAssignee || obj.Key == nil {
+		return nil, nil
+	}
+	return _dl.For(ctx).UserLoader.Load(ctx, *obj.Key)
+}
+
+// Tasks is the resolver for the tasks field.
+func (r *boardLaneResolver) Tasks(ctx context.Context, obj *_model.BoardLane, first *int32, after *string) (*_model.TaskPage, error) {
+	// Call the usecase
+	page, err := r.Usecase.BoardUsecase.GetBoardLaneTasks(ctx, obj, first, after)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// Board is the resolver for the board field.
+func (r *queryResolver) Board(ctx context.Context, teamID string, groupBy *_model.BoardGroupBy, swimlane *_model.BoardSwimlane) (*_model.Board, error) {
+	// Call the usecase
+	board, err := r.Usecase.BoardUsecase.GetBoard(ctx, teamID, groupBy, swimlane)
+	if err != nil {
+		return nil, err
+	}
+	return board, nil
+}
+
+// BoardLane returns _generated.BoardLaneResolver implementation.
+func (r *Resolver) BoardLane() _generated.BoardLaneResolver { return &boardLaneResolver{r} }
+
+type boardLaneResolver struct{ *Resolver }
@@ -0,0 +1,131 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.67
+
+import (
+	"context"
+
+	"bitbucket.org/edts/go-task-management/internal/graph/_generated"
+	_dl "bitbucket.org/edts/go-task-management/internal/graph/loaders"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+)
+
+// Author is the resolver for the author field.
+func (r *commentResolver) Author(ctx context.Context, obj *_model.Comment) (*_model.User, error) {
+	if obj.UserID == nil {
+		return nil, nil
+	}
+	return _dl.For(ctx).UserLoader.Load(ctx, *obj.UserID)
+}
+
+// MentionedTasks is the resolver for the mentionedTasks field.
+func (r *commentResolver) MentionedTasks(ctx context.Context, obj *_model.Comment) ([]*_model.Task, error) {
+	// Call the usecase
+	return r.Usecase.MentionUsecase.GetMentionedTasks(ctx, &obj.Content)
+}
+
+// Task is the resolver for the task field.
+func (r *mentionResolver) Task(ctx context.Context, obj *_model.Mention) (*_model.Task, error) {
+	return _dl.For(ctx).TaskLoader.Load(ctx, obj.TaskID)
+}
+
+// User is the resolver for the user field.
+func (r *mentionResolver) User(ctx context.Context, obj *_model.Mention) (*_model.User, error) {
+	return _dl.For(ctx).UserLoader.Load(ctx, obj.UserID)
+}
+
+// MentionedBy is the resolver for the mentionedBy field.
+func (r *mentionResolver) MentionedBy(ctx context.Context, obj *_model.Mention) (*_model.User, error) {
+	if obj.CreatedBy == nil {
+		return nil, nil
+	}
+	return _dl.For(ctx).UserLoader.Load(ctx, obj.CreatedBy.String())
+}
+
+// AddComment is the resolver for the addComment field.
+func (r *mutationResolver) AddComment(ctx context.Context, taskID string, content string) (*_model.Comment, error) {
+	// Call the usecase
+	comment, err := r.Usecase.CommentUsecase.AddComment(ctx, taskID, content)
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// UpdateComment is the resolver for the updateComment field.
+func (r *mutationResolver) UpdateComment(ctx context.Context, id string, content string) (*_model.Comment, error) {
+	// Call the usecase
+	comment, err := r.Usecase.CommentUsecase.UpdateComment(ctx, id, content)
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// DeleteComment is the resolver for the deleteComment field.
+func (r *mutationResolver) DeleteComment(ctx context.Context, id string) (bool, error) {
+	// Call the usecase
+	return r.Usecase.CommentUsecase.DeleteComment(ctx, id)
+}
+
+// MarkNotificationsRead is the resolver for the markNotificationsRead field.
+func (r *mutationResolver) MarkNotificationsRead(ctx context.Context, ids []string) (int32, error) {
+	// Call the usecase
+	return r.Usecase.NotificationUsecase.MarkNotificationsRead(ctx, ids)
+}
+
+// Task is the resolver for the task field.
+func (r *notificationResolver) Task(ctx context.Context, obj *_model.Notification) (*_model.Task, error) {
+	return _dl.For(ctx).TaskLoader.Load(ctx, obj.TaskID)
+}
+
+// Actor is the resolver for the actor field.
+func (r *notificationResolver) Actor(ctx context.Context, obj *_model.Notification) (*_model.User, error) {
+	if obj.ActorID == nil {
+		return nil, nil
+	}
+	return _dl.For(ctx).UserLoader.Load(ctx, *obj.ActorID)
+}
+
+// MentionedMe is the resolver for the mentionedMe field.
+func (r *queryResolver) MentionedMe(ctx context.Context, limit *int32) ([]*_model.Mention, error) {
+	// Call the usecase
+	return r.Usecase.MentionUsecase.GetMentionedMe(ctx, limit)
+}
+
+// Notifications is the resolver for the notifications field.
+func (r *queryResolver) Notifications(ctx context.Context, unreadOnly *bool, limit *int32) ([]*_model.Notification, error) {
+	// Call the usecase
+	return r.Usecase.NotificationUsecase.GetNotifications(ctx, unreadOnly != nil && *unreadOnly, limit)
+}
+
+// Comments is the resolver for the comments field.
+func (r *taskResolver) Comments(ctx context.Context, obj *_model.Task) ([]*_model.Comment, error) {
+	return _dl.For(ctx).TaskCommentsLoader.Load(ctx, obj.ID)
+}
+
+// Mentions is the resolver for the mentions field.
+func (r *taskResolver) Mentions(ctx context.Context, obj *_model.Task) ([]*_model.Mention, error) {
+	return _dl.For(ctx).TaskMentionsLoader.Load(ctx, obj.ID)
+}
+
+// MentionedTasks is the resolver for the mentionedTasks field.
+func (r *taskResolver) MentionedTasks(ctx context.Context, obj *_model.Task) ([]*_model.Task, error) {
+	// Call the usecase
+	return r.Usecase.MentionUsecase.GetMentionedTasks(ctx, obj.Description)
+}
+
+// Comment returns _generated.CommentResolver implementation.
+func (r *Resolver) Comment() _generated.CommentResolver { return &commentResolver{r} }
+
+// Mention returns _generated.MentionResolver implementation.
+func (r *Resolver) Mention() _generated.MentionResolver { return &mentionResolver{r} }
+
+// Notification returns _generated.NotificationResolver implementation.
+func (r *Resolver) Notification() _generated.NotificationResolver { return &notificationResolver{r} }
+
+type commentResolver struct{ *Resolver }
+type mentionResolver struct{ *Resolver }
+type notificationResolver struct{ *Resolver }
@@ -0,0 +1,62 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.67
+
+import (
+	"context"
+
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+)
+
+// CreateLabel is the resolver for the createLabel field.
+func (r *mutationResolver) CreateLabel(ctx context.Context, input _genModel.CreateLabelInput) (*_model.Label, error) {
+	// Call the usecase
+	label, err := r.Usecase.LabelUsecase.CreateLabel(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return label, nil
+}
+
+// UpdateLabel is the resolver for the updateLabel field.
+func (r *mutationResolver) UpdateLabel(ctx context.Context, input _genModel.UpdateLabelInput) (*_model.Label, error) {
+	// Call the usecase
+	label, err := r.Usecase.LabelUsecase.UpdateLabel(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return label, nil
+}
+
+// DeleteLabel is the resolver for the deleteLabel field.
+func (r *mutationResolver) DeleteLabel(ctx context.Context, id string) (bool, error) {
+	// Call the usecase
+	isDeleted, err := r.Usecase.LabelUsecase.DeleteLabel(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return isDeleted, nil
+}
+
+// SetTaskLabels is the resolver for the setTaskLabels field.
+func (r *mutationResolver) SetTaskLabels(ctx context.Context, taskID string, labelIds []string) (*_model.Task, error) {
+	// Call the usecase
+	task, err := r.Usecase.LabelUsecase.SetTaskLabels(ctx, taskID, labelIds)
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// LabelsByTeam is the resolver for the labelsByTeam field.
+func (r *queryResolver) LabelsByTeam(ctx context.Context, teamID string) ([]*_model.Label, error) {
+	// Call the usecase
+	labels, err := r.Usecase.LabelUsecase.GetLabelsByTeam(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
@@ -0,0 +1,121 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.67
+
+import (
+	"context"
+	"time"
+
+	"bitbucket.org/edts/go-task-management/internal/graph/_generated"
+	_dl "bitbucket.org/edts/go-task-management/internal/graph/loaders"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_usecase "bitbucket.org/edts/go-task-management/internal/usecase"
+)
+
+// Team is the resolver for the team field.
+func (r *milestoneResolver) Team(ctx context.Context, obj *_model.Milestone) (*_model.Team, error) {
+	return _dl.For(ctx).TeamLoader.Load(ctx, obj.TeamID)
+}
+
+// Tasks is the resolver for the tasks field.
+func (r *milestoneResolver) Tasks(ctx context.Context, obj *_model.Milestone) ([]*_model.Task, error) {
+	return _dl.For(ctx).MilestoneTasksLoader.Load(ctx, obj.ID)
+}
+
+// Progress is the resolver for the progress field.
+func (r *milestoneResolver) Progress(ctx context.Context, obj *_model.Milestone, weighted *bool) (*_genModel.MilestoneProgress, error) {
+	count, err := _dl.For(ctx).MilestoneTaskCountLoader.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	return _usecase.ComputeMilestoneProgress(count, weighted != nil && *weighted), nil
+}
+
+// IsAtRisk is the resolver for the isAtRisk field.
+func (r *milestoneResolver) IsAtRisk(ctx context.Context, obj *_model.Milestone) (bool, error) {
+	count, err := _dl.For(ctx).MilestoneTaskCountLoader.Load(ctx, obj.ID)
+	if err != nil {
+		return false, err
+	}
+	throughput, err := _dl.For(ctx).TeamThroughputLoader.Load(ctx, obj.TeamID)
+	if err != nil {
+		return false, err
+	}
+	return _usecase.IsMilestoneAtRisk(obj, count, throughput, time.Now()), nil
+}
+
+// CreateMilestone is the resolver for the createMilestone field.
+func (r *mutationResolver) CreateMilestone(ctx context.Context, input _genModel.CreateMilestoneInput) (*_model.Milestone, error) {
+	// Call the usecase
+	milestone, err := r.Usecase.MilestoneUsecase.CreateMilestone(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return milestone, nil
+}
+
+// UpdateMilestone is the resolver for the updateMilestone field.
+func (r *mutationResolver) UpdateMilestone(ctx context.Context, input _genModel.UpdateMilestoneInput) (*_model.Milestone, error) {
+	// Call the usecase
+	milestone, err := r.Usecase.MilestoneUsecase.UpdateMilestone(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return milestone, nil
+}
+
+// DeleteMilestone is the resolver for the deleteMilestone field.
+func (r *mutationResolver) DeleteMilestone(ctx context.Context, id string) (bool, error) {
+	// Call the usecase
+	isDeleted, err := r.Usecase.MilestoneUsecase.DeleteMilestone(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return isDeleted, nil
+}
+
+// SetTaskMilestone is the resolver for the setTaskMilestone field.
+func (r *mutationResolver) SetTaskMilestone(ctx context.Context, taskIds []string, milestoneID *string) ([]*_model.Task, error) {
+	// Call the usecase
+	tasks, err := r.Usecase.MilestoneUsecase.SetTasksMilestone(ctx, taskIds, milestoneID)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// Milestones is the resolver for the milestones field.
+func (r *queryResolver) Milestones(ctx context.Context, teamID string, state *_model.MilestoneState) ([]*_model.Milestone, error) {
+	// Call the usecase
+	milestones, err := r.Usecase.MilestoneUsecase.GetMilestonesByTeam(ctx, teamID, state)
+	if err != nil {
+		return nil, err
+	}
+	return milestones, nil
+}
+
+// Milestone is the resolver for the milestone field.
+func (r *queryResolver) Milestone(ctx context.Context, id string) (*_model.Milestone, error) {
+	// Call the usecase
+	milestone, err := r.Usecase.MilestoneUsecase.GetMilestoneByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return milestone, nil
+}
+
+// Milestone is the resolver for the milestone field.
+func (r *taskResolver) Milestone(ctx context.Context, obj *_model.Task) (*_model.Milestone, error) {
+	if obj.MilestoneID == nil {
+		return nil, nil
+	}
+	return _dl.For(ctx).MilestoneLoader.Load(ctx, *obj.MilestoneID)
+}
+
+// Milestone returns _generated.MilestoneResolver implementation.
+func (r *Resolver) Milestone() _generated.MilestoneResolver { return &milestoneResolver{r} }
+
+type milestoneResolver struct{ *Resolver }
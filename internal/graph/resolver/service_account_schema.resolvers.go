@@ -0,0 +1,71 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.67
+
+import (
+	"context"
+
+	"bitbucket.org/edts/go-task-management/internal/graph/_generated"
+	_dl "bitbucket.org/edts/go-task-management/internal/graph/loaders"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+)
+
+// CreateServiceAccount is the resolver for the createServiceAccount field.
+func (r *mutationResolver) CreateServiceAccount(ctx context.Context, input _genModel.CreateServiceAccountInput) (*_model.ServiceAccount, error) {
+	// Call the usecase
+	serviceAccount, err := r.Usecase.ServiceAccountUsecase.CreateServiceAccount(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return serviceAccount, nil
+}
+
+// DeleteServiceAccount is the resolver for the deleteServiceAccount field.
+func (r *mutationResolver) DeleteServiceAccount(ctx context.Context, id string) (bool, error) {
+	// Call the usecase
+	isDeleted, err := r.Usecase.ServiceAccountUsecase.DeleteServiceAccount(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return isDeleted, nil
+}
+
+// CreateServiceAccountToken is the resolver for the createServiceAccountToken field.
+func (r *mutationResolver) CreateServiceAccountToken(ctx context.Context, input _genModel.CreateServiceAccountTokenInput) (*_genModel.CreatedAccessToken, error) {
+	// Call the usecase
+	createdToken, err := r.Usecase.ServiceAccountUsecase.CreateServiceAccountToken(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return createdToken, nil
+}
+
+// ServiceAccountsByTeam is the resolver for the serviceAccountsByTeam field.
+func (r *queryResolver) ServiceAccountsByTeam(ctx context.Context, teamID string) ([]*_model.ServiceAccount, error) {
+	// Call the usecase
+	serviceAccounts, err := r.Usecase.ServiceAccountUsecase.GetServiceAccountsByTeam(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	return serviceAccounts, nil
+}
+
+// Team is the resolver for the team field.
+func (r *serviceAccountResolver) Team(ctx context.Context, obj *_model.ServiceAccount) (*_model.Team, error) {
+	return _dl.For(ctx).TeamLoader.Load(ctx, obj.TeamID)
+}
+
+// Tokens is the resolver for the tokens field.
+func (r *serviceAccountResolver) Tokens(ctx context.Context, obj *_model.ServiceAccount) ([]*_model.PersonalAccessToken, error) {
+	return r.Usecase.ServiceAccountUsecase.GetServiceAccountTokens(ctx, obj.ID)
+}
+
+// ServiceAccount returns _generated.ServiceAccountResolver implementation.
+func (r *Resolver) ServiceAccount() _generated.ServiceAccountResolver {
+	return &serviceAccountResolver{r}
+}
+
+type serviceAccountResolver struct{ *Resolver }
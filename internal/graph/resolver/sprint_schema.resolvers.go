@@ -0,0 +1,147 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.67
+
+import (
+	"context"
+
+	"bitbucket.org/edts/go-task-management/internal/graph/_generated"
+	_dl "bitbucket.org/edts/go-task-management/internal/graph/loaders"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+)
+
+// CreateSprint is the resolver for the createSprint field.
+func (r *mutationResolver) CreateSprint(ctx context.Context, input _genModel.CreateSprintInput) (*_model.Sprint, error) {
+	// Call the usecase
+	sprint, err := r.Usecase.SprintUsecase.CreateSprint(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return sprint, nil
+}
+
+// UpdateSprint is the resolver for the updateSprint field.
+func (r *mutationResolver) UpdateSprint(ctx context.Context, input _genModel.UpdateSprintInput) (*_model.Sprint, error) {
+	// Call the usecase
+	sprint, err := r.Usecase.SprintUsecase.UpdateSprint(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return sprint, nil
+}
+
+// DeleteSprint is the resolver for the deleteSprint field.
+func (r *mutationResolver) DeleteSprint(ctx context.Context, id string) (bool, error) {
+	// Call the usecase
+	isDeleted, err := r.Usecase.SprintUsecase.DeleteSprint(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return isDeleted, nil
+}
+
+// PlanTasks is the resolver for the planTasks field.
+func (r *mutationResolver) PlanTasks(ctx context.Context, sprintID string, taskIds []string) (*_model.Sprint, error) {
+	// Call the usecase
+	sprint, err := r.Usecase.SprintUsecase.PlanTasks(ctx, sprintID, taskIds)
+	if err != nil {
+		return nil, err
+	}
+	return sprint, nil
+}
+
+// MoveTasksToBacklog is the resolver for the moveTasksToBacklog field.
+func (r *mutationResolver) MoveTasksToBacklog(ctx context.Context, taskIds []string) ([]*_model.Task, error) {
+	// Call the usecase
+	tasks, err := r.Usecase.SprintUsecase.MoveTasksToBacklog(ctx, taskIds)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// StartSprint is the resolver for the startSprint field.
+func (r *mutationResolver) StartSprint(ctx context.Context, id string) (*_model.Sprint, error) {
+	// Call the usecase
+	sprint, err := r.Usecase.SprintUsecase.StartSprint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return sprint, nil
+}
+
+// CompleteSprint is the resolver for the completeSprint field.
+func (r *mutationResolver) CompleteSprint(ctx context.Context, id string, carryOverTo *_model.SprintCarryOver) (*_model.Sprint, error) {
+	// Call the usecase
+	sprint, err := r.Usecase.SprintUsecase.CompleteSprint(ctx, id, carryOverTo)
+	if err != nil {
+		return nil, err
+	}
+	return sprint, nil
+}
+
+// Sprints is the resolver for the sprints field.
+func (r *queryResolver) Sprints(ctx context.Context, teamID string, state *_model.SprintState) ([]*_model.Sprint, error) {
+	// Call the usecase
+	sprints, err := r.Usecase.SprintUsecase.GetSprintsByTeam(ctx, teamID, state)
+	if err != nil {
+		return nil, err
+	}
+	return sprints, nil
+}
+
+// Sprint is the resolver for the sprint field.
+func (r *queryResolver) Sprint(ctx context.Context, id string) (*_model.Sprint, error) {
+	// Call the usecase
+	sprint, err := r.Usecase.SprintUsecase.GetSprintByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return sprint, nil
+}
+
+// SprintReport is the resolver for the sprintReport field.
+func (r *queryResolver) SprintReport(ctx context.Context, id string) (*_genModel.SprintReport, error) {
+	// Call the usecase
+	report, err := r.Usecase.SprintUsecase.GetSprintReport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Backlog is the resolver for the backlog field.
+func (r *queryResolver) Backlog(ctx context.Context, teamID string) ([]*_model.Task, error) {
+	// Call the usecase
+	tasks, err := r.Usecase.SprintUsecase.GetBacklog(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// Team is the resolver for the team field.
+func (r *sprintResolver) Team(ctx context.Context, obj *_model.Sprint) (*_model.Team, error) {
+	return _dl.For(ctx).TeamLoader.Load(ctx, obj.TeamID)
+}
+
+// Tasks is the resolver for the tasks field.
+func (r *sprintResolver) Tasks(ctx context.Context, obj *_model.Sprint) ([]*_model.Task, error) {
+	return _dl.For(ctx).SprintTasksLoader.Load(ctx, obj.ID)
+}
+
+// Sprint is the resolver for the sprint field.
+func (r *taskResolver) Sprint(ctx context.Context, obj *_model.Task) (*_model.Sprint, error) {
+	if obj.SprintID == nil {
+		return nil, nil
+	}
+	return _dl.For(ctx).SprintLoader.Load(ctx, *obj.SprintID)
+}
+
+// Sprint returns _generated.SprintResolver implementation.
+func (r *Resolver) Sprint() _generated.SprintResolver { return &sprintResolver{r} }
+
+type sprintResolver struct{ *Resolver }
@@ -0,0 +1,42 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.67
+
+import (
+	"context"
+
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+)
+
+// BulkUpdateTasks is the resolver for the bulkUpdateTasks field.
+func (r *mutationResolver) BulkUpdateTasks(ctx context.Context, ids []string, patch _genModel.BulkTaskPatch) ([]*_genModel.BulkTaskResult, error) {
+	// Call the usecase
+	return r.Usecase.TaskUsecase.BulkUpdateTasks(ctx, ids, patch)
+}
+
+// BulkMoveTasks is the resolver for the bulkMoveTasks field.
+func (r *mutationResolver) BulkMoveTasks(ctx context.Context, ids []string, status string) ([]*_genModel.BulkTaskResult, error) {
+	// Call the usecase
+	return r.Usecase.TaskUsecase.BulkMoveTasks(ctx, ids, status)
+}
+
+// BulkAssignTasks is the resolver for the bulkAssignTasks field.
+func (r *mutationResolver) BulkAssignTasks(ctx context.Context, ids []string, assignedTo *string) ([]*_genModel.BulkTaskResult, error) {
+	// Call the usecase
+	return r.Usecase.TaskUsecase.BulkAssignTasks(ctx, ids, assignedTo)
+}
+
+// BulkDeleteTasks is the resolver for the bulkDeleteTasks field.
+func (r *mutationResolver) BulkDeleteTasks(ctx context.Context, ids []string) ([]*_genModel.BulkTaskResult, error) {
+	// Call the usecase
+	return r.Usecase.TaskUsecase.BulkDeleteTasks(ctx, ids)
+}
+
+// TasksBatched is the resolver for the tasksBatched field.
+func (r *subscriptionResolver) TasksBatched(ctx context.Context, teamID string) (<-chan *_model.TaskBatch, error) {
+	// Return the usecase
+	return r.Usecase.TaskUsecase.TasksBatchedEvent(ctx, teamID)
+}
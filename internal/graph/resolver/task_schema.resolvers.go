@@ -7,11 +7,14 @@ package resolver
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"bitbucket.org/edts/go-task-management/internal/graph/_generated"
 	_dl "bitbucket.org/edts/go-task-management/internal/graph/loaders"
 	_model "bitbucket.org/edts/go-task-management/internal/model"
 	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_usecase "bitbucket.org/edts/go-task-management/internal/usecase"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
 )
 
 // CreateTask is the resolver for the createTask field.
@@ -37,9 +40,9 @@ func (r *mutationResolver) UpdateTaskByID(ctx context.Context, input _genModel.U
 }
 
 // DeleteTaskByID is the resolver for the deleteTaskById field.
-func (r *mutationResolver) DeleteTaskByID(ctx context.Context, id string) (bool, error) {
+func (r *mutationResolver) DeleteTaskByID(ctx context.Context, id string, permanent *bool) (bool, error) {
 	// Call the usecase
-	err := r.Usecase.TaskUsecase.DeleteTaskById(ctx, id)
+	err := r.Usecase.TaskUsecase.DeleteTaskById(ctx, id, permanent != nil && *permanent)
 	if err != nil {
 		return false, err
 	}
@@ -47,11 +50,25 @@ func (r *mutationResolver) DeleteTaskByID(ctx context.Context, id string) (bool,
 	return true, nil
 }
 
+// RestoreTask is the resolver for the restoreTask field.
+func (r *mutationResolver) RestoreTask(ctx context.Context, id string) (*_model.Task, error) {
+	// Call the usecase
+	task, err := r.Usecase.TaskUsecase.RestoreTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
 // MoveTaskByID is the resolver for the moveTaskById field.
 func (r *mutationResolver) MoveTaskByID(ctx context.Context, input _genModel.MoveTaskInput) (*_model.Task, error) {
 	// Call the usecase
-	//TODO: find the usecase related to this schema MoveTaskById
-	panic(fmt.Errorf("not implemented: MoveTaskById - moveTaskById"))
+	task, err := r.Usecase.TaskUsecase.MoveTaskByID(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
 }
 
 // AssignTask is the resolver for the assignTask field.
@@ -65,6 +82,106 @@ func (r *mutationResolver) AssignTask(ctx context.Context, input _genModel.Assig
 	return task, nil
 }
 
+// MoveTaskToTeam is the resolver for the moveTaskToTeam field.
+func (r *mutationResolver) MoveTaskToTeam(ctx context.Context, id string, teamID string, expectedVersion *int32) (*_model.Task, error) {
+	// Call the usecase
+	task, err := r.Usecase.TaskUsecase.MoveTaskToTeam(ctx, id, teamID, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// SetTaskParent is the resolver for the setTaskParent field.
+func (r *mutationResolver) SetTaskParent(ctx context.Context, id string, parentID *string) (*_model.Task, error) {
+	// Call the usecase
+	task, err := r.Usecase.TaskUsecase.SetTaskParent(ctx, id, parentID)
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// ReorderTask is the resolver for the reorderTask field.
+func (r *mutationResolver) ReorderTask(ctx context.Context, id string, beforeID *string, afterID *string) (*_model.Task, error) {
+	// Call the usecase
+	task, err := r.Usecase.TaskUsecase.ReorderTask(ctx, id, beforeID, afterID)
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// AddDependency is the resolver for the addDependency field.
+func (r *mutationResolver) AddDependency(ctx context.Context, taskID string, blockedByID string) (*_model.Task, error) {
+	// Call the usecase
+	task, err := r.Usecase.TaskDependencyUsecase.AddDependency(ctx, taskID, blockedByID)
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// RemoveDependency is the resolver for the removeDependency field.
+func (r *mutationResolver) RemoveDependency(ctx context.Context, taskID string, blockedByID string) (*_model.Task, error) {
+	// Call the usecase
+	task, err := r.Usecase.TaskDependencyUsecase.RemoveDependency(ctx, taskID, blockedByID)
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// AddChecklistItem is the resolver for the addChecklistItem field.
+func (r *mutationResolver) AddChecklistItem(ctx context.Context, taskID string, text string) (*_model.ChecklistItem, error) {
+	// Call the usecase
+	item, err := r.Usecase.ChecklistUsecase.AddChecklistItem(ctx, taskID, text)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// ToggleChecklistItem is the resolver for the toggleChecklistItem field.
+func (r *mutationResolver) ToggleChecklistItem(ctx context.Context, id string) (*_model.ChecklistItem, error) {
+	// Call the usecase
+	item, err := r.Usecase.ChecklistUsecase.ToggleChecklistItem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// ReorderChecklist is the resolver for the reorderChecklist field.
+func (r *mutationResolver) ReorderChecklist(ctx context.Context, taskID string, itemIds []string) ([]*_model.ChecklistItem, error) {
+	// Call the usecase
+	items, err := r.Usecase.ChecklistUsecase.ReorderChecklist(ctx, taskID, itemIds)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// DeleteChecklistItem is the resolver for the deleteChecklistItem field.
+func (r *mutationResolver) DeleteChecklistItem(ctx context.Context, id string) (bool, error) {
+	// Call the usecase
+	isDeleted, err := r.Usecase.ChecklistUsecase.DeleteChecklistItem(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return isDeleted, nil
+}
+
+// ConvertChecklistItemToSubtask is the resolver for the convertChecklistItemToSubtask field.
+func (r *mutationResolver) ConvertChecklistItemToSubtask(ctx context.Context, id string) (*_model.Task, error) {
+	// Call the usecase
+	subtask, err := r.Usecase.ChecklistUsecase.ConvertChecklistItemToSubtask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return subtask, nil
+}
+
 // GetTaskByID is the resolver for the getTaskById field.
 func (r *queryResolver) GetTaskByID(ctx context.Context, id string) (*_model.Task, error) {
 	task, err := r.Usecase.TaskUsecase.GetTaskByID(ctx, id)
@@ -74,10 +191,20 @@ func (r *queryResolver) GetTaskByID(ctx context.Context, id string) (*_model.Tas
 	return task, nil
 }
 
+// TaskByKey is the resolver for the taskByKey field.
+func (r *queryResolver) TaskByKey(ctx context.Context, key string) (*_model.Task, error) {
+	// Call the usecase
+	task, err := r.Usecase.TaskUsecase.GetTaskByKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
 // TasksByTeam is the resolver for the tasksByTeam field.
-func (r *queryResolver) TasksByTeam(ctx context.Context, teamID string, status *string) ([]*_model.Task, error) {
+func (r *queryResolver) TasksByTeam(ctx context.Context, teamID string, status *string, labels *_model.LabelFilter) ([]*_model.Task, error) {
 	// Call the usecase
-	tasks, err := r.Usecase.TaskUsecase.GetTasksByTeam(ctx, teamID, status)
+	tasks, err := r.Usecase.TaskUsecase.GetTasksByTeam(ctx, teamID, status, labels)
 	if err != nil {
 		return nil, err
 	}
@@ -85,22 +212,50 @@ func (r *queryResolver) TasksByTeam(ctx context.Context, teamID string, status *
 	return tasks, nil
 }
 
+// CriticalPath is the resolver for the criticalPath field.
+func (r *queryResolver) CriticalPath(ctx context.Context, teamID string) ([]*_model.Task, error) {
+	// Call the usecase
+	tasks, err := r.Usecase.TaskDependencyUsecase.GetCriticalPath(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// Trash is the resolver for the trash field.
+func (r *queryResolver) Trash(ctx context.Context, teamID string) ([]*_model.Task, error) {
+	// Call the usecase
+	return r.Usecase.TaskUsecase.GetTrash(ctx, teamID)
+}
+
 // TaskCreated is the resolver for the taskCreated field.
 func (r *subscriptionResolver) TaskCreated(ctx context.Context, teamID string) (<-chan *_model.Task, error) {
 	// Return the usecase
-	return r.Usecase.TaskUsecase.TaskCreatedEvent(ctx, teamID), nil
+	return r.Usecase.TaskUsecase.TaskCreatedEvent(ctx, teamID)
 }
 
 // TaskUpdated is the resolver for the taskUpdated field.
 func (r *subscriptionResolver) TaskUpdated(ctx context.Context, teamID string) (<-chan *_model.Task, error) {
 	// Return the usecase
-	return r.Usecase.TaskUsecase.TaskUpdatedEvent(ctx, teamID), nil
+	return r.Usecase.TaskUsecase.TaskUpdatedEvent(ctx, teamID)
 }
 
 // TaskDeleted is the resolver for the taskDeleted field.
 func (r *subscriptionResolver) TaskDeleted(ctx context.Context, teamID string) (<-chan *_genModel.DeletedTaskNotification, error) {
 	// Return the usecase
-	return r.Usecase.TaskUsecase.TaskDeletedEvent(ctx, teamID), nil
+	return r.Usecase.TaskUsecase.TaskDeletedEvent(ctx, teamID)
+}
+
+// Key is the resolver for the key field.
+func (r *taskResolver) Key(ctx context.Context, obj *_model.Task) (string, error) {
+	team, err := _dl.For(ctx).TeamLoader.Load(ctx, obj.TeamID)
+	if err != nil {
+		return "", err
+	}
+	if team == nil {
+		return "", _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+	return _model.TaskKey{TeamKey: team.Key, Number: obj.Number}.String(), nil
 }
 
 // AssignedUser is the resolver for the assignedUser field.
@@ -111,6 +266,52 @@ func (r *taskResolver) AssignedUser(ctx context.Context, obj *_model.Task) (*_mo
 	return _dl.For(ctx).UserLoader.Load(ctx, *obj.AssignedTo)
 }
 
+// Parent is the resolver for the parent field.
+func (r *taskResolver) Parent(ctx context.Context, obj *_model.Task) (*_model.Task, error) {
+	if obj.ParentID == nil {
+		return nil, nil
+	}
+	return _dl.For(ctx).TaskLoader.Load(ctx, *obj.ParentID)
+}
+
+// Subtasks is the resolver for the subtasks field.
+func (r *taskResolver) Subtasks(ctx context.Context, obj *_model.Task) ([]*_model.Task, error) {
+	return _dl.For(ctx).SubtasksLoader.Load(ctx, obj.ID)
+}
+
+// Checklist is the resolver for the checklist field.
+func (r *taskResolver) Checklist(ctx context.Context, obj *_model.Task) ([]*_model.ChecklistItem, error) {
+	return _dl.For(ctx).ChecklistLoader.Load(ctx, obj.ID)
+}
+
+// Progress is the resolver for the progress field.
+func (r *taskResolver) Progress(ctx context.Context, obj *_model.Task) (*_genModel.TaskProgress, error) {
+	subtasks, err := _dl.For(ctx).SubtasksLoader.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := _dl.For(ctx).ChecklistLoader.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	return _usecase.ComputeTaskProgress(subtasks, items), nil
+}
+
+// BlockedBy is the resolver for the blockedBy field.
+func (r *taskResolver) BlockedBy(ctx context.Context, obj *_model.Task) ([]*_model.Task, error) {
+	return _dl.For(ctx).BlockedByLoader.Load(ctx, obj.ID)
+}
+
+// Blocks is the resolver for the blocks field.
+func (r *taskResolver) Blocks(ctx context.Context, obj *_model.Task) ([]*_model.Task, error) {
+	return _dl.For(ctx).BlocksLoader.Load(ctx, obj.ID)
+}
+
+// Labels is the resolver for the labels field.
+func (r *taskResolver) Labels(ctx context.Context, obj *_model.Task) ([]*_model.Label, error) {
+	return _dl.For(ctx).TaskLabelsLoader.Load(ctx, obj.ID)
+}
+
 // CreatedBy is the resolver for the createdBy field.
 func (r *taskResolver) CreatedBy(ctx context.Context, obj *_model.Task) (string, error) {
 	panic(fmt.Errorf("not implemented: CreatedBy - createdBy"))
@@ -121,6 +322,14 @@ func (r *taskResolver) ModifiedBy(ctx context.Context, obj *_model.Task) (*strin
 	panic(fmt.Errorf("not implemented: ModifiedBy - modifiedBy"))
 }
 
+// DeletedBy is the resolver for the deletedBy field.
+func (r *taskResolver) DeletedBy(ctx context.Context, obj *_model.Task) (*_model.User, error) {
+	if obj.DeletedBy == nil {
+		return nil, nil
+	}
+	return _dl.For(ctx).UserLoader.Load(ctx, obj.DeletedBy.String())
+}
+
 // Subscription returns _generated.SubscriptionResolver implementation.
 func (r *Resolver) Subscription() _generated.SubscriptionResolver { return &subscriptionResolver{r} }
 
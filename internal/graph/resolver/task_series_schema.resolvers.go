@@ -0,0 +1,80 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.67
+
+import (
+	"context"
+
+	"bitbucket.org/edts/go-task-management/internal/graph/_generated"
+	_dl "bitbucket.org/edts/go-task-management/internal/graph/loaders"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+)
+
+// CreateTaskSeries is the resolver for the createTaskSeries field.
+func (r *mutationResolver) CreateTaskSeries(ctx context.Context, input _genModel.CreateTaskSeriesInput) (*_model.TaskSeries, error) {
+	// Call the usecase
+	series, err := r.Usecase.TaskSeriesUsecase.CreateTaskSeries(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// UpdateTaskSeries is the resolver for the updateTaskSeries field.
+func (r *mutationResolver) UpdateTaskSeries(ctx context.Context, input _genModel.UpdateTaskSeriesInput) (*_model.TaskSeries, error) {
+	// Call the usecase
+	series, err := r.Usecase.TaskSeriesUsecase.UpdateTaskSeries(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// EndTaskSeries is the resolver for the endTaskSeries field.
+func (r *mutationResolver) EndTaskSeries(ctx context.Context, id string) (*_model.TaskSeries, error) {
+	// Call the usecase
+	series, err := r.Usecase.TaskSeriesUsecase.EndTaskSeries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// TaskSeries is the resolver for the taskSeries field.
+func (r *queryResolver) TaskSeries(ctx context.Context, teamID string) ([]*_model.TaskSeries, error) {
+	// Call the usecase
+	seriesList, err := r.Usecase.TaskSeriesUsecase.GetTaskSeriesByTeam(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	return seriesList, nil
+}
+
+// Series is the resolver for the series field.
+func (r *taskResolver) Series(ctx context.Context, obj *_model.Task) (*_model.TaskSeries, error) {
+	if obj.SeriesID == nil {
+		return nil, nil
+	}
+	return _dl.For(ctx).TaskSeriesLoader.Load(ctx, *obj.SeriesID)
+}
+
+// Team is the resolver for the team field.
+func (r *taskSeriesResolver) Team(ctx context.Context, obj *_model.TaskSeries) (*_model.Team, error) {
+	return _dl.For(ctx).TeamLoader.Load(ctx, obj.TeamID)
+}
+
+// AssignedUser is the resolver for the assignedUser field.
+func (r *taskSeriesResolver) AssignedUser(ctx context.Context, obj *_model.TaskSeries) (*_model.User, error) {
+	if obj.AssignedTo == nil {
+		return nil, nil
+	}
+	return _dl.For(ctx).UserLoader.Load(ctx, *obj.AssignedTo)
+}
+
+// TaskSeries returns _generated.TaskSeriesResolver implementation.
+func (r *Resolver) TaskSeries() _generated.TaskSeriesResolver { return &taskSeriesResolver{r} }
+
+type taskSeriesResolver struct{ *Resolver }
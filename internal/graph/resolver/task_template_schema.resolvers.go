@@ -0,0 +1,109 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.67
+
+import (
+	"context"
+
+	"bitbucket.org/edts/go-task-management/internal/graph/_generated"
+	_dl "bitbucket.org/edts/go-task-management/internal/graph/loaders"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+)
+
+// CreateTaskTemplate is the resolver for the createTaskTemplate field.
+func (r *mutationResolver) CreateTaskTemplate(ctx context.Context, input _genModel.CreateTaskTemplateInput) (*_model.TaskTemplate, error) {
+	// Call the usecase
+	template, err := r.Usecase.TaskTemplateUsecase.CreateTaskTemplate(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// UpdateTaskTemplate is the resolver for the updateTaskTemplate field.
+func (r *mutationResolver) UpdateTaskTemplate(ctx context.Context, input _genModel.UpdateTaskTemplateInput) (*_model.TaskTemplate, error) {
+	// Call the usecase
+	template, err := r.Usecase.TaskTemplateUsecase.UpdateTaskTemplate(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// DeleteTaskTemplate is the resolver for the deleteTaskTemplate field.
+func (r *mutationResolver) DeleteTaskTemplate(ctx context.Context, id string) (bool, error) {
+	// Call the usecase
+	return r.Usecase.TaskTemplateUsecase.DeleteTaskTemplate(ctx, id)
+}
+
+// CreateTaskFromTemplate is the resolver for the createTaskFromTemplate field.
+func (r *mutationResolver) CreateTaskFromTemplate(ctx context.Context, templateID string, overrides *_genModel.TaskTemplateOverridesInput) (*_model.Task, error) {
+	// Call the usecase
+	task, err := r.Usecase.TaskTemplateUsecase.CreateTaskFromTemplate(ctx, templateID, overrides)
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// ImportTaskTemplates is the resolver for the importTaskTemplates field.
+func (r *mutationResolver) ImportTaskTemplates(ctx context.Context, teamID string, json string) ([]*_model.TaskTemplate, error) {
+	// Call the usecase
+	templates, err := r.Usecase.TaskTemplateUsecase.ImportTaskTemplates(ctx, teamID, json)
+	if err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// TaskTemplates is the resolver for the taskTemplates field.
+func (r *queryResolver) TaskTemplates(ctx context.Context, teamID string) ([]*_model.TaskTemplate, error) {
+	// Call the usecase
+	templates, err := r.Usecase.TaskTemplateUsecase.GetTaskTemplatesByTeam(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// TaskTemplate is the resolver for the taskTemplate field.
+func (r *queryResolver) TaskTemplate(ctx context.Context, id string) (*_model.TaskTemplate, error) {
+	// Call the usecase
+	template, err := r.Usecase.TaskTemplateUsecase.GetTaskTemplateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// ExportTaskTemplates is the resolver for the exportTaskTemplates field.
+func (r *queryResolver) ExportTaskTemplates(ctx context.Context, teamID string, ids []string) (string, error) {
+	// Call the usecase
+	return r.Usecase.TaskTemplateUsecase.ExportTaskTemplates(ctx, teamID, ids)
+}
+
+// Team is the resolver for the team field.
+func (r *taskTemplateResolver) Team(ctx context.Context, obj *_model.TaskTemplate) (*_model.Team, error) {
+	return _dl.For(ctx).TeamLoader.Load(ctx, obj.TeamID)
+}
+
+// Labels is the resolver for the labels field.
+func (r *taskTemplateResolver) Labels(ctx context.Context, obj *_model.TaskTemplate) ([]*_model.Label, error) {
+	return _dl.For(ctx).TaskTemplateLabelsLoader.Load(ctx, obj.ID)
+}
+
+// AssignedUser is the resolver for the assignedUser field.
+func (r *taskTemplateResolver) AssignedUser(ctx context.Context, obj *_model.TaskTemplate) (*_model.User, error) {
+	if obj.AssignedTo == nil {
+		return nil, nil
+	}
+	return _dl.For(ctx).UserLoader.Load(ctx, *obj.AssignedTo)
+}
+
+// TaskTemplate returns _generated.TaskTemplateResolver implementation.
+func (r *Resolver) TaskTemplate() _generated.TaskTemplateResolver { return &taskTemplateResolver{r} }
+
+type taskTemplateResolver struct{ *Resolver }
@@ -0,0 +1,74 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.67
+
+import (
+	"context"
+
+	"bitbucket.org/edts/go-task-management/internal/graph/_generated"
+	_dl "bitbucket.org/edts/go-task-management/internal/graph/loaders"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+)
+
+// InviteToTeam is the resolver for the inviteToTeam field.
+func (r *mutationResolver) InviteToTeam(ctx context.Context, teamID string, email string, role _model.TeamRole) (*_model.TeamInvitation, error) {
+	// Call the usecase
+	invitation, err := r.Usecase.InvitationUsecase.InviteToTeam(ctx, teamID, email, role)
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// AcceptInvitation is the resolver for the acceptInvitation field.
+func (r *mutationResolver) AcceptInvitation(ctx context.Context, token string) (*_model.Team, error) {
+	// Call the usecase
+	team, err := r.Usecase.InvitationUsecase.AcceptInvitation(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// DeclineInvitation is the resolver for the declineInvitation field.
+func (r *mutationResolver) DeclineInvitation(ctx context.Context, token string) (bool, error) {
+	// Call the usecase
+	isDeclined, err := r.Usecase.InvitationUsecase.DeclineInvitation(ctx, token)
+	if err != nil {
+		return false, err
+	}
+	return isDeclined, nil
+}
+
+// PendingInvitations is the resolver for the pendingInvitations field.
+func (r *queryResolver) PendingInvitations(ctx context.Context, teamID *string) ([]*_model.TeamInvitation, error) {
+	// Call the usecase
+	invitations, err := r.Usecase.InvitationUsecase.GetPendingInvitations(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// Team is the resolver for the team field.
+func (r *teamInvitationResolver) Team(ctx context.Context, obj *_model.TeamInvitation) (*_model.Team, error) {
+	// Not loaded through the dataloader, the invitee cannot see the workspace of the team yet
+	return r.Usecase.InvitationUsecase.GetInvitedTeam(ctx, obj)
+}
+
+// InvitedBy is the resolver for the invitedBy field.
+func (r *teamInvitationResolver) InvitedBy(ctx context.Context, obj *_model.TeamInvitation) (*_model.User, error) {
+	if obj.CreatedBy == nil {
+		return nil, nil
+	}
+	return _dl.For(ctx).UserLoader.Load(ctx, obj.CreatedBy.String())
+}
+
+// TeamInvitation returns _generated.TeamInvitationResolver implementation.
+func (r *Resolver) TeamInvitation() _generated.TeamInvitationResolver {
+	return &teamInvitationResolver{r}
+}
+
+type teamInvitationResolver struct{ *Resolver }
@@ -9,6 +9,7 @@ import (
 	"fmt"
 
 	"bitbucket.org/edts/go-task-management/internal/graph/_generated"
+	_dl "bitbucket.org/edts/go-task-management/internal/graph/loaders"
 	"bitbucket.org/edts/go-task-management/internal/model"
 	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
 )
@@ -33,16 +34,91 @@ func (r *mutationResolver) UpdateTeam(ctx context.Context, input _genModel.Updat
 	return updateTeam, nil
 }
 
+// RemoveTeamMember is the resolver for the removeTeamMember field.
+func (r *mutationResolver) RemoveTeamMember(ctx context.Context, teamID string, userID string, reassignTo *string) (*model.Team, error) {
+	// Call the usecase
+	team, err := r.Usecase.TeamUsecase.RemoveTeamMember(ctx, teamID, userID, reassignTo)
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// LeaveTeam is the resolver for the leaveTeam field.
+func (r *mutationResolver) LeaveTeam(ctx context.Context, teamID string) (bool, error) {
+	// Call the usecase
+	hasLeft, err := r.Usecase.TeamUsecase.LeaveTeam(ctx, teamID)
+	if err != nil {
+		return false, err
+	}
+	return hasLeft, nil
+}
+
+// ArchiveTeam is the resolver for the archiveTeam field.
+func (r *mutationResolver) ArchiveTeam(ctx context.Context, id string) (*model.Team, error) {
+	// Call the usecase
+	team, err := r.Usecase.TeamUsecase.ArchiveTeam(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// UnarchiveTeam is the resolver for the unarchiveTeam field.
+func (r *mutationResolver) UnarchiveTeam(ctx context.Context, id string) (*model.Team, error) {
+	// Call the usecase
+	team, err := r.Usecase.TeamUsecase.UnarchiveTeam(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// DeleteTeam is the resolver for the deleteTeam field.
+func (r *mutationResolver) DeleteTeam(ctx context.Context, id string) (*model.Team, error) {
+	// Call the usecase
+	team, err := r.Usecase.TeamUsecase.DeleteTeam(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// RestoreTeam is the resolver for the restoreTeam field.
+func (r *mutationResolver) RestoreTeam(ctx context.Context, id string) (*model.Team, error) {
+	// Call the usecase
+	team, err := r.Usecase.TeamUsecase.RestoreTeam(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// TransferTeamOwnership is the resolver for the transferTeamOwnership field.
+func (r *mutationResolver) TransferTeamOwnership(ctx context.Context, teamID string, userID string) (*model.Team, error) {
+	// Call the usecase
+	team, err := r.Usecase.TeamUsecase.TransferTeamOwnership(ctx, teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
 // TeamsByUser is the resolver for the teamsByUser field.
-func (r *queryResolver) TeamsByUser(ctx context.Context) ([]*_genModel.TeamSummary, error) {
+func (r *queryResolver) TeamsByUser(ctx context.Context, includeArchived *bool) ([]*_genModel.TeamSummary, error) {
 	// Call the usecase
-	teams, err := r.Usecase.TeamUsecase.GetTeamsByUser(ctx)
+	teams, err := r.Usecase.TeamUsecase.GetTeamsByUser(ctx, includeArchived != nil && *includeArchived)
 	if err != nil {
 		return nil, err
 	}
 	return teams, nil
 }
 
+// WipLimits is the resolver for the wipLimits field.
+func (r *teamResolver) WipLimits(ctx context.Context, obj *model.Team) ([]*model.WipLimit, error) {
+	return _dl.For(ctx).TeamWipLimitsLoader.Load(ctx, obj.ID)
+}
+
 // CreatedBy is the resolver for the createdBy field.
 func (r *teamResolver) CreatedBy(ctx context.Context, obj *model.Team) (string, error) {
 	panic(fmt.Errorf("not implemented: CreatedBy - createdBy"))
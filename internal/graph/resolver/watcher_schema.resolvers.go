@@ -0,0 +1,63 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.67
+
+import (
+	"context"
+
+	_dl "bitbucket.org/edts/go-task-management/internal/graph/loaders"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+)
+
+// WatchTask is the resolver for the watchTask field.
+func (r *mutationResolver) WatchTask(ctx context.Context, taskID string) (*_model.Task, error) {
+	// Call the usecase
+	task, err := r.Usecase.WatcherUsecase.WatchTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// UnwatchTask is the resolver for the unwatchTask field.
+func (r *mutationResolver) UnwatchTask(ctx context.Context, taskID string) (*_model.Task, error) {
+	// Call the usecase
+	task, err := r.Usecase.WatcherUsecase.UnwatchTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// UpdateNotificationPreference is the resolver for the updateNotificationPreference field.
+func (r *mutationResolver) UpdateNotificationPreference(ctx context.Context, input _genModel.UpdateNotificationPreferenceInput) (*_model.NotificationPreference, error) {
+	// Call the usecase
+	preference, err := r.Usecase.NotificationUsecase.UpdateNotificationPreference(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return preference, nil
+}
+
+// NotificationPreferences is the resolver for the notificationPreferences field.
+func (r *queryResolver) NotificationPreferences(ctx context.Context) ([]*_model.NotificationPreference, error) {
+	// Call the usecase
+	return r.Usecase.NotificationUsecase.GetNotificationPreferences(ctx)
+}
+
+// Watchers is the resolver for the watchers field.
+func (r *taskResolver) Watchers(ctx context.Context, obj *_model.Task) ([]*_model.User, error) {
+	watchers, err := _dl.For(ctx).TaskWatchersLoader.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]string, 0, len(watchers))
+	for _, watcher := range watchers {
+		userIDs = append(userIDs, watcher.UserID)
+	}
+	return _dl.For(ctx).UserLoader.LoadAll(ctx, userIDs)
+}
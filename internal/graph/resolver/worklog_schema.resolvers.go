@@ -0,0 +1,113 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.67
+
+import (
+	"context"
+
+	"bitbucket.org/edts/go-task-management/internal/graph/_generated"
+	_dl "bitbucket.org/edts/go-task-management/internal/graph/loaders"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_usecase "bitbucket.org/edts/go-task-management/internal/usecase"
+)
+
+// LogWork is the resolver for the logWork field.
+func (r *mutationResolver) LogWork(ctx context.Context, input _genModel.LogWorkInput) (*_model.Worklog, error) {
+	// Call the usecase
+	worklog, err := r.Usecase.WorklogUsecase.LogWork(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return worklog, nil
+}
+
+// DeleteWorklog is the resolver for the deleteWorklog field.
+func (r *mutationResolver) DeleteWorklog(ctx context.Context, id string) (bool, error) {
+	// Call the usecase
+	isDeleted, err := r.Usecase.WorklogUsecase.DeleteWorklog(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return isDeleted, nil
+}
+
+// StartTimer is the resolver for the startTimer field.
+func (r *mutationResolver) StartTimer(ctx context.Context, taskID string, note *string) (*_model.Worklog, error) {
+	// Call the usecase
+	worklog, err := r.Usecase.WorklogUsecase.StartTimer(ctx, taskID, note)
+	if err != nil {
+		return nil, err
+	}
+	return worklog, nil
+}
+
+// StopTimer is the resolver for the stopTimer field.
+func (r *mutationResolver) StopTimer(ctx context.Context) (*_model.Worklog, error) {
+	// Call the usecase
+	worklog, err := r.Usecase.WorklogUsecase.StopTimer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return worklog, nil
+}
+
+// RunningTimer is the resolver for the runningTimer field.
+func (r *queryResolver) RunningTimer(ctx context.Context) (*_model.Worklog, error) {
+	// Call the usecase
+	worklog, err := r.Usecase.WorklogUsecase.GetRunningTimer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return worklog, nil
+}
+
+// Timesheet is the resolver for the timesheet field.
+func (r *queryResolver) Timesheet(ctx context.Context, userID *string, from string, to string) (*_genModel.Timesheet, error) {
+	// Call the usecase
+	timesheet, err := r.Usecase.WorklogUsecase.GetTimesheet(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return timesheet, nil
+}
+
+// Worklogs is the resolver for the worklogs field.
+func (r *taskResolver) Worklogs(ctx context.Context, obj *_model.Task) ([]*_model.Worklog, error) {
+	return _dl.For(ctx).TaskWorklogsLoader.Load(ctx, obj.ID)
+}
+
+// TimeSpent is the resolver for the timeSpent field.
+func (r *taskResolver) TimeSpent(ctx context.Context, obj *_model.Task) (int32, error) {
+	worklogs, err := _dl.For(ctx).TaskWorklogsLoader.Load(ctx, obj.ID)
+	if err != nil {
+		return 0, err
+	}
+	return _usecase.ComputeTimeSpent(worklogs), nil
+}
+
+// RemainingEstimate is the resolver for the remainingEstimate field.
+func (r *taskResolver) RemainingEstimate(ctx context.Context, obj *_model.Task) (*int32, error) {
+	worklogs, err := _dl.For(ctx).TaskWorklogsLoader.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	return _usecase.ComputeRemainingEstimate(obj, worklogs), nil
+}
+
+// Task is the resolver for the task field.
+func (r *worklogResolver) Task(ctx context.Context, obj *_model.Worklog) (*_model.Task, error) {
+	return _dl.For(ctx).TaskLoader.Load(ctx, obj.TaskID)
+}
+
+// User is the resolver for the user field.
+func (r *worklogResolver) User(ctx context.Context, obj *_model.Worklog) (*_model.User, error) {
+	return _dl.For(ctx).UserLoader.Load(ctx, obj.UserID)
+}
+
+// Worklog returns _generated.WorklogResolver implementation.
+func (r *Resolver) Worklog() _generated.WorklogResolver { return &worklogResolver{r} }
+
+type worklogResolver struct{ *Resolver }
@@ -0,0 +1,75 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.67
+
+import (
+	"context"
+
+	"bitbucket.org/edts/go-task-management/internal/graph/_generated"
+	_dl "bitbucket.org/edts/go-task-management/internal/graph/loaders"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+)
+
+// CreateWorkspace is the resolver for the createWorkspace field.
+func (r *mutationResolver) CreateWorkspace(ctx context.Context, name string) (*_model.Workspace, error) {
+	// Call the usecase
+	workspace, err := r.Usecase.WorkspaceUsecase.CreateWorkspace(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// AddWorkspaceMember is the resolver for the addWorkspaceMember field.
+func (r *mutationResolver) AddWorkspaceMember(ctx context.Context, email string, role _model.WorkspaceRole) (*_model.WorkspaceMember, error) {
+	// Call the usecase
+	member, err := r.Usecase.WorkspaceUsecase.AddWorkspaceMember(ctx, email, role)
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveWorkspaceMember is the resolver for the removeWorkspaceMember field.
+func (r *mutationResolver) RemoveWorkspaceMember(ctx context.Context, userID string) (bool, error) {
+	// Call the usecase
+	isRemoved, err := r.Usecase.WorkspaceUsecase.RemoveWorkspaceMember(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return isRemoved, nil
+}
+
+// Workspaces is the resolver for the workspaces field.
+func (r *queryResolver) Workspaces(ctx context.Context) ([]*_model.Workspace, error) {
+	// Call the usecase
+	workspaces, err := r.Usecase.WorkspaceUsecase.GetWorkspaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+// WorkspaceMembers is the resolver for the workspaceMembers field.
+func (r *queryResolver) WorkspaceMembers(ctx context.Context) ([]*_model.WorkspaceMember, error) {
+	// Call the usecase
+	members, err := r.Usecase.WorkspaceUsecase.GetWorkspaceMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// User is the resolver for the user field.
+func (r *workspaceMemberResolver) User(ctx context.Context, obj *_model.WorkspaceMember) (*_model.User, error) {
+	return _dl.For(ctx).UserLoader.Load(ctx, obj.UserID)
+}
+
+// WorkspaceMember returns _generated.WorkspaceMemberResolver implementation.
+func (r *Resolver) WorkspaceMember() _generated.WorkspaceMemberResolver {
+	return &workspaceMemberResolver{r}
+}
+
+type workspaceMemberResolver struct{ *Resolver }
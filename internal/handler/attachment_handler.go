@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	_usecase "bitbucket.org/edts/go-task-management/internal/usecase"
+	"bitbucket.org/edts/go-task-management/pkg/blobstore"
+)
+
+// AttachmentHandler serves the downloads of the attachments kept by stores that do not sign their own URLs
+type AttachmentHandler struct {
+	attachmentUsecase _usecase.AttachmentUsecaseInterface
+}
+
+func NewAttachmentHandler(attachmentUsecase _usecase.AttachmentUsecaseInterface) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentUsecase: attachmentUsecase,
+	}
+}
+
+// Download streams the attachment of a signed link issued by the attachment url field
+func (h *AttachmentHandler) Download(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+		return
+	}
+
+	query := r.URL.Query()
+	attachment, content, err := h.attachmentUsecase.OpenAttachment(r.Context(), query.Get("id"), query.Get("expires"), query.Get("signature"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(int(attachment.Size)))
+	w.Header().Set("Content-Disposition", blobstore.ContentDisposition(attachment.Filename))
+	// The content type was sniffed on upload, browsers must not guess another one
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	if _, err = io.Copy(w, content); err != nil {
+		logs.Errorf("Download:: Error streaming attachment %s: %v", attachment.ID, err)
+	}
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_usecase "bitbucket.org/edts/go-task-management/internal/usecase"
+	_logger "bitbucket.org/edts/go-task-management/pkg/logger"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+var logs = _logger.GetContextLoggerf(nil)
+
+// oidcFlowCookie keeps the signed flow state between the login redirect and the callback
+const oidcFlowCookie = "oidc_flow"
+
+// OIDCHandler serves the single sign-on HTTP routes next to /graphql
+type OIDCHandler struct {
+	oidcUsecase        _usecase.OIDCUsecaseInterface
+	successRedirectURL string
+	secureCookie       bool
+}
+
+func NewOIDCHandler(oidcUsecase _usecase.OIDCUsecaseInterface, successRedirectURL string, secureCookie bool) *OIDCHandler {
+	return &OIDCHandler{
+		oidcUsecase:        oidcUsecase,
+		successRedirectURL: successRedirectURL,
+		secureCookie:       secureCookie,
+	}
+}
+
+// Login redirects the browser to the identity provider
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	redirect, err := h.oidcUsecase.BeginLogin(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Value:    redirect.FlowToken,
+		Path:     "/auth/oidc",
+		Expires:  redirect.ExpiresAt,
+		HttpOnly: true,
+		Secure:   h.secureCookie,
+		// Lax so the cookie survives the top level redirect back from the provider
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, redirect.AuthURL, http.StatusFound)
+}
+
+// Callback completes the login and hands the access/refresh pair to the frontend
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		logs.Infof("Callback:: Identity provider returned %s: %s", providerErr, query.Get("error_description"))
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Login was cancelled or denied"})
+		return
+	}
+
+	cookie, err := r.Cookie(oidcFlowCookie)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "Login session is invalid or expired"})
+		return
+	}
+	// The flow state is single use
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Path:     "/auth/oidc",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.secureCookie,
+	})
+
+	authResponse, err := h.oidcUsecase.CompleteLogin(r.Context(), query.Get("code"), query.Get("state"), cookie.Value)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if h.successRedirectURL == "" {
+		writeJSON(w, http.StatusOK, authResponse)
+		return
+	}
+	http.Redirect(w, r, h.successRedirect(authResponse), http.StatusFound)
+}
+
+// successRedirect passes the tokens in the URL fragment, fragments are never sent to servers or logged in access logs
+func (h *OIDCHandler) successRedirect(authResponse *_genModel.AuthResponse) string {
+	fragment := url.Values{
+		"token":        {authResponse.Token},
+		"refreshToken": {authResponse.RefreshToken},
+	}
+	return h.successRedirectURL + "#" + fragment.Encode()
+}
+
+// writeError maps the usecase GraphQL errors to an HTTP status
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	message := "Internal Server Error"
+
+	var gqlErr *gqlerror.Error
+	if errors.As(err, &gqlErr) {
+		message = gqlErr.Message
+		if code, ok := gqlErr.Extensions["status"].(int); ok {
+			status = code
+		}
+	}
+	writeJSON(w, status, map[string]string{"message": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logs.Errorf("writeJSON:: Error encoding response: %v", err)
+	}
+}
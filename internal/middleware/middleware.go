@@ -1,15 +1,19 @@
 package middleware
 
 import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
 	"context"
+	"fmt"
 	"github.com/rs/cors"
+	"net"
 	"net/http"
+	"strings"
 )
 
-// RequestMiddleware add request context middleware
+// RequestMiddleware add request context middleware, the database scope starts empty until the caller is authenticated
 func RequestMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.WithValue(r.Context(), "httpRequest", r)
+		ctx := context.WithValue(_db.WithScope(r.Context()), "httpRequest", r)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -19,9 +23,78 @@ func CORSHandler(next http.Handler) http.Handler {
 	corsHandler := cors.New(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:5173"}, // Adjust as needed
 		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-Workspace-ID", "Idempotency-Key"},
 		AllowCredentials: true,
 	})
 
 	return corsHandler.Handler(next)
 }
+
+// TrustedProxies lists the networks of the proxies in front of the API, the only hops whose X-Forwarded-For entries are honored
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses CIDR ranges such as 10.0.0.0/8, a plain address is read as a single host
+func ParseTrustedProxies(entries []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+func (p TrustedProxies) contains(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the caller IP. X-Forwarded-For is only read when the request comes from a trusted proxy,
+// the client sets any value it likes at the left of the header so the entries are walked from the right
+// and the first address that is not one of our proxies is the client
+func ClientIP(r *http.Request, trustedProxies TrustedProxies) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !trustedProxies.contains(remote) {
+		return remote
+	}
+
+	entries := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := strings.TrimSpace(entries[i])
+		if entry == "" {
+			continue
+		}
+		if !trustedProxies.contains(entry) {
+			return entry
+		}
+		remote = entry
+	}
+	// Every hop is one of our proxies, the request started inside the trusted network
+	return remote
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.10"})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		proxies    TrustedProxies
+		want       string
+	}{
+		{
+			name:       "direct request",
+			remoteAddr: "203.0.113.7:51234",
+			proxies:    proxies,
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "header from an untrusted caller is ignored",
+			remoteAddr: "203.0.113.7:51234",
+			forwarded:  []string{"198.51.100.1"},
+			proxies:    proxies,
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "header ignored without trusted proxies",
+			remoteAddr: "10.0.0.5:51234",
+			forwarded:  []string{"198.51.100.1"},
+			want:       "10.0.0.5",
+		},
+		{
+			name:       "one proxy hop",
+			remoteAddr: "10.0.0.5:51234",
+			forwarded:  []string{"203.0.113.7"},
+			proxies:    proxies,
+			want:       "203.0.113.7",
+		},
+		{
+			// The client sent X-Forwarded-For: 198.51.100.1 and the proxy appended the address it saw
+			name:       "spoofed header through one proxy hop",
+			remoteAddr: "10.0.0.5:51234",
+			forwarded:  []string{"198.51.100.1, 203.0.113.7"},
+			proxies:    proxies,
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "spoofed header through two proxy hops",
+			remoteAddr: "10.0.0.5:51234",
+			forwarded:  []string{"198.51.100.1, 203.0.113.7, 192.168.1.10"},
+			proxies:    proxies,
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "repeated headers are read as one list",
+			remoteAddr: "10.0.0.5:51234",
+			forwarded:  []string{"198.51.100.1", "203.0.113.7"},
+			proxies:    proxies,
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "request from inside the trusted network",
+			remoteAddr: "10.0.0.5:51234",
+			forwarded:  []string{"10.1.2.3"},
+			proxies:    proxies,
+			want:       "10.1.2.3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/query", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+
+			if got := ClientIP(r, tt.proxies); got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxiesRejectsInvalidEntries(t *testing.T) {
+	for _, entry := range []string{"proxy.local", "10.0.0.0/33"} {
+		if _, err := ParseTrustedProxies([]string{entry}); err == nil {
+			t.Fatalf("expected %q to be rejected", entry)
+		}
+	}
+}
@@ -3,12 +3,18 @@
 package model
 
 import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
 	"bitbucket.org/edts/go-task-management/internal/model"
 )
 
 type AssignTaskInput struct {
-	ID         string  `json:"id"`
-	AssignedTo *string `json:"assignedTo,omitempty"`
+	ID              string  `json:"id"`
+	AssignedTo      *string `json:"assignedTo,omitempty"`
+	ExpectedVersion *int32  `json:"expectedVersion,omitempty"`
 }
 
 type AssignUserToTeamInput struct {
@@ -28,30 +34,151 @@ type AuthResponse struct {
 	User         *model.User `json:"user"`
 }
 
+type BulkTaskError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type BulkTaskPatch struct {
+	Priority       *model.TaskPriority `json:"priority,omitempty"`
+	StoryPoints    *int32              `json:"storyPoints,omitempty"`
+	DueDate        *string             `json:"dueDate,omitempty"`
+	AddLabelIds    []string            `json:"addLabelIds,omitempty"`
+	RemoveLabelIds []string            `json:"removeLabelIds,omitempty"`
+}
+
+type BulkTaskResult struct {
+	ID    string         `json:"id"`
+	Task  *model.Task    `json:"task,omitempty"`
+	Error *BulkTaskError `json:"error,omitempty"`
+}
+
+type CreateLabelInput struct {
+	TeamID         string  `json:"teamId"`
+	Name           string  `json:"name"`
+	Color          string  `json:"color"`
+	IdempotencyKey *string `json:"idempotencyKey,omitempty"`
+}
+
+type CreateMilestoneInput struct {
+	TeamID         string  `json:"teamId"`
+	Name           string  `json:"name"`
+	Description    *string `json:"description,omitempty"`
+	TargetDate     string  `json:"targetDate"`
+	IdempotencyKey *string `json:"idempotencyKey,omitempty"`
+}
+
+type CreatePersonalAccessTokenInput struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type CreateServiceAccountInput struct {
+	TeamID         string  `json:"teamId"`
+	Name           string  `json:"name"`
+	Description    *string `json:"description,omitempty"`
+	IdempotencyKey *string `json:"idempotencyKey,omitempty"`
+}
+
+type CreateServiceAccountTokenInput struct {
+	ServiceAccountID string     `json:"serviceAccountId"`
+	Name             string     `json:"name"`
+	Scopes           []string   `json:"scopes"`
+	ExpiresAt        *time.Time `json:"expiresAt,omitempty"`
+}
+
+type CreateSprintInput struct {
+	TeamID         string  `json:"teamId"`
+	Name           string  `json:"name"`
+	Goal           *string `json:"goal,omitempty"`
+	StartDate      string  `json:"startDate"`
+	EndDate        string  `json:"endDate"`
+	IdempotencyKey *string `json:"idempotencyKey,omitempty"`
+}
+
 type CreateTaskInput struct {
-	Title       string  `json:"title"`
-	Description *string `json:"description,omitempty"`
-	Status      string  `json:"status"`
-	AssignedTo  *string `json:"assignedTo,omitempty"`
-	TeamID      string  `json:"teamId"`
-	DueDate     string  `json:"dueDate"`
+	Title            string              `json:"title"`
+	Description      *string             `json:"description,omitempty"`
+	Status           string              `json:"status"`
+	AssignedTo       *string             `json:"assignedTo,omitempty"`
+	TeamID           string              `json:"teamId"`
+	ParentID         *string             `json:"parentId,omitempty"`
+	Priority         *model.TaskPriority `json:"priority,omitempty"`
+	StoryPoints      *int32              `json:"storyPoints,omitempty"`
+	OriginalEstimate *int32              `json:"originalEstimate,omitempty"`
+	DueDate          string              `json:"dueDate"`
+	IdempotencyKey   *string             `json:"idempotencyKey,omitempty"`
+}
+
+type CreateTaskSeriesInput struct {
+	TeamID           string              `json:"teamId"`
+	Title            string              `json:"title"`
+	Description      *string             `json:"description,omitempty"`
+	AssignedTo       *string             `json:"assignedTo,omitempty"`
+	Priority         *model.TaskPriority `json:"priority,omitempty"`
+	StoryPoints      *int32              `json:"storyPoints,omitempty"`
+	OriginalEstimate *int32              `json:"originalEstimate,omitempty"`
+	// FREQ of DAILY, WEEKLY or MONTHLY with INTERVAL, BYDAY, BYMONTHDAY and a COUNT or UNTIL end
+	Rrule string `json:"rrule"`
+	// Start of the series, days and clock time of the rule are read from it in UTC.
+	// Unlike the DTSTART of RFC 5545 it is not an occurrence by itself: the first occurrence is the first match
+	// of the rule on or after it, and COUNT only counts the matches
+	StartAt        string  `json:"startAt"`
+	IdempotencyKey *string `json:"idempotencyKey,omitempty"`
+}
+
+type CreateTaskTemplateInput struct {
+	TeamID           string              `json:"teamId"`
+	Name             string              `json:"name"`
+	TitlePattern     string              `json:"titlePattern"`
+	Description      *string             `json:"description,omitempty"`
+	Checklist        []string            `json:"checklist,omitempty"`
+	LabelIds         []string            `json:"labelIds,omitempty"`
+	AssignedTo       *string             `json:"assignedTo,omitempty"`
+	DueOffset        *int32              `json:"dueOffset,omitempty"`
+	Priority         *model.TaskPriority `json:"priority,omitempty"`
+	StoryPoints      *int32              `json:"storyPoints,omitempty"`
+	OriginalEstimate *int32              `json:"originalEstimate,omitempty"`
+	IsDefault        *bool               `json:"isDefault,omitempty"`
+	IdempotencyKey   *string             `json:"idempotencyKey,omitempty"`
 }
 
 type CreateTeamInput struct {
-	Name        string   `json:"name"`
-	Description *string  `json:"description,omitempty"`
-	Assignee    []string `json:"assignee,omitempty"`
+	Name string `json:"name"`
+	// 2 to 10 letters or digits starting with a letter, the first letters of the name when omitted. It cannot change later
+	Key                *string                   `json:"key,omitempty"`
+	Description        *string                   `json:"description,omitempty"`
+	Assignee           []string                  `json:"assignee,omitempty"`
+	TaskReassignPolicy *model.TaskReassignPolicy `json:"taskReassignPolicy,omitempty"`
+	WipLimitPolicy     *model.WipLimitPolicy     `json:"wipLimitPolicy,omitempty"`
+	IdempotencyKey     *string                   `json:"idempotencyKey,omitempty"`
 }
 
 type CreateUserInput struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name            string  `json:"name"`
+	Email           string  `json:"email"`
+	Password        string  `json:"password"`
+	InvitationToken *string `json:"invitationToken,omitempty"`
+}
+
+type CreatedAccessToken struct {
+	Token       string                     `json:"token"`
+	AccessToken *model.PersonalAccessToken `json:"accessToken"`
 }
 
 type DeletedTaskNotification struct {
-	TaskID  string `json:"taskId"`
-	Deleted bool   `json:"deleted"`
+	TaskID    string `json:"taskId"`
+	Deleted   bool   `json:"deleted"`
+	Permanent bool   `json:"permanent"`
+}
+
+type LogWorkInput struct {
+	TaskID         string  `json:"taskId"`
+	StartedAt      string  `json:"startedAt"`
+	Duration       int32   `json:"duration"`
+	Note           *string `json:"note,omitempty"`
+	IdempotencyKey *string `json:"idempotencyKey,omitempty"`
 }
 
 type LoginUserInput struct {
@@ -59,9 +186,17 @@ type LoginUserInput struct {
 	Password string  `json:"password"`
 }
 
+type MilestoneProgress struct {
+	Completed int32   `json:"completed"`
+	Total     int32   `json:"total"`
+	Percent   float64 `json:"percent"`
+}
+
 type MoveTaskInput struct {
-	ID     string `json:"id"`
-	Status string `json:"status"`
+	ID              string `json:"id"`
+	Status          string `json:"status"`
+	Position        *int32 `json:"position,omitempty"`
+	ExpectedVersion *int32 `json:"expectedVersion,omitempty"`
 }
 
 type Mutation struct {
@@ -74,26 +209,182 @@ type RefreshTokenInput struct {
 	RefreshToken string `json:"refreshToken"`
 }
 
+type SprintReport struct {
+	Sprint       *model.Sprint        `json:"sprint"`
+	Committed    []*model.Task        `json:"committed"`
+	Completed    []*model.Task        `json:"completed"`
+	CarriedOver  []*model.Task        `json:"carriedOver"`
+	Added        []*model.Task        `json:"added"`
+	Removed      []*model.Task        `json:"removed"`
+	ScopeChanges []*SprintScopeChange `json:"scopeChanges"`
+}
+
+// Task added to or removed from the sprint while it was active
+type SprintScopeChange struct {
+	Task      *model.Task           `json:"task"`
+	Change    SprintScopeChangeType `json:"change"`
+	ChangedAt time.Time             `json:"changedAt"`
+}
+
 type Subscription struct {
 }
 
+type TaskProgress struct {
+	Completed int32   `json:"completed"`
+	Total     int32   `json:"total"`
+	Percent   float64 `json:"percent"`
+}
+
+type TaskTemplateOverridesInput struct {
+	Title            *string             `json:"title,omitempty"`
+	Description      *string             `json:"description,omitempty"`
+	Status           *string             `json:"status,omitempty"`
+	AssignedTo       *string             `json:"assignedTo,omitempty"`
+	ParentID         *string             `json:"parentId,omitempty"`
+	Priority         *model.TaskPriority `json:"priority,omitempty"`
+	StoryPoints      *int32              `json:"storyPoints,omitempty"`
+	OriginalEstimate *int32              `json:"originalEstimate,omitempty"`
+	DueDate          *string             `json:"dueDate,omitempty"`
+}
+
 type TeamSummary struct {
 	Team        *model.Team `json:"team"`
 	MemberCount *int32      `json:"memberCount,omitempty"`
 }
 
+type Timesheet struct {
+	User          *model.User      `json:"user"`
+	From          time.Time        `json:"from"`
+	To            time.Time        `json:"to"`
+	TotalDuration int32            `json:"totalDuration"`
+	Entries       []*model.Worklog `json:"entries"`
+}
+
+type UpdateLabelInput struct {
+	ID    string  `json:"id"`
+	Name  *string `json:"name,omitempty"`
+	Color *string `json:"color,omitempty"`
+}
+
+type UpdateMilestoneInput struct {
+	ID          string                `json:"id"`
+	Name        *string               `json:"name,omitempty"`
+	Description *string               `json:"description,omitempty"`
+	TargetDate  *string               `json:"targetDate,omitempty"`
+	State       *model.MilestoneState `json:"state,omitempty"`
+}
+
+type UpdateNotificationPreferenceInput struct {
+	Kind  model.NotificationKind `json:"kind"`
+	InApp *bool                  `json:"inApp,omitempty"`
+	Email *bool                  `json:"email,omitempty"`
+}
+
+type UpdateSprintInput struct {
+	ID        string  `json:"id"`
+	Name      *string `json:"name,omitempty"`
+	Goal      *string `json:"goal,omitempty"`
+	StartDate *string `json:"startDate,omitempty"`
+	EndDate   *string `json:"endDate,omitempty"`
+}
+
 type UpdateTaskInput struct {
+	ID               string              `json:"id"`
+	Title            *string             `json:"title,omitempty"`
+	Description      *string             `json:"description,omitempty"`
+	Status           *string             `json:"status,omitempty"`
+	AssignedTo       *string             `json:"assignedTo,omitempty"`
+	Priority         *model.TaskPriority `json:"priority,omitempty"`
+	StoryPoints      *int32              `json:"storyPoints,omitempty"`
+	OriginalEstimate *int32              `json:"originalEstimate,omitempty"`
+	DueDate          *string             `json:"dueDate,omitempty"`
+	ExpectedVersion  *int32              `json:"expectedVersion,omitempty"`
+}
+
+type UpdateTaskSeriesInput struct {
+	ID               string              `json:"id"`
+	Title            *string             `json:"title,omitempty"`
+	Description      *string             `json:"description,omitempty"`
+	AssignedTo       *string             `json:"assignedTo,omitempty"`
+	Priority         *model.TaskPriority `json:"priority,omitempty"`
+	StoryPoints      *int32              `json:"storyPoints,omitempty"`
+	OriginalEstimate *int32              `json:"originalEstimate,omitempty"`
+	Rrule            *string             `json:"rrule,omitempty"`
+	// Copy the changes to the tasks of the series not done yet, the tasks edited on their own are left untouched
+	ApplyToOpenOccurrences bool `json:"applyToOpenOccurrences"`
+}
+
+type UpdateTaskTemplateInput struct {
+	ID               string              `json:"id"`
+	Name             *string             `json:"name,omitempty"`
+	TitlePattern     *string             `json:"titlePattern,omitempty"`
+	Description      *string             `json:"description,omitempty"`
+	Checklist        []string            `json:"checklist,omitempty"`
+	LabelIds         []string            `json:"labelIds,omitempty"`
+	AssignedTo       *string             `json:"assignedTo,omitempty"`
+	DueOffset        *int32              `json:"dueOffset,omitempty"`
+	Priority         *model.TaskPriority `json:"priority,omitempty"`
+	StoryPoints      *int32              `json:"storyPoints,omitempty"`
+	OriginalEstimate *int32              `json:"originalEstimate,omitempty"`
+	IsDefault        *bool               `json:"isDefault,omitempty"`
+}
+
+type UpdateTeamInput struct {
 	ID          string  `json:"id"`
-	Title       *string `json:"title,omitempty"`
+	Name        string  `json:"name"`
 	Description *string `json:"description,omitempty"`
-	Status      *string `json:"status,omitempty"`
-	AssignedTo  *string `json:"assignedTo,omitempty"`
-	DueDate     *string `json:"dueDate,omitempty"`
+	// Replaces the team members when provided, members left out are removed
+	Assignee           []string                  `json:"assignee,omitempty"`
+	TaskReassignPolicy *model.TaskReassignPolicy `json:"taskReassignPolicy,omitempty"`
+	WipLimitPolicy     *model.WipLimitPolicy     `json:"wipLimitPolicy,omitempty"`
+	// Replaces the WIP limits when provided, columns left out are unlimited
+	WipLimits []*WipLimitInput `json:"wipLimits,omitempty"`
+	// Rejects the update with a CONFLICT error carrying the current team when it is no longer at this version
+	ExpectedVersion *int32 `json:"expectedVersion,omitempty"`
 }
 
-type UpdateTeamInput struct {
-	ID          string   `json:"id"`
-	Name        string   `json:"name"`
-	Description *string  `json:"description,omitempty"`
-	Assignee    []string `json:"assignee,omitempty"`
+type WipLimitInput struct {
+	Status string `json:"status"`
+	Limit  int32  `json:"limit"`
+}
+
+type SprintScopeChangeType string
+
+const (
+	SprintScopeChangeTypeAdded   SprintScopeChangeType = "ADDED"
+	SprintScopeChangeTypeRemoved SprintScopeChangeType = "REMOVED"
+)
+
+var AllSprintScopeChangeType = []SprintScopeChangeType{
+	SprintScopeChangeTypeAdded,
+	SprintScopeChangeTypeRemoved,
+}
+
+func (e SprintScopeChangeType) IsValid() bool {
+	switch e {
+	case SprintScopeChangeTypeAdded, SprintScopeChangeTypeRemoved:
+		return true
+	}
+	return false
+}
+
+func (e SprintScopeChangeType) String() string {
+	return string(e)
+}
+
+func (e *SprintScopeChangeType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SprintScopeChangeType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SprintScopeChangeType", str)
+	}
+	return nil
+}
+
+func (e SprintScopeChangeType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
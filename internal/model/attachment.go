@@ -0,0 +1,18 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Attachment is a file attached to a task, its content lives in the blob store
+type Attachment struct {
+	ID          string     `json:"id"`
+	TaskID      string     `json:"task_id"` // Foreign key to Task
+	Filename    string     `json:"filename"`
+	ContentType string     `json:"content_type"` // Sniffed from the content
+	Size        int32      `json:"size"`         // Bytes
+	StorageKey  string     `json:"-"`            // Key of the content in the blob store
+	CreatedAt   time.Time  `json:"created_at"`
+	CreatedBy   *uuid.UUID `json:"created_by"`
+}
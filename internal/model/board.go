@@ -0,0 +1,73 @@
+package model
+
+// BoardGroupBy decides what the columns of a board are
+type BoardGroupBy string
+
+const (
+	BoardGroupByStatus BoardGroupBy = "STATUS"
+)
+
+func (g BoardGroupBy) IsValid() bool {
+	return g == BoardGroupByStatus
+}
+
+// BoardSwimlane splits every board column into rows
+type BoardSwimlane string
+
+const (
+	BoardSwimlaneNone     BoardSwimlane = "NONE"
+	BoardSwimlaneAssignee BoardSwimlane = "ASSIGNEE"
+	BoardSwimlaneLabel    BoardSwimlane = "LABEL"
+	BoardSwimlanePriority BoardSwimlane = "PRIORITY"
+)
+
+func (s BoardSwimlane) IsValid() bool {
+	switch s {
+	case BoardSwimlaneNone, BoardSwimlaneAssignee, BoardSwimlaneLabel, BoardSwimlanePriority:
+		return true
+	}
+	return false
+}
+
+type Board struct {
+	TeamID         string         `json:"team_id"`
+	GroupBy        BoardGroupBy   `json:"group_by"`
+	Swimlane       BoardSwimlane  `json:"swimlane"`
+	WipLimitPolicy WipLimitPolicy `json:"wip_limit_policy"`
+	Columns        []*BoardColumn `json:"columns"`
+}
+
+// BoardColumn holds the tasks of one status, Count and the WIP limit cover every lane of the column
+type BoardColumn struct {
+	Status         string       `json:"status"`
+	Count          int32        `json:"count"`
+	WipLimit       *int32       `json:"wip_limit"`
+	IsOverWipLimit bool         `json:"is_over_wip_limit"`
+	Lanes          []*BoardLane `json:"lanes"`
+}
+
+// BoardLane is the part of a column falling in one swimlane, a nil Key is the lane of the tasks
+// without assignee or label and the single lane of a board without swimlanes
+type BoardLane struct {
+	TeamID   string        `json:"team_id"`
+	Status   string        `json:"status"`
+	Swimlane BoardSwimlane `json:"swimlane"`
+	Key      *string       `json:"key"`
+	Label    *Label        `json:"label"`
+	Priority *TaskPriority `json:"priority"`
+	Count    int32         `json:"count"`
+}
+
+// BoardCount is the number of tasks of a team in one status and lane
+type BoardCount struct {
+	Status  string
+	LaneKey *string
+	Count   int32
+}
+
+// TaskPage is a page of tasks in rank order, EndCursor is passed as after to read the next page
+type TaskPage struct {
+	Nodes       []*Task `json:"nodes"`
+	EndCursor   *string `json:"end_cursor"`
+	HasNextPage bool    `json:"has_next_page"`
+}
@@ -0,0 +1,11 @@
+package model
+
+// ChecklistItem is a lightweight step inside a task, ordered by position
+type ChecklistItem struct {
+	Base
+	ID       string `json:"id"`
+	TaskID   string `json:"task_id"` // Foreign key to Task
+	Text     string `json:"text"`
+	IsDone   bool   `json:"is_done"`
+	Position int32  `json:"position"`
+}
@@ -1,14 +1,13 @@
 package model
 
-import (
-	"github.com/google/uuid"
-	"time"
-)
+import "time"
 
+// Comment is a message of the discussion on a task
 type Comment struct {
-	ID        uuid.UUID `json:"id"`
-	TaskID    uuid.UUID `json:"task_id"`
-	UserID    uuid.UUID `json:"user_id"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         string    `json:"id"`
+	TaskID     string    `json:"task_id"` // Foreign key to Task
+	UserID     *string   `json:"user_id"` // Foreign key to User, the author, nil once their account is removed
+	Content    string    `json:"content"`
+	CreatedAt  time.Time `json:"created_at"`
+	ModifiedAt time.Time `json:"modified_at"`
 }
@@ -0,0 +1,24 @@
+package model
+
+// Label belongs to the palette of a team and can be put on any task of that team
+type Label struct {
+	Base
+	ID     string `json:"id"`
+	TeamID string `json:"team_id"` // Foreign key to Team
+	Name   string `json:"name"`
+	Color  string `json:"color"` // Hex color such as #1f883d
+}
+
+// TaskLabel links a task to one of its labels
+type TaskLabel struct {
+	TaskID  string `json:"task_id"`  // Foreign key to Task
+	LabelID string `json:"label_id"` // Foreign key to Label
+	Label   *Label `json:"label"`
+}
+
+// LabelFilter narrows a task listing, the lists that are set must all match
+type LabelFilter struct {
+	Any  []string `json:"any"`  // Tasks carrying at least one of the labels
+	All  []string `json:"all"`  // Tasks carrying every label
+	None []string `json:"none"` // Tasks carrying none of the labels
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Mention is a team member referenced with @name or @email in a task description or comment
+type Mention struct {
+	ID        string     `json:"id"`
+	TaskID    string     `json:"task_id"`    // Foreign key to Task
+	CommentID *string    `json:"comment_id"` // Foreign key to Comment, nil for the description
+	UserID    string     `json:"user_id"`    // Foreign key to User, the mentioned member
+	CreatedAt time.Time  `json:"created_at"`
+	CreatedBy *uuid.UUID `json:"created_by"`
+}
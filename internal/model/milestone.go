@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+type MilestoneState string
+
+const (
+	MilestoneStateOpen      MilestoneState = "OPEN"
+	MilestoneStateCompleted MilestoneState = "COMPLETED"
+)
+
+func (s MilestoneState) IsValid() bool {
+	return s == MilestoneStateOpen || s == MilestoneStateCompleted
+}
+
+type Milestone struct {
+	Base
+	ID          string         `json:"id"`
+	TeamID      string         `json:"team_id"` // Foreign key to Team
+	Name        string         `json:"name"`
+	Description *string        `json:"description"`
+	TargetDate  time.Time      `json:"target_date"`
+	State       MilestoneState `json:"state"`
+	CompletedAt *time.Time     `json:"completed_at"`
+}
+
+// MilestoneTaskCount counts the tasks of a milestone and the done ones among them, by number and by story points
+type MilestoneTaskCount struct {
+	MilestoneID     string `json:"milestone_id"`
+	Completed       int32  `json:"completed"`
+	Total           int32  `json:"total"`
+	CompletedPoints int32  `json:"completed_points"`
+	TotalPoints     int32  `json:"total_points"`
+}
+
+// TeamThroughput is the number of tasks the team completed since a moment
+type TeamThroughput struct {
+	TeamID    string    `json:"team_id"`
+	Completed int32     `json:"completed"`
+	Since     time.Time `json:"since"`
+}
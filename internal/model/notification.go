@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+type NotificationKind string
+
+const (
+	NotificationKindMentioned   NotificationKind = "MENTIONED"
+	NotificationKindTaskUpdated NotificationKind = "TASK_UPDATED"
+	NotificationKindTaskMoved   NotificationKind = "TASK_MOVED"
+	NotificationKindCommented   NotificationKind = "COMMENTED"
+)
+
+// NotificationKinds lists every kind in the order the preferences are shown
+var NotificationKinds = []NotificationKind{
+	NotificationKindMentioned,
+	NotificationKindTaskUpdated,
+	NotificationKindTaskMoved,
+	NotificationKindCommented,
+}
+
+func (k NotificationKind) IsValid() bool {
+	switch k {
+	case NotificationKindMentioned, NotificationKindTaskUpdated, NotificationKindTaskMoved, NotificationKindCommented:
+		return true
+	}
+	return false
+}
+
+// Notification is an event of a task delivered to a user
+type Notification struct {
+	ID        string           `json:"id"`
+	UserID    string           `json:"user_id"` // Foreign key to User, the recipient
+	Kind      NotificationKind `json:"kind"`
+	TaskID    string           `json:"task_id"`    // Foreign key to Task
+	CommentID *string          `json:"comment_id"` // Foreign key to Comment
+	ActorID   *string          `json:"actor_id"`   // Foreign key to User whose action triggered the notification
+	ReadAt    *time.Time       `json:"read_at"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+func (n *Notification) IsRead() bool {
+	return n.ReadAt != nil
+}
+
+// NotificationPreference holds the channels a user receives a kind of notification on
+type NotificationPreference struct {
+	UserID string           `json:"user_id"` // Foreign key to User
+	Kind   NotificationKind `json:"kind"`
+	InApp  bool             `json:"in_app"`
+	Email  bool             `json:"email"`
+}
+
+// DefaultNotificationPreference applies until the user picks the channels of the kind,
+// everything shows in the app and only mentions are emailed
+func DefaultNotificationPreference(userID string, kind NotificationKind) *NotificationPreference {
+	return &NotificationPreference{
+		UserID: userID,
+		Kind:   kind,
+		InApp:  true,
+		Email:  kind == NotificationKindMentioned,
+	}
+}
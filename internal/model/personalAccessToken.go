@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+type PersonalAccessToken struct {
+	Base
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	TokenHash   string     `json:"-"`            // Excluded from JSON, the plain token is never stored
+	TokenPrefix string     `json:"token_prefix"` // First characters of the plain token, used to recognize it
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+
+	// Exactly one of the owners below is set
+	UserID           *string `json:"user_id"`            // Foreign key to User
+	ServiceAccountID *string `json:"service_account_id"` // Foreign key to ServiceAccount
+}
@@ -0,0 +1,11 @@
+package projection
+
+import "time"
+
+type OIDCLoginRedirect struct {
+	// AuthURL is the identity provider page the browser is redirected to
+	AuthURL string
+	// FlowToken carries state, nonce and PKCE verifier until the callback, it is signed and short lived
+	FlowToken string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,12 @@
+package projection
+
+import _model "bitbucket.org/edts/go-task-management/internal/model"
+
+type PersonalAccessTokenOwner struct {
+	Token *_model.PersonalAccessToken
+	// Filled when the token belongs to a user
+	UserEmail *string
+	// Filled when the token belongs to a service account
+	ServiceAccountName   *string
+	ServiceAccountTeamID *string
+}
@@ -1,6 +1,45 @@
 package projection
 
+import (
+	"slices"
+
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+)
+
 type UserContext struct {
 	UserID string
 	Email  string
+	// Scopes granted to the caller, nil for JWT sessions which are not scope restricted
+	Scopes []string
+	// TokenID is the personal access token used to authenticate, empty for JWT sessions
+	TokenID string
+	// ServiceAccountID and TeamID are filled when the caller is a team service account
+	ServiceAccountID string
+	TeamID           string
+	// WorkspaceID is the workspace selected for the request, empty when none could be resolved
+	WorkspaceID   string
+	WorkspaceRole _model.WorkspaceRole
+}
+
+// HasScope reports whether the caller is allowed to use the given scope
+func (u *UserContext) HasScope(scope string) bool {
+	if u.Scopes == nil {
+		return true
+	}
+	return slices.Contains(u.Scopes, scope)
+}
+
+// IsServiceAccount reports whether the caller is a service account instead of a person
+func (u *UserContext) IsServiceAccount() bool {
+	return u.ServiceAccountID != ""
+}
+
+// IsAccessToken reports whether the caller authenticated with a personal access token
+func (u *UserContext) IsAccessToken() bool {
+	return u.TokenID != ""
+}
+
+// IsWorkspaceAdmin reports whether the caller administers the selected workspace
+func (u *UserContext) IsWorkspaceAdmin() bool {
+	return u.WorkspaceID != "" && u.WorkspaceRole == _model.WorkspaceRoleAdmin
 }
@@ -0,0 +1,9 @@
+package model
+
+type ServiceAccount struct {
+	Base
+	ID          string  `json:"id"`
+	TeamID      string  `json:"team_id"` // Foreign key to Team
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
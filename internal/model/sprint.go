@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// SprintState follows PLANNED, ACTIVE then COMPLETED
+type SprintState string
+
+const (
+	SprintStatePlanned   SprintState = "PLANNED"
+	SprintStateActive    SprintState = "ACTIVE"
+	SprintStateCompleted SprintState = "COMPLETED"
+)
+
+func (s SprintState) IsValid() bool {
+	return s == SprintStatePlanned || s == SprintStateActive || s == SprintStateCompleted
+}
+
+// SprintCarryOver decides where the unfinished tasks of a completed sprint go
+type SprintCarryOver string
+
+const (
+	SprintCarryOverNextSprint SprintCarryOver = "NEXT_SPRINT"
+	SprintCarryOverBacklog    SprintCarryOver = "BACKLOG"
+)
+
+func (c SprintCarryOver) IsValid() bool {
+	return c == SprintCarryOverNextSprint || c == SprintCarryOverBacklog
+}
+
+type Sprint struct {
+	Base
+	ID          string      `json:"id"`
+	TeamID      string      `json:"team_id"` // Foreign key to Team
+	Name        string      `json:"name"`
+	Goal        *string     `json:"goal"`
+	StartDate   time.Time   `json:"start_date"`
+	EndDate     time.Time   `json:"end_date"`
+	State       SprintState `json:"state"`
+	StartedAt   *time.Time  `json:"started_at"`
+	CompletedAt *time.Time  `json:"completed_at"`
+}
+
+// SprintTaskEvent records a change of the sprint scope
+type SprintTaskEvent string
+
+const (
+	SprintTaskEventCommitted   SprintTaskEvent = "COMMITTED"
+	SprintTaskEventAdded       SprintTaskEvent = "ADDED"
+	SprintTaskEventRemoved     SprintTaskEvent = "REMOVED"
+	SprintTaskEventCompleted   SprintTaskEvent = "COMPLETED"
+	SprintTaskEventCarriedOver SprintTaskEvent = "CARRIED_OVER"
+)
+
+type SprintTask struct {
+	SprintID  string          `json:"sprint_id"`
+	TaskID    string          `json:"task_id"`
+	Task      *Task           `json:"task"`
+	Event     SprintTaskEvent `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+}
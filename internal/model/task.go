@@ -1,6 +1,9 @@
 package model
 
-import "time"
+import (
+	"github.com/google/uuid"
+	"time"
+)
 
 type Task struct {
 	Base
@@ -15,4 +18,31 @@ type Task struct {
 
 	TeamID string `json:"team_id"` // Foreign key to Team
 	Team   *Team  `json:"team" gorm:"foreignKey:TeamID"`
+
+	ParentID *string `json:"parent_id"` // Foreign key to the parent Task of a subtask
+
+	Priority TaskPriority `json:"priority"`
+	Rank     string       `json:"rank"` // Sort key of the task within its team and status column
+
+	SprintID    *string `json:"sprint_id"`    // Foreign key to Sprint, nil while the task is in the backlog
+	MilestoneID *string `json:"milestone_id"` // Foreign key to Milestone
+
+	StoryPoints      *int32 `json:"story_points"`
+	OriginalEstimate *int32 `json:"original_estimate"` // Expected effort in seconds
+
+	SeriesID     *string    `json:"series_id"`     // Foreign key to TaskSeries of a recurring task
+	OccurrenceAt *time.Time `json:"occurrence_at"` // Scheduled occurrence of the series the task stands for
+
+	DeletedAt *time.Time `json:"deleted_at"` // Set while the task is in the trash
+	DeletedBy *uuid.UUID `json:"deleted_by"`
+
+	Version int32 `json:"version"` // Incremented on each write of the task
+
+	Number int32 `json:"number"` // Sequence of the task in its team, the task key is the team key followed by it
+}
+
+// TaskColumn identifies the tasks of a team sharing a status, ranks are ordered within a column
+type TaskColumn struct {
+	TeamID string `json:"team_id"`
+	Status string `json:"status"`
 }
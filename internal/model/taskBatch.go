@@ -0,0 +1,23 @@
+package model
+
+// TaskBatchType tells what a bulk operation did to the tasks of a TaskBatch
+type TaskBatchType string
+
+const (
+	TaskBatchTypeUpdated TaskBatchType = "UPDATED"
+	TaskBatchTypeDeleted TaskBatchType = "DELETED" // The tasks were moved to the trash
+)
+
+func (t TaskBatchType) IsValid() bool {
+	switch t {
+	case TaskBatchTypeUpdated, TaskBatchTypeDeleted:
+		return true
+	}
+	return false
+}
+
+// TaskBatch is the single event published to a team for its tasks changed by a bulk operation
+type TaskBatch struct {
+	Type  TaskBatchType `json:"type"`
+	Tasks []*Task       `json:"tasks"`
+}
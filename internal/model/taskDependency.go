@@ -0,0 +1,11 @@
+package model
+
+// TaskDependency records that Task cannot start until BlockedBy is done
+type TaskDependency struct {
+	Base
+	TaskID string `json:"task_id"` // Foreign key to the blocked Task
+	Task   *Task  `json:"task"`
+
+	BlockedByTaskID string `json:"blocked_by_task_id"` // Foreign key to the blocking Task
+	BlockedBy       *Task  `json:"blocked_by"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// TeamKeyPattern is the shape of a team key, a letter followed by up to 9 letters or digits
+	TeamKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,9}$`)
+	// taskKeyPattern is the shape of a task key, the team key and the task number
+	taskKeyPattern = regexp.MustCompile(`^([A-Z][A-Z0-9]{1,9})-([1-9][0-9]{0,9})$`)
+)
+
+// TaskKey is the human-readable key of a task, e.g. ENG-142
+type TaskKey struct {
+	TeamKey string
+	Number  int32
+}
+
+func (k TaskKey) String() string {
+	return fmt.Sprintf("%s-%d", k.TeamKey, k.Number)
+}
+
+// ParseTaskKey reads a task key, the team key is case insensitive
+func ParseTaskKey(key string) (TaskKey, bool) {
+	match := taskKeyPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(key)))
+	if match == nil {
+		return TaskKey{}, false
+	}
+	number, err := strconv.ParseInt(match[2], 10, 32)
+	if err != nil {
+		return TaskKey{}, false
+	}
+	return TaskKey{TeamKey: match[1], Number: int32(number)}, true
+}
@@ -0,0 +1,19 @@
+package model
+
+type TaskPriority string
+
+const (
+	TaskPriorityNone   TaskPriority = "NONE"
+	TaskPriorityLow    TaskPriority = "LOW"
+	TaskPriorityMedium TaskPriority = "MEDIUM"
+	TaskPriorityHigh   TaskPriority = "HIGH"
+	TaskPriorityUrgent TaskPriority = "URGENT"
+)
+
+func (p TaskPriority) IsValid() bool {
+	switch p {
+	case TaskPriorityNone, TaskPriorityLow, TaskPriorityMedium, TaskPriorityHigh, TaskPriorityUrgent:
+		return true
+	}
+	return false
+}
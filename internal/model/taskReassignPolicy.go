@@ -0,0 +1,13 @@
+package model
+
+// TaskReassignPolicy decides what happens to the tasks of a member leaving a team
+type TaskReassignPolicy string
+
+const (
+	TaskReassignPolicyUnassign        TaskReassignPolicy = "UNASSIGN"
+	TaskReassignPolicyReassignToOwner TaskReassignPolicy = "REASSIGN_TO_OWNER"
+)
+
+func (p TaskReassignPolicy) IsValid() bool {
+	return p == TaskReassignPolicyUnassign || p == TaskReassignPolicyReassignToOwner
+}
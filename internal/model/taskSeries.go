@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// TaskSeries is a recurring task, a task is generated for each occurrence of its iCalendar RRULE
+type TaskSeries struct {
+	Base
+	ID          string  `json:"id"`
+	TeamID      string  `json:"team_id"` // Foreign key to Team
+	Title       string  `json:"title"`
+	Description *string `json:"description"`
+	AssignedTo  *string `json:"assigned_to"` // Foreign key to User
+
+	Priority         TaskPriority `json:"priority"`
+	StoryPoints      *int32       `json:"story_points"`
+	OriginalEstimate *int32       `json:"original_estimate"` // Expected effort in seconds
+
+	Rrule            string     `json:"rrule"`
+	StartAt          time.Time  `json:"start_at"`           // DTSTART of the rule
+	LastOccurrenceAt *time.Time `json:"last_occurrence_at"` // Latest occurrence a task was generated for
+	NextOccurrenceAt *time.Time `json:"next_occurrence_at"` // Nil once the rule has no occurrence left
+	EndedAt          *time.Time `json:"ended_at"`
+}
+
+// IsActive tells whether the series still generates tasks
+func (s *TaskSeries) IsActive() bool {
+	return s.EndedAt == nil && s.NextOccurrenceAt != nil
+}
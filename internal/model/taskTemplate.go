@@ -0,0 +1,52 @@
+package model
+
+// TaskTemplate is a reusable blueprint of the tasks of a team
+type TaskTemplate struct {
+	Base
+	ID           string   `json:"id"`
+	TeamID       string   `json:"team_id"` // Foreign key to Team
+	Name         string   `json:"name"`
+	TitlePattern string   `json:"title_pattern"` // Placeholders such as {date} are filled when a task is created
+	Description  *string  `json:"description"`
+	Checklist    []string `json:"checklist"`   // Texts of the checklist items in order
+	LabelIDs     []string `json:"label_ids"`   // Labels from the palette of the team
+	AssignedTo   *string  `json:"assigned_to"` // Foreign key to User, the default assignee
+
+	DueOffset        *int32       `json:"due_offset"` // Seconds between the creation and the due date of the task
+	Priority         TaskPriority `json:"priority"`
+	StoryPoints      *int32       `json:"story_points"`
+	OriginalEstimate *int32       `json:"original_estimate"`
+
+	IsDefault bool `json:"is_default"` // The blueprint the team starts its tasks from
+}
+
+// TaskTemplateLabel links a template to one of its labels
+type TaskTemplateLabel struct {
+	TemplateID string `json:"template_id"` // Foreign key to TaskTemplate
+	Label      *Label `json:"label"`
+}
+
+// TaskTemplateFile is the JSON document templates are exported to and imported from. Labels and the assignee
+// are carried by name and email so the file can be imported into another team
+type TaskTemplateFile struct {
+	Version   int                      `json:"version"`
+	Templates []*TaskTemplateFileEntry `json:"templates"`
+}
+
+type TaskTemplateFileEntry struct {
+	Name             string                   `json:"name"`
+	TitlePattern     string                   `json:"title_pattern"`
+	Description      *string                  `json:"description,omitempty"`
+	Checklist        []string                 `json:"checklist,omitempty"`
+	Labels           []*TaskTemplateFileLabel `json:"labels,omitempty"`
+	AssigneeEmail    *string                  `json:"assignee_email,omitempty"`
+	DueOffset        *int32                   `json:"due_offset,omitempty"`
+	Priority         TaskPriority             `json:"priority,omitempty"`
+	StoryPoints      *int32                   `json:"story_points,omitempty"`
+	OriginalEstimate *int32                   `json:"original_estimate,omitempty"`
+}
+
+type TaskTemplateFileLabel struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
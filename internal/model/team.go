@@ -1,9 +1,22 @@
 package model
 
+import "time"
+
 type Team struct {
 	Base
 	ID          string  `json:"id"`
 	Name        string  `json:"name"`
+	Key         string  `json:"key"` // Prefix of the task keys, e.g. ENG for ENG-142
 	Description *string `json:"description"`
-	Users       []*User `json:"users" gorm:"many2many:user_teams"`
+	WorkspaceID string  `json:"workspace_id"`
+
+	TaskReassignPolicy TaskReassignPolicy `json:"task_reassign_policy"`
+	WipLimitPolicy     WipLimitPolicy     `json:"wip_limit_policy"`
+	// ArchivedAt makes the team read-only, DeletedAt starts the grace period before it is purged
+	ArchivedAt *time.Time `json:"archived_at"`
+	DeletedAt  *time.Time `json:"deleted_at"`
+
+	Version int32 `json:"version"` // Incremented on each write of the team
+
+	Users []*User `json:"users" gorm:"many2many:user_teams"`
 }
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+type InvitationStatus string
+
+const (
+	InvitationStatusPending  InvitationStatus = "PENDING"
+	InvitationStatusAccepted InvitationStatus = "ACCEPTED"
+	InvitationStatusDeclined InvitationStatus = "DECLINED"
+	InvitationStatusRevoked  InvitationStatus = "REVOKED"
+)
+
+type TeamInvitation struct {
+	Base
+	ID          string           `json:"id"`
+	TeamID      string           `json:"team_id"` // Foreign key to Team
+	Email       string           `json:"email"`   // The invitee does not need to be registered
+	Role        TeamRole         `json:"role"`
+	Status      InvitationStatus `json:"status"`
+	TokenHash   string           `json:"-"` // Excluded from JSON, the plain token is only sent by email
+	ExpiresAt   time.Time        `json:"expires_at"`
+	RespondedAt *time.Time       `json:"responded_at"`
+	RespondedBy *string          `json:"responded_by"` // Foreign key to User
+}
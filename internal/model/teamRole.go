@@ -0,0 +1,26 @@
+package model
+
+type TeamRole string
+
+const (
+	TeamRoleOwner  TeamRole = "OWNER"
+	TeamRoleAdmin  TeamRole = "ADMIN"
+	TeamRoleMember TeamRole = "MEMBER"
+)
+
+// teamRoleRank orders roles from the least to the most privileged
+var teamRoleRank = map[TeamRole]int{
+	TeamRoleMember: 1,
+	TeamRoleAdmin:  2,
+	TeamRoleOwner:  3,
+}
+
+func (r TeamRole) IsValid() bool {
+	_, ok := teamRoleRank[r]
+	return ok
+}
+
+// AtLeast reports whether the role grants as much as the given role
+func (r TeamRole) AtLeast(role TeamRole) bool {
+	return teamRoleRank[r] >= teamRoleRank[role]
+}
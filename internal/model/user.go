@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type User struct {
 	Base
 	ID       string  `json:"id"`
@@ -7,4 +9,8 @@ type User struct {
 	Email    string  `json:"email"`
 	Password string  `json:"-"` // Excluded from JSON for security
 	Teams    []*Team `json:"teams" gorm:"many2many:user_teams"`
+
+	// Brute-force protection, excluded from JSON
+	FailedLoginAttempts int        `json:"-"`
+	LockedUntil         *time.Time `json:"-"`
 }
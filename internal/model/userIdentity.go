@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type UserIdentity struct {
+	Base
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"` // Foreign key to User
+	Issuer      string     `json:"issuer"`
+	Subject     string     `json:"subject"` // "sub" claim, stable per issuer
+	Email       *string    `json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
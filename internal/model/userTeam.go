@@ -1,6 +1,7 @@
 package model
 
 type UserTeam struct {
-	UserID string `json:"user_id" gorm:"primaryKey"`
-	TeamID string `json:"team_id" gorm:"primaryKey"`
+	UserID string   `json:"user_id" gorm:"primaryKey"`
+	TeamID string   `json:"team_id" gorm:"primaryKey"`
+	Role   TeamRole `json:"role"`
 }
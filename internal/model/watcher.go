@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// TaskWatcher is a user notified of the changes of a task
+type TaskWatcher struct {
+	TaskID    string    `json:"task_id"` // Foreign key to Task
+	UserID    string    `json:"user_id"` // Foreign key to User
+	CreatedAt time.Time `json:"created_at"`
+}
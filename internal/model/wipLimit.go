@@ -0,0 +1,20 @@
+package model
+
+// WipLimitPolicy decides what happens to a move that takes a board column over its WIP limit
+type WipLimitPolicy string
+
+const (
+	WipLimitPolicyWarn   WipLimitPolicy = "WARN"
+	WipLimitPolicyReject WipLimitPolicy = "REJECT"
+)
+
+func (p WipLimitPolicy) IsValid() bool {
+	return p == WipLimitPolicyWarn || p == WipLimitPolicyReject
+}
+
+// WipLimit caps the number of tasks of a team in one status column
+type WipLimit struct {
+	TeamID string `json:"team_id"`
+	Status string `json:"status"`
+	Limit  int32  `json:"limit"`
+}
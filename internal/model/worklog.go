@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Worklog is time a user spent on a task, a worklog without duration is a running timer
+type Worklog struct {
+	ID         string    `json:"id"`
+	TaskID     string    `json:"task_id"` // Foreign key to Task
+	UserID     string    `json:"user_id"` // Foreign key to User
+	StartedAt  time.Time `json:"started_at"`
+	Duration   *int32    `json:"duration"` // Seconds, nil while the timer runs
+	Note       *string   `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+func (w *Worklog) IsRunning() bool {
+	return w.Duration == nil
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Workspace owns teams and users, nothing is shared between workspaces
+type Workspace struct {
+	Base
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Role of the caller in the workspace, filled when listing the caller workspaces
+	Role WorkspaceRole `json:"role"`
+}
+
+type WorkspaceMember struct {
+	WorkspaceID string        `json:"workspace_id"`
+	UserID      string        `json:"user_id"`
+	Role        WorkspaceRole `json:"role"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
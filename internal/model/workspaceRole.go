@@ -0,0 +1,12 @@
+package model
+
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleAdmin  WorkspaceRole = "ADMIN"
+	WorkspaceRoleMember WorkspaceRole = "MEMBER"
+)
+
+func (r WorkspaceRole) IsValid() bool {
+	return r == WorkspaceRoleAdmin || r == WorkspaceRoleMember
+}
@@ -1,6 +1,7 @@
 package pubsub
 
 import (
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
 	_model "bitbucket.org/edts/go-task-management/internal/model"
 	_logger "bitbucket.org/edts/go-task-management/pkg/logger"
 	"sync"
@@ -11,13 +12,15 @@ var logs = _logger.GetContextLoggerf(nil)
 type TaskPubSubInterface interface {
 	Subscribe(teamID string) <-chan TaskEvent
 	Publish(teamID, eventType string, task *_model.Task)
+	PublishBatch(teamID string, batch *_model.TaskBatch)
 	Unsubscribe(teamID string, ch <-chan TaskEvent)
 }
 
 // TaskEvent define task event types
 type TaskEvent struct {
-	Type string // "created" or "updated"
-	Task *_model.Task
+	Type  string // "created" or "updated"
+	Task  *_model.Task
+	Batch *_model.TaskBatch // Set instead of Task by the "batch" events
 }
 
 // TaskPubSub manages task related events
@@ -60,6 +63,20 @@ func (ps *TaskPubSub) Publish(teamID, eventType string, task *_model.Task) {
 	logs.Info("Publish:: Finish notifying the published task")
 }
 
+// PublishBatch notifies all subs of the team once for the tasks changed by a bulk operation
+func (ps *TaskPubSub) PublishBatch(teamID string, batch *_model.TaskBatch) {
+	logs.Infof("PublishBatch:: Start publishing %d tasks with type:%s", len(batch.Tasks), batch.Type)
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	event := TaskEvent{Type: _const.BATCH, Batch: batch}
+
+	for _, ch := range ps.subscribers[teamID] {
+		ch <- event
+	}
+	logs.Info("PublishBatch:: Finish notifying the published batch")
+}
+
 // Unsubscribe from task events
 func (ps *TaskPubSub) Unsubscribe(teamID string, ch <-chan TaskEvent) {
 	logs.Infof("Unsubscribe:: Start unsubscribe task of teamId: %s", teamID)
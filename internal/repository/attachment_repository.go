@@ -0,0 +1,182 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"github.com/jackc/pgx/v5"
+)
+
+type AttachmentRepositoryInterface interface {
+	CreateAttachment(ctx context.Context, attachment *_model.Attachment) (*_model.Attachment, error)
+	DeleteAttachment(ctx context.Context, id string) error
+	GetAttachmentByID(ctx context.Context, id string) (*_model.Attachment, error)
+	GetAttachmentsByTaskIDs(ctx context.Context, taskIDs []string) ([]*_model.Attachment, error)
+	LockBlobDeletions(ctx context.Context, limit int) ([]string, error)
+	DeleteBlobDeletion(ctx context.Context, storageKey string) error
+	RetryBlobDeletion(ctx context.Context, storageKey string) error
+}
+
+type AttachmentRepository struct {
+	db *_db.Database
+}
+
+func NewAttachmentRepository(db *_db.Database) AttachmentRepositoryInterface {
+	return &AttachmentRepository{
+		db: db,
+	}
+}
+
+const attachmentColumns = `
+	a.id,
+	a.task_id,
+	a.filename,
+	a.content_type,
+	a.size,
+	a.storage_key,
+	a.created_at,
+	a.created_by
+`
+
+func scanAttachment(row pgx.Row, attachment *_model.Attachment) error {
+	return row.Scan(
+		&attachment.ID,
+		&attachment.TaskID,
+		&attachment.Filename,
+		&attachment.ContentType,
+		&attachment.Size,
+		&attachment.StorageKey,
+		&attachment.CreatedAt,
+		&attachment.CreatedBy,
+	)
+}
+
+func (r *AttachmentRepository) CreateAttachment(ctx context.Context, attachment *_model.Attachment) (*_model.Attachment, error) {
+	query := `
+		INSERT INTO app.task_attachments AS a (task_id, filename, content_type, size, storage_key, created_at, created_by)
+		VALUES (@task_id, @filename, @content_type, @size, @storage_key, current_timestamp, @created_by)
+		RETURNING ` + attachmentColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"task_id":      attachment.TaskID,
+		"filename":     attachment.Filename,
+		"content_type": attachment.ContentType,
+		"size":         attachment.Size,
+		"storage_key":  attachment.StorageKey,
+		"created_by":   attachment.CreatedBy,
+	}
+
+	var created _model.Attachment
+	if err := scanAttachment(r.db.Conn(ctx).QueryRow(ctx, query, args), &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// DeleteAttachment removes the attachment, its blob is queued for the cleanup job by the database
+func (r *AttachmentRepository) DeleteAttachment(ctx context.Context, id string) error {
+	query := `
+		DELETE FROM app.task_attachments WHERE id = $1;
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	return err
+}
+
+func (r *AttachmentRepository) GetAttachmentByID(ctx context.Context, id string) (*_model.Attachment, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM app.task_attachments a
+		WHERE a.id = @id
+	`
+
+	var attachment _model.Attachment
+	if err := scanAttachment(r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"id": id}), &attachment); err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// GetAttachmentsByTaskIDs returns the attachments of every task in taskIDs, oldest first
+func (r *AttachmentRepository) GetAttachmentsByTaskIDs(ctx context.Context, taskIDs []string) ([]*_model.Attachment, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM app.task_attachments a
+		WHERE a.task_id = ANY(@task_ids)
+		ORDER BY a.created_at, a.id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"task_ids": taskIDs})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []*_model.Attachment
+	for rows.Next() {
+		var attachment _model.Attachment
+		if err = scanAttachment(rows, &attachment); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, &attachment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// LockBlobDeletions takes the oldest queued blobs, the ones another replica is cleaning up are skipped.
+// Blobs failing again and again go to the back of the queue
+func (r *AttachmentRepository) LockBlobDeletions(ctx context.Context, limit int) ([]string, error) {
+	query := `
+		SELECT storage_key
+		FROM app.blob_deletions
+		ORDER BY attempts, queued_at
+		LIMIT @limit
+		FOR UPDATE SKIP LOCKED
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"limit": limit})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err = rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *AttachmentRepository) DeleteBlobDeletion(ctx context.Context, storageKey string) error {
+	query := `
+		DELETE FROM app.blob_deletions WHERE storage_key = $1;
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, storageKey)
+	return err
+}
+
+func (r *AttachmentRepository) RetryBlobDeletion(ctx context.Context, storageKey string) error {
+	query := `
+		UPDATE app.blob_deletions SET attempts = attempts + 1 WHERE storage_key = $1;
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, storageKey)
+	return err
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"github.com/jackc/pgx/v5"
+)
+
+type BoardRepositoryInterface interface {
+	GetBoardCounts(ctx context.Context, teamID string, swimlane _model.BoardSwimlane) ([]*_model.BoardCount, error)
+	GetBoardLaneTasks(ctx context.Context, lane *_model.BoardLane, after string, limit int) ([]*_model.Task, error)
+	CountColumnTasks(ctx context.Context, column _model.TaskColumn, excludeID string) (int, error)
+}
+
+type BoardRepository struct {
+	db *_db.Database
+}
+
+func NewBoardRepository(db *_db.Database) BoardRepositoryInterface {
+	return &BoardRepository{
+		db: db,
+	}
+}
+
+// boardLaneKeys is the expression giving the lane of a task for every swimlane, a task
+// carrying several labels appears once in the lane of each of them
+var boardLaneKeys = map[_model.BoardSwimlane]string{
+	_model.BoardSwimlaneNone:     `NULL::TEXT`,
+	_model.BoardSwimlaneAssignee: `t.assigned_to::TEXT`,
+	_model.BoardSwimlaneLabel:    `tl.label_id::TEXT`,
+	_model.BoardSwimlanePriority: `t.priority`,
+}
+
+// boardLaneFilters keeps the tasks of the lane @lane_key, a NULL key is the lane of the tasks without value
+var boardLaneFilters = map[_model.BoardSwimlane]string{
+	_model.BoardSwimlaneNone:     `TRUE`,
+	_model.BoardSwimlaneAssignee: `((@lane_key::UUID IS NULL AND t.assigned_to IS NULL) OR t.assigned_to = @lane_key::UUID)`,
+	_model.BoardSwimlaneLabel: `(CASE WHEN @lane_key::UUID IS NULL
+		THEN NOT EXISTS (SELECT 1 FROM app.task_labels tl WHERE tl.task_id = t.id)
+		ELSE EXISTS (SELECT 1 FROM app.task_labels tl WHERE tl.task_id = t.id AND tl.label_id = @lane_key::UUID)
+	END)`,
+	_model.BoardSwimlanePriority: `t.priority = @lane_key::TEXT`,
+}
+
+// GetBoardCounts counts the tasks of the team per status and lane of the swimlane
+func (r *BoardRepository) GetBoardCounts(ctx context.Context, teamID string, swimlane _model.BoardSwimlane) ([]*_model.BoardCount, error) {
+	query := `
+		SELECT t.status, ` + boardLaneKeys[swimlane] + ` AS lane_key, COUNT(DISTINCT t.id)
+		FROM app.tasks t
+		JOIN app.teams tm ON tm.id = t.team_id AND tm.deleted_at IS NULL
+		LEFT JOIN app.task_labels tl ON tl.task_id = t.id AND @swimlane::TEXT = 'LABEL'
+		WHERE t.team_id = @team_id
+		AND t.deleted_at IS NULL
+		GROUP BY 1, 2
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id":  teamID,
+		"swimlane": swimlane,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []*_model.BoardCount
+	for rows.Next() {
+		var count _model.BoardCount
+		if err = rows.Scan(&count.Status, &count.LaneKey, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, &count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// GetBoardLaneTasks returns up to limit tasks of the lane ranked after the given rank, an empty rank starts at the top
+func (r *BoardRepository) GetBoardLaneTasks(ctx context.Context, lane *_model.BoardLane, after string, limit int) ([]*_model.Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM app.tasks t
+		JOIN app.teams tm ON tm.id = t.team_id AND tm.deleted_at IS NULL
+		WHERE t.team_id = @team_id
+		AND t.status = @status
+		AND t.deleted_at IS NULL
+		AND t.rank > @after
+		AND ` + boardLaneFilters[lane.Swimlane] + `
+		ORDER BY t.rank, t.id
+		LIMIT @limit
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id":  lane.TeamID,
+		"status":   lane.Status,
+		"lane_key": lane.Key,
+		"after":    after,
+		"limit":    limit,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// CountColumnTasks counts the tasks in the column leaving out excludeID
+func (r *BoardRepository) CountColumnTasks(ctx context.Context, column _model.TaskColumn, excludeID string) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM app.tasks
+		WHERE team_id = @team_id
+		AND status = @status
+		AND id <> @exclude_id
+		AND deleted_at IS NULL
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id":    column.TeamID,
+		"status":     column.Status,
+		"exclude_id": excludeID,
+	}
+
+	var count int
+	if err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
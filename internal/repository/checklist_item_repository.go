@@ -0,0 +1,190 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type ChecklistItemRepositoryInterface interface {
+	CreateChecklistItem(ctx context.Context, item *_model.ChecklistItem) (*_model.ChecklistItem, error)
+	GetChecklistItemByID(ctx context.Context, id string) (*_model.ChecklistItem, error)
+	GetChecklistItemsByTaskIDs(ctx context.Context, taskIDs []string) ([]*_model.ChecklistItem, error)
+	SetChecklistItemDone(ctx context.Context, id string, isDone bool, modifiedBy *uuid.UUID) (*_model.ChecklistItem, error)
+	ReorderChecklistItems(ctx context.Context, taskID string, ids []string, modifiedBy *uuid.UUID) ([]*_model.ChecklistItem, error)
+	DeleteChecklistItem(ctx context.Context, id string) error
+}
+
+type ChecklistItemRepository struct {
+	db *_db.Database
+}
+
+func NewChecklistItemRepository(db *_db.Database) ChecklistItemRepositoryInterface {
+	return &ChecklistItemRepository{
+		db: db,
+	}
+}
+
+const checklistItemColumns = `
+	ci.id,
+	ci.task_id,
+	ci.text,
+	ci.is_done,
+	ci.position,
+	ci.created_at,
+	ci.modified_at,
+	ci.created_by,
+	ci.modified_by
+`
+
+func scanChecklistItem(row pgx.Row, item *_model.ChecklistItem) error {
+	return row.Scan(
+		&item.ID,
+		&item.TaskID,
+		&item.Text,
+		&item.IsDone,
+		&item.Position,
+		&item.CreatedAt,
+		&item.ModifiedAt,
+		&item.CreatedBy,
+		&item.ModifiedBy,
+	)
+}
+
+func scanChecklistItems(rows pgx.Rows) ([]*_model.ChecklistItem, error) {
+	defer rows.Close()
+
+	var items []*_model.ChecklistItem
+	for rows.Next() {
+		var item _model.ChecklistItem
+		if err := scanChecklistItem(rows, &item); err != nil {
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// CreateChecklistItem appends the item at the end of the task checklist
+func (r *ChecklistItemRepository) CreateChecklistItem(ctx context.Context, item *_model.ChecklistItem) (*_model.ChecklistItem, error) {
+	query := `
+		INSERT INTO app.task_checklist_items AS ci (task_id, text, is_done, position, created_at, modified_at, created_by, modified_by)
+		VALUES (
+			@task_id, @text, @is_done,
+			(SELECT COALESCE(MAX(position), 0) + 1 FROM app.task_checklist_items WHERE task_id = @task_id),
+			current_timestamp, current_timestamp, @created_by, @modified_by
+		)
+		RETURNING ` + checklistItemColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"task_id":     item.TaskID,
+		"text":        item.Text,
+		"is_done":     item.IsDone,
+		"created_by":  item.CreatedBy,
+		"modified_by": item.ModifiedBy,
+	}
+
+	var created _model.ChecklistItem
+	if err := scanChecklistItem(r.db.Conn(ctx).QueryRow(ctx, query, args), &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (r *ChecklistItemRepository) GetChecklistItemByID(ctx context.Context, id string) (*_model.ChecklistItem, error) {
+	query := `
+		SELECT ` + checklistItemColumns + `
+		FROM app.task_checklist_items ci
+		WHERE ci.id = @id
+	`
+
+	var item _model.ChecklistItem
+	if err := scanChecklistItem(r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"id": id}), &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// GetChecklistItemsByTaskIDs returns the checklist items of every task in taskIDs in checklist order
+func (r *ChecklistItemRepository) GetChecklistItemsByTaskIDs(ctx context.Context, taskIDs []string) ([]*_model.ChecklistItem, error) {
+	query := `
+		SELECT ` + checklistItemColumns + `
+		FROM app.task_checklist_items ci
+		WHERE ci.task_id = ANY(@task_ids)
+		ORDER BY ci.task_id, ci.position
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"task_ids": taskIDs})
+	if err != nil {
+		return nil, err
+	}
+	return scanChecklistItems(rows)
+}
+
+func (r *ChecklistItemRepository) SetChecklistItemDone(ctx context.Context, id string, isDone bool, modifiedBy *uuid.UUID) (*_model.ChecklistItem, error) {
+	query := `
+		UPDATE app.task_checklist_items ci
+		SET is_done = @is_done, modified_at = current_timestamp, modified_by = @modified_by
+		WHERE ci.id = @id
+		RETURNING ` + checklistItemColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"id":          id,
+		"is_done":     isDone,
+		"modified_by": modifiedBy,
+	}
+
+	var item _model.ChecklistItem
+	if err := scanChecklistItem(r.db.Conn(ctx).QueryRow(ctx, query, args), &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// ReorderChecklistItems renumbers the task checklist following the order of ids, ids must list every item of the task
+func (r *ChecklistItemRepository) ReorderChecklistItems(ctx context.Context, taskID string, ids []string, modifiedBy *uuid.UUID) ([]*_model.ChecklistItem, error) {
+	query := `
+		WITH ordering AS (
+			SELECT id::UUID AS id, position
+			FROM unnest(@ids::TEXT[]) WITH ORDINALITY AS o(id, position)
+		)
+		UPDATE app.task_checklist_items ci
+		SET position = o.position, modified_at = current_timestamp, modified_by = @modified_by
+		FROM ordering o
+		WHERE ci.id = o.id
+		AND ci.task_id = @task_id
+		AND ci.position <> o.position
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"task_id":     taskID,
+		"ids":         ids,
+		"modified_by": modifiedBy,
+	}
+
+	if _, err := r.db.Conn(ctx).Exec(ctx, query, args); err != nil {
+		return nil, err
+	}
+	return r.GetChecklistItemsByTaskIDs(ctx, []string{taskID})
+}
+
+func (r *ChecklistItemRepository) DeleteChecklistItem(ctx context.Context, id string) error {
+	query := `
+		DELETE FROM app.task_checklist_items WHERE id = $1;
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	return err
+}
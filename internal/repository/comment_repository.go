@@ -0,0 +1,143 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"github.com/jackc/pgx/v5"
+)
+
+type CommentRepositoryInterface interface {
+	CreateComment(ctx context.Context, comment *_model.Comment) (*_model.Comment, error)
+	UpdateComment(ctx context.Context, id string, content string) (*_model.Comment, error)
+	DeleteComment(ctx context.Context, id string) error
+	GetCommentByID(ctx context.Context, id string) (*_model.Comment, error)
+	GetCommentsByTaskIDs(ctx context.Context, taskIDs []string) ([]*_model.Comment, error)
+}
+
+type CommentRepository struct {
+	db *_db.Database
+}
+
+func NewCommentRepository(db *_db.Database) CommentRepositoryInterface {
+	return &CommentRepository{
+		db: db,
+	}
+}
+
+const commentColumns = `
+	c.id,
+	c.task_id,
+	c.user_id,
+	c.content,
+	c.created_at,
+	c.modified_at
+`
+
+func scanComment(row pgx.Row, comment *_model.Comment) error {
+	return row.Scan(
+		&comment.ID,
+		&comment.TaskID,
+		&comment.UserID,
+		&comment.Content,
+		&comment.CreatedAt,
+		&comment.ModifiedAt,
+	)
+}
+
+func (r *CommentRepository) CreateComment(ctx context.Context, comment *_model.Comment) (*_model.Comment, error) {
+	query := `
+		INSERT INTO app.task_comments AS c (task_id, user_id, content, created_at, modified_at)
+		VALUES (@task_id, @user_id, @content, current_timestamp, current_timestamp)
+		RETURNING ` + commentColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"task_id": comment.TaskID,
+		"user_id": comment.UserID,
+		"content": comment.Content,
+	}
+
+	var created _model.Comment
+	if err := scanComment(r.db.Conn(ctx).QueryRow(ctx, query, args), &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (r *CommentRepository) UpdateComment(ctx context.Context, id string, content string) (*_model.Comment, error) {
+	query := `
+		UPDATE app.task_comments c
+		SET content = @content, modified_at = current_timestamp
+		WHERE c.id = @id
+		RETURNING ` + commentColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"id":      id,
+		"content": content,
+	}
+
+	var updated _model.Comment
+	if err := scanComment(r.db.Conn(ctx).QueryRow(ctx, query, args), &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteComment removes the comment along with its mentions and notifications
+func (r *CommentRepository) DeleteComment(ctx context.Context, id string) error {
+	query := `
+		DELETE FROM app.task_comments WHERE id = $1;
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	return err
+}
+
+func (r *CommentRepository) GetCommentByID(ctx context.Context, id string) (*_model.Comment, error) {
+	query := `
+		SELECT ` + commentColumns + `
+		FROM app.task_comments c
+		WHERE c.id = @id
+	`
+
+	var comment _model.Comment
+	if err := scanComment(r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"id": id}), &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// GetCommentsByTaskIDs returns the comments of every task in taskIDs, oldest first
+func (r *CommentRepository) GetCommentsByTaskIDs(ctx context.Context, taskIDs []string) ([]*_model.Comment, error) {
+	query := `
+		SELECT ` + commentColumns + `
+		FROM app.task_comments c
+		WHERE c.task_id = ANY(@task_ids)
+		ORDER BY c.created_at, c.id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"task_ids": taskIDs})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []*_model.Comment
+	for rows.Next() {
+		var comment _model.Comment
+		if err = scanComment(rows, &comment); err != nil {
+			return nil, err
+		}
+		comments = append(comments, &comment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
@@ -0,0 +1,307 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type LabelRepositoryInterface interface {
+	CreateLabel(ctx context.Context, label *_model.Label) (*_model.Label, error)
+	UpdateLabel(ctx context.Context, label *_model.Label) (*_model.Label, error)
+	DeleteLabel(ctx context.Context, id string) error
+	GetLabelByID(ctx context.Context, id string) (*_model.Label, error)
+	GetLabelsByTeamID(ctx context.Context, teamID string) ([]*_model.Label, error)
+	CountTeamLabels(ctx context.Context, teamID string, ids []string) (int, error)
+	GetTaskLabelsByTaskIDs(ctx context.Context, taskIDs []string) ([]*_model.TaskLabel, error)
+	GetTaskIDsByLabelID(ctx context.Context, labelID string) ([]string, error)
+	SetTaskLabels(ctx context.Context, taskID string, labelIDs []string, createdBy *uuid.UUID) error
+	AddTaskLabels(ctx context.Context, taskID string, labelIDs []string, createdBy *uuid.UUID) error
+	RemoveTaskLabels(ctx context.Context, taskID string, labelIDs []string) error
+}
+
+type LabelRepository struct {
+	db *_db.Database
+}
+
+func NewLabelRepository(db *_db.Database) LabelRepositoryInterface {
+	return &LabelRepository{
+		db: db,
+	}
+}
+
+const labelColumns = `
+	l.id,
+	l.team_id,
+	l.name,
+	l.color,
+	l.created_at,
+	l.modified_at,
+	l.created_by,
+	l.modified_by
+`
+
+func scanLabel(row pgx.Row, label *_model.Label) error {
+	return row.Scan(
+		&label.ID,
+		&label.TeamID,
+		&label.Name,
+		&label.Color,
+		&label.CreatedAt,
+		&label.ModifiedAt,
+		&label.CreatedBy,
+		&label.ModifiedBy,
+	)
+}
+
+func (r *LabelRepository) CreateLabel(ctx context.Context, label *_model.Label) (*_model.Label, error) {
+	query := `
+		INSERT INTO app.labels AS l (team_id, name, color, created_at, modified_at, created_by, modified_by)
+		VALUES (@team_id, @name, @color, current_timestamp, current_timestamp, @created_by, @modified_by)
+		RETURNING ` + labelColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id":     label.TeamID,
+		"name":        label.Name,
+		"color":       label.Color,
+		"created_by":  label.CreatedBy,
+		"modified_by": label.ModifiedBy,
+	}
+
+	var created _model.Label
+	if err := scanLabel(r.db.Conn(ctx).QueryRow(ctx, query, args), &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (r *LabelRepository) UpdateLabel(ctx context.Context, label *_model.Label) (*_model.Label, error) {
+	query := `
+		UPDATE app.labels l
+		SET name = @name, color = @color, modified_at = current_timestamp, modified_by = @modified_by
+		WHERE l.id = @id
+		RETURNING ` + labelColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"id":          label.ID,
+		"name":        label.Name,
+		"color":       label.Color,
+		"modified_by": label.ModifiedBy,
+	}
+
+	var updated _model.Label
+	if err := scanLabel(r.db.Conn(ctx).QueryRow(ctx, query, args), &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteLabel also takes the label off every task carrying it
+func (r *LabelRepository) DeleteLabel(ctx context.Context, id string) error {
+	query := `
+		DELETE FROM app.labels WHERE id = $1;
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	return err
+}
+
+func (r *LabelRepository) GetLabelByID(ctx context.Context, id string) (*_model.Label, error) {
+	query := `
+		SELECT ` + labelColumns + `
+		FROM app.labels l
+		WHERE l.id = @id
+	`
+
+	var label _model.Label
+	if err := scanLabel(r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"id": id}), &label); err != nil {
+		return nil, err
+	}
+	return &label, nil
+}
+
+func (r *LabelRepository) GetLabelsByTeamID(ctx context.Context, teamID string) ([]*_model.Label, error) {
+	query := `
+		SELECT ` + labelColumns + `
+		FROM app.labels l
+		WHERE l.team_id = @team_id
+		ORDER BY lower(l.name)
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"team_id": teamID})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var labels []*_model.Label
+	for rows.Next() {
+		var label _model.Label
+		if err = scanLabel(rows, &label); err != nil {
+			return nil, err
+		}
+		labels = append(labels, &label)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return labels, nil
+}
+
+// CountTeamLabels counts how many of ids are labels of the team
+func (r *LabelRepository) CountTeamLabels(ctx context.Context, teamID string, ids []string) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM app.labels
+		WHERE team_id = @team_id
+		AND id = ANY(@ids)
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id": teamID,
+		"ids":     ids,
+	}
+
+	var count int
+	if err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetTaskLabelsByTaskIDs returns the labels of every task in taskIDs ordered by label name
+func (r *LabelRepository) GetTaskLabelsByTaskIDs(ctx context.Context, taskIDs []string) ([]*_model.TaskLabel, error) {
+	query := `
+		SELECT tl.task_id, ` + labelColumns + `
+		FROM app.task_labels tl
+		JOIN app.labels l ON l.id = tl.label_id
+		WHERE tl.task_id = ANY(@task_ids)
+		ORDER BY lower(l.name)
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"task_ids": taskIDs})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var taskLabels []*_model.TaskLabel
+	for rows.Next() {
+		var taskLabel _model.TaskLabel
+		var label _model.Label
+		if err = rows.Scan(
+			&taskLabel.TaskID,
+			&label.ID,
+			&label.TeamID,
+			&label.Name,
+			&label.Color,
+			&label.CreatedAt,
+			&label.ModifiedAt,
+			&label.CreatedBy,
+			&label.ModifiedBy,
+		); err != nil {
+			return nil, err
+		}
+		taskLabel.LabelID = label.ID
+		taskLabel.Label = &label
+		taskLabels = append(taskLabels, &taskLabel)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return taskLabels, nil
+}
+
+func (r *LabelRepository) GetTaskIDsByLabelID(ctx context.Context, labelID string) ([]string, error) {
+	query := `
+		SELECT task_id FROM app.task_labels WHERE label_id = @label_id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"label_id": labelID})
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// SetTaskLabels makes labelIDs the exact set of labels of the task
+func (r *LabelRepository) SetTaskLabels(ctx context.Context, taskID string, labelIDs []string, createdBy *uuid.UUID) error {
+	deleteQuery := `
+		DELETE FROM app.task_labels
+		WHERE task_id = @task_id
+		AND NOT (label_id = ANY(@label_ids))
+	`
+	insertQuery := `
+		INSERT INTO app.task_labels (task_id, label_id, created_at, created_by)
+		SELECT @task_id, label_id, current_timestamp, @created_by
+		FROM unnest(@label_ids::UUID[]) AS label_id
+		ON CONFLICT (task_id, label_id) DO NOTHING
+	`
+
+	// An empty list clears the labels, a nil one would match nothing in the delete
+	if labelIDs == nil {
+		labelIDs = []string{}
+	}
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"task_id":    taskID,
+		"label_ids":  labelIDs,
+		"created_by": createdBy,
+	}
+
+	if _, err := r.db.Conn(ctx).Exec(ctx, deleteQuery, args); err != nil {
+		return err
+	}
+	_, err := r.db.Conn(ctx).Exec(ctx, insertQuery, args)
+	return err
+}
+
+// AddTaskLabels links the labels to the task, the labels it already has are kept
+func (r *LabelRepository) AddTaskLabels(ctx context.Context, taskID string, labelIDs []string, createdBy *uuid.UUID) error {
+	query := `
+		INSERT INTO app.task_labels (task_id, label_id, created_at, created_by)
+		SELECT @task_id, label_id, current_timestamp, @created_by
+		FROM unnest(@label_ids::UUID[]) AS label_id
+		ON CONFLICT (task_id, label_id) DO NOTHING
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"task_id":    taskID,
+		"label_ids":  labelIDs,
+		"created_by": createdBy,
+	}
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, args)
+	return err
+}
+
+// RemoveTaskLabels unlinks the labels from the task
+func (r *LabelRepository) RemoveTaskLabels(ctx context.Context, taskID string, labelIDs []string) error {
+	query := `
+		DELETE FROM app.task_labels
+		WHERE task_id = @task_id
+		AND label_id = ANY(@label_ids)
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"task_id":   taskID,
+		"label_ids": labelIDs,
+	}
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, args)
+	return err
+}
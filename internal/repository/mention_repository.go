@@ -0,0 +1,157 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type MentionRepositoryInterface interface {
+	SetMentions(ctx context.Context, taskID string, commentID *string, userIDs []string, createdBy *uuid.UUID) ([]string, error)
+	GetMentionsByTaskIDs(ctx context.Context, taskIDs []string) ([]*_model.Mention, error)
+	GetMentionsByUserID(ctx context.Context, userID string, limit int) ([]*_model.Mention, error)
+}
+
+type MentionRepository struct {
+	db *_db.Database
+}
+
+func NewMentionRepository(db *_db.Database) MentionRepositoryInterface {
+	return &MentionRepository{
+		db: db,
+	}
+}
+
+const mentionColumns = `
+	m.id,
+	m.task_id,
+	m.comment_id,
+	m.user_id,
+	m.created_at,
+	m.created_by
+`
+
+func scanMentions(rows pgx.Rows) ([]*_model.Mention, error) {
+	defer rows.Close()
+
+	var mentions []*_model.Mention
+	for rows.Next() {
+		var mention _model.Mention
+		if err := rows.Scan(
+			&mention.ID,
+			&mention.TaskID,
+			&mention.CommentID,
+			&mention.UserID,
+			&mention.CreatedAt,
+			&mention.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+		mentions = append(mentions, &mention)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return mentions, nil
+}
+
+// SetMentions makes userIDs the exact set of users mentioned by the description of the task, or by the comment
+// when commentID is set. It returns the users that were not mentioned there before
+func (r *MentionRepository) SetMentions(ctx context.Context, taskID string, commentID *string, userIDs []string, createdBy *uuid.UUID) ([]string, error) {
+	deleteQuery := `
+		DELETE FROM app.task_mentions
+		WHERE task_id = @task_id
+		AND comment_id IS NOT DISTINCT FROM @comment_id::UUID
+		AND NOT (user_id = ANY(@user_ids))
+	`
+	insertQuery := `
+		INSERT INTO app.task_mentions (task_id, comment_id, user_id, created_at, created_by)
+		SELECT @task_id, @comment_id::UUID, user_id, current_timestamp, @created_by
+		FROM unnest(@user_ids::UUID[]) AS user_id
+		ON CONFLICT DO NOTHING
+		RETURNING user_id::TEXT
+	`
+
+	// An empty list clears the mentions, a nil one would match nothing in the delete
+	if userIDs == nil {
+		userIDs = []string{}
+	}
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"task_id":    taskID,
+		"comment_id": commentID,
+		"user_ids":   userIDs,
+		"created_by": createdBy,
+	}
+
+	if _, err := r.db.Conn(ctx).Exec(ctx, deleteQuery, args); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, insertQuery, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var added []string
+	for rows.Next() {
+		var userID string
+		if err = rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		added = append(added, userID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return added, nil
+}
+
+// GetMentionsByTaskIDs returns the mentions of the descriptions and comments of every task in taskIDs, oldest first
+func (r *MentionRepository) GetMentionsByTaskIDs(ctx context.Context, taskIDs []string) ([]*_model.Mention, error) {
+	query := `
+		SELECT ` + mentionColumns + `
+		FROM app.task_mentions m
+		WHERE m.task_id = ANY(@task_ids)
+		ORDER BY m.created_at, m.id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"task_ids": taskIDs})
+	if err != nil {
+		return nil, err
+	}
+	return scanMentions(rows)
+}
+
+// GetMentionsByUserID returns the latest mentions of the user in the teams they are still a member of
+func (r *MentionRepository) GetMentionsByUserID(ctx context.Context, userID string, limit int) ([]*_model.Mention, error) {
+	query := `
+		SELECT ` + mentionColumns + `
+		FROM app.task_mentions m
+		JOIN app.tasks t ON t.id = m.task_id AND t.deleted_at IS NULL
+		JOIN app.user_teams ut ON ut.team_id = t.team_id AND ut.user_id = m.user_id
+		WHERE m.user_id = @user_id
+		ORDER BY m.created_at DESC, m.id
+		LIMIT @limit
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"user_id": userID,
+		"limit":   limit,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return scanMentions(rows)
+}
@@ -0,0 +1,281 @@
+package repository
+
+import (
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type MilestoneRepositoryInterface interface {
+	CreateMilestone(ctx context.Context, milestone *_model.Milestone) (*_model.Milestone, error)
+	UpdateMilestone(ctx context.Context, milestone *_model.Milestone) (*_model.Milestone, error)
+	DeleteMilestone(ctx context.Context, id string) error
+	GetMilestoneByID(ctx context.Context, id string) (*_model.Milestone, error)
+	GetMilestonesByIDs(ctx context.Context, ids []string) ([]*_model.Milestone, error)
+	GetMilestonesByTeamID(ctx context.Context, teamID string, state *_model.MilestoneState) ([]*_model.Milestone, error)
+	SetTasksMilestone(ctx context.Context, taskIDs []string, milestoneID *string, modifiedBy *uuid.UUID) ([]*_model.Task, error)
+	GetTasksByMilestoneIDs(ctx context.Context, milestoneIDs []string) ([]*_model.Task, error)
+	GetMilestoneTaskCounts(ctx context.Context, milestoneIDs []string) ([]*_model.MilestoneTaskCount, error)
+}
+
+type MilestoneRepository struct {
+	db *_db.Database
+}
+
+func NewMilestoneRepository(db *_db.Database) MilestoneRepositoryInterface {
+	return &MilestoneRepository{
+		db: db,
+	}
+}
+
+const milestoneColumns = `
+	m.id,
+	m.team_id,
+	m.name,
+	m.description,
+	m.target_date,
+	m.state,
+	m.completed_at,
+	m.created_at,
+	m.modified_at,
+	m.created_by,
+	m.modified_by
+`
+
+func scanMilestone(row pgx.Row, milestone *_model.Milestone) error {
+	return row.Scan(
+		&milestone.ID,
+		&milestone.TeamID,
+		&milestone.Name,
+		&milestone.Description,
+		&milestone.TargetDate,
+		&milestone.State,
+		&milestone.CompletedAt,
+		&milestone.CreatedAt,
+		&milestone.ModifiedAt,
+		&milestone.CreatedBy,
+		&milestone.ModifiedBy,
+	)
+}
+
+func scanMilestones(rows pgx.Rows) ([]*_model.Milestone, error) {
+	defer rows.Close()
+
+	var milestones []*_model.Milestone
+	for rows.Next() {
+		var milestone _model.Milestone
+		if err := scanMilestone(rows, &milestone); err != nil {
+			return nil, err
+		}
+		milestones = append(milestones, &milestone)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return milestones, nil
+}
+
+func (r *MilestoneRepository) CreateMilestone(ctx context.Context, milestone *_model.Milestone) (*_model.Milestone, error) {
+	query := `
+		INSERT INTO app.milestones AS m (team_id, name, description, target_date, state, created_at, modified_at, created_by, modified_by)
+		VALUES (@team_id, @name, @description, @target_date, @state, current_timestamp, current_timestamp, @created_by, @modified_by)
+		RETURNING ` + milestoneColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id":     milestone.TeamID,
+		"name":        milestone.Name,
+		"description": milestone.Description,
+		"target_date": milestone.TargetDate,
+		"state":       milestone.State,
+		"created_by":  milestone.CreatedBy,
+		"modified_by": milestone.ModifiedBy,
+	}
+
+	var created _model.Milestone
+	if err := scanMilestone(r.db.Conn(ctx).QueryRow(ctx, query, args), &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateMilestone stamps completed_at when the milestone gets completed and clears it when it is reopened
+func (r *MilestoneRepository) UpdateMilestone(ctx context.Context, milestone *_model.Milestone) (*_model.Milestone, error) {
+	query := `
+		UPDATE app.milestones m
+		SET name = @name, description = @description, target_date = @target_date, state = @state,
+		    completed_at = CASE
+		        WHEN @state::TEXT = 'OPEN' THEN NULL
+		        WHEN m.state = @state::TEXT THEN m.completed_at
+		        ELSE current_timestamp
+		    END,
+		    modified_at = current_timestamp, modified_by = @modified_by
+		WHERE m.id = @id
+		RETURNING ` + milestoneColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"id":          milestone.ID,
+		"name":        milestone.Name,
+		"description": milestone.Description,
+		"target_date": milestone.TargetDate,
+		"state":       milestone.State,
+		"modified_by": milestone.ModifiedBy,
+	}
+
+	var updated _model.Milestone
+	if err := scanMilestone(r.db.Conn(ctx).QueryRow(ctx, query, args), &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteMilestone leaves its tasks without milestone
+func (r *MilestoneRepository) DeleteMilestone(ctx context.Context, id string) error {
+	query := `
+		DELETE FROM app.milestones WHERE id = $1;
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	return err
+}
+
+func (r *MilestoneRepository) GetMilestoneByID(ctx context.Context, id string) (*_model.Milestone, error) {
+	query := `
+		SELECT ` + milestoneColumns + `
+		FROM app.milestones m
+		WHERE m.id = @id
+	`
+
+	var milestone _model.Milestone
+	if err := scanMilestone(r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"id": id}), &milestone); err != nil {
+		return nil, err
+	}
+	return &milestone, nil
+}
+
+func (r *MilestoneRepository) GetMilestonesByIDs(ctx context.Context, ids []string) ([]*_model.Milestone, error) {
+	query := `
+		SELECT ` + milestoneColumns + `
+		FROM app.milestones m
+		WHERE m.id = ANY(@ids)
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"ids": ids})
+	if err != nil {
+		return nil, err
+	}
+	return scanMilestones(rows)
+}
+
+// GetMilestonesByTeamID lists the milestones of the team in target date order, state is an optional filter
+func (r *MilestoneRepository) GetMilestonesByTeamID(ctx context.Context, teamID string, state *_model.MilestoneState) ([]*_model.Milestone, error) {
+	query := `
+		SELECT ` + milestoneColumns + `
+		FROM app.milestones m
+		WHERE m.team_id = @team_id
+		AND (@state::TEXT IS NULL OR m.state = @state::TEXT)
+		ORDER BY m.target_date, m.created_at
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id": teamID,
+		"state":   state,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return scanMilestones(rows)
+}
+
+// SetTasksMilestone assigns the tasks to milestoneID, a nil milestoneID takes them out of their milestone
+func (r *MilestoneRepository) SetTasksMilestone(ctx context.Context, taskIDs []string, milestoneID *string, modifiedBy *uuid.UUID) ([]*_model.Task, error) {
+	query := `
+		UPDATE app.tasks t
+		SET milestone_id = @milestone_id, modified_at = current_timestamp, modified_by = @modified_by, version = version + 1
+		WHERE t.id = ANY(@task_ids)
+		RETURNING ` + taskColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"task_ids":     taskIDs,
+		"milestone_id": milestoneID,
+		"modified_by":  modifiedBy,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// GetTasksByMilestoneIDs returns the tasks of every milestone of milestoneIDs in due date order
+func (r *MilestoneRepository) GetTasksByMilestoneIDs(ctx context.Context, milestoneIDs []string) ([]*_model.Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM app.tasks t
+		WHERE t.milestone_id = ANY(@milestone_ids)
+		AND t.deleted_at IS NULL
+		ORDER BY t.due_date, t.id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"milestone_ids": milestoneIDs})
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// GetMilestoneTaskCounts counts the tasks and sums the story points of every milestone of milestoneIDs holding tasks
+func (r *MilestoneRepository) GetMilestoneTaskCounts(ctx context.Context, milestoneIDs []string) ([]*_model.MilestoneTaskCount, error) {
+	query := `
+		SELECT t.milestone_id,
+		       COUNT(*) FILTER (WHERE t.status = @done_status),
+		       COUNT(*),
+		       COALESCE(SUM(t.story_points) FILTER (WHERE t.status = @done_status), 0),
+		       COALESCE(SUM(t.story_points), 0)
+		FROM app.tasks t
+		WHERE t.milestone_id = ANY(@milestone_ids)
+		AND t.deleted_at IS NULL
+		GROUP BY t.milestone_id
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"milestone_ids": milestoneIDs,
+		"done_status":   _const.TASK_STATUS_DONE,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []*_model.MilestoneTaskCount
+	for rows.Next() {
+		var count _model.MilestoneTaskCount
+		if err = rows.Scan(&count.MilestoneID, &count.Completed, &count.Total, &count.CompletedPoints, &count.TotalPoints); err != nil {
+			return nil, err
+		}
+		counts = append(counts, &count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"github.com/jackc/pgx/v5"
+)
+
+type NotificationRepositoryInterface interface {
+	CreateNotifications(ctx context.Context, userIDs []string, notification *_model.Notification) error
+	GetNotificationsByUserID(ctx context.Context, userID string, unreadOnly bool, limit int) ([]*_model.Notification, error)
+	MarkNotificationsRead(ctx context.Context, userID string, ids []string) (int64, error)
+	GetNotificationPreferences(ctx context.Context, userIDs []string) ([]*_model.NotificationPreference, error)
+	SetNotificationPreference(ctx context.Context, preference *_model.NotificationPreference) (*_model.NotificationPreference, error)
+}
+
+type NotificationRepository struct {
+	db *_db.Database
+}
+
+func NewNotificationRepository(db *_db.Database) NotificationRepositoryInterface {
+	return &NotificationRepository{
+		db: db,
+	}
+}
+
+const notificationColumns = `
+	n.id,
+	n.user_id,
+	n.kind,
+	n.task_id,
+	n.comment_id,
+	n.actor_id,
+	n.read_at,
+	n.created_at
+`
+
+// CreateNotifications delivers the same notification to every user in userIDs
+func (r *NotificationRepository) CreateNotifications(ctx context.Context, userIDs []string, notification *_model.Notification) error {
+	query := `
+		INSERT INTO app.notifications (user_id, kind, task_id, comment_id, actor_id, created_at)
+		SELECT user_id, @kind, @task_id, @comment_id, @actor_id, current_timestamp
+		FROM unnest(@user_ids::UUID[]) AS user_id
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"user_ids":   userIDs,
+		"kind":       notification.Kind,
+		"task_id":    notification.TaskID,
+		"comment_id": notification.CommentID,
+		"actor_id":   notification.ActorID,
+	}
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, args)
+	return err
+}
+
+// GetNotificationsByUserID returns the latest notifications of the user about the teams they are still a member of
+func (r *NotificationRepository) GetNotificationsByUserID(ctx context.Context, userID string, unreadOnly bool, limit int) ([]*_model.Notification, error) {
+	query := `
+		SELECT ` + notificationColumns + `
+		FROM app.notifications n
+		JOIN app.tasks t ON t.id = n.task_id AND t.deleted_at IS NULL
+		JOIN app.user_teams ut ON ut.team_id = t.team_id AND ut.user_id = n.user_id
+		WHERE n.user_id = @user_id
+		AND (NOT @unread_only OR n.read_at IS NULL)
+		ORDER BY n.created_at DESC, n.id
+		LIMIT @limit
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"user_id":     userID,
+		"unread_only": unreadOnly,
+		"limit":       limit,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*_model.Notification
+	for rows.Next() {
+		var notification _model.Notification
+		if err = rows.Scan(
+			&notification.ID,
+			&notification.UserID,
+			&notification.Kind,
+			&notification.TaskID,
+			&notification.CommentID,
+			&notification.ActorID,
+			&notification.ReadAt,
+			&notification.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, &notification)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// MarkNotificationsRead marks the unread notifications of the user in ids as read, all of them when ids is nil
+func (r *NotificationRepository) MarkNotificationsRead(ctx context.Context, userID string, ids []string) (int64, error) {
+	query := `
+		UPDATE app.notifications
+		SET read_at = current_timestamp
+		WHERE user_id = @user_id
+		AND read_at IS NULL
+		AND (@ids::UUID[] IS NULL OR id = ANY(@ids::UUID[]))
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"user_id": userID,
+		"ids":     ids,
+	}
+
+	tag, err := r.db.Conn(ctx).Exec(ctx, query, args)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// GetNotificationPreferences returns the preferences saved by the users in userIDs, kinds left to the defaults are missing
+func (r *NotificationRepository) GetNotificationPreferences(ctx context.Context, userIDs []string) ([]*_model.NotificationPreference, error) {
+	query := `
+		SELECT user_id, kind, in_app, email
+		FROM app.notification_preferences
+		WHERE user_id = ANY(@user_ids)
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"user_ids": userIDs})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var preferences []*_model.NotificationPreference
+	for rows.Next() {
+		var preference _model.NotificationPreference
+		if err = rows.Scan(&preference.UserID, &preference.Kind, &preference.InApp, &preference.Email); err != nil {
+			return nil, err
+		}
+		preferences = append(preferences, &preference)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+func (r *NotificationRepository) SetNotificationPreference(ctx context.Context, preference *_model.NotificationPreference) (*_model.NotificationPreference, error) {
+	query := `
+		INSERT INTO app.notification_preferences (user_id, kind, in_app, email, modified_at)
+		VALUES (@user_id, @kind, @in_app, @email, current_timestamp)
+		ON CONFLICT (user_id, kind) DO UPDATE
+		SET in_app = EXCLUDED.in_app, email = EXCLUDED.email, modified_at = EXCLUDED.modified_at
+		RETURNING user_id, kind, in_app, email
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"user_id": preference.UserID,
+		"kind":    preference.Kind,
+		"in_app":  preference.InApp,
+		"email":   preference.Email,
+	}
+
+	var saved _model.NotificationPreference
+	if err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&saved.UserID, &saved.Kind, &saved.InApp, &saved.Email); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_projection "bitbucket.org/edts/go-task-management/internal/model/projection"
+	"context"
+	"github.com/jackc/pgx/v5"
+)
+
+type PersonalAccessTokenRepositoryInterface interface {
+	CreatePersonalAccessToken(ctx context.Context, token *_model.PersonalAccessToken) (*_model.PersonalAccessToken, error)
+	GetPersonalAccessTokenByID(ctx context.Context, id string) (*_model.PersonalAccessToken, error)
+	GetPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (*_projection.PersonalAccessTokenOwner, error)
+	GetPersonalAccessTokensByUserID(ctx context.Context, userID string) ([]*_model.PersonalAccessToken, error)
+	GetPersonalAccessTokensByServiceAccountID(ctx context.Context, serviceAccountID string) ([]*_model.PersonalAccessToken, error)
+	RevokePersonalAccessToken(ctx context.Context, id string) error
+	TouchPersonalAccessToken(ctx context.Context, id string) error
+}
+
+type PersonalAccessTokenRepository struct {
+	db *_db.Database
+}
+
+func NewPersonalAccessTokenRepository(db *_db.Database) PersonalAccessTokenRepositoryInterface {
+	return &PersonalAccessTokenRepository{
+		db: db,
+	}
+}
+
+const personalAccessTokenColumns = `
+	pat.id,
+	pat.name,
+	pat.token_prefix,
+	pat.scopes,
+	pat.expires_at,
+	pat.last_used_at,
+	pat.revoked_at,
+	pat.user_id,
+	pat.service_account_id,
+	pat.created_at,
+	pat.modified_at,
+	pat.created_by,
+	pat.modified_by
+`
+
+func scanPersonalAccessToken(row pgx.Row, token *_model.PersonalAccessToken, extra ...any) error {
+	dest := []any{
+		&token.ID,
+		&token.Name,
+		&token.TokenPrefix,
+		&token.Scopes,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.RevokedAt,
+		&token.UserID,
+		&token.ServiceAccountID,
+		&token.CreatedAt,
+		&token.ModifiedAt,
+		&token.CreatedBy,
+		&token.ModifiedBy,
+	}
+	return row.Scan(append(dest, extra...)...)
+}
+
+func (r *PersonalAccessTokenRepository) CreatePersonalAccessToken(ctx context.Context, token *_model.PersonalAccessToken) (*_model.PersonalAccessToken, error) {
+	query := `
+		INSERT INTO app.personal_access_tokens (name, token_hash, token_prefix, scopes, user_id, service_account_id, expires_at, created_at, modified_at, created_by, modified_by)
+		VALUES (@name, @token_hash, @token_prefix, @scopes, @user_id, @service_account_id, @expires_at, current_timestamp, current_timestamp, @created_by, @modified_by)
+		RETURNING id, created_at, modified_at
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"name":               token.Name,
+		"token_hash":         token.TokenHash,
+		"token_prefix":       token.TokenPrefix,
+		"scopes":             token.Scopes,
+		"user_id":            token.UserID,
+		"service_account_id": token.ServiceAccountID,
+		"expires_at":         token.ExpiresAt,
+		"created_by":         token.CreatedBy,
+		"modified_by":        token.ModifiedBy,
+	}
+
+	err := r.db.Pool.QueryRow(ctx, query, args).Scan(&token.ID, &token.CreatedAt, &token.ModifiedAt)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *PersonalAccessTokenRepository) GetPersonalAccessTokenByID(ctx context.Context, id string) (*_model.PersonalAccessToken, error) {
+	query := `SELECT ` + personalAccessTokenColumns + ` FROM app.personal_access_tokens pat WHERE pat.id = @id`
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+
+	var token _model.PersonalAccessToken
+	if err := scanPersonalAccessToken(r.db.Pool.QueryRow(ctx, query, args), &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *PersonalAccessTokenRepository) GetPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (*_projection.PersonalAccessTokenOwner, error) {
+	query := `
+		SELECT ` + personalAccessTokenColumns + `,
+			u.email,
+			sa.name,
+			sa.team_id
+		FROM app.personal_access_tokens pat
+		LEFT JOIN app.users u ON u.id = pat.user_id
+		LEFT JOIN app.service_accounts sa ON sa.id = pat.service_account_id
+		WHERE pat.token_hash = @token_hash
+	`
+	args := pgx.NamedArgs{
+		"token_hash": tokenHash,
+	}
+
+	var owner _projection.PersonalAccessTokenOwner
+	var token _model.PersonalAccessToken
+	if err := scanPersonalAccessToken(
+		r.db.Pool.QueryRow(ctx, query, args),
+		&token,
+		&owner.UserEmail,
+		&owner.ServiceAccountName,
+		&owner.ServiceAccountTeamID,
+	); err != nil {
+		return nil, err
+	}
+	owner.Token = &token
+
+	return &owner, nil
+}
+
+func (r *PersonalAccessTokenRepository) GetPersonalAccessTokensByUserID(ctx context.Context, userID string) ([]*_model.PersonalAccessToken, error) {
+	query := `
+		SELECT ` + personalAccessTokenColumns + `
+		FROM app.personal_access_tokens pat
+		WHERE pat.user_id = @user_id
+		ORDER BY pat.created_at DESC
+	`
+	return r.queryPersonalAccessTokens(ctx, query, pgx.NamedArgs{"user_id": userID})
+}
+
+func (r *PersonalAccessTokenRepository) GetPersonalAccessTokensByServiceAccountID(ctx context.Context, serviceAccountID string) ([]*_model.PersonalAccessToken, error) {
+	query := `
+		SELECT ` + personalAccessTokenColumns + `
+		FROM app.personal_access_tokens pat
+		WHERE pat.service_account_id = @service_account_id
+		ORDER BY pat.created_at DESC
+	`
+	return r.queryPersonalAccessTokens(ctx, query, pgx.NamedArgs{"service_account_id": serviceAccountID})
+}
+
+func (r *PersonalAccessTokenRepository) queryPersonalAccessTokens(ctx context.Context, query string, args pgx.NamedArgs) ([]*_model.PersonalAccessToken, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*_model.PersonalAccessToken{}
+	for rows.Next() {
+		var token _model.PersonalAccessToken
+		if err := scanPersonalAccessToken(rows, &token); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, &token)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return tokens, nil
+}
+
+func (r *PersonalAccessTokenRepository) RevokePersonalAccessToken(ctx context.Context, id string) error {
+	query := `
+		UPDATE app.personal_access_tokens
+		SET revoked_at = current_timestamp, modified_at = current_timestamp
+		WHERE id = @id AND revoked_at IS NULL
+	`
+
+	_, err := r.db.Pool.Exec(ctx, query, pgx.NamedArgs{"id": id})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PersonalAccessTokenRepository) TouchPersonalAccessToken(ctx context.Context, id string) error {
+	query := `UPDATE app.personal_access_tokens SET last_used_at = current_timestamp WHERE id = @id`
+
+	_, err := r.db.Pool.Exec(ctx, query, pgx.NamedArgs{"id": id})
+	if err != nil {
+		return err
+	}
+	return nil
+}
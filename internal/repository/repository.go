@@ -1,22 +1,67 @@
 package repository
 
-import _db "bitbucket.org/edts/go-task-management/internal/db"
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_logger "bitbucket.org/edts/go-task-management/pkg/logger"
+)
+
+var logs = _logger.GetContextLoggerf(nil)
 
 type Repository struct {
-	TaskRepo        TaskRepositoryInterface
-	UserRepo        UserRepositoryInterface
-	TeamRepo        TeamRepositoryInterface
-	UserTeamRepo    UserTeamRepositoryInterface
-	UserSessionRepo UserSessionRepositoryInterface
+	TaskRepo           TaskRepositoryInterface
+	UserRepo           UserRepositoryInterface
+	TeamRepo           TeamRepositoryInterface
+	UserTeamRepo       UserTeamRepositoryInterface
+	UserSessionRepo    UserSessionRepositoryInterface
+	AccessTokenRepo    PersonalAccessTokenRepositoryInterface
+	ServiceAccountRepo ServiceAccountRepositoryInterface
+	UserIdentityRepo   UserIdentityRepositoryInterface
+	InvitationRepo     TeamInvitationRepositoryInterface
+	TransactionRepo    TransactionRepositoryInterface
+	WorkspaceRepo      WorkspaceRepositoryInterface
+	ChecklistItemRepo  ChecklistItemRepositoryInterface
+	TaskDependencyRepo TaskDependencyRepositoryInterface
+	LabelRepo          LabelRepositoryInterface
+	BoardRepo          BoardRepositoryInterface
+	SprintRepo         SprintRepositoryInterface
+	MilestoneRepo      MilestoneRepositoryInterface
+	WorklogRepo        WorklogRepositoryInterface
+	TaskSeriesRepo     TaskSeriesRepositoryInterface
+	TaskTemplateRepo   TaskTemplateRepositoryInterface
+	AttachmentRepo     AttachmentRepositoryInterface
+	CommentRepo        CommentRepositoryInterface
+	MentionRepo        MentionRepositoryInterface
+	NotificationRepo   NotificationRepositoryInterface
+	WatcherRepo        WatcherRepositoryInterface
 }
 
 // NewRepository Repo dependency injection here
 func NewRepository(dbConn *_db.Database) *Repository {
 	return &Repository{
-		TaskRepo:        NewTaskRepository(dbConn),
-		UserRepo:        NewUserRepository(dbConn),
-		TeamRepo:        NewTeamRepository(dbConn),
-		UserTeamRepo:    NewUserTeamRepository(dbConn),
-		UserSessionRepo: NewUserSessionRepository(dbConn),
+		TaskRepo:           NewTaskRepository(dbConn),
+		UserRepo:           NewUserRepository(dbConn),
+		TeamRepo:           NewTeamRepository(dbConn),
+		UserTeamRepo:       NewUserTeamRepository(dbConn),
+		UserSessionRepo:    NewUserSessionRepository(dbConn),
+		AccessTokenRepo:    NewPersonalAccessTokenRepository(dbConn),
+		ServiceAccountRepo: NewServiceAccountRepository(dbConn),
+		UserIdentityRepo:   NewUserIdentityRepository(dbConn),
+		InvitationRepo:     NewTeamInvitationRepository(dbConn),
+		TransactionRepo:    NewTransactionRepository(dbConn),
+		WorkspaceRepo:      NewWorkspaceRepository(dbConn),
+		ChecklistItemRepo:  NewChecklistItemRepository(dbConn),
+		TaskDependencyRepo: NewTaskDependencyRepository(dbConn),
+		LabelRepo:          NewLabelRepository(dbConn),
+		BoardRepo:          NewBoardRepository(dbConn),
+		SprintRepo:         NewSprintRepository(dbConn),
+		MilestoneRepo:      NewMilestoneRepository(dbConn),
+		WorklogRepo:        NewWorklogRepository(dbConn),
+		TaskSeriesRepo:     NewTaskSeriesRepository(dbConn),
+		TaskTemplateRepo:   NewTaskTemplateRepository(dbConn),
+		AttachmentRepo:     NewAttachmentRepository(dbConn),
+		CommentRepo:        NewCommentRepository(dbConn),
+		MentionRepo:        NewMentionRepository(dbConn),
+		NotificationRepo:   NewNotificationRepository(dbConn),
+		WatcherRepo:        NewWatcherRepository(dbConn),
 	}
 }
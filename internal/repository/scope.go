@@ -0,0 +1,19 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	"context"
+)
+
+// ScopeRequestToWorkspace restricts every following query of the request to the workspace
+func ScopeRequestToWorkspace(ctx context.Context, workspaceID string) {
+	if scope := _db.ScopeFrom(ctx); scope != nil {
+		scope.SetWorkspace(workspaceID)
+	}
+}
+
+// WithoutWorkspaceIsolation is reserved to background jobs and to the flows that cross workspaces on purpose,
+// such as accepting an invitation to a workspace the caller is not part of yet
+func WithoutWorkspaceIsolation(ctx context.Context) context.Context {
+	return _db.WithoutRowLevelSecurity(ctx)
+}
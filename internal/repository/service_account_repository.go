@@ -0,0 +1,129 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"github.com/jackc/pgx/v5"
+)
+
+type ServiceAccountRepositoryInterface interface {
+	CreateServiceAccount(ctx context.Context, serviceAccount *_model.ServiceAccount) (*_model.ServiceAccount, error)
+	GetServiceAccountByID(ctx context.Context, id string) (*_model.ServiceAccount, error)
+	GetServiceAccountsByTeamID(ctx context.Context, teamID string) ([]*_model.ServiceAccount, error)
+	DeleteServiceAccountByID(ctx context.Context, id string) error
+}
+
+type ServiceAccountRepository struct {
+	db *_db.Database
+}
+
+func NewServiceAccountRepository(db *_db.Database) ServiceAccountRepositoryInterface {
+	return &ServiceAccountRepository{
+		db: db,
+	}
+}
+
+func (r *ServiceAccountRepository) CreateServiceAccount(ctx context.Context, serviceAccount *_model.ServiceAccount) (*_model.ServiceAccount, error) {
+	query := `
+		INSERT INTO app.service_accounts (team_id, "name", description, created_at, modified_at, created_by, modified_by)
+		VALUES (@team_id, @name, @description, current_timestamp, current_timestamp, @created_by, @modified_by)
+		RETURNING id, created_at, modified_at
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id":     serviceAccount.TeamID,
+		"name":        serviceAccount.Name,
+		"description": serviceAccount.Description,
+		"created_by":  serviceAccount.CreatedBy,
+		"modified_by": serviceAccount.ModifiedBy,
+	}
+
+	err := r.db.Pool.QueryRow(ctx, query, args).Scan(&serviceAccount.ID, &serviceAccount.CreatedAt, &serviceAccount.ModifiedAt)
+	if err != nil {
+		return nil, err
+	}
+	return serviceAccount, nil
+}
+
+func (r *ServiceAccountRepository) GetServiceAccountByID(ctx context.Context, id string) (*_model.ServiceAccount, error) {
+	query := `
+		SELECT id, team_id, "name", description, created_at, modified_at, created_by, modified_by
+		FROM app.service_accounts
+		WHERE id = @id
+	`
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+
+	var serviceAccount _model.ServiceAccount
+	err := r.db.Pool.QueryRow(ctx, query, args).Scan(
+		&serviceAccount.ID,
+		&serviceAccount.TeamID,
+		&serviceAccount.Name,
+		&serviceAccount.Description,
+		&serviceAccount.CreatedAt,
+		&serviceAccount.ModifiedAt,
+		&serviceAccount.CreatedBy,
+		&serviceAccount.ModifiedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &serviceAccount, nil
+}
+
+func (r *ServiceAccountRepository) GetServiceAccountsByTeamID(ctx context.Context, teamID string) ([]*_model.ServiceAccount, error) {
+	query := `
+		SELECT id, team_id, "name", description, created_at, modified_at, created_by, modified_by
+		FROM app.service_accounts
+		WHERE team_id = @team_id
+		ORDER BY "name"
+	`
+	args := pgx.NamedArgs{
+		"team_id": teamID,
+	}
+
+	rows, err := r.db.Pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	serviceAccounts := []*_model.ServiceAccount{}
+	for rows.Next() {
+		var serviceAccount _model.ServiceAccount
+		if err := rows.Scan(
+			&serviceAccount.ID,
+			&serviceAccount.TeamID,
+			&serviceAccount.Name,
+			&serviceAccount.Description,
+			&serviceAccount.CreatedAt,
+			&serviceAccount.ModifiedAt,
+			&serviceAccount.CreatedBy,
+			&serviceAccount.ModifiedBy,
+		); err != nil {
+			return nil, err
+		}
+		serviceAccounts = append(serviceAccounts, &serviceAccount)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return serviceAccounts, nil
+}
+
+func (r *ServiceAccountRepository) DeleteServiceAccountByID(ctx context.Context, id string) error {
+	// Tokens of the service account are removed by ON DELETE CASCADE
+	query := `DELETE FROM app.service_accounts WHERE id = @id`
+
+	_, err := r.db.Pool.Exec(ctx, query, pgx.NamedArgs{"id": id})
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,380 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type SprintRepositoryInterface interface {
+	CreateSprint(ctx context.Context, sprint *_model.Sprint) (*_model.Sprint, error)
+	UpdateSprint(ctx context.Context, sprint *_model.Sprint) (*_model.Sprint, error)
+	DeleteSprint(ctx context.Context, id string) error
+	GetSprintByID(ctx context.Context, id string) (*_model.Sprint, error)
+	GetSprintsByIDs(ctx context.Context, ids []string) ([]*_model.Sprint, error)
+	GetSprintsByTeamID(ctx context.Context, teamID string, state *_model.SprintState) ([]*_model.Sprint, error)
+	GetNextPlannedSprint(ctx context.Context, teamID string) (*_model.Sprint, error)
+	LockSprint(ctx context.Context, id string) (*_model.Sprint, error)
+	SetSprintState(ctx context.Context, id string, state _model.SprintState, modifiedBy *uuid.UUID) (*_model.Sprint, error)
+	SetTasksSprint(ctx context.Context, taskIDs []string, sprintID *string, modifiedBy *uuid.UUID) ([]*_model.Task, error)
+	GetTasksBySprintIDs(ctx context.Context, sprintIDs []string) ([]*_model.Task, error)
+	GetBacklogTasks(ctx context.Context, teamID string) ([]*_model.Task, error)
+	AddSprintTaskEvents(ctx context.Context, sprintID string, taskIDs []string, event _model.SprintTaskEvent, createdBy *uuid.UUID) error
+	GetSprintTaskEvents(ctx context.Context, sprintID string) ([]*_model.SprintTask, error)
+}
+
+type SprintRepository struct {
+	db *_db.Database
+}
+
+func NewSprintRepository(db *_db.Database) SprintRepositoryInterface {
+	return &SprintRepository{
+		db: db,
+	}
+}
+
+const sprintColumns = `
+	s.id,
+	s.team_id,
+	s.name,
+	s.goal,
+	s.start_date,
+	s.end_date,
+	s.state,
+	s.started_at,
+	s.completed_at,
+	s.created_at,
+	s.modified_at,
+	s.created_by,
+	s.modified_by
+`
+
+func scanSprint(row pgx.Row, sprint *_model.Sprint) error {
+	return row.Scan(
+		&sprint.ID,
+		&sprint.TeamID,
+		&sprint.Name,
+		&sprint.Goal,
+		&sprint.StartDate,
+		&sprint.EndDate,
+		&sprint.State,
+		&sprint.StartedAt,
+		&sprint.CompletedAt,
+		&sprint.CreatedAt,
+		&sprint.ModifiedAt,
+		&sprint.CreatedBy,
+		&sprint.ModifiedBy,
+	)
+}
+
+func scanSprints(rows pgx.Rows) ([]*_model.Sprint, error) {
+	defer rows.Close()
+
+	var sprints []*_model.Sprint
+	for rows.Next() {
+		var sprint _model.Sprint
+		if err := scanSprint(rows, &sprint); err != nil {
+			return nil, err
+		}
+		sprints = append(sprints, &sprint)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sprints, nil
+}
+
+func (r *SprintRepository) CreateSprint(ctx context.Context, sprint *_model.Sprint) (*_model.Sprint, error) {
+	query := `
+		INSERT INTO app.sprints AS s (team_id, name, goal, start_date, end_date, state, created_at, modified_at, created_by, modified_by)
+		VALUES (@team_id, @name, @goal, @start_date, @end_date, @state, current_timestamp, current_timestamp, @created_by, @modified_by)
+		RETURNING ` + sprintColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id":     sprint.TeamID,
+		"name":        sprint.Name,
+		"goal":        sprint.Goal,
+		"start_date":  sprint.StartDate,
+		"end_date":    sprint.EndDate,
+		"state":       sprint.State,
+		"created_by":  sprint.CreatedBy,
+		"modified_by": sprint.ModifiedBy,
+	}
+
+	var created _model.Sprint
+	if err := scanSprint(r.db.Conn(ctx).QueryRow(ctx, query, args), &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (r *SprintRepository) UpdateSprint(ctx context.Context, sprint *_model.Sprint) (*_model.Sprint, error) {
+	query := `
+		UPDATE app.sprints s
+		SET name = @name, goal = @goal, start_date = @start_date, end_date = @end_date,
+		    modified_at = current_timestamp, modified_by = @modified_by
+		WHERE s.id = @id
+		RETURNING ` + sprintColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"id":          sprint.ID,
+		"name":        sprint.Name,
+		"goal":        sprint.Goal,
+		"start_date":  sprint.StartDate,
+		"end_date":    sprint.EndDate,
+		"modified_by": sprint.ModifiedBy,
+	}
+
+	var updated _model.Sprint
+	if err := scanSprint(r.db.Conn(ctx).QueryRow(ctx, query, args), &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteSprint sends the tasks of the sprint back to the backlog
+func (r *SprintRepository) DeleteSprint(ctx context.Context, id string) error {
+	query := `
+		DELETE FROM app.sprints WHERE id = $1;
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	return err
+}
+
+func (r *SprintRepository) GetSprintByID(ctx context.Context, id string) (*_model.Sprint, error) {
+	query := `
+		SELECT ` + sprintColumns + `
+		FROM app.sprints s
+		WHERE s.id = @id
+	`
+
+	var sprint _model.Sprint
+	if err := scanSprint(r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"id": id}), &sprint); err != nil {
+		return nil, err
+	}
+	return &sprint, nil
+}
+
+func (r *SprintRepository) GetSprintsByIDs(ctx context.Context, ids []string) ([]*_model.Sprint, error) {
+	query := `
+		SELECT ` + sprintColumns + `
+		FROM app.sprints s
+		WHERE s.id = ANY(@ids)
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"ids": ids})
+	if err != nil {
+		return nil, err
+	}
+	return scanSprints(rows)
+}
+
+// GetSprintsByTeamID lists the sprints of the team in start date order, state is an optional filter
+func (r *SprintRepository) GetSprintsByTeamID(ctx context.Context, teamID string, state *_model.SprintState) ([]*_model.Sprint, error) {
+	query := `
+		SELECT ` + sprintColumns + `
+		FROM app.sprints s
+		WHERE s.team_id = @team_id
+		AND (@state::TEXT IS NULL OR s.state = @state::TEXT)
+		ORDER BY s.start_date, s.created_at
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id": teamID,
+		"state":   state,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return scanSprints(rows)
+}
+
+// GetNextPlannedSprint returns the planned sprint of the team starting first, nil when none is planned
+func (r *SprintRepository) GetNextPlannedSprint(ctx context.Context, teamID string) (*_model.Sprint, error) {
+	query := `
+		SELECT ` + sprintColumns + `
+		FROM app.sprints s
+		WHERE s.team_id = @team_id
+		AND s.state = 'PLANNED'
+		ORDER BY s.start_date, s.created_at
+		LIMIT 1
+	`
+
+	var sprint _model.Sprint
+	err := scanSprint(r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"team_id": teamID}), &sprint)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sprint, nil
+}
+
+// LockSprint reads the sprint and locks it until the surrounding transaction ends
+func (r *SprintRepository) LockSprint(ctx context.Context, id string) (*_model.Sprint, error) {
+	query := `
+		SELECT ` + sprintColumns + `
+		FROM app.sprints s
+		WHERE s.id = @id
+		FOR UPDATE
+	`
+
+	var sprint _model.Sprint
+	if err := scanSprint(r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"id": id}), &sprint); err != nil {
+		return nil, err
+	}
+	return &sprint, nil
+}
+
+// SetSprintState moves the sprint to state and stamps started_at or completed_at
+func (r *SprintRepository) SetSprintState(ctx context.Context, id string, state _model.SprintState, modifiedBy *uuid.UUID) (*_model.Sprint, error) {
+	query := `
+		UPDATE app.sprints s
+		SET state = @state,
+		    started_at = CASE WHEN @state::TEXT = 'ACTIVE' THEN current_timestamp ELSE s.started_at END,
+		    completed_at = CASE WHEN @state::TEXT = 'COMPLETED' THEN current_timestamp ELSE s.completed_at END,
+		    modified_at = current_timestamp,
+		    modified_by = @modified_by
+		WHERE s.id = @id
+		RETURNING ` + sprintColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"id":          id,
+		"state":       state,
+		"modified_by": modifiedBy,
+	}
+
+	var sprint _model.Sprint
+	if err := scanSprint(r.db.Conn(ctx).QueryRow(ctx, query, args), &sprint); err != nil {
+		return nil, err
+	}
+	return &sprint, nil
+}
+
+// SetTasksSprint plans the tasks into sprintID, a nil sprintID sends them to the backlog
+func (r *SprintRepository) SetTasksSprint(ctx context.Context, taskIDs []string, sprintID *string, modifiedBy *uuid.UUID) ([]*_model.Task, error) {
+	query := `
+		UPDATE app.tasks t
+		SET sprint_id = @sprint_id, modified_at = current_timestamp, modified_by = @modified_by, version = version + 1
+		WHERE t.id = ANY(@task_ids)
+		RETURNING ` + taskColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"task_ids":    taskIDs,
+		"sprint_id":   sprintID,
+		"modified_by": modifiedBy,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// GetTasksBySprintIDs returns the tasks planned in every sprint of sprintIDs in rank order
+func (r *SprintRepository) GetTasksBySprintIDs(ctx context.Context, sprintIDs []string) ([]*_model.Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM app.tasks t
+		WHERE t.sprint_id = ANY(@sprint_ids)
+		AND t.deleted_at IS NULL
+		ORDER BY t.rank, t.id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"sprint_ids": sprintIDs})
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// GetBacklogTasks returns the team tasks planned in no sprint in rank order
+func (r *SprintRepository) GetBacklogTasks(ctx context.Context, teamID string) ([]*_model.Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM app.tasks t
+		JOIN app.teams tm ON tm.id = t.team_id AND tm.deleted_at IS NULL
+		WHERE t.team_id = @team_id
+		AND t.sprint_id IS NULL
+		AND t.deleted_at IS NULL
+		ORDER BY t.rank, t.id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"team_id": teamID})
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+func (r *SprintRepository) AddSprintTaskEvents(ctx context.Context, sprintID string, taskIDs []string, event _model.SprintTaskEvent, createdBy *uuid.UUID) error {
+	query := `
+		INSERT INTO app.sprint_task_events (sprint_id, task_id, event, created_at, created_by)
+		SELECT @sprint_id, task_id, @event, current_timestamp, @created_by
+		FROM unnest(@task_ids::UUID[]) AS task_id
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"sprint_id":  sprintID,
+		"task_ids":   taskIDs,
+		"event":      event,
+		"created_by": createdBy,
+	}
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, args)
+	return err
+}
+
+// GetSprintTaskEvents returns the scope history of the sprint oldest first with the tasks loaded
+func (r *SprintRepository) GetSprintTaskEvents(ctx context.Context, sprintID string) ([]*_model.SprintTask, error) {
+	query := `
+		SELECT ` + taskColumns + `, e.sprint_id, e.task_id, e.event, e.created_at
+		FROM app.sprint_task_events e
+		JOIN app.tasks t ON t.id = e.task_id AND t.deleted_at IS NULL
+		WHERE e.sprint_id = @sprint_id
+		ORDER BY e.created_at, t.rank
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"sprint_id": sprintID})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*_model.SprintTask
+	for rows.Next() {
+		var event _model.SprintTask
+		var task _model.Task
+		if err = scanTask(rows, &task, &event.SprintID, &event.TaskID, &event.Event, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		event.Task = &task
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
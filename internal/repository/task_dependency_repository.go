@@ -0,0 +1,200 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"github.com/jackc/pgx/v5"
+)
+
+type TaskDependencyRepositoryInterface interface {
+	CreateTaskDependency(ctx context.Context, dependency *_model.TaskDependency) error
+	DeleteTaskDependency(ctx context.Context, taskID string, blockedByTaskID string) (bool, error)
+	GetBlockingTasks(ctx context.Context, taskIDs []string) ([]*_model.TaskDependency, error)
+	GetBlockedTasks(ctx context.Context, blockedByTaskIDs []string) ([]*_model.TaskDependency, error)
+	GetTeamTaskDependencies(ctx context.Context, teamID string) ([]*_model.TaskDependency, error)
+	IsTaskBlockedBy(ctx context.Context, taskID string, blockedByTaskID string) (bool, error)
+	LockTeamTaskDependencies(ctx context.Context, teamID string) error
+}
+
+type TaskDependencyRepository struct {
+	db *_db.Database
+}
+
+func NewTaskDependencyRepository(db *_db.Database) TaskDependencyRepositoryInterface {
+	return &TaskDependencyRepository{
+		db: db,
+	}
+}
+
+// CreateTaskDependency is a no-op when the dependency already exists
+func (r *TaskDependencyRepository) CreateTaskDependency(ctx context.Context, dependency *_model.TaskDependency) error {
+	query := `
+		INSERT INTO app.task_dependencies (task_id, blocked_by_task_id, created_at, created_by)
+		VALUES (@task_id, @blocked_by_task_id, current_timestamp, @created_by)
+		ON CONFLICT (task_id, blocked_by_task_id) DO NOTHING
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"task_id":            dependency.TaskID,
+		"blocked_by_task_id": dependency.BlockedByTaskID,
+		"created_by":         dependency.CreatedBy,
+	}
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, args)
+	return err
+}
+
+func (r *TaskDependencyRepository) DeleteTaskDependency(ctx context.Context, taskID string, blockedByTaskID string) (bool, error) {
+	query := `
+		DELETE FROM app.task_dependencies
+		WHERE task_id = @task_id
+		AND blocked_by_task_id = @blocked_by_task_id
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"task_id":            taskID,
+		"blocked_by_task_id": blockedByTaskID,
+	}
+
+	tag, err := r.db.Conn(ctx).Exec(ctx, query, args)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetBlockingTasks returns the dependencies of every task in taskIDs with the blocking task loaded in BlockedBy
+func (r *TaskDependencyRepository) GetBlockingTasks(ctx context.Context, taskIDs []string) ([]*_model.TaskDependency, error) {
+	query := `
+		SELECT ` + taskColumns + `, d.task_id, d.blocked_by_task_id, d.created_at, d.created_by
+		FROM app.task_dependencies d
+		JOIN app.tasks t ON t.id = d.blocked_by_task_id AND t.deleted_at IS NULL
+		WHERE d.task_id = ANY(@task_ids)
+		ORDER BY t.due_date, t.id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"task_ids": taskIDs})
+	if err != nil {
+		return nil, err
+	}
+	return scanTaskDependencies(rows, func(dependency *_model.TaskDependency, task *_model.Task) {
+		dependency.BlockedBy = task
+	})
+}
+
+// GetBlockedTasks returns the dependencies on every task in blockedByTaskIDs with the blocked task loaded in Task
+func (r *TaskDependencyRepository) GetBlockedTasks(ctx context.Context, blockedByTaskIDs []string) ([]*_model.TaskDependency, error) {
+	query := `
+		SELECT ` + taskColumns + `, d.task_id, d.blocked_by_task_id, d.created_at, d.created_by
+		FROM app.task_dependencies d
+		JOIN app.tasks t ON t.id = d.task_id AND t.deleted_at IS NULL
+		WHERE d.blocked_by_task_id = ANY(@blocked_by_task_ids)
+		ORDER BY t.due_date, t.id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"blocked_by_task_ids": blockedByTaskIDs})
+	if err != nil {
+		return nil, err
+	}
+	return scanTaskDependencies(rows, func(dependency *_model.TaskDependency, task *_model.Task) {
+		dependency.Task = task
+	})
+}
+
+// GetTeamTaskDependencies returns every dependency between the tasks of the team without loading the tasks
+func (r *TaskDependencyRepository) GetTeamTaskDependencies(ctx context.Context, teamID string) ([]*_model.TaskDependency, error) {
+	query := `
+		SELECT d.task_id, d.blocked_by_task_id, d.created_at, d.created_by
+		FROM app.task_dependencies d
+		JOIN app.tasks t ON t.id = d.task_id AND t.deleted_at IS NULL
+		JOIN app.tasks b ON b.id = d.blocked_by_task_id AND b.deleted_at IS NULL
+		WHERE t.team_id = @team_id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"team_id": teamID})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dependencies []*_model.TaskDependency
+	for rows.Next() {
+		var dependency _model.TaskDependency
+		if err = rows.Scan(
+			&dependency.TaskID,
+			&dependency.BlockedByTaskID,
+			&dependency.CreatedAt,
+			&dependency.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, &dependency)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return dependencies, nil
+}
+
+// IsTaskBlockedBy reports whether taskID waits on blockedByTaskID directly or through a chain of dependencies
+func (r *TaskDependencyRepository) IsTaskBlockedBy(ctx context.Context, taskID string, blockedByTaskID string) (bool, error) {
+	query := `
+		WITH RECURSIVE blockers AS (
+			SELECT blocked_by_task_id AS id FROM app.task_dependencies WHERE task_id = @task_id
+			UNION
+			SELECT d.blocked_by_task_id
+			FROM app.task_dependencies d
+			JOIN blockers b ON d.task_id = b.id
+		)
+		SELECT EXISTS (SELECT 1 FROM blockers WHERE id = @blocked_by_task_id)
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"task_id":            taskID,
+		"blocked_by_task_id": blockedByTaskID,
+	}
+
+	var isBlocked bool
+	if err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&isBlocked); err != nil {
+		return false, err
+	}
+	return isBlocked, nil
+}
+
+// LockTeamTaskDependencies serializes dependency changes of a team until the surrounding transaction ends,
+// otherwise concurrent additions could each pass the cycle check and close a cycle together
+func (r *TaskDependencyRepository) LockTeamTaskDependencies(ctx context.Context, teamID string) error {
+	query := `
+		SELECT pg_advisory_xact_lock(hashtextextended('task_dependencies:' || @team_id::TEXT, 0))
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, pgx.NamedArgs{"team_id": teamID})
+	return err
+}
+
+func scanTaskDependencies(rows pgx.Rows, setTask func(dependency *_model.TaskDependency, task *_model.Task)) ([]*_model.TaskDependency, error) {
+	defer rows.Close()
+
+	var dependencies []*_model.TaskDependency
+	for rows.Next() {
+		var dependency _model.TaskDependency
+		var task _model.Task
+		if err := scanTask(rows, &task, &dependency.TaskID, &dependency.BlockedByTaskID, &dependency.CreatedAt, &dependency.CreatedBy); err != nil {
+			return nil, err
+		}
+		setTask(&dependency, &task)
+		dependencies = append(dependencies, &dependency)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return dependencies, nil
+}
@@ -1,21 +1,45 @@
 package repository
 
 import (
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
 	_db "bitbucket.org/edts/go-task-management/internal/db"
 	_model "bitbucket.org/edts/go-task-management/internal/model"
 	"context"
+	"errors"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"time"
 )
 
 type TaskRepositoryInterface interface {
 	CreateTask(ctx context.Context, task *_model.Task) (*_model.Task, error)
-	GetTasksByTeam(ctx context.Context, teamID string, status *string) ([]*_model.Task, error)
+	GetTasksByTeam(ctx context.Context, teamID string, status *string, labels *_model.LabelFilter) ([]*_model.Task, error)
 	GetTaskByID(ctx context.Context, id string) (*_model.Task, error)
-	UpdateTaskById(ctx context.Context, task *_model.Task) (*_model.Task, error)
+	UpdateTaskById(ctx context.Context, task *_model.Task, expectedVersion *int32) (*_model.Task, error)
 	DeleteTaskById(ctx context.Context, taskID string) error
-	MoveTaskById(ctx context.Context, task *_model.Task) (*_model.Task, error)
-	AssignTask(ctx context.Context, task *_model.Task) (*_model.Task, error)
+	TrashTask(ctx context.Context, taskID string, deletedBy *uuid.UUID) ([]*_model.Task, error)
+	RestoreTask(ctx context.Context, taskID string, rank string, modifiedBy *uuid.UUID) ([]*_model.Task, error)
+	GetTrashedTaskByID(ctx context.Context, id string) (*_model.Task, error)
+	GetTrashedTasksByTeam(ctx context.Context, teamID string) ([]*_model.Task, error)
+	PurgeTrashedTasks(ctx context.Context, deletedBefore time.Time) ([]*_model.Task, error)
+	MoveTaskById(ctx context.Context, task *_model.Task, expectedVersion *int32) (*_model.Task, error)
+	AssignTask(ctx context.Context, task *_model.Task, expectedVersion *int32) (*_model.Task, error)
+	ReassignTeamTasks(ctx context.Context, teamID string, fromUserIDs []string, toUserID *string, modifiedBy *uuid.UUID) ([]*_model.Task, error)
+	GetTasksByIDs(ctx context.Context, ids []string) ([]*_model.Task, error)
+	GetTasksByKeys(ctx context.Context, keys []_model.TaskKey) ([]*_model.Task, error)
+	MoveTaskToTeam(ctx context.Context, task *_model.Task, modifiedBy *uuid.UUID) (*_model.Task, error)
+	GetSubtasksByParentIDs(ctx context.Context, parentIDs []string) ([]*_model.Task, error)
+	SetTaskParent(ctx context.Context, id string, parentID *string, modifiedBy *uuid.UUID) (*_model.Task, error)
+	IsTaskAncestor(ctx context.Context, ancestorID string, taskID string) (bool, error)
+	LockTasks(ctx context.Context, ids []string) error
+	SetTaskRank(ctx context.Context, id string, rank string, modifiedBy *uuid.UUID) (*_model.Task, error)
+	GetColumnRankGap(ctx context.Context, column _model.TaskColumn, position int, excludeID *string) (string, string, error)
+	GetAdjacentRank(ctx context.Context, column _model.TaskColumn, rank string, after bool, excludeID string) (string, error)
+	LockTaskColumn(ctx context.Context, column _model.TaskColumn) error
+	GetTaskColumnsWithLongRanks(ctx context.Context, maxLength int) ([]*_model.TaskColumn, error)
+	GetColumnTaskIDs(ctx context.Context, column _model.TaskColumn) ([]string, error)
+	SetTaskRanks(ctx context.Context, ids []string, ranks []string) error
+	GetTeamThroughputs(ctx context.Context, teamIDs []string, since time.Time) ([]*_model.TeamThroughput, error)
 }
 
 type TaskRepository struct {
@@ -28,33 +52,128 @@ func NewTaskRepository(db *_db.Database) TaskRepositoryInterface {
 	}
 }
 
+const taskColumns = `
+	t.id,
+	t.title,
+	t.description,
+	t.status,
+	t.due_date,
+	t.assigned_to,
+	t.team_id,
+	t.parent_id,
+	t.priority,
+	t.rank,
+	t.sprint_id,
+	t.milestone_id,
+	t.story_points,
+	t.original_estimate,
+	t.series_id,
+	t.occurrence_at,
+	t.deleted_at,
+	t.deleted_by,
+	t.version,
+	t.number,
+	t.created_at,
+	t.modified_at
+`
+
+// scanTask reads the taskColumns of the row into the task, extra receives the columns selected after them
+func scanTask(row pgx.Row, task *_model.Task, extra ...any) error {
+	dest := []any{
+		&task.ID,
+		&task.Title,
+		&task.Description,
+		&task.Status,
+		&task.DueDate,
+		&task.AssignedTo,
+		&task.TeamID,
+		&task.ParentID,
+		&task.Priority,
+		&task.Rank,
+		&task.SprintID,
+		&task.MilestoneID,
+		&task.StoryPoints,
+		&task.OriginalEstimate,
+		&task.SeriesID,
+		&task.OccurrenceAt,
+		&task.DeletedAt,
+		&task.DeletedBy,
+		&task.Version,
+		&task.Number,
+		&task.CreatedAt,
+		&task.ModifiedAt,
+	}
+	return row.Scan(append(dest, extra...)...)
+}
+
+func scanTasks(rows pgx.Rows) ([]*_model.Task, error) {
+	defer rows.Close()
+
+	var tasks []*_model.Task
+	for rows.Next() {
+		var task _model.Task
+		if err := scanTask(rows, &task); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, &task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// nextTaskNumber increments the task counter of @team_id, creates allocating the number of the task with it
+// wait on the row lock of the team so concurrent creates never get the same number
+const nextTaskNumber = `
+		WITH next_number AS (
+			UPDATE app.teams SET task_counter = task_counter + 1 WHERE id = @team_id RETURNING task_counter
+		)
+`
+
+// CreateTask gives the task the next number of its team
 func (r *TaskRepository) CreateTask(ctx context.Context, task *_model.Task) (*_model.Task, error) {
-	query := `
-		INSERT INTO app.tasks (title, description, status, assigned_to, team_id, due_date, created_at, modified_at, created_by, modified_by)
-		VALUES (@title, @description, @status, @assigned_to, @team_id, @due_date, current_timestamp, current_timestamp, @created_by, @modified_by)
-		RETURNING id, created_at
+	query := nextTaskNumber + `
+		INSERT INTO app.tasks (title, description, status, assigned_to, team_id, parent_id, priority, rank, due_date, story_points, original_estimate, completed_at, number, created_at, modified_at, created_by, modified_by)
+		VALUES (@title, @description, @status, @assigned_to, @team_id, @parent_id, @priority, @rank, @due_date, @story_points, @original_estimate,
+		        CASE WHEN @status::TEXT = @done_status::TEXT THEN current_timestamp END,
+		        (SELECT task_counter FROM next_number), current_timestamp, current_timestamp, @created_by, @modified_by)
+		RETURNING id, version, number, created_at
 	`
 
 	// Query arguments
 	args := pgx.NamedArgs{
-		"title":       task.Title,
-		"description": task.Description,
-		"status":      task.Status,
-		"assigned_to": task.AssignedTo,
-		"team_id":     task.TeamID,
-		"due_date":    task.DueDate,
-		"created_by":  task.CreatedBy,
-		"modified_by": task.ModifiedBy,
+		"title":             task.Title,
+		"description":       task.Description,
+		"status":            task.Status,
+		"assigned_to":       task.AssignedTo,
+		"team_id":           task.TeamID,
+		"parent_id":         task.ParentID,
+		"priority":          task.Priority,
+		"rank":              task.Rank,
+		"due_date":          task.DueDate,
+		"story_points":      task.StoryPoints,
+		"original_estimate": task.OriginalEstimate,
+		"done_status":       _const.TASK_STATUS_DONE,
+		"created_by":        task.CreatedBy,
+		"modified_by":       task.ModifiedBy,
 	}
 
-	err := r.db.Pool.QueryRow(ctx, query, args).Scan(&task.ID, &task.CreatedAt)
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&task.ID, &task.Version, &task.Number, &task.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return task, nil
 }
 
-func (r *TaskRepository) GetTasksByTeam(ctx context.Context, teamID string, status *string) ([]*_model.Task, error) {
+// GetTasksByTeam lists the team tasks, status and labels are optional filters
+func (r *TaskRepository) GetTasksByTeam(ctx context.Context, teamID string, status *string, labels *_model.LabelFilter) ([]*_model.Task, error) {
+	if labels == nil {
+		labels = &_model.LabelFilter{}
+	}
+
 	query := `
 		SELECT 
 			t.id,
@@ -64,22 +183,47 @@ func (r *TaskRepository) GetTasksByTeam(ctx context.Context, teamID string, stat
 			t.due_date,
 			t.assigned_to,
 			t.team_id,
+			t.parent_id,
+			t.priority,
+			t.rank,
+			t.sprint_id,
+			t.milestone_id,
+			t.story_points,
+			t.original_estimate,
+			t.series_id,
+			t.occurrence_at,
+			t.version,
+			t.number,
 			t.created_at,
 			t.modified_at
 		FROM app.tasks t 
+		JOIN app.teams tm ON tm.id = t.team_id AND tm.deleted_at IS NULL
 		WHERE t.team_id = @team_id
+		AND t.deleted_at IS NULL
 		AND (@status::varchar IS NULL OR t.status = @status::varchar)
-		ORDER BY t.created_at DESC
+		AND (@label_any::UUID[] IS NULL OR EXISTS (
+			SELECT 1 FROM app.task_labels tl WHERE tl.task_id = t.id AND tl.label_id = ANY(@label_any::UUID[])
+		))
+		AND (@label_all::UUID[] IS NULL OR (
+			SELECT COUNT(*) FROM app.task_labels tl WHERE tl.task_id = t.id AND tl.label_id = ANY(@label_all::UUID[])
+		) = cardinality(@label_all::UUID[]))
+		AND (@label_none::UUID[] IS NULL OR NOT EXISTS (
+			SELECT 1 FROM app.task_labels tl WHERE tl.task_id = t.id AND tl.label_id = ANY(@label_none::UUID[])
+		))
+		ORDER BY t.rank, t.id
 	`
 
-	// Query arguments
+	// Query arguments, a nil label list does not filter
 	args := pgx.NamedArgs{
-		"team_id": teamID,
-		"status":  status,
+		"team_id":    teamID,
+		"status":     status,
+		"label_any":  labels.Any,
+		"label_all":  labels.All,
+		"label_none": labels.None,
 	}
 
 	var tasks []*_model.Task
-	rows, err := r.db.Pool.Query(ctx, query, args)
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
 	if err != nil {
 		return nil, err
 	}
@@ -96,6 +240,17 @@ func (r *TaskRepository) GetTasksByTeam(ctx context.Context, teamID string, stat
 			&task.DueDate,
 			&task.AssignedTo,
 			&task.TeamID,
+			&task.ParentID,
+			&task.Priority,
+			&task.Rank,
+			&task.SprintID,
+			&task.MilestoneID,
+			&task.StoryPoints,
+			&task.OriginalEstimate,
+			&task.SeriesID,
+			&task.OccurrenceAt,
+			&task.Version,
+			&task.Number,
 			&task.CreatedAt,
 			&task.ModifiedAt,
 		); err != nil {
@@ -118,6 +273,17 @@ func (r *TaskRepository) GetTaskByID(ctx context.Context, id string) (*_model.Ta
 			ts.due_date,
 			ts.assigned_to,
 			ts.team_id,
+			ts.parent_id,
+			ts.priority,
+			ts.rank,
+			ts.sprint_id,
+			ts.milestone_id,
+			ts.story_points,
+			ts.original_estimate,
+			ts.series_id,
+			ts.occurrence_at,
+			ts.version,
+			ts.number,
 			ts.created_at,
 			ts.modified_at,
 			u.id AS user_id,
@@ -126,12 +292,16 @@ func (r *TaskRepository) GetTaskByID(ctx context.Context, id string) (*_model.Ta
 			u.created_at AS user_created_at,
 			t.id AS team_id,
 			t."name" AS team_name,
+			t.key AS team_key,
 			t.description AS team_desc,
-			t.created_at AS team_created_at
+			t.created_at AS team_created_at,
+			t.archived_at AS team_archived_at,
+			t.wip_limit_policy AS team_wip_limit_policy
 		FROM app.tasks ts 
-		JOIN app.teams t ON t.id = ts.team_id
+		JOIN app.teams t ON t.id = ts.team_id AND t.deleted_at IS NULL
 		LEFT JOIN app.users u ON u.id = ts.assigned_to
 		WHERE ts.id = @id
+		AND ts.deleted_at IS NULL
 	`
 
 	// Query arguments
@@ -149,7 +319,7 @@ func (r *TaskRepository) GetTaskByID(ctx context.Context, id string) (*_model.Ta
 	var userEmail *string
 	var userCreatedAt *time.Time
 
-	if err := r.db.Pool.QueryRow(ctx, query, args).Scan(
+	if err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(
 		&task.ID,
 		&task.Title,
 		&task.Description,
@@ -157,6 +327,17 @@ func (r *TaskRepository) GetTaskByID(ctx context.Context, id string) (*_model.Ta
 		&task.DueDate,
 		&task.AssignedTo,
 		&task.TeamID,
+		&task.ParentID,
+		&task.Priority,
+		&task.Rank,
+		&task.SprintID,
+		&task.MilestoneID,
+		&task.StoryPoints,
+		&task.OriginalEstimate,
+		&task.SeriesID,
+		&task.OccurrenceAt,
+		&task.Version,
+		&task.Number,
 		&task.CreatedAt,
 		&task.ModifiedAt,
 		&userId,
@@ -165,8 +346,11 @@ func (r *TaskRepository) GetTaskByID(ctx context.Context, id string) (*_model.Ta
 		&userCreatedAt,
 		&team.ID,
 		&team.Name,
+		&team.Key,
 		&team.Description,
 		&team.CreatedAt,
+		&team.ArchivedAt,
+		&team.WipLimitPolicy,
 	); err != nil {
 		return nil, err
 	}
@@ -192,32 +376,32 @@ func (r *TaskRepository) GetTaskByID(ctx context.Context, id string) (*_model.Ta
 	return &task, nil
 }
 
-func (r *TaskRepository) UpdateTaskById(ctx context.Context, task *_model.Task) (*_model.Task, error) {
+// UpdateTaskById turns a task of a series into an exception that no longer follows the series edits.
+// A non nil expectedVersion only updates the task at that version, pgx.ErrNoRows is returned otherwise
+func (r *TaskRepository) UpdateTaskById(ctx context.Context, task *_model.Task, expectedVersion *int32) (*_model.Task, error) {
 	sqlStatement := `
-		UPDATE app.tasks
-		SET title = $1, description = $2, due_date = $3, modified_at = current_timestamp
-		WHERE id = $4
-		RETURNING id, title, description, status, assigned_to, due_date;
+		UPDATE app.tasks t
+		SET title = $1, description = $2, due_date = $3, priority = $4, story_points = $6, original_estimate = $7, modified_at = current_timestamp,
+		    series_exception = series_id IS NOT NULL, version = version + 1
+		WHERE id = $5
+		AND ($8::INT IS NULL OR version = $8)
+		RETURNING ` + taskColumns + `
 	`
 
 	var updatedTask _model.Task
-	err := r.db.Pool.QueryRow(
+	row := r.db.Conn(ctx).QueryRow(
 		ctx,
 		sqlStatement,
 		task.Title,
 		task.Description,
 		task.DueDate,
+		task.Priority,
 		task.ID,
-	).Scan(
-		&updatedTask.ID,
-		&updatedTask.Title,
-		&updatedTask.Description,
-		&updatedTask.Status,
-		&updatedTask.AssignedTo,
-		&updatedTask.DueDate,
+		task.StoryPoints,
+		task.OriginalEstimate,
+		expectedVersion,
 	)
-
-	if err != nil {
+	if err := scanTask(row, &updatedTask); err != nil {
 		return nil, err
 	}
 
@@ -229,7 +413,7 @@ func (r *TaskRepository) DeleteTaskById(ctx context.Context, taskID string) erro
 		DELETE FROM app.tasks WHERE id = $1;
 	`
 
-	_, err := r.db.Pool.Exec(ctx, query, taskID)
+	_, err := r.db.Conn(ctx).Exec(ctx, query, taskID)
 	if err != nil {
 		return err
 	}
@@ -237,63 +421,637 @@ func (r *TaskRepository) DeleteTaskById(ctx context.Context, taskID string) erro
 
 }
 
-func (r *TaskRepository) MoveTaskById(ctx context.Context, task *_model.Task) (*_model.Task, error) {
-	//TODO: update task status by task id
+// TrashTask moves the task and its subtasks to the trash, they all share the same deleted_at
+// so restoring the task brings back the subtasks trashed along with it
+func (r *TaskRepository) TrashTask(ctx context.Context, taskID string, deletedBy *uuid.UUID) ([]*_model.Task, error) {
+	query := `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM app.tasks WHERE id = @id AND deleted_at IS NULL
+			UNION
+			SELECT c.id FROM app.tasks c
+			JOIN subtree s ON c.parent_id = s.id
+			WHERE c.deleted_at IS NULL
+		)
+		UPDATE app.tasks t
+		SET deleted_at = current_timestamp, deleted_by = @deleted_by, version = version + 1
+		WHERE t.id IN (SELECT id FROM subtree)
+		RETURNING ` + taskColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"id":         taskID,
+		"deleted_by": deletedBy,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// RestoreTask takes the task out of the trash at the given rank, with the subtasks trashed along with it
+func (r *TaskRepository) RestoreTask(ctx context.Context, taskID string, rank string, modifiedBy *uuid.UUID) ([]*_model.Task, error) {
+	query := `
+		WITH RECURSIVE subtree AS (
+			SELECT id, deleted_at FROM app.tasks WHERE id = @id AND deleted_at IS NOT NULL
+			UNION
+			SELECT c.id, c.deleted_at FROM app.tasks c
+			JOIN subtree s ON c.parent_id = s.id AND c.deleted_at = s.deleted_at
+		)
+		UPDATE app.tasks t
+		SET deleted_at = NULL, deleted_by = NULL,
+		    rank = CASE WHEN t.id = @id THEN @rank ELSE t.rank END,
+		    modified_at = current_timestamp, modified_by = @modified_by, version = version + 1
+		WHERE t.id IN (SELECT id FROM subtree)
+		RETURNING ` + taskColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"id":          taskID,
+		"rank":        rank,
+		"modified_by": modifiedBy,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// GetTrashedTaskByID returns the task when it is in the trash of a team that is not deleted
+func (r *TaskRepository) GetTrashedTaskByID(ctx context.Context, id string) (*_model.Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM app.tasks t
+		JOIN app.teams tm ON tm.id = t.team_id AND tm.deleted_at IS NULL
+		WHERE t.id = @id
+		AND t.deleted_at IS NOT NULL
+	`
+
+	var task _model.Task
+	if err := scanTask(r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"id": id}), &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// GetTrashedTasksByTeam returns the trash of the team, most recently deleted first
+func (r *TaskRepository) GetTrashedTasksByTeam(ctx context.Context, teamID string) ([]*_model.Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM app.tasks t
+		WHERE t.team_id = @team_id
+		AND t.deleted_at IS NOT NULL
+		ORDER BY t.deleted_at DESC, t.id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"team_id": teamID})
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// PurgeTrashedTasks hard deletes the tasks trashed before deletedBefore.
+// Rows locked by another replica are skipped so concurrent purges do not block each other
+func (r *TaskRepository) PurgeTrashedTasks(ctx context.Context, deletedBefore time.Time) ([]*_model.Task, error) {
+	query := `
+		WITH expired AS (
+			SELECT id FROM app.tasks
+			WHERE deleted_at < @deleted_before
+			FOR UPDATE SKIP LOCKED
+		)
+		DELETE FROM app.tasks t
+		WHERE t.id IN (SELECT id FROM expired)
+		RETURNING ` + taskColumns + `
+	`
+
+	args := pgx.NamedArgs{
+		"deleted_before": deletedBefore,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// MoveTaskById stamps completed_at when the task reaches the done status and clears it when the task leaves it,
+// expectedVersion works like in UpdateTaskById
+func (r *TaskRepository) MoveTaskById(ctx context.Context, task *_model.Task, expectedVersion *int32) (*_model.Task, error) {
 	sqlStatement := `
-		UPDATE app.tasks
-		SET title = $1, modified_at = current_timestamp
-		WHERE id = $2
-		RETURNING id, title, description, status, assigned_to, due_date;
+		UPDATE app.tasks t
+		SET status = $1, rank = $2, modified_at = current_timestamp, version = version + 1,
+		    completed_at = CASE WHEN status = $1::TEXT THEN completed_at WHEN $1::TEXT = $4::TEXT THEN current_timestamp END
+		WHERE id = $3
+		AND ($5::INT IS NULL OR version = $5)
+		RETURNING ` + taskColumns + `
 	`
 
 	var moveTask _model.Task
-	err := r.db.Pool.QueryRow(
+	row := r.db.Conn(ctx).QueryRow(
 		ctx,
 		sqlStatement,
 		task.Status,
+		task.Rank,
 		task.ID,
-	).Scan(
-		&moveTask.ID,
-		&moveTask.Title,
-		&moveTask.Description,
-		&moveTask.Status,
-		&moveTask.AssignedTo,
-		&moveTask.DueDate,
+		_const.TASK_STATUS_DONE,
+		expectedVersion,
 	)
-
-	if err != nil {
+	if err := scanTask(row, &moveTask); err != nil {
 		return nil, err
 	}
 
 	return &moveTask, nil
 }
 
-func (r *TaskRepository) AssignTask(ctx context.Context, task *_model.Task) (*_model.Task, error) {
+// AssignTask turns a task of a series into an exception like UpdateTaskById, expectedVersion works the same way
+func (r *TaskRepository) AssignTask(ctx context.Context, task *_model.Task, expectedVersion *int32) (*_model.Task, error) {
 	sqlStatement := `
-		UPDATE app.tasks
-		SET assigned_to = $1, modified_at = current_timestamp
+		UPDATE app.tasks t
+		SET assigned_to = $1, modified_at = current_timestamp, series_exception = series_id IS NOT NULL, version = version + 1
 		WHERE id = $2
-		RETURNING id, title, description, status, assigned_to, due_date;
+		AND ($3::INT IS NULL OR version = $3)
+		RETURNING ` + taskColumns + `
 	`
 
 	var assignedTask _model.Task
-	err := r.db.Pool.QueryRow(
+	row := r.db.Conn(ctx).QueryRow(
 		ctx,
 		sqlStatement,
 		task.AssignedTo,
 		task.ID,
-	).Scan(
-		&assignedTask.ID,
-		&assignedTask.Title,
-		&assignedTask.Description,
-		&assignedTask.Status,
-		&assignedTask.AssignedTo,
-		&assignedTask.DueDate,
+		expectedVersion,
 	)
+	if err := scanTask(row, &assignedTask); err != nil {
+		return nil, err
+	}
+
+	return &assignedTask, nil
+}
+
+// ReassignTeamTasks moves the team tasks assigned to any of fromUserIDs to toUserID, a nil toUserID unassigns them
+func (r *TaskRepository) ReassignTeamTasks(ctx context.Context, teamID string, fromUserIDs []string, toUserID *string, modifiedBy *uuid.UUID) ([]*_model.Task, error) {
+	query := `
+		UPDATE app.tasks
+		SET assigned_to = @to_user_id, modified_at = current_timestamp, modified_by = @modified_by, version = version + 1
+		WHERE team_id = @team_id
+		AND assigned_to = ANY(@from_user_ids)
+		AND deleted_at IS NULL
+		RETURNING id, title, description, status, due_date, assigned_to, team_id, parent_id, priority, rank, sprint_id, milestone_id, story_points, original_estimate, series_id, occurrence_at, deleted_at, deleted_by, version, number, created_at, modified_at
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id":       teamID,
+		"from_user_ids": fromUserIDs,
+		"to_user_id":    toUserID,
+		"modified_by":   modifiedBy,
+	}
 
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
 	if err != nil {
 		return nil, err
 	}
+	return scanTasks(rows)
+}
 
-	return &assignedTask, nil
+// GetTasksByIDs returns the tasks found among ids, tasks in the trash or of deleted teams are left out
+func (r *TaskRepository) GetTasksByIDs(ctx context.Context, ids []string) ([]*_model.Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM app.tasks t
+		JOIN app.teams tm ON tm.id = t.team_id AND tm.deleted_at IS NULL
+		WHERE t.id = ANY(@ids)
+		AND t.deleted_at IS NULL
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"ids": ids})
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// GetTasksByKeys returns the tasks found among keys, current keys and the former keys of the tasks moved to
+// another team both resolve. Tasks in the trash or of deleted teams are left out
+func (r *TaskRepository) GetTasksByKeys(ctx context.Context, keys []_model.TaskKey) ([]*_model.Task, error) {
+	query := `
+		WITH keys AS (
+			SELECT * FROM unnest(@team_keys::TEXT[], @numbers::INT[]) AS k(team_key, number)
+		)
+		SELECT ` + taskColumns + `
+		FROM app.tasks t
+		JOIN app.teams tm ON tm.id = t.team_id AND tm.deleted_at IS NULL
+		WHERE t.deleted_at IS NULL
+		AND t.id IN (
+			SELECT kt.id
+			FROM app.tasks kt
+			JOIN app.teams ktm ON ktm.id = kt.team_id
+			JOIN keys k ON k.team_key = ktm.key AND k.number = kt.number
+			UNION
+			SELECT a.task_id
+			FROM app.task_key_aliases a
+			JOIN app.teams atm ON atm.id = a.team_id
+			JOIN keys k ON k.team_key = atm.key AND k.number = a.number
+		)
+		ORDER BY t.team_id, t.number
+	`
+
+	teamKeys := make([]string, len(keys))
+	numbers := make([]int32, len(keys))
+	for i, key := range keys {
+		teamKeys[i] = key.TeamKey
+		numbers[i] = key.Number
+	}
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_keys": teamKeys,
+		"numbers":   numbers,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// MoveTaskToTeam moves the task to task.TeamID with the next number of that team, its current key is kept as an
+// alias. The parent, sprint, milestone, labels and dependencies belong to the previous team and are dropped,
+// so are the watchers who are not members of the new team. A task of a series becomes an exception.
+// The task must be locked by the caller
+func (r *TaskRepository) MoveTaskToTeam(ctx context.Context, task *_model.Task, modifiedBy *uuid.UUID) (*_model.Task, error) {
+	query := nextTaskNumber + `,
+		alias AS (
+			INSERT INTO app.task_key_aliases (team_id, number, task_id)
+			SELECT team_id, number, id FROM app.tasks WHERE id = @id
+		),
+		sprint_removal AS (
+			INSERT INTO app.sprint_task_events (sprint_id, task_id, event, created_at, created_by)
+			SELECT t.sprint_id, t.id, @removed_event, current_timestamp, @modified_by
+			FROM app.tasks t
+			JOIN app.sprints s ON s.id = t.sprint_id AND s.state = 'ACTIVE'
+			WHERE t.id = @id
+		),
+		dropped_labels AS (
+			DELETE FROM app.task_labels WHERE task_id = @id
+		),
+		dropped_dependencies AS (
+			DELETE FROM app.task_dependencies WHERE task_id = @id OR blocked_by_task_id = @id
+		),
+		dropped_watchers AS (
+			DELETE FROM app.task_watchers w
+			WHERE w.task_id = @id
+			AND NOT EXISTS (SELECT 1 FROM app.user_teams ut WHERE ut.team_id = @team_id AND ut.user_id = w.user_id)
+		)
+		UPDATE app.tasks t
+		SET team_id = @team_id,
+		    number = (SELECT task_counter FROM next_number),
+		    rank = @rank,
+		    assigned_to = @assigned_to,
+		    parent_id = NULL,
+		    sprint_id = NULL,
+		    milestone_id = NULL,
+		    series_exception = series_id IS NOT NULL,
+		    modified_at = current_timestamp,
+		    modified_by = @modified_by,
+		    version = version + 1
+		WHERE t.id = @id
+		RETURNING ` + taskColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"id":            task.ID,
+		"team_id":       task.TeamID,
+		"rank":          task.Rank,
+		"assigned_to":   task.AssignedTo,
+		"removed_event": _model.SprintTaskEventRemoved,
+		"modified_by":   modifiedBy,
+	}
+
+	var moved _model.Task
+	if err := scanTask(r.db.Conn(ctx).QueryRow(ctx, query, args), &moved); err != nil {
+		return nil, err
+	}
+	return &moved, nil
+}
+
+// GetSubtasksByParentIDs returns the direct subtasks of every parent in parentIDs, oldest first
+func (r *TaskRepository) GetSubtasksByParentIDs(ctx context.Context, parentIDs []string) ([]*_model.Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM app.tasks t
+		JOIN app.teams tm ON tm.id = t.team_id AND tm.deleted_at IS NULL
+		WHERE t.parent_id = ANY(@parent_ids)
+		AND t.deleted_at IS NULL
+		ORDER BY t.created_at, t.id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"parent_ids": parentIDs})
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
+// SetTaskParent makes the task a subtask of parentID, a nil parentID turns it back into a top level task
+func (r *TaskRepository) SetTaskParent(ctx context.Context, id string, parentID *string, modifiedBy *uuid.UUID) (*_model.Task, error) {
+	query := `
+		UPDATE app.tasks t
+		SET parent_id = @parent_id, modified_at = current_timestamp, modified_by = @modified_by, version = version + 1
+		WHERE t.id = @id
+		RETURNING ` + taskColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"id":          id,
+		"parent_id":   parentID,
+		"modified_by": modifiedBy,
+	}
+
+	var task _model.Task
+	if err := scanTask(r.db.Conn(ctx).QueryRow(ctx, query, args), &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// IsTaskAncestor reports whether ancestorID is taskID itself or one of its parents at any depth
+func (r *TaskRepository) IsTaskAncestor(ctx context.Context, ancestorID string, taskID string) (bool, error) {
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM app.tasks WHERE id = @task_id
+			UNION
+			SELECT t.id, t.parent_id
+			FROM app.tasks t
+			JOIN ancestors a ON t.id = a.parent_id
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = @ancestor_id)
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"task_id":     taskID,
+		"ancestor_id": ancestorID,
+	}
+
+	var isAncestor bool
+	if err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&isAncestor); err != nil {
+		return false, err
+	}
+	return isAncestor, nil
+}
+
+// LockTasks locks the task rows until the surrounding transaction ends, rows are locked in id order to avoid deadlocks
+func (r *TaskRepository) LockTasks(ctx context.Context, ids []string) error {
+	query := `
+		SELECT id FROM app.tasks
+		WHERE id = ANY(@ids)
+		ORDER BY id
+		FOR UPDATE
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"ids": ids})
+	if err != nil {
+		return err
+	}
+	rows.Close()
+	return rows.Err()
+}
+
+func (r *TaskRepository) SetTaskRank(ctx context.Context, id string, rank string, modifiedBy *uuid.UUID) (*_model.Task, error) {
+	query := `
+		UPDATE app.tasks t
+		SET rank = @rank, modified_at = current_timestamp, modified_by = @modified_by, version = version + 1
+		WHERE t.id = @id
+		RETURNING ` + taskColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"id":          id,
+		"rank":        rank,
+		"modified_by": modifiedBy,
+	}
+
+	var task _model.Task
+	if err := scanTask(r.db.Conn(ctx).QueryRow(ctx, query, args), &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// GetColumnRankGap returns the ranks around the given zero based position of the column ignoring excludeID,
+// an empty rank means the position is at the start or at the end of the column
+func (r *TaskRepository) GetColumnRankGap(ctx context.Context, column _model.TaskColumn, position int, excludeID *string) (string, string, error) {
+	query := `
+		WITH col AS (
+			SELECT rank, row_number() OVER (ORDER BY rank, id) - 1 AS position
+			FROM app.tasks
+			WHERE team_id = @team_id
+			AND status = @status
+			AND deleted_at IS NULL
+			AND (@exclude_id::UUID IS NULL OR id <> @exclude_id::UUID)
+		)
+		SELECT
+			COALESCE((SELECT rank FROM col WHERE position < @position ORDER BY position DESC LIMIT 1), ''),
+			COALESCE((SELECT rank FROM col WHERE position = @position), '')
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id":    column.TeamID,
+		"status":     column.Status,
+		"position":   position,
+		"exclude_id": excludeID,
+	}
+
+	var lower, upper string
+	if err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&lower, &upper); err != nil {
+		return "", "", err
+	}
+	return lower, upper, nil
+}
+
+// GetAdjacentRank returns the rank right after (or right before) rank in the column ignoring excludeID,
+// an empty rank means there is no task on that side
+func (r *TaskRepository) GetAdjacentRank(ctx context.Context, column _model.TaskColumn, rank string, after bool, excludeID string) (string, error) {
+	query := `
+		SELECT rank FROM app.tasks
+		WHERE team_id = @team_id
+		AND status = @status
+		AND id <> @exclude_id
+		AND deleted_at IS NULL
+		AND rank < @rank
+		ORDER BY rank DESC
+		LIMIT 1
+	`
+	if after {
+		query = `
+			SELECT rank FROM app.tasks
+			WHERE team_id = @team_id
+			AND status = @status
+			AND id <> @exclude_id
+			AND deleted_at IS NULL
+			AND rank > @rank
+			ORDER BY rank
+			LIMIT 1
+		`
+	}
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id":    column.TeamID,
+		"status":     column.Status,
+		"rank":       rank,
+		"exclude_id": excludeID,
+	}
+
+	var adjacent string
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&adjacent)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return adjacent, nil
+}
+
+// LockTaskColumn serializes rank changes in the column until the surrounding transaction ends,
+// otherwise two tasks dropped in the same gap would get the same rank
+func (r *TaskRepository) LockTaskColumn(ctx context.Context, column _model.TaskColumn) error {
+	query := `
+		SELECT pg_advisory_xact_lock(hashtextextended('task_column:' || @team_id::TEXT || ':' || @status::TEXT, 0))
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id": column.TeamID,
+		"status":  column.Status,
+	}
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, args)
+	return err
+}
+
+// GetTaskColumnsWithLongRanks returns the columns holding at least one rank longer than maxLength
+func (r *TaskRepository) GetTaskColumnsWithLongRanks(ctx context.Context, maxLength int) ([]*_model.TaskColumn, error) {
+	query := `
+		SELECT DISTINCT team_id, status
+		FROM app.tasks
+		WHERE length(rank) > @max_length
+		AND deleted_at IS NULL
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"max_length": maxLength})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []*_model.TaskColumn
+	for rows.Next() {
+		var column _model.TaskColumn
+		if err = rows.Scan(&column.TeamID, &column.Status); err != nil {
+			return nil, err
+		}
+		columns = append(columns, &column)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return columns, nil
+}
+
+// GetColumnTaskIDs returns the ids of the column tasks in rank order
+func (r *TaskRepository) GetColumnTaskIDs(ctx context.Context, column _model.TaskColumn) ([]string, error) {
+	query := `
+		SELECT id FROM app.tasks
+		WHERE team_id = @team_id
+		AND status = @status
+		AND deleted_at IS NULL
+		ORDER BY rank, id
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id": column.TeamID,
+		"status":  column.Status,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// SetTaskRanks gives ranks[i] to the task ids[i] in one statement, modified_at and version are kept since the order does not change
+func (r *TaskRepository) SetTaskRanks(ctx context.Context, ids []string, ranks []string) error {
+	query := `
+		UPDATE app.tasks t
+		SET rank = r.rank
+		FROM unnest(@ids::UUID[], @ranks::TEXT[]) AS r(id, rank)
+		WHERE t.id = r.id
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"ids":   ids,
+		"ranks": ranks,
+	}
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, args)
+	return err
+}
+
+// GetTeamThroughputs counts the tasks every team of teamIDs completed since the given moment, teams without any are left out
+func (r *TaskRepository) GetTeamThroughputs(ctx context.Context, teamIDs []string, since time.Time) ([]*_model.TeamThroughput, error) {
+	query := `
+		SELECT t.team_id, COUNT(*)
+		FROM app.tasks t
+		WHERE t.team_id = ANY(@team_ids)
+		AND t.completed_at >= @since
+		AND t.deleted_at IS NULL
+		GROUP BY t.team_id
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_ids": teamIDs,
+		"since":    since,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var throughputs []*_model.TeamThroughput
+	for rows.Next() {
+		throughput := _model.TeamThroughput{Since: since}
+		if err = rows.Scan(&throughput.TeamID, &throughput.Completed); err != nil {
+			return nil, err
+		}
+		throughputs = append(throughputs, &throughput)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return throughputs, nil
 }
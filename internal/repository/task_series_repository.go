@@ -0,0 +1,348 @@
+package repository
+
+import (
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type TaskSeriesRepositoryInterface interface {
+	CreateTaskSeries(ctx context.Context, series *_model.TaskSeries) (*_model.TaskSeries, error)
+	UpdateTaskSeries(ctx context.Context, series *_model.TaskSeries) (*_model.TaskSeries, error)
+	GetTaskSeriesByID(ctx context.Context, id string) (*_model.TaskSeries, error)
+	GetTaskSeriesByIDs(ctx context.Context, ids []string) ([]*_model.TaskSeries, error)
+	GetTaskSeriesByTeamID(ctx context.Context, teamID string) ([]*_model.TaskSeries, error)
+	LockTaskSeries(ctx context.Context, id string) (*_model.TaskSeries, error)
+	LockDueTaskSeries(ctx context.Context, now time.Time, limit int, skipIDs []string) ([]*_model.TaskSeries, error)
+	CreateOccurrence(ctx context.Context, task *_model.Task) (*_model.Task, error)
+	UpdateOpenOccurrences(ctx context.Context, series *_model.TaskSeries) ([]*_model.Task, error)
+}
+
+type TaskSeriesRepository struct {
+	db *_db.Database
+}
+
+func NewTaskSeriesRepository(db *_db.Database) TaskSeriesRepositoryInterface {
+	return &TaskSeriesRepository{
+		db: db,
+	}
+}
+
+const taskSeriesColumns = `
+	s.id,
+	s.team_id,
+	s.title,
+	s.description,
+	s.assigned_to,
+	s.priority,
+	s.story_points,
+	s.original_estimate,
+	s.rrule,
+	s.start_at,
+	s.last_occurrence_at,
+	s.next_occurrence_at,
+	s.ended_at,
+	s.created_at,
+	s.modified_at,
+	s.created_by,
+	s.modified_by
+`
+
+func scanTaskSeries(row pgx.Row, series *_model.TaskSeries) error {
+	return row.Scan(
+		&series.ID,
+		&series.TeamID,
+		&series.Title,
+		&series.Description,
+		&series.AssignedTo,
+		&series.Priority,
+		&series.StoryPoints,
+		&series.OriginalEstimate,
+		&series.Rrule,
+		&series.StartAt,
+		&series.LastOccurrenceAt,
+		&series.NextOccurrenceAt,
+		&series.EndedAt,
+		&series.CreatedAt,
+		&series.ModifiedAt,
+		&series.CreatedBy,
+		&series.ModifiedBy,
+	)
+}
+
+func scanTaskSeriesRows(rows pgx.Rows) ([]*_model.TaskSeries, error) {
+	defer rows.Close()
+
+	var seriesList []*_model.TaskSeries
+	for rows.Next() {
+		var series _model.TaskSeries
+		if err := scanTaskSeries(rows, &series); err != nil {
+			return nil, err
+		}
+		seriesList = append(seriesList, &series)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return seriesList, nil
+}
+
+func (r *TaskSeriesRepository) CreateTaskSeries(ctx context.Context, series *_model.TaskSeries) (*_model.TaskSeries, error) {
+	query := `
+		INSERT INTO app.task_series AS s (team_id, title, description, assigned_to, priority, story_points, original_estimate,
+		                                  rrule, start_at, next_occurrence_at, created_at, modified_at, created_by, modified_by)
+		VALUES (@team_id, @title, @description, @assigned_to, @priority, @story_points, @original_estimate,
+		        @rrule, @start_at, @next_occurrence_at, current_timestamp, current_timestamp, @created_by, @modified_by)
+		RETURNING ` + taskSeriesColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id":            series.TeamID,
+		"title":              series.Title,
+		"description":        series.Description,
+		"assigned_to":        series.AssignedTo,
+		"priority":           series.Priority,
+		"story_points":       series.StoryPoints,
+		"original_estimate":  series.OriginalEstimate,
+		"rrule":              series.Rrule,
+		"start_at":           series.StartAt,
+		"next_occurrence_at": series.NextOccurrenceAt,
+		"created_by":         series.CreatedBy,
+		"modified_by":        series.ModifiedBy,
+	}
+
+	var created _model.TaskSeries
+	if err := scanTaskSeries(r.db.Conn(ctx).QueryRow(ctx, query, args), &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateTaskSeries saves the template, the rule and the schedule of the series
+func (r *TaskSeriesRepository) UpdateTaskSeries(ctx context.Context, series *_model.TaskSeries) (*_model.TaskSeries, error) {
+	query := `
+		UPDATE app.task_series s
+		SET title = @title, description = @description, assigned_to = @assigned_to, priority = @priority,
+		    story_points = @story_points, original_estimate = @original_estimate, rrule = @rrule, start_at = @start_at,
+		    last_occurrence_at = @last_occurrence_at, next_occurrence_at = @next_occurrence_at, ended_at = @ended_at,
+		    modified_at = current_timestamp, modified_by = @modified_by
+		WHERE s.id = @id
+		RETURNING ` + taskSeriesColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"id":                 series.ID,
+		"title":              series.Title,
+		"description":        series.Description,
+		"assigned_to":        series.AssignedTo,
+		"priority":           series.Priority,
+		"story_points":       series.StoryPoints,
+		"original_estimate":  series.OriginalEstimate,
+		"rrule":              series.Rrule,
+		"start_at":           series.StartAt,
+		"last_occurrence_at": series.LastOccurrenceAt,
+		"next_occurrence_at": series.NextOccurrenceAt,
+		"ended_at":           series.EndedAt,
+		"modified_by":        series.ModifiedBy,
+	}
+
+	var updated _model.TaskSeries
+	if err := scanTaskSeries(r.db.Conn(ctx).QueryRow(ctx, query, args), &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (r *TaskSeriesRepository) GetTaskSeriesByID(ctx context.Context, id string) (*_model.TaskSeries, error) {
+	query := `
+		SELECT ` + taskSeriesColumns + `
+		FROM app.task_series s
+		WHERE s.id = @id
+	`
+
+	var series _model.TaskSeries
+	if err := scanTaskSeries(r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"id": id}), &series); err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+func (r *TaskSeriesRepository) GetTaskSeriesByIDs(ctx context.Context, ids []string) ([]*_model.TaskSeries, error) {
+	query := `
+		SELECT ` + taskSeriesColumns + `
+		FROM app.task_series s
+		WHERE s.id = ANY(@ids)
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"ids": ids})
+	if err != nil {
+		return nil, err
+	}
+	return scanTaskSeriesRows(rows)
+}
+
+// GetTaskSeriesByTeamID lists the series of the team, the running ones first
+func (r *TaskSeriesRepository) GetTaskSeriesByTeamID(ctx context.Context, teamID string) ([]*_model.TaskSeries, error) {
+	query := `
+		SELECT ` + taskSeriesColumns + `
+		FROM app.task_series s
+		WHERE s.team_id = @team_id
+		ORDER BY s.next_occurrence_at NULLS LAST, s.created_at
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"team_id": teamID})
+	if err != nil {
+		return nil, err
+	}
+	return scanTaskSeriesRows(rows)
+}
+
+// LockTaskSeries reads the series and locks it until the surrounding transaction ends
+func (r *TaskSeriesRepository) LockTaskSeries(ctx context.Context, id string) (*_model.TaskSeries, error) {
+	query := `
+		SELECT ` + taskSeriesColumns + `
+		FROM app.task_series s
+		WHERE s.id = @id
+		FOR UPDATE
+	`
+
+	var series _model.TaskSeries
+	if err := scanTaskSeries(r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"id": id}), &series); err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+// LockDueTaskSeries locks the running series of live teams whose next occurrence is due, or which have
+// no open task left since the last one was completed or deleted.
+// Rows locked by another replica are skipped so concurrent generators do not block each other, so are the series of skipIDs
+func (r *TaskSeriesRepository) LockDueTaskSeries(ctx context.Context, now time.Time, limit int, skipIDs []string) ([]*_model.TaskSeries, error) {
+	query := `
+		SELECT ` + taskSeriesColumns + `
+		FROM app.task_series s
+		WHERE s.ended_at IS NULL
+		AND s.next_occurrence_at IS NOT NULL
+		AND s.team_id IN (SELECT id FROM app.teams WHERE deleted_at IS NULL AND archived_at IS NULL)
+		AND s.id <> ALL(@skip_ids)
+		AND (
+			s.next_occurrence_at <= @now
+			OR NOT EXISTS (SELECT 1 FROM app.tasks t WHERE t.series_id = s.id AND t.status <> @done_status AND t.deleted_at IS NULL)
+		)
+		ORDER BY s.next_occurrence_at
+		LIMIT @limit
+		FOR UPDATE OF s SKIP LOCKED
+	`
+
+	// A nil slice is sent as NULL, which no id would pass
+	if skipIDs == nil {
+		skipIDs = []string{}
+	}
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"now":         now,
+		"limit":       limit,
+		"skip_ids":    skipIDs,
+		"done_status": _const.TASK_STATUS_DONE,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return scanTaskSeriesRows(rows)
+}
+
+// CreateOccurrence inserts the task of an occurrence, nil is returned when the occurrence already has its task.
+// The task gets the next number of its team like in TaskRepository.CreateTask. The series must be locked by the caller,
+// the occurrence is looked up first so the counter of the team only moves for an inserted task
+func (r *TaskSeriesRepository) CreateOccurrence(ctx context.Context, task *_model.Task) (*_model.Task, error) {
+	existsQuery := `SELECT EXISTS (SELECT 1 FROM app.tasks WHERE series_id = @series_id AND occurrence_at = @occurrence_at)`
+	existsArgs := pgx.NamedArgs{
+		"series_id":     task.SeriesID,
+		"occurrence_at": task.OccurrenceAt,
+	}
+
+	var exists bool
+	if err := r.db.Conn(ctx).QueryRow(ctx, existsQuery, existsArgs).Scan(&exists); err != nil || exists {
+		return nil, err
+	}
+
+	query := nextTaskNumber + `
+		INSERT INTO app.tasks AS t (title, description, status, assigned_to, team_id, priority, rank, due_date, story_points, original_estimate,
+		                            series_id, occurrence_at, number, created_at, modified_at, created_by, modified_by)
+		VALUES (@title, @description, @status, @assigned_to, @team_id, @priority, @rank, @due_date, @story_points, @original_estimate,
+		        @series_id, @occurrence_at, (SELECT task_counter FROM next_number), current_timestamp, current_timestamp, @created_by, @modified_by)
+		ON CONFLICT (series_id, occurrence_at) DO NOTHING
+		RETURNING ` + taskColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"title":             task.Title,
+		"description":       task.Description,
+		"status":            task.Status,
+		"assigned_to":       task.AssignedTo,
+		"team_id":           task.TeamID,
+		"priority":          task.Priority,
+		"rank":              task.Rank,
+		"due_date":          task.DueDate,
+		"story_points":      task.StoryPoints,
+		"original_estimate": task.OriginalEstimate,
+		"series_id":         task.SeriesID,
+		"occurrence_at":     task.OccurrenceAt,
+		"created_by":        task.CreatedBy,
+		"modified_by":       task.ModifiedBy,
+	}
+
+	var created _model.Task
+	if err := scanTask(r.db.Conn(ctx).QueryRow(ctx, query, args), &created); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateOpenOccurrences copies the template of the series to its tasks not done yet, exceptions keep their own values
+func (r *TaskSeriesRepository) UpdateOpenOccurrences(ctx context.Context, series *_model.TaskSeries) ([]*_model.Task, error) {
+	query := `
+		UPDATE app.tasks t
+		SET title = @title, description = @description, assigned_to = @assigned_to, priority = @priority,
+		    story_points = @story_points, original_estimate = @original_estimate,
+		    modified_at = current_timestamp, modified_by = @modified_by, version = t.version + 1
+		WHERE t.series_id = @series_id
+		AND t.status <> @done_status
+		AND NOT t.series_exception
+		AND t.deleted_at IS NULL
+		RETURNING ` + taskColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"series_id":         series.ID,
+		"title":             series.Title,
+		"description":       series.Description,
+		"assigned_to":       series.AssignedTo,
+		"priority":          series.Priority,
+		"story_points":      series.StoryPoints,
+		"original_estimate": series.OriginalEstimate,
+		"modified_by":       series.ModifiedBy,
+		"done_status":       _const.TASK_STATUS_DONE,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
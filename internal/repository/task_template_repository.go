@@ -0,0 +1,291 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"github.com/jackc/pgx/v5"
+)
+
+type TaskTemplateRepositoryInterface interface {
+	CreateTaskTemplate(ctx context.Context, template *_model.TaskTemplate) (*_model.TaskTemplate, error)
+	UpdateTaskTemplate(ctx context.Context, template *_model.TaskTemplate) (*_model.TaskTemplate, error)
+	DeleteTaskTemplate(ctx context.Context, id string) error
+	GetTaskTemplateByID(ctx context.Context, id string) (*_model.TaskTemplate, error)
+	GetTaskTemplatesByTeamID(ctx context.Context, teamID string) ([]*_model.TaskTemplate, error)
+	SetTaskTemplateLabels(ctx context.Context, templateID string, labelIDs []string) error
+	ClearDefaultTaskTemplate(ctx context.Context, teamID string) error
+	GetTemplateLabelsByTemplateIDs(ctx context.Context, templateIDs []string) ([]*_model.TaskTemplateLabel, error)
+}
+
+type TaskTemplateRepository struct {
+	db *_db.Database
+}
+
+func NewTaskTemplateRepository(db *_db.Database) TaskTemplateRepositoryInterface {
+	return &TaskTemplateRepository{
+		db: db,
+	}
+}
+
+const taskTemplateColumns = `
+	tt.id,
+	tt.team_id,
+	tt.name,
+	tt.title_pattern,
+	tt.description,
+	tt.checklist,
+	ARRAY(SELECT ttl.label_id::TEXT FROM app.task_template_labels ttl WHERE ttl.template_id = tt.id ORDER BY ttl.label_id),
+	tt.assigned_to,
+	tt.due_offset,
+	tt.priority,
+	tt.story_points,
+	tt.original_estimate,
+	tt.is_default,
+	tt.created_at,
+	tt.modified_at,
+	tt.created_by,
+	tt.modified_by
+`
+
+func scanTaskTemplate(row pgx.Row, template *_model.TaskTemplate) error {
+	return row.Scan(
+		&template.ID,
+		&template.TeamID,
+		&template.Name,
+		&template.TitlePattern,
+		&template.Description,
+		&template.Checklist,
+		&template.LabelIDs,
+		&template.AssignedTo,
+		&template.DueOffset,
+		&template.Priority,
+		&template.StoryPoints,
+		&template.OriginalEstimate,
+		&template.IsDefault,
+		&template.CreatedAt,
+		&template.ModifiedAt,
+		&template.CreatedBy,
+		&template.ModifiedBy,
+	)
+}
+
+func scanTaskTemplates(rows pgx.Rows) ([]*_model.TaskTemplate, error) {
+	defer rows.Close()
+
+	var templates []*_model.TaskTemplate
+	for rows.Next() {
+		var template _model.TaskTemplate
+		if err := scanTaskTemplate(rows, &template); err != nil {
+			return nil, err
+		}
+		templates = append(templates, &template)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// CreateTaskTemplate saves the template without its labels, they are set with SetTaskTemplateLabels
+func (r *TaskTemplateRepository) CreateTaskTemplate(ctx context.Context, template *_model.TaskTemplate) (*_model.TaskTemplate, error) {
+	query := `
+		INSERT INTO app.task_templates AS tt (team_id, name, title_pattern, description, checklist, assigned_to, due_offset, priority,
+		                                      story_points, original_estimate, is_default, created_at, modified_at, created_by, modified_by)
+		VALUES (@team_id, @name, @title_pattern, @description, @checklist, @assigned_to, @due_offset, @priority,
+		        @story_points, @original_estimate, @is_default, current_timestamp, current_timestamp, @created_by, @modified_by)
+		RETURNING ` + taskTemplateColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id":           template.TeamID,
+		"name":              template.Name,
+		"title_pattern":     template.TitlePattern,
+		"description":       template.Description,
+		"checklist":         checklistOrEmpty(template.Checklist),
+		"assigned_to":       template.AssignedTo,
+		"due_offset":        template.DueOffset,
+		"priority":          template.Priority,
+		"story_points":      template.StoryPoints,
+		"original_estimate": template.OriginalEstimate,
+		"is_default":        template.IsDefault,
+		"created_by":        template.CreatedBy,
+		"modified_by":       template.ModifiedBy,
+	}
+
+	var created _model.TaskTemplate
+	if err := scanTaskTemplate(r.db.Conn(ctx).QueryRow(ctx, query, args), &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (r *TaskTemplateRepository) UpdateTaskTemplate(ctx context.Context, template *_model.TaskTemplate) (*_model.TaskTemplate, error) {
+	query := `
+		UPDATE app.task_templates tt
+		SET name = @name, title_pattern = @title_pattern, description = @description, checklist = @checklist,
+		    assigned_to = @assigned_to, due_offset = @due_offset, priority = @priority, story_points = @story_points,
+		    original_estimate = @original_estimate, is_default = @is_default,
+		    modified_at = current_timestamp, modified_by = @modified_by
+		WHERE tt.id = @id
+		RETURNING ` + taskTemplateColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"id":                template.ID,
+		"name":              template.Name,
+		"title_pattern":     template.TitlePattern,
+		"description":       template.Description,
+		"checklist":         checklistOrEmpty(template.Checklist),
+		"assigned_to":       template.AssignedTo,
+		"due_offset":        template.DueOffset,
+		"priority":          template.Priority,
+		"story_points":      template.StoryPoints,
+		"original_estimate": template.OriginalEstimate,
+		"is_default":        template.IsDefault,
+		"modified_by":       template.ModifiedBy,
+	}
+
+	var updated _model.TaskTemplate
+	if err := scanTaskTemplate(r.db.Conn(ctx).QueryRow(ctx, query, args), &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (r *TaskTemplateRepository) DeleteTaskTemplate(ctx context.Context, id string) error {
+	query := `
+		DELETE FROM app.task_templates WHERE id = $1;
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	return err
+}
+
+func (r *TaskTemplateRepository) GetTaskTemplateByID(ctx context.Context, id string) (*_model.TaskTemplate, error) {
+	query := `
+		SELECT ` + taskTemplateColumns + `
+		FROM app.task_templates tt
+		WHERE tt.id = @id
+	`
+
+	var template _model.TaskTemplate
+	if err := scanTaskTemplate(r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"id": id}), &template); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// GetTaskTemplatesByTeamID lists the templates of the team, the default one first then by name
+func (r *TaskTemplateRepository) GetTaskTemplatesByTeamID(ctx context.Context, teamID string) ([]*_model.TaskTemplate, error) {
+	query := `
+		SELECT ` + taskTemplateColumns + `
+		FROM app.task_templates tt
+		WHERE tt.team_id = @team_id
+		ORDER BY tt.is_default DESC, lower(tt.name)
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"team_id": teamID})
+	if err != nil {
+		return nil, err
+	}
+	return scanTaskTemplates(rows)
+}
+
+// SetTaskTemplateLabels makes labelIDs the exact set of labels of the template
+func (r *TaskTemplateRepository) SetTaskTemplateLabels(ctx context.Context, templateID string, labelIDs []string) error {
+	deleteQuery := `
+		DELETE FROM app.task_template_labels
+		WHERE template_id = @template_id
+		AND NOT (label_id = ANY(@label_ids))
+	`
+	insertQuery := `
+		INSERT INTO app.task_template_labels (template_id, label_id)
+		SELECT @template_id, label_id
+		FROM unnest(@label_ids::UUID[]) AS label_id
+		ON CONFLICT (template_id, label_id) DO NOTHING
+	`
+
+	// An empty list clears the labels, a nil one would match nothing in the delete
+	if labelIDs == nil {
+		labelIDs = []string{}
+	}
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"template_id": templateID,
+		"label_ids":   labelIDs,
+	}
+
+	if _, err := r.db.Conn(ctx).Exec(ctx, deleteQuery, args); err != nil {
+		return err
+	}
+	_, err := r.db.Conn(ctx).Exec(ctx, insertQuery, args)
+	return err
+}
+
+// ClearDefaultTaskTemplate unmarks the default template of the team so another one can take its place
+func (r *TaskTemplateRepository) ClearDefaultTaskTemplate(ctx context.Context, teamID string) error {
+	query := `
+		UPDATE app.task_templates SET is_default = false WHERE team_id = $1 AND is_default;
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, teamID)
+	return err
+}
+
+// GetTemplateLabelsByTemplateIDs returns the labels of every template in templateIDs ordered by label name
+func (r *TaskTemplateRepository) GetTemplateLabelsByTemplateIDs(ctx context.Context, templateIDs []string) ([]*_model.TaskTemplateLabel, error) {
+	query := `
+		SELECT ttl.template_id, ` + labelColumns + `
+		FROM app.task_template_labels ttl
+		JOIN app.labels l ON l.id = ttl.label_id
+		WHERE ttl.template_id = ANY(@template_ids)
+		ORDER BY lower(l.name)
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"template_ids": templateIDs})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templateLabels []*_model.TaskTemplateLabel
+	for rows.Next() {
+		var templateLabel _model.TaskTemplateLabel
+		var label _model.Label
+		if err = rows.Scan(
+			&templateLabel.TemplateID,
+			&label.ID,
+			&label.TeamID,
+			&label.Name,
+			&label.Color,
+			&label.CreatedAt,
+			&label.ModifiedAt,
+			&label.CreatedBy,
+			&label.ModifiedBy,
+		); err != nil {
+			return nil, err
+		}
+		templateLabel.Label = &label
+		templateLabels = append(templateLabels, &templateLabel)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return templateLabels, nil
+}
+
+// checklistOrEmpty keeps the NOT NULL checklist column an empty array when the template has no checklist
+func checklistOrEmpty(checklist []string) []string {
+	if checklist == nil {
+		return []string{}
+	}
+	return checklist
+}
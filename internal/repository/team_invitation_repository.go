@@ -0,0 +1,203 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"github.com/jackc/pgx/v5"
+)
+
+type TeamInvitationRepositoryInterface interface {
+	CreateTeamInvitation(ctx context.Context, invitation *_model.TeamInvitation) (*_model.TeamInvitation, error)
+	RevokePendingTeamInvitations(ctx context.Context, teamID string, email string) error
+	GetTeamInvitationByTokenHash(ctx context.Context, tokenHash string) (*_model.TeamInvitation, error)
+	GetPendingTeamInvitationsByTeamID(ctx context.Context, teamID string) ([]*_model.TeamInvitation, error)
+	GetPendingTeamInvitationsByEmail(ctx context.Context, email string) ([]*_model.TeamInvitation, error)
+	AcceptTeamInvitation(ctx context.Context, id string, userID string) (string, error)
+	DeclineTeamInvitation(ctx context.Context, id string, userID string) error
+}
+
+type TeamInvitationRepository struct {
+	db *_db.Database
+}
+
+func NewTeamInvitationRepository(db *_db.Database) TeamInvitationRepositoryInterface {
+	return &TeamInvitationRepository{
+		db: db,
+	}
+}
+
+const teamInvitationColumns = `
+	ti.id,
+	ti.team_id,
+	ti.email,
+	ti.role,
+	ti.status,
+	ti.expires_at,
+	ti.responded_at,
+	ti.responded_by,
+	ti.created_at,
+	ti.modified_at,
+	ti.created_by,
+	ti.modified_by
+`
+
+func scanTeamInvitation(row pgx.Row, invitation *_model.TeamInvitation) error {
+	return row.Scan(
+		&invitation.ID,
+		&invitation.TeamID,
+		&invitation.Email,
+		&invitation.Role,
+		&invitation.Status,
+		&invitation.ExpiresAt,
+		&invitation.RespondedAt,
+		&invitation.RespondedBy,
+		&invitation.CreatedAt,
+		&invitation.ModifiedAt,
+		&invitation.CreatedBy,
+		&invitation.ModifiedBy,
+	)
+}
+
+func (r *TeamInvitationRepository) CreateTeamInvitation(ctx context.Context, invitation *_model.TeamInvitation) (*_model.TeamInvitation, error) {
+	query := `
+		INSERT INTO app.team_invitations (team_id, email, role, status, token_hash, expires_at, created_at, modified_at, created_by, modified_by)
+		VALUES (@team_id, @email, @role, @status, @token_hash, @expires_at, current_timestamp, current_timestamp, @created_by, @modified_by)
+		RETURNING id, status, created_at, modified_at
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id":     invitation.TeamID,
+		"email":       invitation.Email,
+		"role":        invitation.Role,
+		"status":      _model.InvitationStatusPending,
+		"token_hash":  invitation.TokenHash,
+		"expires_at":  invitation.ExpiresAt,
+		"created_by":  invitation.CreatedBy,
+		"modified_by": invitation.ModifiedBy,
+	}
+
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&invitation.ID, &invitation.Status, &invitation.CreatedAt, &invitation.ModifiedAt)
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// RevokePendingTeamInvitations revokes the previous invitation when an email is invited again
+func (r *TeamInvitationRepository) RevokePendingTeamInvitations(ctx context.Context, teamID string, email string) error {
+	query := `
+		UPDATE app.team_invitations
+		SET status = 'REVOKED', modified_at = current_timestamp
+		WHERE team_id = @team_id AND LOWER(email) = LOWER(@email) AND status = 'PENDING'
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, pgx.NamedArgs{"team_id": teamID, "email": email})
+	return err
+}
+
+func (r *TeamInvitationRepository) GetTeamInvitationByTokenHash(ctx context.Context, tokenHash string) (*_model.TeamInvitation, error) {
+	query := `SELECT ` + teamInvitationColumns + ` FROM app.team_invitations ti WHERE ti.token_hash = @token_hash`
+
+	var invitation _model.TeamInvitation
+	if err := scanTeamInvitation(r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"token_hash": tokenHash}), &invitation); err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *TeamInvitationRepository) GetPendingTeamInvitationsByTeamID(ctx context.Context, teamID string) ([]*_model.TeamInvitation, error) {
+	query := `
+		SELECT ` + teamInvitationColumns + `
+		FROM app.team_invitations ti
+		WHERE ti.team_id = @team_id AND ti.status = 'PENDING' AND ti.expires_at > current_timestamp
+		ORDER BY ti.created_at DESC
+	`
+	return r.queryTeamInvitations(ctx, query, pgx.NamedArgs{"team_id": teamID})
+}
+
+func (r *TeamInvitationRepository) GetPendingTeamInvitationsByEmail(ctx context.Context, email string) ([]*_model.TeamInvitation, error) {
+	query := `
+		SELECT ` + teamInvitationColumns + `
+		FROM app.team_invitations ti
+		WHERE LOWER(ti.email) = LOWER(@email) AND ti.status = 'PENDING' AND ti.expires_at > current_timestamp
+		ORDER BY ti.created_at DESC
+	`
+	return r.queryTeamInvitations(ctx, query, pgx.NamedArgs{"email": email})
+}
+
+func (r *TeamInvitationRepository) queryTeamInvitations(ctx context.Context, query string, args pgx.NamedArgs) ([]*_model.TeamInvitation, error) {
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []*_model.TeamInvitation{}
+	for rows.Next() {
+		var invitation _model.TeamInvitation
+		if err := scanTeamInvitation(rows, &invitation); err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, &invitation)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return invitations, nil
+}
+
+// AcceptTeamInvitation marks the invitation accepted and creates the membership in a single statement,
+// pgx.ErrNoRows is returned when the invitation is no longer pending or has expired
+func (r *TeamInvitationRepository) AcceptTeamInvitation(ctx context.Context, id string, userID string) (string, error) {
+	query := `
+		WITH accepted AS (
+			UPDATE app.team_invitations
+			SET status = 'ACCEPTED',
+			    responded_at = current_timestamp,
+			    responded_by = @user_id,
+			    modified_at = current_timestamp
+			WHERE id = @id AND status = 'PENDING' AND expires_at > current_timestamp
+			RETURNING team_id, role
+		), joined AS (
+			INSERT INTO app.user_teams (user_id, team_id, role)
+			SELECT @user_id, team_id, role FROM accepted
+			ON CONFLICT (user_id, team_id) DO NOTHING
+		), joined_workspace AS (
+			INSERT INTO app.workspace_members (workspace_id, user_id)
+			SELECT t.workspace_id, @user_id FROM accepted a JOIN app.teams t ON t.id = a.team_id
+			ON CONFLICT (workspace_id, user_id) DO NOTHING
+		)
+		SELECT team_id FROM accepted
+	`
+
+	var teamID string
+	err := r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"id": id, "user_id": userID}).Scan(&teamID)
+	if err != nil {
+		return "", err
+	}
+	return teamID, nil
+}
+
+func (r *TeamInvitationRepository) DeclineTeamInvitation(ctx context.Context, id string, userID string) error {
+	query := `
+		UPDATE app.team_invitations
+		SET status = 'DECLINED',
+		    responded_at = current_timestamp,
+		    responded_by = @user_id,
+		    modified_at = current_timestamp
+		WHERE id = @id AND status = 'PENDING'
+	`
+
+	tag, err := r.db.Conn(ctx).Exec(ctx, query, pgx.NamedArgs{"id": id, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
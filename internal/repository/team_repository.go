@@ -5,15 +5,25 @@ import (
 	_model "bitbucket.org/edts/go-task-management/internal/model"
 	_projection "bitbucket.org/edts/go-task-management/internal/model/projection"
 	"context"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"time"
 )
 
 type TeamRepositoryInterface interface {
 	CreateTeam(ctx context.Context, team *_model.Team) (*_model.Team, error)
-	UpdateTeam(ctx context.Context, team *_model.Team) (*_model.Team, error)
+	UpdateTeam(ctx context.Context, team *_model.Team, expectedVersion *int32) (*_model.Team, error)
 	GetTeamByID(ctx context.Context, teamID string) (*_model.Team, error)
 	GetTeamsByIDs(ctx context.Context, teamIDs []string) ([]*_model.Team, error)
-	GetTeamsByUserID(ctx context.Context, userID string) ([]*_projection.TeamSummary, error)
+	TeamKeyExists(ctx context.Context, key string) (bool, error)
+	GetTeamsByUserID(ctx context.Context, userID string, includeArchived bool) ([]*_projection.TeamSummary, error)
+	SetTeamArchived(ctx context.Context, teamID string, archived bool, modifiedBy *uuid.UUID) (*_model.Team, error)
+	SoftDeleteTeam(ctx context.Context, teamID string, modifiedBy *uuid.UUID) (*_model.Team, error)
+	RestoreTeam(ctx context.Context, teamID string, deletedAfter time.Time, modifiedBy *uuid.UUID) (*_model.Team, error)
+	PurgeDeletedTeams(ctx context.Context, deletedBefore time.Time) ([]string, error)
+	GetTeamWipLimits(ctx context.Context, teamIDs []string) ([]*_model.WipLimit, error)
+	SetTeamWipLimits(ctx context.Context, teamID string, limits []*_model.WipLimit, createdBy *uuid.UUID) error
+	LockTeam(ctx context.Context, teamID string) error
 }
 
 type TeamRepository struct {
@@ -28,79 +38,125 @@ func NewTeamRepository(db *_db.Database) TeamRepositoryInterface {
 
 func (r *TeamRepository) CreateTeam(ctx context.Context, team *_model.Team) (*_model.Team, error) {
 	query := `
-		INSERT INTO app.teams ("name", description, created_at, modified_at, created_by, modified_by)
-		VALUES (@name, @description, current_timestamp, current_timestamp, @created_by, @modified_by)
-		RETURNING id, created_at
+		INSERT INTO app.teams ("name", key, description, workspace_id, task_reassign_policy, wip_limit_policy, created_at, modified_at, created_by, modified_by)
+		VALUES (@name, @key, @description, @workspace_id, @task_reassign_policy, @wip_limit_policy, current_timestamp, current_timestamp, @created_by, @modified_by)
+		RETURNING id, version, created_at
 	`
 
 	// Query arguments
 	args := pgx.NamedArgs{
-		"name":        team.Name,
-		"description": team.Description,
+		"name":                 team.Name,
+		"key":                  team.Key,
+		"description":          team.Description,
+		"workspace_id":         team.WorkspaceID,
+		"task_reassign_policy": team.TaskReassignPolicy,
+		"wip_limit_policy":     team.WipLimitPolicy,
+		"created_by":           team.CreatedBy,
+		"modified_by":          team.ModifiedBy,
 	}
 
-	err := r.db.Pool.QueryRow(ctx, query, args).Scan(&team.ID, &team.CreatedAt)
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&team.ID, &team.Version, &team.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return team, nil
 }
 
-func (r *TeamRepository) UpdateTeam(ctx context.Context, team *_model.Team) (*_model.Team, error) {
+// UpdateTeam only updates the team at expectedVersion when it is not nil, pgx.ErrNoRows is returned otherwise
+func (r *TeamRepository) UpdateTeam(ctx context.Context, team *_model.Team, expectedVersion *int32) (*_model.Team, error) {
 	query := `
 		UPDATE app.teams
 		SET name = @name, 
 		    description = @description,
+		    task_reassign_policy = COALESCE(@task_reassign_policy, task_reassign_policy),
+		    wip_limit_policy = COALESCE(@wip_limit_policy, wip_limit_policy),
 		    modified_at = current_timestamp,
-		    modified_by = @modified_by
+		    modified_by = @modified_by,
+		    version = version + 1
 		WHERE id = @id
-		RETURNING id, created_at, modified_at
+		AND deleted_at IS NULL
+		AND (@expected_version::INT IS NULL OR version = @expected_version)
+		RETURNING id, key, workspace_id, task_reassign_policy, wip_limit_policy, version, created_at, modified_at, created_by
 	`
 
+	// An empty policy keeps the current one
+	var policy *_model.TaskReassignPolicy
+	if team.TaskReassignPolicy != "" {
+		policy = &team.TaskReassignPolicy
+	}
+	var wipLimitPolicy *_model.WipLimitPolicy
+	if team.WipLimitPolicy != "" {
+		wipLimitPolicy = &team.WipLimitPolicy
+	}
+
 	// Query arguments
 	args := pgx.NamedArgs{
-		"id":          team.ID,
-		"name":        team.Name,
-		"description": team.Description,
-		"modified_by": team.ModifiedBy,
+		"id":                   team.ID,
+		"name":                 team.Name,
+		"description":          team.Description,
+		"task_reassign_policy": policy,
+		"wip_limit_policy":     wipLimitPolicy,
+		"modified_by":          team.ModifiedBy,
+		"expected_version":     expectedVersion,
 	}
 
-	err := r.db.Pool.QueryRow(ctx, query, args).Scan(&team.ID, &team.CreatedAt, &team.ModifiedAt)
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&team.ID, &team.Key, &team.WorkspaceID, &team.TaskReassignPolicy, &team.WipLimitPolicy, &team.Version, &team.CreatedAt, &team.ModifiedAt, &team.CreatedBy)
 	if err != nil {
 		return nil, err
 	}
 	return team, nil
 }
 
-func (r *TeamRepository) GetTeamByID(ctx context.Context, teamID string) (*_model.Team, error) {
-	query := `
-		SELECT
+// teamColumns is the column list read by scanTeam
+const teamColumns = `
 		    t.id,
 		    t.name,
+		    t.key,
+		    t.description,
+		    t.workspace_id,
+		    t.task_reassign_policy,
+		    t.wip_limit_policy,
+		    t.archived_at,
+		    t.deleted_at,
+		    t.version,
 		    t.created_at,
 		    t.modified_at,
 		    t.created_by,
-		    t.modified_by
+		    t.modified_by`
+
+func scanTeam(row pgx.Row, team *_model.Team) error {
+	return row.Scan(
+		&team.ID,
+		&team.Name,
+		&team.Key,
+		&team.Description,
+		&team.WorkspaceID,
+		&team.TaskReassignPolicy,
+		&team.WipLimitPolicy,
+		&team.ArchivedAt,
+		&team.DeletedAt,
+		&team.Version,
+		&team.CreatedAt,
+		&team.ModifiedAt,
+		&team.CreatedBy,
+		&team.ModifiedBy,
+	)
+}
+
+// GetTeamByID ignores deleted teams
+func (r *TeamRepository) GetTeamByID(ctx context.Context, teamID string) (*_model.Team, error) {
+	query := `
+		SELECT` + teamColumns + `
 		FROM app.teams t 
 		WHERE t.id = @team_id
+		AND t.deleted_at IS NULL
 `
 	args := pgx.NamedArgs{
 		"team_id": teamID,
 	}
 
 	var team _model.Team
-	scan := func(row pgx.Row) error {
-		return row.Scan(
-			&team.ID,
-			&team.Name,
-			&team.CreatedAt,
-			&team.ModifiedAt,
-			&team.CreatedBy,
-			&team.ModifiedBy,
-		)
-	}
-
-	err := scan(r.db.Pool.QueryRow(ctx, query, args))
+	err := scanTeam(r.db.Conn(ctx).QueryRow(ctx, query, args), &team)
 	if err != nil {
 		return nil, err
 	}
@@ -110,18 +166,13 @@ func (r *TeamRepository) GetTeamByID(ctx context.Context, teamID string) (*_mode
 
 func (r *TeamRepository) GetTeamsByIDs(ctx context.Context, teamIDs []string) ([]*_model.Team, error) {
 	query := `
-		SELECT
-		    t.id,
-		    t.name,
-		    t.created_at,
-		    t.modified_at,
-		    t.created_by,
-		    t.modified_by
+		SELECT` + teamColumns + `
 		FROM app.teams t 
 		WHERE t.id = ANY($1)
+		AND t.deleted_at IS NULL
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query, teamIDs)
+	rows, err := r.db.Conn(ctx).Query(ctx, query, teamIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -130,14 +181,7 @@ func (r *TeamRepository) GetTeamsByIDs(ctx context.Context, teamIDs []string) ([
 	var teams []*_model.Team
 	for rows.Next() {
 		var team _model.Team
-		if err := rows.Scan(
-			&team.ID,
-			&team.Name,
-			&team.CreatedAt,
-			&team.ModifiedAt,
-			&team.CreatedBy,
-			&team.ModifiedBy,
-		); err != nil {
+		if err := scanTeam(rows, &team); err != nil {
 			return nil, err
 		}
 		teams = append(teams, &team)
@@ -150,7 +194,18 @@ func (r *TeamRepository) GetTeamsByIDs(ctx context.Context, teamIDs []string) ([
 	return teams, nil
 }
 
-func (r *TeamRepository) GetTeamsByUserID(ctx context.Context, userID string) ([]*_projection.TeamSummary, error) {
+// TeamKeyExists tells whether a team of the workspace uses the key, deleted teams keep theirs until purged
+func (r *TeamRepository) TeamKeyExists(ctx context.Context, key string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM app.teams WHERE key = @key)`
+
+	var exists bool
+	if err := r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"key": key}).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func (r *TeamRepository) GetTeamsByUserID(ctx context.Context, userID string, includeArchived bool) ([]*_projection.TeamSummary, error) {
 	query := `
 		WITH count_team_member AS (
 			SELECT ut.team_id, count(1) member_count
@@ -160,7 +215,12 @@ func (r *TeamRepository) GetTeamsByUserID(ctx context.Context, userID string) ([
 		SELECT
 			t.id,
 			t."name",
+			t.key,
 			t.description,
+			t.workspace_id,
+			t.task_reassign_policy,
+			t.wip_limit_policy,
+			t.archived_at,
 			(SELECT member_count FROM count_team_member WHERE team_id = t.id) AS member_count,
 			t.created_at,
 			t.modified_at,
@@ -169,15 +229,18 @@ func (r *TeamRepository) GetTeamsByUserID(ctx context.Context, userID string) ([
 		FROM app.teams t 
 		JOIN app.user_teams ut ON t.id = ut.team_id
 		WHERE ut.user_id = @userId
+		AND t.deleted_at IS NULL
+		AND (@includeArchived OR t.archived_at IS NULL)
 	`
 
 	// Query arguments
 	args := pgx.NamedArgs{
-		"userId": userID,
+		"userId":          userID,
+		"includeArchived": includeArchived,
 	}
 
 	var teams []*_projection.TeamSummary
-	rows, err := r.db.Pool.Query(ctx, query, args)
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
 	if err != nil {
 		return nil, err
 	}
@@ -189,7 +252,12 @@ func (r *TeamRepository) GetTeamsByUserID(ctx context.Context, userID string) ([
 		if err = rows.Scan(
 			&team.ID,
 			&team.Name,
+			&team.Key,
 			&team.Description,
+			&team.WorkspaceID,
+			&team.TaskReassignPolicy,
+			&team.WipLimitPolicy,
+			&team.ArchivedAt,
 			&row.MemberCount,
 			&team.CreatedAt,
 			&team.ModifiedAt,
@@ -203,3 +271,190 @@ func (r *TeamRepository) GetTeamsByUserID(ctx context.Context, userID string) ([
 	}
 	return teams, nil
 }
+
+// SetTeamArchived archives or unarchives a team, archiving twice keeps the first archive date
+func (r *TeamRepository) SetTeamArchived(ctx context.Context, teamID string, archived bool, modifiedBy *uuid.UUID) (*_model.Team, error) {
+	query := `
+		UPDATE app.teams t
+		SET archived_at = CASE WHEN @archived THEN COALESCE(t.archived_at, current_timestamp) END,
+		    modified_at = current_timestamp,
+		    modified_by = @modified_by,
+		    version = t.version + 1
+		WHERE t.id = @team_id
+		AND t.deleted_at IS NULL
+		RETURNING` + teamColumns
+
+	args := pgx.NamedArgs{
+		"team_id":     teamID,
+		"archived":    archived,
+		"modified_by": modifiedBy,
+	}
+
+	var team _model.Team
+	if err := scanTeam(r.db.Conn(ctx).QueryRow(ctx, query, args), &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+func (r *TeamRepository) SoftDeleteTeam(ctx context.Context, teamID string, modifiedBy *uuid.UUID) (*_model.Team, error) {
+	query := `
+		UPDATE app.teams t
+		SET deleted_at = current_timestamp,
+		    modified_at = current_timestamp,
+		    modified_by = @modified_by,
+		    version = t.version + 1
+		WHERE t.id = @team_id
+		AND t.deleted_at IS NULL
+		RETURNING` + teamColumns
+
+	args := pgx.NamedArgs{
+		"team_id":     teamID,
+		"modified_by": modifiedBy,
+	}
+
+	var team _model.Team
+	if err := scanTeam(r.db.Conn(ctx).QueryRow(ctx, query, args), &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// RestoreTeam undeletes a team deleted after deletedAfter, older deletions are waiting for the purge
+func (r *TeamRepository) RestoreTeam(ctx context.Context, teamID string, deletedAfter time.Time, modifiedBy *uuid.UUID) (*_model.Team, error) {
+	query := `
+		UPDATE app.teams t
+		SET deleted_at = NULL,
+		    modified_at = current_timestamp,
+		    modified_by = @modified_by,
+		    version = t.version + 1
+		WHERE t.id = @team_id
+		AND t.deleted_at > @deleted_after
+		RETURNING` + teamColumns
+
+	args := pgx.NamedArgs{
+		"team_id":       teamID,
+		"deleted_after": deletedAfter,
+		"modified_by":   modifiedBy,
+	}
+
+	var team _model.Team
+	if err := scanTeam(r.db.Conn(ctx).QueryRow(ctx, query, args), &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// PurgeDeletedTeams hard deletes the teams deleted before deletedBefore together with their tasks.
+// Rows locked by another replica are skipped so concurrent purges do not block each other
+func (r *TeamRepository) PurgeDeletedTeams(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	query := `
+		WITH expired AS (
+			SELECT id FROM app.teams
+			WHERE deleted_at < @deleted_before
+			FOR UPDATE SKIP LOCKED
+		), purged_tasks AS (
+			DELETE FROM app.tasks WHERE team_id IN (SELECT id FROM expired)
+		)
+		DELETE FROM app.teams WHERE id IN (SELECT id FROM expired)
+		RETURNING id
+	`
+
+	args := pgx.NamedArgs{
+		"deleted_before": deletedBefore,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teamIDs []string
+	for rows.Next() {
+		var teamID string
+		if err = rows.Scan(&teamID); err != nil {
+			return nil, err
+		}
+		teamIDs = append(teamIDs, teamID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return teamIDs, nil
+}
+
+// GetTeamWipLimits returns the WIP limits of every team in teamIDs
+func (r *TeamRepository) GetTeamWipLimits(ctx context.Context, teamIDs []string) ([]*_model.WipLimit, error) {
+	query := `
+		SELECT team_id, status, wip_limit
+		FROM app.team_wip_limits
+		WHERE team_id = ANY(@team_ids)
+		ORDER BY status
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"team_ids": teamIDs})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var limits []*_model.WipLimit
+	for rows.Next() {
+		var limit _model.WipLimit
+		if err = rows.Scan(&limit.TeamID, &limit.Status, &limit.Limit); err != nil {
+			return nil, err
+		}
+		limits = append(limits, &limit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return limits, nil
+}
+
+// LockTeam holds the row lock of the team until the surrounding transaction ends, creates take the same lock
+// when they allocate the task number
+func (r *TeamRepository) LockTeam(ctx context.Context, teamID string) error {
+	query := `SELECT id FROM app.teams WHERE id = @id FOR UPDATE`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, pgx.NamedArgs{"id": teamID})
+	return err
+}
+
+// SetTeamWipLimits makes limits the exact set of WIP limits of the team
+func (r *TeamRepository) SetTeamWipLimits(ctx context.Context, teamID string, limits []*_model.WipLimit, createdBy *uuid.UUID) error {
+	deleteQuery := `
+		DELETE FROM app.team_wip_limits WHERE team_id = @team_id
+	`
+	insertQuery := `
+		INSERT INTO app.team_wip_limits (team_id, status, wip_limit, created_at, created_by)
+		SELECT @team_id, l.status, l.wip_limit, current_timestamp, @created_by
+		FROM unnest(@statuses::TEXT[], @wip_limits::INTEGER[]) AS l(status, wip_limit)
+	`
+
+	statuses := make([]string, 0, len(limits))
+	wipLimits := make([]int32, 0, len(limits))
+	for _, limit := range limits {
+		statuses = append(statuses, limit.Status)
+		wipLimits = append(wipLimits, limit.Limit)
+	}
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"team_id":    teamID,
+		"statuses":   statuses,
+		"wip_limits": wipLimits,
+		"created_by": createdBy,
+	}
+
+	if _, err := r.db.Conn(ctx).Exec(ctx, deleteQuery, args); err != nil {
+		return err
+	}
+	_, err := r.db.Conn(ctx).Exec(ctx, insertQuery, args)
+	return err
+}
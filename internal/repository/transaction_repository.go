@@ -0,0 +1,26 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	"context"
+)
+
+// TransactionRepositoryInterface lets usecases group repository calls atomically,
+// repositories pick up the transaction from the context passed to fn
+type TransactionRepositoryInterface interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type TransactionRepository struct {
+	db *_db.Database
+}
+
+func NewTransactionRepository(db *_db.Database) TransactionRepositoryInterface {
+	return &TransactionRepository{
+		db: db,
+	}
+}
+
+func (r *TransactionRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.db.WithTransaction(ctx, fn)
+}
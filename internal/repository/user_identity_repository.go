@@ -0,0 +1,94 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+)
+
+type UserIdentityRepositoryInterface interface {
+	CreateUserIdentity(ctx context.Context, identity *_model.UserIdentity) (*_model.UserIdentity, error)
+	GetUserIdentity(ctx context.Context, issuer string, subject string) (*_model.UserIdentity, error)
+	TouchUserIdentity(ctx context.Context, id string, email *string) error
+}
+
+type UserIdentityRepository struct {
+	db *_db.Database
+}
+
+func NewUserIdentityRepository(db *_db.Database) UserIdentityRepositoryInterface {
+	return &UserIdentityRepository{
+		db: db,
+	}
+}
+
+func (r *UserIdentityRepository) CreateUserIdentity(ctx context.Context, identity *_model.UserIdentity) (*_model.UserIdentity, error) {
+	query := `
+		INSERT INTO app.user_identities (user_id, issuer, subject, email, last_login_at, created_at, modified_at)
+		VALUES (@user_id, @issuer, @subject, @email, current_timestamp, current_timestamp, current_timestamp)
+		RETURNING id, last_login_at, created_at, modified_at
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"user_id": identity.UserID,
+		"issuer":  identity.Issuer,
+		"subject": identity.Subject,
+		"email":   identity.Email,
+	}
+
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&identity.ID, &identity.LastLoginAt, &identity.CreatedAt, &identity.ModifiedAt)
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// GetUserIdentity returns nil without error when the identity is not linked yet
+func (r *UserIdentityRepository) GetUserIdentity(ctx context.Context, issuer string, subject string) (*_model.UserIdentity, error) {
+	query := `
+		SELECT id, user_id, issuer, subject, email, last_login_at, created_at, modified_at
+		FROM app.user_identities
+		WHERE issuer = @issuer AND subject = @subject
+	`
+	args := pgx.NamedArgs{
+		"issuer":  issuer,
+		"subject": subject,
+	}
+
+	var identity _model.UserIdentity
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Issuer,
+		&identity.Subject,
+		&identity.Email,
+		&identity.LastLoginAt,
+		&identity.CreatedAt,
+		&identity.ModifiedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+// TouchUserIdentity records a login and keeps the email in sync with the provider
+func (r *UserIdentityRepository) TouchUserIdentity(ctx context.Context, id string, email *string) error {
+	query := `
+		UPDATE app.user_identities
+		SET last_login_at = current_timestamp,
+		    email = COALESCE(@email, email),
+		    modified_at = current_timestamp
+		WHERE id = @id
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, pgx.NamedArgs{"id": id, "email": email})
+	return err
+}
@@ -5,6 +5,7 @@ import (
 	_model "bitbucket.org/edts/go-task-management/internal/model"
 	"context"
 	"github.com/jackc/pgx/v5"
+	"time"
 )
 
 type UserRepositoryInterface interface {
@@ -12,6 +13,8 @@ type UserRepositoryInterface interface {
 	GetUserByID(ctx context.Context, id string) (*_model.User, error)
 	GetUsersByIDs(ctx context.Context, ids []string) ([]*_model.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*_model.User, error)
+	RegisterFailedLogin(ctx context.Context, userID string, maxAttempts int, lockoutDuration time.Duration) error
+	ResetFailedLogins(ctx context.Context, userID string) error
 	//GetAllUser(ctx context.Context) ([]*_model.User, error)
 }
 
@@ -41,7 +44,7 @@ func (r *UserRepository) CreateUser(ctx context.Context, user *_model.User) (*_m
 		"modified_by": user.ModifiedBy,
 	}
 
-	err := r.db.Pool.QueryRow(ctx, query, args).Scan(&user.ID, &user.CreatedAt)
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&user.ID, &user.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -125,7 +128,7 @@ func (r *UserRepository) GetUsersByIDs(ctx context.Context, ids []string) ([]*_m
 
 func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*_model.User, error) {
 	query := `
-		SELECT id, "name", email, created_at, modified_at, created_by, modified_by, password
+		SELECT id, "name", email, created_at, modified_at, created_by, modified_by, password, failed_login_attempts, locked_until
 		FROM app.users
 		WHERE LOWER(email) = LOWER(@email)
 	`
@@ -146,6 +149,8 @@ func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*_mo
 			&user.CreatedBy,
 			&user.ModifiedBy,
 			&user.Password,
+			&user.FailedLoginAttempts,
+			&user.LockedUntil,
 		)
 	}
 
@@ -157,5 +162,43 @@ func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*_mo
 	return &user, nil
 }
 
+// RegisterFailedLogin counts a failed login, the account is locked once maxAttempts is reached
+// and the counter starts over for the next lockout
+func (r *UserRepository) RegisterFailedLogin(ctx context.Context, userID string, maxAttempts int, lockoutDuration time.Duration) error {
+	query := `
+		UPDATE app.users
+		SET failed_login_attempts = CASE
+		        WHEN failed_login_attempts + 1 >= @max_attempts THEN 0
+		        ELSE failed_login_attempts + 1
+		    END,
+		    locked_until = CASE
+		        WHEN failed_login_attempts + 1 >= @max_attempts THEN current_timestamp + make_interval(secs => @lockout_seconds)
+		        ELSE locked_until
+		    END
+		WHERE id = @id
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"id":              userID,
+		"max_attempts":    maxAttempts,
+		"lockout_seconds": lockoutDuration.Seconds(),
+	}
+
+	_, err := r.db.Pool.Exec(ctx, query, args)
+	return err
+}
+
+func (r *UserRepository) ResetFailedLogins(ctx context.Context, userID string) error {
+	query := `
+		UPDATE app.users
+		SET failed_login_attempts = 0, locked_until = NULL
+		WHERE id = @id AND (failed_login_attempts > 0 OR locked_until IS NOT NULL)
+	`
+
+	_, err := r.db.Pool.Exec(ctx, query, pgx.NamedArgs{"id": userID})
+	return err
+}
+
 //TODO: GetAllUser Repository Method
 //1. Fetch all user from app.users table
@@ -5,7 +5,6 @@ import (
 	_model "bitbucket.org/edts/go-task-management/internal/model"
 	"context"
 	"errors"
-	"fmt"
 	"github.com/jackc/pgx/v5"
 )
 
@@ -40,7 +39,7 @@ func (r *UserSessionRepository) CreateUserSession(ctx context.Context, userSessi
 	err := r.db.Pool.QueryRow(ctx, insertQuery, insertArgs).Scan(&userSession.ExpiredAccessDate, &userSession.ExpiredRefreshDate, &userSession.UserID)
 
 	if err != nil {
-		fmt.Printf("", err)
+		logs.Errorf("CreateUserSession:: Error inserting the session: %v", err)
 		return nil, err
 	}
 
@@ -4,6 +4,7 @@ import (
 	_db "bitbucket.org/edts/go-task-management/internal/db"
 	_model "bitbucket.org/edts/go-task-management/internal/model"
 	"context"
+	"errors"
 	"github.com/jackc/pgx/v5"
 )
 
@@ -12,6 +13,12 @@ type UserTeamRepositoryInterface interface {
 	DeleteUserTeamsByTeamId(ctx context.Context, teamId string) error
 	GetAssigneeByTeamId(ctx context.Context, teamID string) (*_model.Team, error)
 	ExistUserTeamsByTeamId(ctx context.Context, teamID string) (bool, error)
+	IsUserInTeam(ctx context.Context, userID string, teamID string) (bool, error)
+	InsertUserTeam(ctx context.Context, userID string, teamID string, role _model.TeamRole) error
+	GetUserTeamRole(ctx context.Context, userID string, teamID string) (*_model.TeamRole, error)
+	GetTeamMemberRoles(ctx context.Context, teamID string) (map[string]_model.TeamRole, error)
+	DeleteUserTeams(ctx context.Context, teamID string, userIDs []string) error
+	UpdateUserTeamRole(ctx context.Context, userID string, teamID string, role _model.TeamRole) error
 }
 
 type UserTeamRepository struct {
@@ -40,7 +47,7 @@ func (r *UserTeamRepository) InsertUserTeams(ctx context.Context, userIDs *[]str
 			"teamId": team.ID,
 		}
 
-		err := r.db.Pool.QueryRow(ctx, insertQuery, args).Scan(&userID, &team.ID)
+		err := r.db.Conn(ctx).QueryRow(ctx, insertQuery, args).Scan(&userID, &team.ID)
 		if err != nil {
 			// Skip the error if it's a conflict (i.e., the user-team pair already exists)
 			if err.Error() == "no rows in result set" {
@@ -73,7 +80,7 @@ func (r *UserTeamRepository) GetAssigneeByTeamId(ctx context.Context, teamID str
 	var users []*_model.User
 
 	// Execute query and scan multiple rows into users slice
-	rows, err := r.db.Pool.Query(ctx, query, args)
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
 	if err != nil {
 		return nil, err
 	}
@@ -108,7 +115,7 @@ func (r *UserTeamRepository) DeleteUserTeamsByTeamId(ctx context.Context, teamId
 		"teamId": teamId,
 	}
 
-	_, err := r.db.Pool.Exec(ctx, deleteQuery, deleteArgs)
+	_, err := r.db.Conn(ctx).Exec(ctx, deleteQuery, deleteArgs)
 	if err != nil {
 		return err
 	}
@@ -126,7 +133,7 @@ func (r *UserTeamRepository) ExistUserTeamsByTeamId(ctx context.Context, teamId
 		"teamId": teamId,
 	}
 
-	err := r.db.Pool.QueryRow(ctx, existQuery, existArgs).Scan(&count)
+	err := r.db.Conn(ctx).QueryRow(ctx, existQuery, existArgs).Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -138,3 +145,109 @@ func (r *UserTeamRepository) ExistUserTeamsByTeamId(ctx context.Context, teamId
 	return isExist, nil
 
 }
+
+func (r *UserTeamRepository) IsUserInTeam(ctx context.Context, userID string, teamID string) (bool, error) {
+	isMember := false
+
+	query := `SELECT EXISTS (SELECT 1 FROM app.user_teams WHERE user_id = @userId AND team_id = @teamId)`
+	args := pgx.NamedArgs{
+		"userId": userID,
+		"teamId": teamID,
+	}
+
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&isMember)
+	if err != nil {
+		return false, err
+	}
+
+	return isMember, nil
+}
+
+func (r *UserTeamRepository) InsertUserTeam(ctx context.Context, userID string, teamID string, role _model.TeamRole) error {
+	query := `
+		INSERT INTO app.user_teams (user_id, team_id, role)
+		VALUES (@userId, @teamId, @role)
+		ON CONFLICT (user_id, team_id) DO NOTHING`
+	args := pgx.NamedArgs{
+		"userId": userID,
+		"teamId": teamID,
+		"role":   role,
+	}
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, args)
+	return err
+}
+
+// GetUserTeamRole returns nil without error when the user is not a member of the team
+func (r *UserTeamRepository) GetUserTeamRole(ctx context.Context, userID string, teamID string) (*_model.TeamRole, error) {
+	query := `SELECT role FROM app.user_teams WHERE user_id = @userId AND team_id = @teamId`
+	args := pgx.NamedArgs{
+		"userId": userID,
+		"teamId": teamID,
+	}
+
+	var role _model.TeamRole
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// GetTeamMemberRoles maps every member of the team to their role,
+// inside a transaction the rows stay locked until it ends so concurrent membership changes are serialized
+func (r *UserTeamRepository) GetTeamMemberRoles(ctx context.Context, teamID string) (map[string]_model.TeamRole, error) {
+	query := `SELECT user_id, role FROM app.user_teams WHERE team_id = @teamId FOR UPDATE`
+	args := pgx.NamedArgs{
+		"teamId": teamID,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := make(map[string]_model.TeamRole)
+	for rows.Next() {
+		var userID string
+		var role _model.TeamRole
+		if err = rows.Scan(&userID, &role); err != nil {
+			return nil, err
+		}
+		roles[userID] = role
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (r *UserTeamRepository) DeleteUserTeams(ctx context.Context, teamID string, userIDs []string) error {
+	query := `DELETE FROM app.user_teams WHERE team_id = @teamId AND user_id = ANY(@userIds)`
+	args := pgx.NamedArgs{
+		"teamId":  teamID,
+		"userIds": userIDs,
+	}
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, args)
+	return err
+}
+
+func (r *UserTeamRepository) UpdateUserTeamRole(ctx context.Context, userID string, teamID string, role _model.TeamRole) error {
+	query := `UPDATE app.user_teams SET role = @role WHERE user_id = @userId AND team_id = @teamId`
+	args := pgx.NamedArgs{
+		"userId": userID,
+		"teamId": teamID,
+		"role":   role,
+	}
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, args)
+	return err
+}
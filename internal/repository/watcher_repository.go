@@ -0,0 +1,116 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"github.com/jackc/pgx/v5"
+)
+
+type WatcherRepositoryInterface interface {
+	AddWatchers(ctx context.Context, taskID string, userIDs []string) error
+	RemoveWatcher(ctx context.Context, taskID string, userID string) error
+	GetWatchersByTaskIDs(ctx context.Context, taskIDs []string) ([]*_model.TaskWatcher, error)
+	GetWatcherIDsOfTeamMembers(ctx context.Context, taskID string) ([]string, error)
+}
+
+type WatcherRepository struct {
+	db *_db.Database
+}
+
+func NewWatcherRepository(db *_db.Database) WatcherRepositoryInterface {
+	return &WatcherRepository{
+		db: db,
+	}
+}
+
+// AddWatchers makes the users in userIDs watch the task, users already watching it are left as they are
+func (r *WatcherRepository) AddWatchers(ctx context.Context, taskID string, userIDs []string) error {
+	query := `
+		INSERT INTO app.task_watchers (task_id, user_id, created_at)
+		SELECT @task_id, user_id, current_timestamp
+		FROM unnest(@user_ids::UUID[]) AS user_id
+		ON CONFLICT DO NOTHING
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"task_id":  taskID,
+		"user_ids": userIDs,
+	}
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, args)
+	return err
+}
+
+func (r *WatcherRepository) RemoveWatcher(ctx context.Context, taskID string, userID string) error {
+	query := `
+		DELETE FROM app.task_watchers WHERE task_id = $1 AND user_id = $2;
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, taskID, userID)
+	return err
+}
+
+// GetWatchersByTaskIDs returns the watchers of every task in taskIDs, oldest first
+func (r *WatcherRepository) GetWatchersByTaskIDs(ctx context.Context, taskIDs []string) ([]*_model.TaskWatcher, error) {
+	query := `
+		SELECT task_id, user_id, created_at
+		FROM app.task_watchers
+		WHERE task_id = ANY(@task_ids)
+		ORDER BY created_at, user_id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"task_ids": taskIDs})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var watchers []*_model.TaskWatcher
+	for rows.Next() {
+		var watcher _model.TaskWatcher
+		if err = rows.Scan(&watcher.TaskID, &watcher.UserID, &watcher.CreatedAt); err != nil {
+			return nil, err
+		}
+		watchers = append(watchers, &watcher)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return watchers, nil
+}
+
+// GetWatcherIDsOfTeamMembers returns the watchers of the task still in its team, former members are not notified
+func (r *WatcherRepository) GetWatcherIDsOfTeamMembers(ctx context.Context, taskID string) ([]string, error) {
+	query := `
+		SELECT w.user_id
+		FROM app.task_watchers w
+		JOIN app.tasks t ON t.id = w.task_id AND t.deleted_at IS NULL
+		JOIN app.user_teams ut ON ut.team_id = t.team_id AND ut.user_id = w.user_id
+		WHERE w.task_id = @task_id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"task_id": taskID})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err = rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return userIDs, nil
+}
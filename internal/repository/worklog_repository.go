@@ -0,0 +1,204 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type WorklogRepositoryInterface interface {
+	CreateWorklog(ctx context.Context, worklog *_model.Worklog) (*_model.Worklog, error)
+	DeleteWorklog(ctx context.Context, id string) error
+	GetWorklogByID(ctx context.Context, id string) (*_model.Worklog, error)
+	GetRunningWorklog(ctx context.Context, userID string) (*_model.Worklog, error)
+	StopRunningWorklog(ctx context.Context, userID string) (*_model.Worklog, error)
+	GetWorklogsByTaskIDs(ctx context.Context, taskIDs []string) ([]*_model.Worklog, error)
+	GetUserWorklogs(ctx context.Context, userID string, from time.Time, to time.Time) ([]*_model.Worklog, error)
+}
+
+type WorklogRepository struct {
+	db *_db.Database
+}
+
+func NewWorklogRepository(db *_db.Database) WorklogRepositoryInterface {
+	return &WorklogRepository{
+		db: db,
+	}
+}
+
+const worklogColumns = `
+	w.id,
+	w.task_id,
+	w.user_id,
+	w.started_at,
+	w.duration,
+	w.note,
+	w.created_at,
+	w.modified_at
+`
+
+func scanWorklog(row pgx.Row, worklog *_model.Worklog) error {
+	return row.Scan(
+		&worklog.ID,
+		&worklog.TaskID,
+		&worklog.UserID,
+		&worklog.StartedAt,
+		&worklog.Duration,
+		&worklog.Note,
+		&worklog.CreatedAt,
+		&worklog.ModifiedAt,
+	)
+}
+
+func scanWorklogs(rows pgx.Rows) ([]*_model.Worklog, error) {
+	defer rows.Close()
+
+	var worklogs []*_model.Worklog
+	for rows.Next() {
+		var worklog _model.Worklog
+		if err := scanWorklog(rows, &worklog); err != nil {
+			return nil, err
+		}
+		worklogs = append(worklogs, &worklog)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return worklogs, nil
+}
+
+// CreateWorklog saves the worklog, a nil duration starts a timer
+func (r *WorklogRepository) CreateWorklog(ctx context.Context, worklog *_model.Worklog) (*_model.Worklog, error) {
+	query := `
+		INSERT INTO app.worklogs AS w (task_id, user_id, started_at, duration, note, created_at, modified_at)
+		VALUES (@task_id, @user_id, @started_at, @duration, @note, current_timestamp, current_timestamp)
+		RETURNING ` + worklogColumns + `
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"task_id":    worklog.TaskID,
+		"user_id":    worklog.UserID,
+		"started_at": worklog.StartedAt,
+		"duration":   worklog.Duration,
+		"note":       worklog.Note,
+	}
+
+	var created _model.Worklog
+	if err := scanWorklog(r.db.Conn(ctx).QueryRow(ctx, query, args), &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (r *WorklogRepository) DeleteWorklog(ctx context.Context, id string) error {
+	query := `
+		DELETE FROM app.worklogs WHERE id = $1;
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	return err
+}
+
+func (r *WorklogRepository) GetWorklogByID(ctx context.Context, id string) (*_model.Worklog, error) {
+	query := `
+		SELECT ` + worklogColumns + `
+		FROM app.worklogs w
+		WHERE w.id = @id
+	`
+
+	var worklog _model.Worklog
+	if err := scanWorklog(r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"id": id}), &worklog); err != nil {
+		return nil, err
+	}
+	return &worklog, nil
+}
+
+// GetRunningWorklog returns the running timer of the user, nil when none runs
+func (r *WorklogRepository) GetRunningWorklog(ctx context.Context, userID string) (*_model.Worklog, error) {
+	query := `
+		SELECT ` + worklogColumns + `
+		FROM app.worklogs w
+		WHERE w.user_id = @user_id
+		AND w.duration IS NULL
+	`
+
+	var worklog _model.Worklog
+	err := scanWorklog(r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"user_id": userID}), &worklog)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &worklog, nil
+}
+
+// StopRunningWorklog stops the running timer of the user with the time elapsed since it started, nil when none runs
+func (r *WorklogRepository) StopRunningWorklog(ctx context.Context, userID string) (*_model.Worklog, error) {
+	query := `
+		UPDATE app.worklogs w
+		SET duration = GREATEST(EXTRACT(EPOCH FROM current_timestamp - w.started_at), 0)::INT,
+		    modified_at = current_timestamp
+		WHERE w.user_id = @user_id
+		AND w.duration IS NULL
+		RETURNING ` + worklogColumns + `
+	`
+
+	var worklog _model.Worklog
+	err := scanWorklog(r.db.Conn(ctx).QueryRow(ctx, query, pgx.NamedArgs{"user_id": userID}), &worklog)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &worklog, nil
+}
+
+// GetWorklogsByTaskIDs returns the worklogs of every task of taskIDs oldest first, running timers included
+func (r *WorklogRepository) GetWorklogsByTaskIDs(ctx context.Context, taskIDs []string) ([]*_model.Worklog, error) {
+	query := `
+		SELECT ` + worklogColumns + `
+		FROM app.worklogs w
+		WHERE w.task_id = ANY(@task_ids)
+		ORDER BY w.started_at, w.id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, pgx.NamedArgs{"task_ids": taskIDs})
+	if err != nil {
+		return nil, err
+	}
+	return scanWorklogs(rows)
+}
+
+// GetUserWorklogs returns the stopped worklogs of the user started in [from, to) oldest first
+func (r *WorklogRepository) GetUserWorklogs(ctx context.Context, userID string, from time.Time, to time.Time) ([]*_model.Worklog, error) {
+	query := `
+		SELECT ` + worklogColumns + `
+		FROM app.worklogs w
+		WHERE w.user_id = @user_id
+		AND w.started_at >= @from
+		AND w.started_at < @to
+		AND w.duration IS NOT NULL
+		ORDER BY w.started_at, w.id
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"user_id": userID,
+		"from":    from,
+		"to":      to,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return scanWorklogs(rows)
+}
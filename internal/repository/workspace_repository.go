@@ -0,0 +1,239 @@
+package repository
+
+import (
+	_db "bitbucket.org/edts/go-task-management/internal/db"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+)
+
+type WorkspaceRepositoryInterface interface {
+	CreateWorkspace(ctx context.Context, workspace *_model.Workspace) (*_model.Workspace, error)
+	GetWorkspacesByUserID(ctx context.Context, userID string) ([]*_model.Workspace, error)
+	GetWorkspaceIDByTeamID(ctx context.Context, teamID string) (string, error)
+	UpsertWorkspaceMember(ctx context.Context, member *_model.WorkspaceMember) (*_model.WorkspaceMember, error)
+	GetWorkspaceMemberRole(ctx context.Context, workspaceID string, userID string) (*_model.WorkspaceRole, error)
+	GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]*_model.WorkspaceMember, error)
+	CountWorkspaceMembers(ctx context.Context, workspaceID string, userIDs []string) (int, error)
+	CountWorkspaceAdmins(ctx context.Context, workspaceID string) (int, error)
+	DeleteWorkspaceMember(ctx context.Context, workspaceID string, userID string) error
+}
+
+type WorkspaceRepository struct {
+	db *_db.Database
+}
+
+func NewWorkspaceRepository(db *_db.Database) WorkspaceRepositoryInterface {
+	return &WorkspaceRepository{
+		db: db,
+	}
+}
+
+func (r *WorkspaceRepository) CreateWorkspace(ctx context.Context, workspace *_model.Workspace) (*_model.Workspace, error) {
+	query := `
+		INSERT INTO app.workspaces ("name", created_at, modified_at, created_by, modified_by)
+		VALUES (@name, current_timestamp, current_timestamp, @created_by, @modified_by)
+		RETURNING id, created_at, modified_at
+	`
+
+	// Query arguments
+	args := pgx.NamedArgs{
+		"name":        workspace.Name,
+		"created_by":  workspace.CreatedBy,
+		"modified_by": workspace.ModifiedBy,
+	}
+
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&workspace.ID, &workspace.CreatedAt, &workspace.ModifiedAt)
+	if err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// GetWorkspacesByUserID lists the workspaces of the user with the role they hold in each
+func (r *WorkspaceRepository) GetWorkspacesByUserID(ctx context.Context, userID string) ([]*_model.Workspace, error) {
+	query := `
+		SELECT
+		    w.id,
+		    w.name,
+		    wm.role,
+		    w.created_at,
+		    w.modified_at,
+		    w.created_by,
+		    w.modified_by
+		FROM app.workspaces w
+		JOIN app.workspace_members wm ON wm.workspace_id = w.id
+		WHERE wm.user_id = @user_id
+		ORDER BY w.name
+	`
+	args := pgx.NamedArgs{
+		"user_id": userID,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var workspaces []*_model.Workspace
+	for rows.Next() {
+		var workspace _model.Workspace
+		if err = rows.Scan(
+			&workspace.ID,
+			&workspace.Name,
+			&workspace.Role,
+			&workspace.CreatedAt,
+			&workspace.ModifiedAt,
+			&workspace.CreatedBy,
+			&workspace.ModifiedBy,
+		); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, &workspace)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return workspaces, nil
+}
+
+// GetWorkspaceIDByTeamID runs before the request is scoped, the team row is read without isolation
+func (r *WorkspaceRepository) GetWorkspaceIDByTeamID(ctx context.Context, teamID string) (string, error) {
+	query := `SELECT workspace_id FROM app.teams WHERE id = @team_id`
+	args := pgx.NamedArgs{
+		"team_id": teamID,
+	}
+
+	ctx = _db.WithoutRowLevelSecurity(ctx)
+	var workspaceID string
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&workspaceID)
+	if err != nil {
+		return "", err
+	}
+	return workspaceID, nil
+}
+
+func (r *WorkspaceRepository) UpsertWorkspaceMember(ctx context.Context, member *_model.WorkspaceMember) (*_model.WorkspaceMember, error) {
+	query := `
+		INSERT INTO app.workspace_members (workspace_id, user_id, role)
+		VALUES (@workspace_id, @user_id, @role)
+		ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role
+		RETURNING created_at
+	`
+	args := pgx.NamedArgs{
+		"workspace_id": member.WorkspaceID,
+		"user_id":      member.UserID,
+		"role":         member.Role,
+	}
+
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&member.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// GetWorkspaceMemberRole returns nil without error when the user is not a member of the workspace
+func (r *WorkspaceRepository) GetWorkspaceMemberRole(ctx context.Context, workspaceID string, userID string) (*_model.WorkspaceRole, error) {
+	query := `SELECT role FROM app.workspace_members WHERE workspace_id = @workspace_id AND user_id = @user_id`
+	args := pgx.NamedArgs{
+		"workspace_id": workspaceID,
+		"user_id":      userID,
+	}
+
+	var role _model.WorkspaceRole
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (r *WorkspaceRepository) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]*_model.WorkspaceMember, error) {
+	query := `
+		SELECT workspace_id, user_id, role, created_at
+		FROM app.workspace_members
+		WHERE workspace_id = @workspace_id
+		ORDER BY created_at
+	`
+	args := pgx.NamedArgs{
+		"workspace_id": workspaceID,
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*_model.WorkspaceMember
+	for rows.Next() {
+		var member _model.WorkspaceMember
+		if err = rows.Scan(&member.WorkspaceID, &member.UserID, &member.Role, &member.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, &member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// CountWorkspaceMembers counts how many of the given users belong to the workspace
+func (r *WorkspaceRepository) CountWorkspaceMembers(ctx context.Context, workspaceID string, userIDs []string) (int, error) {
+	query := `SELECT COUNT(1) FROM app.workspace_members WHERE workspace_id = @workspace_id AND user_id = ANY(@user_ids)`
+	args := pgx.NamedArgs{
+		"workspace_id": workspaceID,
+		"user_ids":     userIDs,
+	}
+
+	count := 0
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// CountWorkspaceAdmins locks the admin rows so two admins cannot demote or remove each other at the same time
+func (r *WorkspaceRepository) CountWorkspaceAdmins(ctx context.Context, workspaceID string) (int, error) {
+	query := `
+		SELECT COUNT(1) FROM (
+			SELECT 1 FROM app.workspace_members
+			WHERE workspace_id = @workspace_id AND role = 'ADMIN'
+			FOR UPDATE
+		) admins
+	`
+	args := pgx.NamedArgs{
+		"workspace_id": workspaceID,
+	}
+
+	count := 0
+	err := r.db.Conn(ctx).QueryRow(ctx, query, args).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *WorkspaceRepository) DeleteWorkspaceMember(ctx context.Context, workspaceID string, userID string) error {
+	query := `DELETE FROM app.workspace_members WHERE workspace_id = @workspace_id AND user_id = @user_id`
+	args := pgx.NamedArgs{
+		"workspace_id": workspaceID,
+		"user_id":      userID,
+	}
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, args)
+	return err
+}
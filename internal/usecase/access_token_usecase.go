@@ -0,0 +1,215 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_projection "bitbucket.org/edts/go-task-management/internal/model/projection"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"github.com/jackc/pgx/v5"
+)
+
+type AccessTokenUsecaseInterface interface {
+	CreatePersonalAccessToken(ctx context.Context, input _genModel.CreatePersonalAccessTokenInput) (*_genModel.CreatedAccessToken, error)
+	GetPersonalAccessTokens(ctx context.Context) ([]*_model.PersonalAccessToken, error)
+	RevokePersonalAccessToken(ctx context.Context, id string) (bool, error)
+	VerifyPersonalAccessToken(ctx context.Context, rawToken string) (*_projection.UserContext, error)
+}
+
+type AccessTokenUsecase struct {
+	accessTokenRepo    _repo.PersonalAccessTokenRepositoryInterface
+	serviceAccountRepo _repo.ServiceAccountRepositoryInterface
+	userTeamRepo       _repo.UserTeamRepositoryInterface
+}
+
+func NewAccessTokenUsecase(
+	accessTokenRepo _repo.PersonalAccessTokenRepositoryInterface,
+	serviceAccountRepo _repo.ServiceAccountRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface) AccessTokenUsecaseInterface {
+	return &AccessTokenUsecase{
+		accessTokenRepo:    accessTokenRepo,
+		serviceAccountRepo: serviceAccountRepo,
+		userTeamRepo:       userTeamRepo,
+	}
+}
+
+func (uc *AccessTokenUsecase) CreatePersonalAccessToken(ctx context.Context, input _genModel.CreatePersonalAccessTokenInput) (*_genModel.CreatedAccessToken, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// A token must not be able to mint other tokens, otherwise a leaked token can never be contained
+	if userCtx.IsAccessToken() {
+		return nil, _customErr.NewGraphQLError(http.StatusForbidden, "personal access tokens can only be created from a login session")
+	}
+
+	token := &_model.PersonalAccessToken{
+		Name:      input.Name,
+		Scopes:    input.Scopes,
+		ExpiresAt: input.ExpiresAt,
+		UserID:    &userCtx.UserID,
+	}
+
+	return issuePersonalAccessToken(ctx, uc.accessTokenRepo, userCtx, token)
+}
+
+func (uc *AccessTokenUsecase) GetPersonalAccessTokens(ctx context.Context) ([]*_model.PersonalAccessToken, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := uc.accessTokenRepo.GetPersonalAccessTokensByUserID(ctx, userCtx.UserID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return tokens, nil
+}
+
+func (uc *AccessTokenUsecase) RevokePersonalAccessToken(ctx context.Context, id string) (bool, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	token, err := uc.accessTokenRepo.GetPersonalAccessTokenByID(ctx, id)
+	if err != nil {
+		return false, _customErr.NewGraphQLError(http.StatusNotFound, "Personal access token not found")
+	}
+
+	// Users revoke their own tokens, service account tokens can be revoked by the owning team
+	if token.UserID != nil && *token.UserID != userCtx.UserID {
+		return false, _customErr.NewGraphQLError(http.StatusNotFound, "Personal access token not found")
+	}
+	if token.ServiceAccountID != nil {
+		serviceAccount, err := uc.serviceAccountRepo.GetServiceAccountByID(ctx, *token.ServiceAccountID)
+		if err != nil {
+			return false, _customErr.NewGraphQLError(http.StatusNotFound, "Personal access token not found")
+		}
+		if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, serviceAccount.TeamID); err != nil {
+			return false, err
+		}
+	}
+
+	if err = uc.accessTokenRepo.RevokePersonalAccessToken(ctx, token.ID); err != nil {
+		return false, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return true, nil
+}
+
+// VerifyPersonalAccessToken resolves the caller behind a personal access token and tracks its last usage
+func (uc *AccessTokenUsecase) VerifyPersonalAccessToken(ctx context.Context, rawToken string) (*_projection.UserContext, error) {
+	// The token is verified before the workspace of the request is known
+	ctx = _repo.WithoutWorkspaceIsolation(ctx)
+	owner, err := uc.accessTokenRepo.GetPersonalAccessTokenByHash(ctx, hashSecretToken(rawToken))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, _customErr.NewGraphQLError(http.StatusUnauthorized, "unauthorized: invalid token")
+	}
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	token := owner.Token
+	if token.RevokedAt != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusUnauthorized, "unauthorized: token has been revoked")
+	}
+	if token.ExpiresAt != nil && !token.ExpiresAt.After(time.Now()) {
+		return nil, _customErr.NewGraphQLError(http.StatusUnauthorized, "unauthorized: token is expired")
+	}
+
+	// Last used tracking is best effort, it must not block the request
+	if err = uc.accessTokenRepo.TouchPersonalAccessToken(ctx, token.ID); err != nil {
+		logs.Errorf("VerifyPersonalAccessToken:: Error TouchPersonalAccessToken repo: %v", err)
+	}
+
+	userCtx := &_projection.UserContext{
+		Scopes:  token.Scopes,
+		TokenID: token.ID,
+	}
+	if userCtx.Scopes == nil {
+		userCtx.Scopes = []string{}
+	}
+
+	switch {
+	case token.UserID != nil && owner.UserEmail != nil:
+		userCtx.UserID = *token.UserID
+		userCtx.Email = *owner.UserEmail
+	case token.ServiceAccountID != nil && owner.ServiceAccountTeamID != nil:
+		// Service accounts act on their own behalf, they are not tied to a person
+		userCtx.UserID = *token.ServiceAccountID
+		userCtx.ServiceAccountID = *token.ServiceAccountID
+		userCtx.TeamID = *owner.ServiceAccountTeamID
+	default:
+		return nil, _customErr.NewGraphQLError(http.StatusUnauthorized, "unauthorized: invalid token")
+	}
+
+	return userCtx, nil
+}
+
+// issuePersonalAccessToken generates a new plain token, stores its hash and returns the plain value once
+func issuePersonalAccessToken(
+	ctx context.Context,
+	accessTokenRepo _repo.PersonalAccessTokenRepositoryInterface,
+	userCtx *_projection.UserContext,
+	token *_model.PersonalAccessToken) (*_genModel.CreatedAccessToken, error) {
+	// A caller can never hand out more than it has been granted itself
+	for _, scope := range token.Scopes {
+		if !userCtx.HasScope(scope) {
+			return nil, _customErr.NewGraphQLError(http.StatusForbidden, "cannot grant scope "+scope)
+		}
+	}
+	if token.ExpiresAt != nil && !token.ExpiresAt.After(time.Now()) {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Expiry date must be in the future")
+	}
+
+	rawToken, err := generateSecretToken(_const.PERSONAL_ACCESS_TOKEN_PREFIX)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, "Failed to generate personal access token")
+	}
+
+	token.TokenHash = hashSecretToken(rawToken)
+	token.TokenPrefix = rawToken[:len(_const.PERSONAL_ACCESS_TOKEN_PREFIX)+6]
+
+	createdToken, err := accessTokenRepo.CreatePersonalAccessToken(ctx, token)
+	if err != nil {
+		logs.Errorf("issuePersonalAccessToken:: Error CreatePersonalAccessToken repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	return &_genModel.CreatedAccessToken{
+		Token:       rawToken,
+		AccessToken: createdToken,
+	}, nil
+}
+
+// IsPersonalAccessToken tells a personal access token apart from a JWT
+func IsPersonalAccessToken(rawToken string) bool {
+	return strings.HasPrefix(rawToken, _const.PERSONAL_ACCESS_TOKEN_PREFIX)
+}
+
+// generateSecretToken returns a random url safe token, used for access tokens and invitations
+func generateSecretToken(prefix string) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// hashSecretToken uses sha256 rather than bcrypt, tokens carry 256 bits of entropy
+// and have to be looked up by their hash
+func hashSecretToken(rawToken string) string {
+	sum := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,327 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	_config "bitbucket.org/edts/go-task-management/config"
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_pubsub "bitbucket.org/edts/go-task-management/internal/pubsub"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	"bitbucket.org/edts/go-task-management/pkg/blobstore"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/google/uuid"
+)
+
+type AttachmentUsecaseInterface interface {
+	UploadAttachment(ctx context.Context, taskID string, file graphql.Upload) (*_model.Attachment, error)
+	DeleteAttachment(ctx context.Context, id string) (bool, error)
+	AttachmentURL(ctx context.Context, attachment *_model.Attachment) (string, error)
+	OpenAttachment(ctx context.Context, id string, expires string, signature string) (*_model.Attachment, io.ReadCloser, error)
+	CleanupDeletedBlobs(ctx context.Context) (int, error)
+}
+
+// Defaults used when the attachment section is missing from config
+const (
+	defaultAttachmentMaxSize = 10 << 20
+	defaultAttachmentURLTTL  = 15 * time.Minute
+)
+
+var defaultAttachmentTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"}
+
+// blobCleanupBatchSize bounds the blobs removed by one run of the cleanup job
+const blobCleanupBatchSize = 100
+
+// sniffLength is the amount of content http.DetectContentType looks at
+const sniffLength = 512
+
+type AttachmentUsecase struct {
+	// Repo
+	attachmentRepo _repo.AttachmentRepositoryInterface
+	taskRepo       _repo.TaskRepositoryInterface
+	teamRepo       _repo.TeamRepositoryInterface
+	userTeamRepo   _repo.UserTeamRepositoryInterface
+	txRepo         _repo.TransactionRepositoryInterface
+	// PubSub
+	taskPubSub _pubsub.TaskPubSubInterface
+	// Storage
+	store      blobstore.BlobStore
+	signingKey []byte
+}
+
+func NewAttachmentUsecase(
+	attachmentRepo _repo.AttachmentRepositoryInterface,
+	taskRepo _repo.TaskRepositoryInterface,
+	teamRepo _repo.TeamRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface,
+	txRepo _repo.TransactionRepositoryInterface,
+	taskPubSub _pubsub.TaskPubSubInterface,
+	store blobstore.BlobStore) AttachmentUsecaseInterface {
+	return &AttachmentUsecase{
+		attachmentRepo: attachmentRepo,
+		taskRepo:       taskRepo,
+		teamRepo:       teamRepo,
+		userTeamRepo:   userTeamRepo,
+		txRepo:         txRepo,
+		taskPubSub:     taskPubSub,
+		store:          store,
+		signingKey:     getAttachmentSigningKey(),
+	}
+}
+
+// UploadAttachment stores the file and attaches it to the task. The type is sniffed from the content,
+// the one announced by the client is not trusted
+func (uc *AttachmentUsecase) UploadAttachment(ctx context.Context, taskID string, file graphql.Upload) (*_model.Attachment, error) {
+	logs.Infof("UploadAttachment:: Starting with task %s, file %s of %d bytes", taskID, file.Filename, file.Size)
+	task, err := uc.taskRepo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, task.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	if file.Size <= 0 {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "The file is empty")
+	}
+	if maxSize := GetAttachmentMaxSize(); file.Size > maxSize {
+		return nil, _customErr.NewGraphQLError(http.StatusRequestEntityTooLarge, fmt.Sprintf("Files are limited to %d bytes", maxSize))
+	}
+
+	content := bufio.NewReaderSize(file.File, sniffLength)
+	// A file shorter than the sniff length is returned whole along with io.EOF
+	head, _ := content.Peek(sniffLength)
+	contentType := sniffContentType(head)
+	if !isAllowedAttachmentType(contentType) {
+		return nil, _customErr.NewGraphQLError(http.StatusUnsupportedMediaType, fmt.Sprintf("Files of type %s are not accepted", contentType))
+	}
+
+	key := path.Join(task.TeamID, task.ID, uuid.NewString())
+	if err = uc.store.Put(ctx, key, content, file.Size, contentType); err != nil {
+		logs.Errorf("UploadAttachment:: Error storing the blob %s: %v", key, err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, "The file could not be stored")
+	}
+
+	attachment, err := uc.attachmentRepo.CreateAttachment(ctx, &_model.Attachment{
+		TaskID:      task.ID,
+		Filename:    sanitizeFilename(file.Filename),
+		ContentType: contentType,
+		Size:        int32(file.Size),
+		StorageKey:  key,
+		CreatedBy:   actorID(userCtx),
+	})
+	if err != nil {
+		logs.Errorf("UploadAttachment:: Error CreateAttachment repo: %v", err)
+		// Nothing references the blob, it would never be cleaned up
+		if deleteErr := uc.store.Delete(ctx, key); deleteErr != nil {
+			logs.Errorf("UploadAttachment:: Error deleting the orphan blob %s: %v", key, deleteErr)
+		}
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	// The attachments of the task changed
+	uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+
+	logs.Info("UploadAttachment:: Finish UploadAttachment")
+
+	return attachment, nil
+}
+
+// DeleteAttachment detaches the file, the blob is removed by the cleanup job
+func (uc *AttachmentUsecase) DeleteAttachment(ctx context.Context, id string) (bool, error) {
+	logs.Infof("DeleteAttachment:: Starting with attachment %s", id)
+	attachment, err := uc.attachmentRepo.GetAttachmentByID(ctx, id)
+	if err != nil {
+		return false, _customErr.NewGraphQLError(http.StatusNotFound, "Attachment not found")
+	}
+	task, err := uc.taskRepo.GetTaskByID(ctx, attachment.TaskID)
+	if err != nil {
+		return false, _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+	}
+	if _, err = ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, task.TeamID); err != nil {
+		return false, err
+	}
+
+	if err = uc.attachmentRepo.DeleteAttachment(ctx, attachment.ID); err != nil {
+		logs.Errorf("DeleteAttachment:: Error DeleteAttachment repo: %v", err)
+		return false, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+
+	logs.Info("DeleteAttachment:: Finish DeleteAttachment")
+
+	return true, nil
+}
+
+// AttachmentURL returns a download link valid for the configured TTL. Stores able to sign their own
+// URLs serve the download, otherwise the link points to the API and carries an HMAC of the attachment and the expiry
+func (uc *AttachmentUsecase) AttachmentURL(ctx context.Context, attachment *_model.Attachment) (string, error) {
+	ttl := getAttachmentURLTTL()
+	if signer, ok := uc.store.(blobstore.URLSigner); ok {
+		signedURL, err := signer.SignedURL(ctx, attachment.StorageKey, attachment.Filename, ttl)
+		if err != nil {
+			logs.Errorf("AttachmentURL:: Error signing the URL of %s: %v", attachment.ID, err)
+			return "", _customErr.NewGraphQLError(http.StatusInternalServerError, "The download link could not be created")
+		}
+		return signedURL, nil
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	query := url.Values{}
+	query.Set("id", attachment.ID)
+	query.Set("expires", expires)
+	query.Set("signature", uc.signDownload(attachment.ID, expires))
+	return _config.AppConfigInstance.Attachment.DownloadURL + "?" + query.Encode(), nil
+}
+
+// OpenAttachment checks a download link issued by AttachmentURL and opens the content. The link is the
+// authorization, it was only handed out to members of the team so the workspace isolation is lifted
+func (uc *AttachmentUsecase) OpenAttachment(ctx context.Context, id string, expires string, signature string) (*_model.Attachment, io.ReadCloser, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(uc.signDownload(id, expires))) {
+		return nil, nil, _customErr.NewGraphQLError(http.StatusForbidden, "The download link is invalid")
+	}
+	if time.Now().Unix() > expiresAt {
+		return nil, nil, _customErr.NewGraphQLError(http.StatusForbidden, "The download link has expired")
+	}
+
+	attachment, err := uc.attachmentRepo.GetAttachmentByID(_repo.WithoutWorkspaceIsolation(ctx), id)
+	if err != nil {
+		return nil, nil, _customErr.NewGraphQLError(http.StatusNotFound, "Attachment not found")
+	}
+	content, err := uc.store.Get(ctx, attachment.StorageKey)
+	if errors.Is(err, blobstore.ErrNotFound) {
+		return nil, nil, _customErr.NewGraphQLError(http.StatusNotFound, "Attachment not found")
+	}
+	if err != nil {
+		logs.Errorf("OpenAttachment:: Error reading the blob %s: %v", attachment.StorageKey, err)
+		return nil, nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return attachment, content, nil
+}
+
+// CleanupDeletedBlobs removes from the store the blobs of deleted attachments, including the ones deleted
+// along with their task or team. Replicas skip the blobs another replica is removing
+func (uc *AttachmentUsecase) CleanupDeletedBlobs(ctx context.Context) (int, error) {
+	ctx = _repo.WithoutWorkspaceIsolation(ctx)
+
+	removed := 0
+	err := uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		keys, err := uc.attachmentRepo.LockBlobDeletions(ctx, blobCleanupBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err = uc.store.Delete(ctx, key); err != nil {
+				logs.Errorf("CleanupDeletedBlobs:: Error deleting the blob %s: %v", key, err)
+				if err = uc.attachmentRepo.RetryBlobDeletion(ctx, key); err != nil {
+					return err
+				}
+				continue
+			}
+			if err = uc.attachmentRepo.DeleteBlobDeletion(ctx, key); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if removed > 0 {
+		logs.Infof("CleanupDeletedBlobs:: Removed %d blobs", removed)
+	}
+	return removed, nil
+}
+
+func (uc *AttachmentUsecase) signDownload(id string, expires string) string {
+	mac := hmac.New(sha256.New, uc.signingKey)
+	mac.Write([]byte(id + ":" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sniffContentType detects the media type of the first bytes of a file, without its parameters
+func sniffContentType(head []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
+
+func isAllowedAttachmentType(contentType string) bool {
+	allowed := _config.AppConfigInstance.Attachment.AllowedTypes
+	if len(allowed) == 0 {
+		allowed = defaultAttachmentTypes
+	}
+	for _, allowedType := range allowed {
+		if strings.EqualFold(allowedType, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeFilename keeps the base name of the uploaded file without control characters, within the column size
+func sanitizeFilename(filename string) string {
+	filename = filepath.Base(strings.ReplaceAll(filename, "\\", "/"))
+	filename = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, filename))
+	if filename == "" || filename == "." || filename == "/" {
+		return "file"
+	}
+
+	for len(filename) > 255 {
+		_, size := utf8.DecodeLastRuneInString(filename)
+		filename = filename[:len(filename)-size]
+	}
+	return filename
+}
+
+// GetAttachmentMaxSize is the largest accepted upload in bytes, sizes are stored as 32 bits integers
+func GetAttachmentMaxSize() int64 {
+	if maxSize := _config.AppConfigInstance.Attachment.MaxSize; maxSize > 0 {
+		return min(maxSize, math.MaxInt32)
+	}
+	return defaultAttachmentMaxSize
+}
+
+func getAttachmentURLTTL() time.Duration {
+	if ttl := _config.AppConfigInstance.Attachment.URLTTL; ttl > 0 {
+		return ttl
+	}
+	return defaultAttachmentURLTTL
+}
+
+// getAttachmentSigningKey is required by the config loading, download links stay valid across restarts and replicas
+func getAttachmentSigningKey() []byte {
+	return []byte(_config.AppConfigInstance.Attachment.SigningKey)
+}
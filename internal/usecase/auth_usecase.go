@@ -3,8 +3,10 @@ package usecase
 import (
 	"context"
 	"net/http"
+	"sync"
 	"time"
 
+	_config "bitbucket.org/edts/go-task-management/config"
 	_model "bitbucket.org/edts/go-task-management/internal/model"
 	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
 	_repo "bitbucket.org/edts/go-task-management/internal/repository"
@@ -20,17 +22,36 @@ type AuthUsecaseInterface interface {
 	LogoutUser(ctx context.Context, input _genModel.RefreshTokenInput) (bool, error)
 }
 
+const invalidCredentialsMessage = "Invalid email or password"
+
+// Defaults used when the login section is missing from config
+const (
+	defaultMaxFailedAttempts = 5
+	defaultLockoutDuration   = 15 * time.Minute
+)
+
+var (
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
+)
+
 type AuthUsecase struct {
 	userRepo        _repo.UserRepositoryInterface
 	userSessionRepo _repo.UserSessionRepositoryInterface
+	invitationRepo  _repo.TeamInvitationRepositoryInterface
+	txRepo          _repo.TransactionRepositoryInterface
 }
 
 func NewAuthUsecase(
 	userRepo _repo.UserRepositoryInterface,
-	userSessionRepo _repo.UserSessionRepositoryInterface) AuthUsecaseInterface {
+	userSessionRepo _repo.UserSessionRepositoryInterface,
+	invitationRepo _repo.TeamInvitationRepositoryInterface,
+	txRepo _repo.TransactionRepositoryInterface) AuthUsecaseInterface {
 	return &AuthUsecase{
 		userRepo:        userRepo,
 		userSessionRepo: userSessionRepo,
+		invitationRepo:  invitationRepo,
+		txRepo:          txRepo,
 	}
 }
 
@@ -54,10 +75,26 @@ func (uc *AuthUsecase) RegisterUser(ctx context.Context, input _genModel.CreateU
 		Password: string(hashedPass),
 	}
 
-	// Save user to repo
-	createdUser, err := uc.userRepo.CreateUser(ctx, newUser)
+	// Save user to repo and join the teams that invited the email, the user is not created when joining fails.
+	// The invitee is not part of the workspaces of the inviting teams yet
+	var createdUser *_model.User
+	err = uc.txRepo.WithTransaction(_repo.WithoutWorkspaceIsolation(ctx), func(ctx context.Context) error {
+		createdUser, err = uc.userRepo.CreateUser(ctx, newUser)
+		if err != nil {
+			return gqlerror.Errorf(err.Error())
+		}
+
+		// The token of the invitation link must have been sent to the registered email
+		if input.InvitationToken != nil {
+			if _, err = acceptTeamInvitation(ctx, uc.invitationRepo, *input.InvitationToken, createdUser.ID, createdUser.Email); err != nil {
+				return err
+			}
+		}
+		return acceptPendingInvitations(ctx, uc.invitationRepo, createdUser.ID, createdUser.Email)
+	})
 	if err != nil {
-		return nil, gqlerror.Errorf(err.Error())
+		logs.Errorf("RegisterUser:: Error registering the user: %v", err)
+		return nil, err
 	}
 	// Set the password to empty (do not expose it)
 	createdUser.Password = ""
@@ -66,49 +103,78 @@ func (uc *AuthUsecase) RegisterUser(ctx context.Context, input _genModel.CreateU
 }
 
 func (uc *AuthUsecase) LoginUser(ctx context.Context, input _genModel.LoginUserInput) (*_genModel.AuthResponse, error) {
+	// Every credential failure answers the same message, it must not reveal which emails are registered
+	if input.Email == nil {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, invalidCredentialsMessage)
+	}
+
 	//check user exist
 	userExist, err := uc.userRepo.GetUserByEmail(ctx, *input.Email)
-	if userExist == nil {
-		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "User does not exist")
+	if err != nil || userExist == nil {
+		// Spend the same bcrypt time as a wrong password so response time does not leak it either
+		_ = bcrypt.CompareHashAndPassword(getDummyPasswordHash(), []byte(input.Password))
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, invalidCredentialsMessage)
+	}
+
+	// Locked accounts are rejected without checking the password
+	if userExist.LockedUntil != nil && userExist.LockedUntil.After(time.Now()) {
+		logs.Infof("LoginUser:: Account %s is locked until %v", userExist.ID, userExist.LockedUntil)
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, invalidCredentialsMessage)
 	}
 
 	//compare the password
 	err = bcrypt.CompareHashAndPassword([]byte(userExist.Password), []byte(input.Password))
 	if err != nil {
-		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid email or password")
+		loginCfg := getLoginConfig()
+		if err = uc.userRepo.RegisterFailedLogin(ctx, userExist.ID, loginCfg.MaxFailedAttempts, loginCfg.LockoutDuration); err != nil {
+			logs.Errorf("LoginUser:: Error RegisterFailedLogin repo: %v", err)
+		}
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, invalidCredentialsMessage)
+	}
+
+	// Successful login clears the failed attempts
+	if userExist.FailedLoginAttempts > 0 || userExist.LockedUntil != nil {
+		if err = uc.userRepo.ResetFailedLogins(ctx, userExist.ID); err != nil {
+			logs.Errorf("LoginUser:: Error ResetFailedLogins repo: %v", err)
+		}
 	}
 
+	return issueAuthResponse(ctx, uc.userSessionRepo, userExist)
+}
+
+// issueAuthResponse generates the access/refresh pair of a new session, shared by every login method
+func issueAuthResponse(ctx context.Context, userSessionRepo _repo.UserSessionRepositoryInterface, user *_model.User) (*_genModel.AuthResponse, error) {
 	// Generate JWT tokens
-	accessToken, expiredAccessTokenDate, err := GenerateToken(*userExist, "access")
+	accessToken, expiredAccessTokenDate, err := GenerateToken(*user, "access")
 	if err != nil {
 		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Failed to generate access token")
 	}
 
 	// Generate refresh token
-	refreshToken, expiredRefreshTokenDate, err := GenerateToken(*userExist, "refresh")
+	refreshToken, expiredRefreshTokenDate, err := GenerateToken(*user, "refresh")
 	if err != nil {
 		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Failed to generate refresh token")
 	}
 
 	//create new session
 	userSession := &_model.UserSession{
-		UserID:             userExist.ID,
+		UserID:             user.ID,
 		ExpiredAccessDate:  expiredAccessTokenDate,
 		ExpiredRefreshDate: expiredRefreshTokenDate,
 	}
-	_, err = uc.userSessionRepo.CreateUserSession(ctx, userSession)
+	_, err = userSessionRepo.CreateUserSession(ctx, userSession)
 	if err != nil {
 		return nil, err
 	}
 
 	// Set the password to empty (do not expose it)
-	userExist.Password = ""
+	user.Password = ""
 
 	// Return AuthPayload
 	return &_genModel.AuthResponse{
 		Token:        accessToken,
 		RefreshToken: refreshToken,
-		User:         userExist,
+		User:         user,
 	}, nil
 }
 
@@ -122,7 +188,7 @@ func (uc *AuthUsecase) RefreshToken(ctx context.Context, input _genModel.Refresh
 	// Get user by email
 	userExist, err := uc.userRepo.GetUserByEmail(ctx, user["email"])
 	if userExist == nil {
-		return nil, gqlerror.Errorf("User does not exist")
+		return nil, gqlerror.Errorf("Invalid or expired refresh token")
 	}
 
 	// Generate new access
@@ -197,3 +263,22 @@ func (uc *AuthUsecase) LogoutUser(ctx context.Context, input _genModel.RefreshTo
 	// Step 4: Return success
 	return true, nil
 }
+
+// getDummyPasswordHash returns a bcrypt hash compared against when the email is unknown
+func getDummyPasswordHash() []byte {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+	})
+	return dummyPasswordHash
+}
+
+func getLoginConfig() _config.LoginConfig {
+	loginCfg := _config.AppConfigInstance.Login
+	if loginCfg.MaxFailedAttempts <= 0 {
+		loginCfg.MaxFailedAttempts = defaultMaxFailedAttempts
+	}
+	if loginCfg.LockoutDuration <= 0 {
+		loginCfg.LockoutDuration = defaultLockoutDuration
+	}
+	return loginCfg
+}
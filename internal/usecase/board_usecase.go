@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+)
+
+type BoardUsecaseInterface interface {
+	GetBoard(ctx context.Context, teamID string, groupBy *_model.BoardGroupBy, swimlane *_model.BoardSwimlane) (*_model.Board, error)
+	GetBoardLaneTasks(ctx context.Context, lane *_model.BoardLane, first *int32, after *string) (*_model.TaskPage, error)
+}
+
+// defaultBoardPageSize is used when the lane tasks are read without first
+const defaultBoardPageSize = 20
+
+// boardStatuses are the board columns in display order
+var boardStatuses = []string{_const.TASK_STATUS_TODO, _const.TASK_STATUS_IN_PROGRESS, _const.TASK_STATUS_DONE}
+
+// boardPriorities are the priority swimlanes from the most urgent down
+var boardPriorities = []_model.TaskPriority{
+	_model.TaskPriorityUrgent,
+	_model.TaskPriorityHigh,
+	_model.TaskPriorityMedium,
+	_model.TaskPriorityLow,
+	_model.TaskPriorityNone,
+}
+
+type BoardUsecase struct {
+	// Repo
+	boardRepo    _repo.BoardRepositoryInterface
+	teamRepo     _repo.TeamRepositoryInterface
+	userRepo     _repo.UserRepositoryInterface
+	userTeamRepo _repo.UserTeamRepositoryInterface
+	labelRepo    _repo.LabelRepositoryInterface
+}
+
+func NewBoardUsecase(
+	boardRepo _repo.BoardRepositoryInterface,
+	teamRepo _repo.TeamRepositoryInterface,
+	userRepo _repo.UserRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface,
+	labelRepo _repo.LabelRepositoryInterface) BoardUsecaseInterface {
+	return &BoardUsecase{
+		boardRepo:    boardRepo,
+		teamRepo:     teamRepo,
+		userRepo:     userRepo,
+		userTeamRepo: userTeamRepo,
+		labelRepo:    labelRepo,
+	}
+}
+
+// GetBoard returns the columns of the team board with their counts and WIP limits, split into swimlanes.
+// The tasks themselves are read page by page from every lane
+func (uc *BoardUsecase) GetBoard(ctx context.Context, teamID string, groupBy *_model.BoardGroupBy, swimlane *_model.BoardSwimlane) (*_model.Board, error) {
+	logs.Infof("GetBoard:: Starting with team %s, groupBy %v and swimlane %v", teamID, groupBy, swimlane)
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := uc.teamRepo.GetTeamByID(ctx, teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, teamID); err != nil {
+		return nil, err
+	}
+
+	board := &_model.Board{
+		TeamID:         team.ID,
+		GroupBy:        _model.BoardGroupByStatus,
+		Swimlane:       _model.BoardSwimlaneNone,
+		WipLimitPolicy: team.WipLimitPolicy,
+	}
+	if groupBy != nil {
+		board.GroupBy = *groupBy
+	}
+	if swimlane != nil {
+		board.Swimlane = *swimlane
+	}
+
+	// Column totals are counted without swimlanes since a task can sit in several label lanes
+	columnCounts, err := uc.boardRepo.GetBoardCounts(ctx, team.ID, _model.BoardSwimlaneNone)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	laneCounts := columnCounts
+	if board.Swimlane != _model.BoardSwimlaneNone {
+		laneCounts, err = uc.boardRepo.GetBoardCounts(ctx, team.ID, board.Swimlane)
+		if err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	limits, err := uc.teamRepo.GetTeamWipLimits(ctx, []string{team.ID})
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	lanes, err := uc.getBoardLanes(ctx, board, laneCounts)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]int32)
+	for _, count := range columnCounts {
+		totals[count.Status] += count.Count
+	}
+	perLane := make(map[string]map[string]int32)
+	for _, count := range laneCounts {
+		if perLane[count.Status] == nil {
+			perLane[count.Status] = make(map[string]int32)
+		}
+		perLane[count.Status][laneKey(count.LaneKey)] = count.Count
+	}
+	wipLimits := make(map[string]int32)
+	for _, limit := range limits {
+		wipLimits[limit.Status] = limit.Limit
+	}
+
+	for _, status := range boardStatuses {
+		column := &_model.BoardColumn{
+			Status: status,
+			Count:  totals[status],
+		}
+		if limit, ok := wipLimits[status]; ok {
+			column.WipLimit = &limit
+			column.IsOverWipLimit = column.Count > limit
+		}
+
+		// Every column lists the same lanes so the rows line up
+		for _, lane := range lanes {
+			columnLane := *lane
+			columnLane.Status = status
+			columnLane.Count = perLane[status][laneKey(lane.Key)]
+			column.Lanes = append(column.Lanes, &columnLane)
+		}
+		board.Columns = append(board.Columns, column)
+	}
+
+	logs.Info("GetBoard:: Finish GetBoard")
+
+	return board, nil
+}
+
+// GetBoardLaneTasks returns a page of the lane tasks in rank order, after is the end cursor of the previous page
+func (uc *BoardUsecase) GetBoardLaneTasks(ctx context.Context, lane *_model.BoardLane, first *int32, after *string) (*_model.TaskPage, error) {
+	limit := defaultBoardPageSize
+	if first != nil && *first > 0 {
+		limit = int(*first)
+	}
+	afterRank := ""
+	if after != nil {
+		afterRank = *after
+	}
+
+	// Read one more task to know if another page follows
+	tasks, err := uc.boardRepo.GetBoardLaneTasks(ctx, lane, afterRank, limit+1)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	page := &_model.TaskPage{
+		Nodes:       tasks,
+		HasNextPage: len(tasks) > limit,
+	}
+	if page.HasNextPage {
+		page.Nodes = tasks[:limit]
+	}
+	if len(page.Nodes) > 0 {
+		page.EndCursor = &page.Nodes[len(page.Nodes)-1].Rank
+	}
+	return page, nil
+}
+
+// getBoardLanes returns the swimlanes of the board, the lane without assignee or label comes last
+func (uc *BoardUsecase) getBoardLanes(ctx context.Context, board *_model.Board, counts []*_model.BoardCount) ([]*_model.BoardLane, error) {
+	newLane := func(key *string) *_model.BoardLane {
+		return &_model.BoardLane{TeamID: board.TeamID, Swimlane: board.Swimlane, Key: key}
+	}
+
+	switch board.Swimlane {
+	case _model.BoardSwimlanePriority:
+		lanes := make([]*_model.BoardLane, 0, len(boardPriorities))
+		for _, priority := range boardPriorities {
+			key := string(priority)
+			lane := newLane(&key)
+			lane.Priority = &priority
+			lanes = append(lanes, lane)
+		}
+		return lanes, nil
+
+	case _model.BoardSwimlaneAssignee:
+		users, err := uc.userRepo.GetUsersByIDs(ctx, countedLaneKeys(counts))
+		if err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		sort.SliceStable(users, func(i, j int) bool {
+			return users[i].Name < users[j].Name
+		})
+
+		lanes := make([]*_model.BoardLane, 0, len(users)+1)
+		for _, user := range users {
+			lanes = append(lanes, newLane(&user.ID))
+		}
+		return append(lanes, newLane(nil)), nil
+
+	case _model.BoardSwimlaneLabel:
+		labels, err := uc.labelRepo.GetLabelsByTeamID(ctx, board.TeamID)
+		if err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+
+		// Only the labels carried by a task get a lane, labels come sorted by name
+		used := make(map[string]bool)
+		for _, key := range countedLaneKeys(counts) {
+			used[key] = true
+		}
+		lanes := make([]*_model.BoardLane, 0, len(used)+1)
+		for _, label := range labels {
+			if used[label.ID] {
+				lane := newLane(&label.ID)
+				lane.Label = label
+				lanes = append(lanes, lane)
+			}
+		}
+		return append(lanes, newLane(nil)), nil
+	}
+
+	return []*_model.BoardLane{newLane(nil)}, nil
+}
+
+// countedLaneKeys returns the distinct lane keys holding tasks, the lane without key is left out
+func countedLaneKeys(counts []*_model.BoardCount) []string {
+	var keys []string
+	for _, count := range counts {
+		if count.LaneKey != nil {
+			keys = append(keys, *count.LaneKey)
+		}
+	}
+	return uniqueIDs(keys)
+}
+
+func laneKey(key *string) string {
+	if key == nil {
+		return ""
+	}
+	return *key
+}
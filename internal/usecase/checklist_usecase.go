@@ -0,0 +1,292 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_pubsub "bitbucket.org/edts/go-task-management/internal/pubsub"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+)
+
+type ChecklistUsecaseInterface interface {
+	AddChecklistItem(ctx context.Context, taskID string, text string) (*_model.ChecklistItem, error)
+	ToggleChecklistItem(ctx context.Context, id string) (*_model.ChecklistItem, error)
+	ReorderChecklist(ctx context.Context, taskID string, itemIDs []string) ([]*_model.ChecklistItem, error)
+	DeleteChecklistItem(ctx context.Context, id string) (bool, error)
+	ConvertChecklistItemToSubtask(ctx context.Context, id string) (*_model.Task, error)
+}
+
+type ChecklistUsecase struct {
+	// Repo
+	checklistItemRepo _repo.ChecklistItemRepositoryInterface
+	taskRepo          _repo.TaskRepositoryInterface
+	txRepo            _repo.TransactionRepositoryInterface
+	// PubSub
+	taskPubSub _pubsub.TaskPubSubInterface
+}
+
+func NewChecklistUsecase(
+	checklistItemRepo _repo.ChecklistItemRepositoryInterface,
+	taskRepo _repo.TaskRepositoryInterface,
+	txRepo _repo.TransactionRepositoryInterface,
+	taskPubSub _pubsub.TaskPubSubInterface) ChecklistUsecaseInterface {
+	return &ChecklistUsecase{
+		checklistItemRepo: checklistItemRepo,
+		taskRepo:          taskRepo,
+		txRepo:            txRepo,
+		taskPubSub:        taskPubSub,
+	}
+}
+
+func (uc *ChecklistUsecase) AddChecklistItem(ctx context.Context, taskID string, text string) (*_model.ChecklistItem, error) {
+	logs.Infof("AddChecklistItem:: Starting with task %s", taskID)
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := uc.getWritableTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := uc.checklistItemRepo.CreateChecklistItem(ctx, &_model.ChecklistItem{
+		TaskID: task.ID,
+		Text:   text,
+		Base: _model.Base{
+			CreatedBy:  actorID(userCtx),
+			ModifiedBy: actorID(userCtx),
+		},
+	})
+	if err != nil {
+		logs.Errorf("AddChecklistItem:: Error CreateChecklistItem repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	// The progress of the task changed
+	uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+
+	logs.Info("AddChecklistItem:: Finish AddChecklistItem")
+
+	return item, nil
+}
+
+func (uc *ChecklistUsecase) ToggleChecklistItem(ctx context.Context, id string) (*_model.ChecklistItem, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := uc.checklistItemRepo.GetChecklistItemByID(ctx, id)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Checklist item not found")
+	}
+	task, err := uc.getWritableTask(ctx, item.TaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedItem, err := uc.checklistItemRepo.SetChecklistItemDone(ctx, item.ID, !item.IsDone, actorID(userCtx))
+	if err != nil {
+		logs.Errorf("ToggleChecklistItem:: Error SetChecklistItemDone repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+
+	return updatedItem, nil
+}
+
+// ReorderChecklist applies the order of itemIDs to the task checklist, every item has to be listed exactly once
+func (uc *ChecklistUsecase) ReorderChecklist(ctx context.Context, taskID string, itemIDs []string) ([]*_model.ChecklistItem, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := uc.getWritableTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*_model.ChecklistItem
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		// Lock the task so concurrent additions do not slip past the completeness check
+		if err := uc.taskRepo.LockTasks(ctx, []string{task.ID}); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+
+		existingItems, err := uc.checklistItemRepo.GetChecklistItemsByTaskIDs(ctx, []string{task.ID})
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if !isSameChecklist(existingItems, itemIDs) {
+			return _customErr.NewGraphQLError(http.StatusBadRequest, "itemIds must list every checklist item of the task exactly once")
+		}
+
+		items, err = uc.checklistItemRepo.ReorderChecklistItems(ctx, task.ID, itemIDs, actorID(userCtx))
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("ReorderChecklist:: Error reordering the checklist: %v", err)
+		return nil, err
+	}
+
+	uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+
+	return items, nil
+}
+
+func (uc *ChecklistUsecase) DeleteChecklistItem(ctx context.Context, id string) (bool, error) {
+	item, err := uc.checklistItemRepo.GetChecklistItemByID(ctx, id)
+	if err != nil {
+		return false, _customErr.NewGraphQLError(http.StatusNotFound, "Checklist item not found")
+	}
+	task, err := uc.getWritableTask(ctx, item.TaskID)
+	if err != nil {
+		return false, err
+	}
+
+	if err = uc.checklistItemRepo.DeleteChecklistItem(ctx, item.ID); err != nil {
+		logs.Errorf("DeleteChecklistItem:: Error DeleteChecklistItem repo: %v", err)
+		return false, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+
+	return true, nil
+}
+
+// ConvertChecklistItemToSubtask replaces the item with a subtask of its task, a checked item becomes a done subtask
+func (uc *ChecklistUsecase) ConvertChecklistItemToSubtask(ctx context.Context, id string) (*_model.Task, error) {
+	logs.Infof("ConvertChecklistItemToSubtask:: Starting with item %s", id)
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var task, subtask *_model.Task
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		item, err := uc.checklistItemRepo.GetChecklistItemByID(ctx, id)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusNotFound, "Checklist item not found")
+		}
+
+		// Lock the task and read the item again so a concurrent conversion cannot create a second subtask
+		if err = uc.taskRepo.LockTasks(ctx, []string{item.TaskID}); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		item, err = uc.checklistItemRepo.GetChecklistItemByID(ctx, id)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusNotFound, "Checklist item not found")
+		}
+
+		task, err = uc.getWritableTask(ctx, item.TaskID)
+		if err != nil {
+			return err
+		}
+
+		status := _const.TASK_STATUS_TODO
+		if item.IsDone {
+			status = _const.TASK_STATUS_DONE
+		}
+
+		taskRank, err := rankAtPosition(ctx, uc.taskRepo, _model.TaskColumn{TeamID: task.TeamID, Status: status}, 0, nil)
+		if err != nil {
+			return err
+		}
+
+		subtask, err = uc.taskRepo.CreateTask(ctx, &_model.Task{
+			Title:    item.Text,
+			Status:   status,
+			TeamID:   task.TeamID,
+			ParentID: &task.ID,
+			Priority: _model.TaskPriorityNone,
+			Rank:     taskRank,
+			DueDate:  task.DueDate,
+			Base: _model.Base{
+				CreatedBy:  actorID(userCtx),
+				ModifiedBy: actorID(userCtx),
+			},
+		})
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+
+		if err = uc.checklistItemRepo.DeleteChecklistItem(ctx, item.ID); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("ConvertChecklistItemToSubtask:: Error converting the item: %v", err)
+		return nil, err
+	}
+
+	uc.taskPubSub.Publish(task.TeamID, _const.CREATED, subtask)
+	uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+
+	logs.Info("ConvertChecklistItemToSubtask:: Finish ConvertChecklistItemToSubtask")
+
+	return subtask, nil
+}
+
+// getWritableTask returns the task owning a checklist, archived teams are read-only
+func (uc *ChecklistUsecase) getWritableTask(ctx context.Context, taskID string) (*_model.Task, error) {
+	task, err := uc.taskRepo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+	}
+	if err = ensureTeamWritable(task.Team); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// isSameChecklist reports whether ids lists every item exactly once
+func isSameChecklist(items []*_model.ChecklistItem, ids []string) bool {
+	if len(items) != len(ids) {
+		return false
+	}
+
+	remaining := make(map[string]bool, len(items))
+	for _, item := range items {
+		remaining[item.ID] = true
+	}
+	for _, id := range ids {
+		if !remaining[id] {
+			return false
+		}
+		delete(remaining, id)
+	}
+	return true
+}
+
+// ComputeTaskProgress counts the done direct subtasks and the checked checklist items of a task
+func ComputeTaskProgress(subtasks []*_model.Task, items []*_model.ChecklistItem) *_genModel.TaskProgress {
+	progress := &_genModel.TaskProgress{
+		Total: int32(len(subtasks) + len(items)),
+	}
+	for _, subtask := range subtasks {
+		if subtask.Status == _const.TASK_STATUS_DONE {
+			progress.Completed++
+		}
+	}
+	for _, item := range items {
+		if item.IsDone {
+			progress.Completed++
+		}
+	}
+
+	if progress.Total > 0 {
+		progress.Percent = float64(progress.Completed) * 100 / float64(progress.Total)
+	}
+	return progress
+}
@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_pubsub "bitbucket.org/edts/go-task-management/internal/pubsub"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+)
+
+type CommentUsecaseInterface interface {
+	AddComment(ctx context.Context, taskID string, content string) (*_model.Comment, error)
+	UpdateComment(ctx context.Context, id string, content string) (*_model.Comment, error)
+	DeleteComment(ctx context.Context, id string) (bool, error)
+}
+
+type CommentUsecase struct {
+	// Repo
+	commentRepo  _repo.CommentRepositoryInterface
+	taskRepo     _repo.TaskRepositoryInterface
+	teamRepo     _repo.TeamRepositoryInterface
+	userTeamRepo _repo.UserTeamRepositoryInterface
+	txRepo       _repo.TransactionRepositoryInterface
+	// PubSub
+	taskPubSub _pubsub.TaskPubSubInterface
+	// Usecase
+	mentionUsecase MentionUsecaseInterface
+	watcherUsecase WatcherUsecaseInterface
+}
+
+func NewCommentUsecase(
+	commentRepo _repo.CommentRepositoryInterface,
+	taskRepo _repo.TaskRepositoryInterface,
+	teamRepo _repo.TeamRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface,
+	txRepo _repo.TransactionRepositoryInterface,
+	taskPubSub _pubsub.TaskPubSubInterface,
+	mentionUsecase MentionUsecaseInterface,
+	watcherUsecase WatcherUsecaseInterface) CommentUsecaseInterface {
+	return &CommentUsecase{
+		commentRepo:    commentRepo,
+		taskRepo:       taskRepo,
+		teamRepo:       teamRepo,
+		userTeamRepo:   userTeamRepo,
+		txRepo:         txRepo,
+		taskPubSub:     taskPubSub,
+		mentionUsecase: mentionUsecase,
+		watcherUsecase: watcherUsecase,
+	}
+}
+
+func (uc *CommentUsecase) AddComment(ctx context.Context, taskID string, content string) (*_model.Comment, error) {
+	logs.Infof("AddComment:: Starting with task %s", taskID)
+	task, err := uc.taskRepo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, task.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	mentioned, err := uc.mentionUsecase.ResolveMentions(ctx, task.TeamID, &content)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &_model.Comment{
+		TaskID:  task.ID,
+		Content: content,
+	}
+	// Service accounts are not users, their comments have no author
+	if !userCtx.IsServiceAccount() {
+		comment.UserID = &userCtx.UserID
+	}
+
+	var createdComment *_model.Comment
+	var added []string
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		createdComment, err = uc.commentRepo.CreateComment(ctx, comment)
+		if err != nil {
+			logs.Errorf("AddComment:: Error CreateComment repo: %v", err)
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+
+		added, err = uc.mentionUsecase.SaveMentions(ctx, task, &createdComment.ID, mentioned)
+		if err != nil || createdComment.UserID == nil {
+			return err
+		}
+		// Commenters follow the rest of the discussion
+		return uc.watcherUsecase.AddWatchers(ctx, task.ID, []string{*createdComment.UserID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+	uc.mentionUsecase.NotifyMentions(ctx, task, &createdComment.ID, added, content)
+	uc.watcherUsecase.NotifyWatchers(ctx, task, _model.NotificationKindCommented, &createdComment.ID, content, added)
+
+	logs.Info("AddComment:: Finish AddComment")
+
+	return createdComment, nil
+}
+
+// UpdateComment changes the content of a comment, only its author can edit it.
+// Members mentioned for the first time are notified, removed mentions are dropped
+func (uc *CommentUsecase) UpdateComment(ctx context.Context, id string, content string) (*_model.Comment, error) {
+	comment, task, err := uc.getEditableComment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID == nil || *comment.UserID != userCtx.UserID {
+		return nil, _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: only the author can edit a comment")
+	}
+
+	mentioned, err := uc.mentionUsecase.ResolveMentions(ctx, task.TeamID, &content)
+	if err != nil {
+		return nil, err
+	}
+
+	var updatedComment *_model.Comment
+	var added []string
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		updatedComment, err = uc.commentRepo.UpdateComment(ctx, comment.ID, content)
+		if err != nil {
+			logs.Errorf("UpdateComment:: Error UpdateComment repo: %v", err)
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+
+		added, err = uc.mentionUsecase.SaveMentions(ctx, task, &comment.ID, mentioned)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+	uc.mentionUsecase.NotifyMentions(ctx, task, &comment.ID, added, content)
+
+	return updatedComment, nil
+}
+
+// DeleteComment removes a comment, allowed to its author and to the admins of the team
+func (uc *CommentUsecase) DeleteComment(ctx context.Context, id string) (bool, error) {
+	comment, task, err := uc.getEditableComment(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if comment.UserID == nil || *comment.UserID != userCtx.UserID {
+		role, err := getTeamRole(ctx, uc.userTeamRepo, userCtx, task.TeamID)
+		if err != nil {
+			return false, err
+		}
+		if !role.AtLeast(_model.TeamRoleAdmin) {
+			return false, _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: only the author or a team admin can delete a comment")
+		}
+	}
+
+	if err = uc.commentRepo.DeleteComment(ctx, comment.ID); err != nil {
+		logs.Errorf("DeleteComment:: Error DeleteComment repo: %v", err)
+		return false, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+
+	return true, nil
+}
+
+// getEditableComment returns the comment with its task, rejecting callers that cannot edit the team content
+func (uc *CommentUsecase) getEditableComment(ctx context.Context, id string) (*_model.Comment, *_model.Task, error) {
+	comment, err := uc.commentRepo.GetCommentByID(ctx, id)
+	if err != nil {
+		return nil, nil, _customErr.NewGraphQLError(http.StatusNotFound, "Comment not found")
+	}
+	task, err := uc.taskRepo.GetTaskByID(ctx, comment.TaskID)
+	if err != nil {
+		return nil, nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+	}
+	if _, err = ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, task.TeamID); err != nil {
+		return nil, nil, err
+	}
+	return comment, task, nil
+}
@@ -0,0 +1,294 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	_config "bitbucket.org/edts/go-task-management/config"
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"bitbucket.org/edts/go-task-management/pkg/mailer"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type InvitationUsecaseInterface interface {
+	InviteToTeam(ctx context.Context, teamID string, email string, role _model.TeamRole) (*_model.TeamInvitation, error)
+	AcceptInvitation(ctx context.Context, token string) (*_model.Team, error)
+	DeclineInvitation(ctx context.Context, token string) (bool, error)
+	GetPendingInvitations(ctx context.Context, teamID *string) ([]*_model.TeamInvitation, error)
+	GetInvitedTeam(ctx context.Context, invitation *_model.TeamInvitation) (*_model.Team, error)
+}
+
+// defaultInvitationTTL is used when the invitation section is missing from config
+const defaultInvitationTTL = 7 * 24 * time.Hour
+
+type InvitationUsecase struct {
+	invitationRepo _repo.TeamInvitationRepositoryInterface
+	teamRepo       _repo.TeamRepositoryInterface
+	userRepo       _repo.UserRepositoryInterface
+	userTeamRepo   _repo.UserTeamRepositoryInterface
+	mailer         mailer.Mailer
+}
+
+func NewInvitationUsecase(
+	invitationRepo _repo.TeamInvitationRepositoryInterface,
+	teamRepo _repo.TeamRepositoryInterface,
+	userRepo _repo.UserRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface,
+	mailer mailer.Mailer) InvitationUsecaseInterface {
+	return &InvitationUsecase{
+		invitationRepo: invitationRepo,
+		teamRepo:       teamRepo,
+		userRepo:       userRepo,
+		userTeamRepo:   userTeamRepo,
+		mailer:         mailer,
+	}
+}
+
+func (uc *InvitationUsecase) InviteToTeam(ctx context.Context, teamID string, email string, role _model.TeamRole) (*_model.TeamInvitation, error) {
+	logs.Infof("InviteToTeam:: Starting with variables teamId: %s and role: %s", teamID, role)
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !role.IsValid() {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid team role")
+	}
+
+	team, err := uc.teamRepo.GetTeamByID(ctx, teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+	if err = ensureTeamWritable(team); err != nil {
+		return nil, err
+	}
+
+	// Members can invite members, granting a higher role requires holding it
+	callerRole, err := getTeamRole(ctx, uc.userTeamRepo, userCtx, team.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !callerRole.AtLeast(role) {
+		return nil, _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: cannot invite with a role higher than your own")
+	}
+
+	email = strings.TrimSpace(email)
+	existingUser, err := uc.userRepo.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if existingUser != nil {
+		isMember, err := uc.userTeamRepo.IsUserInTeam(ctx, existingUser.ID, team.ID)
+		if err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if isMember {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "User is already a member of this team")
+		}
+	}
+
+	rawToken, err := generateSecretToken(_const.TEAM_INVITATION_TOKEN_PREFIX)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, "Failed to generate invitation token")
+	}
+
+	// Inviting an email again replaces the previous invitation
+	if err = uc.invitationRepo.RevokePendingTeamInvitations(ctx, team.ID, email); err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	invitedBy, _ := uuid.Parse(userCtx.UserID)
+	invitation, err := uc.invitationRepo.CreateTeamInvitation(ctx, &_model.TeamInvitation{
+		TeamID:    team.ID,
+		Email:     email,
+		Role:      role,
+		TokenHash: hashSecretToken(rawToken),
+		ExpiresAt: time.Now().Add(getInvitationTTL()),
+		Base: _model.Base{
+			CreatedBy:  &invitedBy,
+			ModifiedBy: &invitedBy,
+		},
+	})
+	if err != nil {
+		logs.Errorf("InviteToTeam:: Error CreateTeamInvitation repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = uc.mailer.Send(ctx, invitationMessage(invitation, team, userCtx.Email, rawToken)); err != nil {
+		logs.Errorf("InviteToTeam:: Error sending invitation email: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, "Failed to send invitation email")
+	}
+
+	logs.Info("InviteToTeam:: Finish InviteToTeam")
+
+	return invitation, nil
+}
+
+func (uc *InvitationUsecase) AcceptInvitation(ctx context.Context, token string) (*_model.Team, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if userCtx.IsServiceAccount() {
+		return nil, _customErr.NewGraphQLError(http.StatusForbidden, "service accounts cannot accept invitations")
+	}
+
+	teamID, err := acceptTeamInvitation(ctx, uc.invitationRepo, token, userCtx.UserID, userCtx.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	// The team usually belongs to another workspace than the one of the request
+	team, err := uc.teamRepo.GetTeamByID(_repo.WithoutWorkspaceIsolation(ctx), teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+	return team, nil
+}
+
+func (uc *InvitationUsecase) DeclineInvitation(ctx context.Context, token string) (bool, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	invitation, err := getPendingInvitation(ctx, uc.invitationRepo, token, userCtx.Email)
+	if err != nil {
+		return false, err
+	}
+
+	if err = uc.invitationRepo.DeclineTeamInvitation(_repo.WithoutWorkspaceIsolation(ctx), invitation.ID, userCtx.UserID); err != nil {
+		return false, _customErr.NewGraphQLError(http.StatusBadRequest, "Invitation is no longer pending")
+	}
+	return true, nil
+}
+
+func (uc *InvitationUsecase) GetPendingInvitations(ctx context.Context, teamID *string) ([]*_model.TeamInvitation, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var invitations []*_model.TeamInvitation
+	if teamID != nil {
+		if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, *teamID); err != nil {
+			return nil, err
+		}
+		invitations, err = uc.invitationRepo.GetPendingTeamInvitationsByTeamID(ctx, *teamID)
+	} else {
+		// Invitations sent to the caller span every workspace
+		invitations, err = uc.invitationRepo.GetPendingTeamInvitationsByEmail(_repo.WithoutWorkspaceIsolation(ctx), userCtx.Email)
+	}
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	return invitations, nil
+}
+
+// GetInvitedTeam lets the invitee see the team they are invited to before joining its workspace
+func (uc *InvitationUsecase) GetInvitedTeam(ctx context.Context, invitation *_model.TeamInvitation) (*_model.Team, error) {
+	team, err := uc.teamRepo.GetTeamByID(_repo.WithoutWorkspaceIsolation(ctx), invitation.TeamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+	return team, nil
+}
+
+// acceptTeamInvitation joins the user to the inviting team and its workspace, shared by acceptInvitation and registration.
+// The token proves the invitee received the email, the email check prevents forwarding it to someone else
+func acceptTeamInvitation(
+	ctx context.Context,
+	invitationRepo _repo.TeamInvitationRepositoryInterface,
+	token string,
+	userID string,
+	email string) (string, error) {
+	// The invitee is not part of the workspace of the team yet
+	ctx = _repo.WithoutWorkspaceIsolation(ctx)
+	invitation, err := getPendingInvitation(ctx, invitationRepo, token, email)
+	if err != nil {
+		return "", err
+	}
+
+	teamID, err := invitationRepo.AcceptTeamInvitation(ctx, invitation.ID, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", _customErr.NewGraphQLError(http.StatusBadRequest, "Invitation is no longer pending")
+	}
+	if err != nil {
+		return "", _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	return teamID, nil
+}
+
+// acceptPendingInvitations joins a new user to every team that invited their email, shared by registration and the
+// first single sign-on login. Invitations answered in the meantime are skipped
+func acceptPendingInvitations(
+	ctx context.Context,
+	invitationRepo _repo.TeamInvitationRepositoryInterface,
+	userID string,
+	email string) error {
+	// The invitee is not part of the workspaces of the teams yet
+	ctx = _repo.WithoutWorkspaceIsolation(ctx)
+	invitations, err := invitationRepo.GetPendingTeamInvitationsByEmail(ctx, email)
+	if err != nil {
+		return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	for _, invitation := range invitations {
+		_, err = invitationRepo.AcceptTeamInvitation(ctx, invitation.ID, userID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+	}
+	return nil
+}
+
+func getPendingInvitation(ctx context.Context, invitationRepo _repo.TeamInvitationRepositoryInterface, token string, email string) (*_model.TeamInvitation, error) {
+	invitation, err := invitationRepo.GetTeamInvitationByTokenHash(_repo.WithoutWorkspaceIsolation(ctx), hashSecretToken(token))
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Invitation not found")
+	}
+	if !strings.EqualFold(invitation.Email, email) {
+		return nil, _customErr.NewGraphQLError(http.StatusForbidden, "This invitation was sent to another email")
+	}
+	if invitation.Status != _model.InvitationStatusPending {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invitation is no longer pending")
+	}
+	if !invitation.ExpiresAt.After(time.Now()) {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invitation has expired")
+	}
+	return invitation, nil
+}
+
+func invitationMessage(invitation *_model.TeamInvitation, team *_model.Team, inviterEmail string, rawToken string) mailer.Message {
+	acceptURL := _config.AppConfigInstance.Invitation.AcceptURL + "?token=" + url.QueryEscape(rawToken)
+
+	return mailer.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("You have been invited to join %s", team.Name),
+		Body: fmt.Sprintf(
+			"%s invited you to join the team %s as %s.\n\nAccept the invitation: %s\n\nThe invitation expires on %s.",
+			inviterEmail, team.Name, strings.ToLower(string(invitation.Role)), acceptURL,
+			invitation.ExpiresAt.Format(time.RFC1123),
+		),
+	}
+}
+
+func getInvitationTTL() time.Duration {
+	if ttl := _config.AppConfigInstance.Invitation.TTL; ttl > 0 {
+		return ttl
+	}
+	return defaultInvitationTTL
+}
@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_pubsub "bitbucket.org/edts/go-task-management/internal/pubsub"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type LabelUsecaseInterface interface {
+	CreateLabel(ctx context.Context, input _genModel.CreateLabelInput) (*_model.Label, error)
+	UpdateLabel(ctx context.Context, input _genModel.UpdateLabelInput) (*_model.Label, error)
+	DeleteLabel(ctx context.Context, id string) (bool, error)
+	GetLabelsByTeam(ctx context.Context, teamID string) ([]*_model.Label, error)
+	SetTaskLabels(ctx context.Context, taskID string, labelIDs []string) (*_model.Task, error)
+}
+
+type LabelUsecase struct {
+	// Repo
+	labelRepo    _repo.LabelRepositoryInterface
+	taskRepo     _repo.TaskRepositoryInterface
+	teamRepo     _repo.TeamRepositoryInterface
+	userTeamRepo _repo.UserTeamRepositoryInterface
+	txRepo       _repo.TransactionRepositoryInterface
+	// PubSub
+	taskPubSub _pubsub.TaskPubSubInterface
+}
+
+func NewLabelUsecase(
+	labelRepo _repo.LabelRepositoryInterface,
+	taskRepo _repo.TaskRepositoryInterface,
+	teamRepo _repo.TeamRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface,
+	txRepo _repo.TransactionRepositoryInterface,
+	taskPubSub _pubsub.TaskPubSubInterface) LabelUsecaseInterface {
+	return &LabelUsecase{
+		labelRepo:    labelRepo,
+		taskRepo:     taskRepo,
+		teamRepo:     teamRepo,
+		userTeamRepo: userTeamRepo,
+		txRepo:       txRepo,
+		taskPubSub:   taskPubSub,
+	}
+}
+
+func (uc *LabelUsecase) CreateLabel(ctx context.Context, input _genModel.CreateLabelInput) (*_model.Label, error) {
+	logs.Infof("CreateLabel:: Starting with payload %v", input)
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, input.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	label, err := uc.labelRepo.CreateLabel(ctx, &_model.Label{
+		TeamID: input.TeamID,
+		Name:   strings.TrimSpace(input.Name),
+		Color:  strings.ToLower(input.Color),
+		Base: _model.Base{
+			CreatedBy:  actorID(userCtx),
+			ModifiedBy: actorID(userCtx),
+		},
+	})
+	if err != nil {
+		logs.Errorf("CreateLabel:: Error CreateLabel repo: %v", err)
+		return nil, labelError(err)
+	}
+
+	logs.Info("CreateLabel:: Finish CreateLabel")
+
+	return label, nil
+}
+
+// UpdateLabel renames or recolors the label, the tasks carrying it are published as updated
+func (uc *LabelUsecase) UpdateLabel(ctx context.Context, input _genModel.UpdateLabelInput) (*_model.Label, error) {
+	logs.Infof("UpdateLabel:: Starting with payload %v", input)
+	label, err := uc.labelRepo.GetLabelByID(ctx, input.ID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Label not found")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, label.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update only the fields that are provided
+	if input.Name != nil {
+		label.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Color != nil {
+		label.Color = strings.ToLower(*input.Color)
+	}
+	label.ModifiedBy = actorID(userCtx)
+
+	updatedLabel, err := uc.labelRepo.UpdateLabel(ctx, label)
+	if err != nil {
+		logs.Errorf("UpdateLabel:: Error UpdateLabel repo: %v", err)
+		return nil, labelError(err)
+	}
+
+	taskIDs, err := uc.labelRepo.GetTaskIDsByLabelID(ctx, label.ID)
+	if err != nil {
+		logs.Errorf("UpdateLabel:: Error GetTaskIDsByLabelID repo: %v", err)
+		return updatedLabel, nil
+	}
+	uc.publishTasks(ctx, taskIDs)
+
+	logs.Info("UpdateLabel:: Finish UpdateLabel")
+
+	return updatedLabel, nil
+}
+
+// DeleteLabel removes the label from the palette and from every task carrying it
+func (uc *LabelUsecase) DeleteLabel(ctx context.Context, id string) (bool, error) {
+	logs.Infof("DeleteLabel:: Starting with label %s", id)
+	label, err := uc.labelRepo.GetLabelByID(ctx, id)
+	if err != nil {
+		return false, _customErr.NewGraphQLError(http.StatusNotFound, "Label not found")
+	}
+	if _, err = ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, label.TeamID); err != nil {
+		return false, err
+	}
+
+	// Collect the labelled tasks before the cascade removes the links
+	var taskIDs []string
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		taskIDs, err = uc.labelRepo.GetTaskIDsByLabelID(ctx, label.ID)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if err = uc.labelRepo.DeleteLabel(ctx, label.ID); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("DeleteLabel:: Error deleting the label: %v", err)
+		return false, err
+	}
+
+	uc.publishTasks(ctx, taskIDs)
+
+	logs.Info("DeleteLabel:: Finish DeleteLabel")
+
+	return true, nil
+}
+
+func (uc *LabelUsecase) GetLabelsByTeam(ctx context.Context, teamID string) ([]*_model.Label, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, teamID); err != nil {
+		return nil, err
+	}
+
+	labels, err := uc.labelRepo.GetLabelsByTeamID(ctx, teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return labels, nil
+}
+
+// SetTaskLabels replaces the labels of the task, every label must come from the palette of the task team
+func (uc *LabelUsecase) SetTaskLabels(ctx context.Context, taskID string, labelIDs []string) (*_model.Task, error) {
+	logs.Infof("SetTaskLabels:: Starting with task %s and labels %v", taskID, labelIDs)
+	task, err := uc.taskRepo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, task.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	labelIDs = uniqueIDs(labelIDs)
+	if len(labelIDs) > 0 {
+		count, err := uc.labelRepo.CountTeamLabels(ctx, task.TeamID, labelIDs)
+		if err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if count != len(labelIDs) {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Labels must belong to the team of the task")
+		}
+	}
+
+	// The delete and insert of the label links happen together
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		return uc.labelRepo.SetTaskLabels(ctx, task.ID, labelIDs, actorID(userCtx))
+	})
+	if err != nil {
+		logs.Errorf("SetTaskLabels:: Error SetTaskLabels repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Publish taskUpdated event
+	uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+
+	logs.Info("SetTaskLabels:: Finish SetTaskLabels")
+
+	return task, nil
+}
+
+// publishTasks publishes the tasks as updated, failures are only logged since the change is already saved
+func (uc *LabelUsecase) publishTasks(ctx context.Context, taskIDs []string) {
+	if len(taskIDs) == 0 {
+		return
+	}
+
+	tasks, err := uc.taskRepo.GetTasksByIDs(ctx, taskIDs)
+	if err != nil {
+		logs.Errorf("publishTasks:: Error GetTasksByIDs repo: %v", err)
+		return
+	}
+	for _, task := range tasks {
+		uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+	}
+}
+
+// labelError turns the unique name violation into a readable error
+func labelError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return _customErr.NewGraphQLError(http.StatusConflict, "A label with this name already exists in the team")
+	}
+	return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+}
@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"github.com/google/uuid"
+)
+
+type MentionUsecaseInterface interface {
+	GetMentionedMe(ctx context.Context, limit *int32) ([]*_model.Mention, error)
+	ResolveMentions(ctx context.Context, teamID string, text *string) ([]string, error)
+	SaveMentions(ctx context.Context, task *_model.Task, commentID *string, userIDs []string) ([]string, error)
+	NotifyMentions(ctx context.Context, task *_model.Task, commentID *string, userIDs []string, text string)
+	GetMentionedTasks(ctx context.Context, text *string) ([]*_model.Task, error)
+}
+
+var (
+	// mentionCodePattern matches code blocks and spans, an @ inside code is not a mention
+	mentionCodePattern = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+	// mentionPattern matches @email and @handle not preceded by a word character, so foo@bar.com is not a mention
+	mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@.])@([\p{L}\p{N}._%+-]+@[\p{L}\p{N}.-]+\.[\p{L}]{2,}|[\p{L}\p{N}_.-]+)`)
+)
+
+type MentionUsecase struct {
+	// Repo
+	mentionRepo  _repo.MentionRepositoryInterface
+	userTeamRepo _repo.UserTeamRepositoryInterface
+	taskRepo     _repo.TaskRepositoryInterface
+	// Usecase
+	watcherUsecase      WatcherUsecaseInterface
+	notificationUsecase NotificationUsecaseInterface
+}
+
+func NewMentionUsecase(
+	mentionRepo _repo.MentionRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface,
+	taskRepo _repo.TaskRepositoryInterface,
+	watcherUsecase WatcherUsecaseInterface,
+	notificationUsecase NotificationUsecaseInterface) MentionUsecaseInterface {
+	return &MentionUsecase{
+		mentionRepo:         mentionRepo,
+		userTeamRepo:        userTeamRepo,
+		taskRepo:            taskRepo,
+		watcherUsecase:      watcherUsecase,
+		notificationUsecase: notificationUsecase,
+	}
+}
+
+// GetMentionedMe lists the latest mentions of the caller in the teams they belong to
+func (uc *MentionUsecase) GetMentionedMe(ctx context.Context, limit *int32) ([]*_model.Mention, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mentions, err := uc.mentionRepo.GetMentionsByUserID(ctx, userCtx.UserID, notificationsPageSize(limit))
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return mentions, nil
+}
+
+// ResolveMentions returns the members of the team referenced in text with @email or @handle, a handle being the
+// name of the member without spaces or with dots instead, or the local part of their email.
+// An email outside of the team is rejected so mentions cannot reach people the team was not shared with,
+// a handle matching no member is likely plain text and only reported with a warning.
+// Task keys such as @ENG-142 mention tasks and are left to GetMentionedTasks
+func (uc *MentionUsecase) ResolveMentions(ctx context.Context, teamID string, text *string) ([]string, error) {
+	var tokens []string
+	for _, token := range parseMentions(text) {
+		if _, ok := taskKeyMention(token); !ok {
+			tokens = append(tokens, token)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	team, err := uc.userTeamRepo.GetAssigneeByTeamId(ctx, teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	var userIDs []string
+	var unresolved []string
+	for _, token := range tokens {
+		if strings.Contains(token, "@") {
+			member := findMemberByEmail(team.Users, token)
+			if member == nil {
+				return nil, _customErr.NewGraphQLError(http.StatusBadRequest, fmt.Sprintf("Cannot mention %s: not a member of the team", token))
+			}
+			userIDs = append(userIDs, member.ID)
+			continue
+		}
+
+		members := findMembersByHandle(team.Users, token)
+		switch len(members) {
+		case 0:
+			unresolved = append(unresolved, "@"+token)
+		case 1:
+			userIDs = append(userIDs, members[0].ID)
+		default:
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, fmt.Sprintf("@%s matches several members of the team, mention them by email", token))
+		}
+	}
+
+	if len(unresolved) > 0 {
+		addWarning(ctx, "MENTION_UNRESOLVED", fmt.Sprintf("No member of the team matches %s, not notified", strings.Join(unresolved, ", ")))
+	}
+	return uniqueIDs(userIDs), nil
+}
+
+// SaveMentions records userIDs as the members mentioned by the description of the task, or by the comment when
+// commentID is set, and returns the newly mentioned ones. Mentioned members start watching the task
+func (uc *MentionUsecase) SaveMentions(ctx context.Context, task *_model.Task, commentID *string, userIDs []string) ([]string, error) {
+	// Background jobs have no caller
+	var createdBy *uuid.UUID
+	if userCtx, err := userFromContext(ctx); err == nil {
+		createdBy = actorID(userCtx)
+	}
+
+	added, err := uc.mentionRepo.SetMentions(ctx, task.ID, commentID, userIDs, createdBy)
+	if err != nil {
+		logs.Errorf("SaveMentions:: Error SetMentions repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if err = uc.watcherUsecase.AddWatchers(ctx, task.ID, added); err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// NotifyMentions notifies the newly mentioned members once the change is committed
+func (uc *MentionUsecase) NotifyMentions(ctx context.Context, task *_model.Task, commentID *string, userIDs []string, text string) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	notification := &_model.Notification{
+		Kind:      _model.NotificationKindMentioned,
+		TaskID:    task.ID,
+		CommentID: commentID,
+		ActorID:   notificationActor(ctx),
+	}
+	uc.notificationUsecase.Notify(ctx, userIDs, notification, task, text)
+}
+
+// GetMentionedTasks returns the tasks referenced in text by their key, e.g. @ENG-142. Former keys of the tasks
+// moved to another team resolve too, keys matching no task are plain text
+func (uc *MentionUsecase) GetMentionedTasks(ctx context.Context, text *string) ([]*_model.Task, error) {
+	var keys []_model.TaskKey
+	for _, token := range parseMentions(text) {
+		if key, ok := taskKeyMention(token); ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return []*_model.Task{}, nil
+	}
+
+	tasks, err := uc.taskRepo.GetTasksByKeys(ctx, keys)
+	if err != nil {
+		logs.Errorf("GetMentionedTasks:: Error GetTasksByKeys repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return tasks, nil
+}
+
+// taskKeyMention reads a mention token written as an upper case task key, so handles such as @ana-2 stay handles
+func taskKeyMention(token string) (_model.TaskKey, bool) {
+	if token != strings.ToUpper(token) {
+		return _model.TaskKey{}, false
+	}
+	return _model.ParseTaskKey(token)
+}
+
+// parseMentions returns the distinct @references of text outside of code, without the leading @
+func parseMentions(text *string) []string {
+	if text == nil || !strings.Contains(*text, "@") {
+		return nil
+	}
+
+	var tokens []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(mentionCodePattern.ReplaceAllString(*text, " "), -1) {
+		// Sentence punctuation is not part of the handle
+		token := strings.TrimRight(match[1], ".-")
+		key := strings.ToLower(token)
+		if token == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+func findMemberByEmail(members []*_model.User, email string) *_model.User {
+	for _, member := range members {
+		if strings.EqualFold(member.Email, email) {
+			return member
+		}
+	}
+	return nil
+}
+
+func findMembersByHandle(members []*_model.User, handle string) []*_model.User {
+	handle = strings.ToLower(handle)
+
+	var matches []*_model.User
+	for _, member := range members {
+		name := strings.Join(strings.Fields(strings.ToLower(member.Name)), " ")
+		localPart, _, _ := strings.Cut(strings.ToLower(member.Email), "@")
+		if handle == strings.ReplaceAll(name, " ", "") || handle == strings.ReplaceAll(name, " ", ".") || handle == localPart {
+			matches = append(matches, member)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,303 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_pubsub "bitbucket.org/edts/go-task-management/internal/pubsub"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+)
+
+type MilestoneUsecaseInterface interface {
+	CreateMilestone(ctx context.Context, input _genModel.CreateMilestoneInput) (*_model.Milestone, error)
+	UpdateMilestone(ctx context.Context, input _genModel.UpdateMilestoneInput) (*_model.Milestone, error)
+	DeleteMilestone(ctx context.Context, id string) (bool, error)
+	GetMilestonesByTeam(ctx context.Context, teamID string, state *_model.MilestoneState) ([]*_model.Milestone, error)
+	GetMilestoneByID(ctx context.Context, id string) (*_model.Milestone, error)
+	SetTasksMilestone(ctx context.Context, taskIDs []string, milestoneID *string) ([]*_model.Task, error)
+}
+
+type MilestoneUsecase struct {
+	// Repo
+	milestoneRepo _repo.MilestoneRepositoryInterface
+	taskRepo      _repo.TaskRepositoryInterface
+	teamRepo      _repo.TeamRepositoryInterface
+	userTeamRepo  _repo.UserTeamRepositoryInterface
+	txRepo        _repo.TransactionRepositoryInterface
+	// PubSub
+	taskPubSub _pubsub.TaskPubSubInterface
+}
+
+func NewMilestoneUsecase(
+	milestoneRepo _repo.MilestoneRepositoryInterface,
+	taskRepo _repo.TaskRepositoryInterface,
+	teamRepo _repo.TeamRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface,
+	txRepo _repo.TransactionRepositoryInterface,
+	taskPubSub _pubsub.TaskPubSubInterface) MilestoneUsecaseInterface {
+	return &MilestoneUsecase{
+		milestoneRepo: milestoneRepo,
+		taskRepo:      taskRepo,
+		teamRepo:      teamRepo,
+		userTeamRepo:  userTeamRepo,
+		txRepo:        txRepo,
+		taskPubSub:    taskPubSub,
+	}
+}
+
+func (uc *MilestoneUsecase) CreateMilestone(ctx context.Context, input _genModel.CreateMilestoneInput) (*_model.Milestone, error) {
+	logs.Infof("CreateMilestone:: Starting with payload %v", input)
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, input.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	targetDate, err := time.Parse(time.RFC3339, input.TargetDate)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid target date format")
+	}
+
+	milestone, err := uc.milestoneRepo.CreateMilestone(ctx, &_model.Milestone{
+		TeamID:      input.TeamID,
+		Name:        strings.TrimSpace(input.Name),
+		Description: input.Description,
+		TargetDate:  targetDate,
+		State:       _model.MilestoneStateOpen,
+		Base: _model.Base{
+			CreatedBy:  actorID(userCtx),
+			ModifiedBy: actorID(userCtx),
+		},
+	})
+	if err != nil {
+		logs.Errorf("CreateMilestone:: Error CreateMilestone repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	logs.Info("CreateMilestone:: Finish CreateMilestone")
+
+	return milestone, nil
+}
+
+// UpdateMilestone changes the milestone, completing it with open tasks is allowed and leaves the tasks as they are
+func (uc *MilestoneUsecase) UpdateMilestone(ctx context.Context, input _genModel.UpdateMilestoneInput) (*_model.Milestone, error) {
+	logs.Infof("UpdateMilestone:: Starting with payload %v", input)
+	milestone, err := uc.milestoneRepo.GetMilestoneByID(ctx, input.ID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Milestone not found")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, milestone.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update only the fields that are provided
+	if input.Name != nil {
+		milestone.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Description != nil {
+		milestone.Description = input.Description
+	}
+	if input.TargetDate != nil {
+		milestone.TargetDate, err = time.Parse(time.RFC3339, *input.TargetDate)
+		if err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid target date format")
+		}
+	}
+	if input.State != nil {
+		milestone.State = *input.State
+	}
+	milestone.ModifiedBy = actorID(userCtx)
+
+	updatedMilestone, err := uc.milestoneRepo.UpdateMilestone(ctx, milestone)
+	if err != nil {
+		logs.Errorf("UpdateMilestone:: Error UpdateMilestone repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	logs.Info("UpdateMilestone:: Finish UpdateMilestone")
+
+	return updatedMilestone, nil
+}
+
+// DeleteMilestone removes the milestone, its tasks are kept without milestone
+func (uc *MilestoneUsecase) DeleteMilestone(ctx context.Context, id string) (bool, error) {
+	logs.Infof("DeleteMilestone:: Starting with milestone %s", id)
+	milestone, err := uc.milestoneRepo.GetMilestoneByID(ctx, id)
+	if err != nil {
+		return false, _customErr.NewGraphQLError(http.StatusNotFound, "Milestone not found")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, milestone.TeamID)
+	if err != nil {
+		return false, err
+	}
+
+	var tasks []*_model.Task
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		milestoneTasks, err := uc.milestoneRepo.GetTasksByMilestoneIDs(ctx, []string{milestone.ID})
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if len(milestoneTasks) > 0 {
+			tasks, err = uc.milestoneRepo.SetTasksMilestone(ctx, taskIDsOf(milestoneTasks), nil, actorID(userCtx))
+			if err != nil {
+				return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+			}
+		}
+		if err = uc.milestoneRepo.DeleteMilestone(ctx, milestone.ID); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("DeleteMilestone:: Error deleting the milestone: %v", err)
+		return false, err
+	}
+
+	uc.publishTasks(tasks)
+
+	logs.Info("DeleteMilestone:: Finish DeleteMilestone")
+
+	return true, nil
+}
+
+func (uc *MilestoneUsecase) GetMilestonesByTeam(ctx context.Context, teamID string, state *_model.MilestoneState) ([]*_model.Milestone, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, teamID); err != nil {
+		return nil, err
+	}
+
+	milestones, err := uc.milestoneRepo.GetMilestonesByTeamID(ctx, teamID, state)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return milestones, nil
+}
+
+func (uc *MilestoneUsecase) GetMilestoneByID(ctx context.Context, id string) (*_model.Milestone, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	milestone, err := uc.milestoneRepo.GetMilestoneByID(ctx, id)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Milestone not found")
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, milestone.TeamID); err != nil {
+		return nil, err
+	}
+	return milestone, nil
+}
+
+// SetTasksMilestone assigns the tasks to an open milestone of their team, a nil milestoneID takes them out of their milestone
+func (uc *MilestoneUsecase) SetTasksMilestone(ctx context.Context, taskIDs []string, milestoneID *string) ([]*_model.Task, error) {
+	logs.Infof("SetTasksMilestone:: Starting with tasks %v and milestone %v", taskIDs, milestoneID)
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	taskIDs = uniqueIDs(taskIDs)
+	tasks, err := uc.taskRepo.GetTasksByIDs(ctx, taskIDs)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if len(tasks) != len(taskIDs) {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+	}
+
+	teamIDs := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		teamIDs = append(teamIDs, task.TeamID)
+	}
+	if milestoneID != nil {
+		milestone, err := uc.milestoneRepo.GetMilestoneByID(ctx, *milestoneID)
+		if err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Milestone not found")
+		}
+		if milestone.State == _model.MilestoneStateCompleted {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Tasks cannot be added to a completed milestone")
+		}
+		for _, teamID := range teamIDs {
+			if teamID != milestone.TeamID {
+				return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Tasks must belong to the team of the milestone")
+			}
+		}
+	}
+	for _, teamID := range uniqueIDs(teamIDs) {
+		if _, err = ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, teamID); err != nil {
+			return nil, err
+		}
+	}
+
+	updatedTasks, err := uc.milestoneRepo.SetTasksMilestone(ctx, taskIDs, milestoneID, actorID(userCtx))
+	if err != nil {
+		logs.Errorf("SetTasksMilestone:: Error SetTasksMilestone repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	uc.publishTasks(updatedTasks)
+
+	logs.Info("SetTasksMilestone:: Finish SetTasksMilestone")
+
+	return updatedTasks, nil
+}
+
+// publishTasks publishes the tasks as updated once the change is saved
+func (uc *MilestoneUsecase) publishTasks(tasks []*_model.Task) {
+	for _, task := range tasks {
+		uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+	}
+}
+
+// ComputeMilestoneProgress turns the task counts of a milestone into its progress, count is nil for a milestone without tasks.
+// Weighted progress counts story points, tasks without points do not weigh
+func ComputeMilestoneProgress(count *_model.MilestoneTaskCount, weighted bool) *_genModel.MilestoneProgress {
+	progress := &_genModel.MilestoneProgress{}
+	if count == nil {
+		return progress
+	}
+
+	progress.Completed = count.Completed
+	progress.Total = count.Total
+	if weighted {
+		progress.Completed = count.CompletedPoints
+		progress.Total = count.TotalPoints
+	}
+	if progress.Total > 0 {
+		progress.Percent = float64(progress.Completed) * 100 / float64(progress.Total)
+	}
+	return progress
+}
+
+// IsMilestoneAtRisk reports whether the open tasks of the milestone take longer than the time left before its target date
+// at the pace the team completed tasks since throughput.Since. A team that completed nothing lately cannot make any
+// progress at that pace, so open work is always at risk
+func IsMilestoneAtRisk(milestone *_model.Milestone, count *_model.MilestoneTaskCount, throughput *_model.TeamThroughput, now time.Time) bool {
+	if milestone.State == _model.MilestoneStateCompleted || count == nil {
+		return false
+	}
+	open := count.Total - count.Completed
+	if open <= 0 {
+		return false
+	}
+
+	remaining := milestone.TargetDate.Sub(now)
+	if remaining <= 0 || throughput == nil || throughput.Completed == 0 {
+		return true
+	}
+
+	// Time needed for the open tasks at the average time the team took per completed task
+	window := now.Sub(throughput.Since)
+	needed := time.Duration(float64(window) * float64(open) / float64(throughput.Completed))
+	return needed > remaining
+}
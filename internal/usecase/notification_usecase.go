@@ -0,0 +1,278 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_projection "bitbucket.org/edts/go-task-management/internal/model/projection"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"bitbucket.org/edts/go-task-management/pkg/mailer"
+)
+
+type NotificationUsecaseInterface interface {
+	GetNotifications(ctx context.Context, unreadOnly bool, limit *int32) ([]*_model.Notification, error)
+	MarkNotificationsRead(ctx context.Context, ids []string) (int32, error)
+	Notify(ctx context.Context, recipients []string, notification *_model.Notification, task *_model.Task, excerpt string)
+	GetNotificationPreferences(ctx context.Context) ([]*_model.NotificationPreference, error)
+	UpdateNotificationPreference(ctx context.Context, input _genModel.UpdateNotificationPreferenceInput) (*_model.NotificationPreference, error)
+}
+
+// defaultNotificationsPageSize is used when notifications and mentions are read without limit
+const defaultNotificationsPageSize = 50
+
+// maxExcerptLength bounds the text quoted in notification emails
+const maxExcerptLength = 280
+
+type NotificationUsecase struct {
+	// Repo
+	notificationRepo _repo.NotificationRepositoryInterface
+	userRepo         _repo.UserRepositoryInterface
+	// Mail
+	mailer mailer.Mailer
+}
+
+func NewNotificationUsecase(
+	notificationRepo _repo.NotificationRepositoryInterface,
+	userRepo _repo.UserRepositoryInterface,
+	mailer mailer.Mailer) NotificationUsecaseInterface {
+	return &NotificationUsecase{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		mailer:           mailer,
+	}
+}
+
+// GetNotifications lists the latest notifications of the caller
+func (uc *NotificationUsecase) GetNotifications(ctx context.Context, unreadOnly bool, limit *int32) ([]*_model.Notification, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications, err := uc.notificationRepo.GetNotificationsByUserID(ctx, userCtx.UserID, unreadOnly, notificationsPageSize(limit))
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return notifications, nil
+}
+
+// MarkNotificationsRead marks the notifications of the caller in ids as read, all of them when ids is nil
+func (uc *NotificationUsecase) MarkNotificationsRead(ctx context.Context, ids []string) (int32, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := uc.notificationRepo.MarkNotificationsRead(ctx, userCtx.UserID, ids)
+	if err != nil {
+		return 0, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return int32(count), nil
+}
+
+// Notify delivers the notification to the recipients, except the user who triggered it, on the channels each of
+// them chose for its kind. It runs once the change is committed, a failed delivery is logged and does not fail the change
+func (uc *NotificationUsecase) Notify(ctx context.Context, recipients []string, notification *_model.Notification, task *_model.Task, excerpt string) {
+	var userIDs []string
+	for _, userID := range uniqueIDs(recipients) {
+		if notification.ActorID == nil || userID != *notification.ActorID {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+	logs.Infof("Notify:: Sending %s of task %s to %v", notification.Kind, task.ID, userIDs)
+
+	preferences, err := uc.getPreferences(ctx, userIDs, notification.Kind)
+	if err != nil {
+		logs.Errorf("Notify:: Error GetNotificationPreferences repo: %v", err)
+		return
+	}
+
+	var inAppIDs, emailIDs []string
+	for _, userID := range userIDs {
+		if preferences[userID].InApp {
+			inAppIDs = append(inAppIDs, userID)
+		}
+		if preferences[userID].Email {
+			emailIDs = append(emailIDs, userID)
+		}
+	}
+
+	if len(inAppIDs) > 0 {
+		if err = uc.notificationRepo.CreateNotifications(ctx, inAppIDs, notification); err != nil {
+			logs.Errorf("Notify:: Error CreateNotifications repo: %v", err)
+		}
+	}
+	if len(emailIDs) == 0 {
+		return
+	}
+
+	// Emails are sent in the background, the request does not wait on the mail server
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		users, err := uc.userRepo.GetUsersByIDs(ctx, emailIDs)
+		if err != nil {
+			logs.Errorf("Notify:: Error GetUsersByIDs repo: %v", err)
+			return
+		}
+
+		actorName := "Someone"
+		if notification.ActorID != nil {
+			if actor, err := uc.userRepo.GetUserByID(ctx, *notification.ActorID); err == nil {
+				actorName = actor.Name
+			}
+		}
+
+		for _, user := range users {
+			if err = uc.mailer.Send(ctx, notificationMessage(user, actorName, notification, task, excerpt)); err != nil {
+				logs.Errorf("Notify:: Error sending the notification to %s: %v", user.ID, err)
+			}
+		}
+	}()
+}
+
+// GetNotificationPreferences returns the channels of every kind of notification for the caller
+func (uc *NotificationUsecase) GetNotificationPreferences(ctx context.Context) ([]*_model.NotificationPreference, error) {
+	userCtx, err := personFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := uc.notificationRepo.GetNotificationPreferences(ctx, []string{userCtx.UserID})
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	preferences := make([]*_model.NotificationPreference, 0, len(_model.NotificationKinds))
+	for _, kind := range _model.NotificationKinds {
+		preference := _model.DefaultNotificationPreference(userCtx.UserID, kind)
+		for _, s := range saved {
+			if s.Kind == kind {
+				preference = s
+			}
+		}
+		preferences = append(preferences, preference)
+	}
+	return preferences, nil
+}
+
+// UpdateNotificationPreference changes the channels of a kind of notification for the caller,
+// a channel left out of the input keeps its current value
+func (uc *NotificationUsecase) UpdateNotificationPreference(ctx context.Context, input _genModel.UpdateNotificationPreferenceInput) (*_model.NotificationPreference, error) {
+	logs.Infof("UpdateNotificationPreference:: Starting with payload %v", input)
+	userCtx, err := personFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences, err := uc.getPreferences(ctx, []string{userCtx.UserID}, input.Kind)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	preference := preferences[userCtx.UserID]
+	if input.InApp != nil {
+		preference.InApp = *input.InApp
+	}
+	if input.Email != nil {
+		preference.Email = *input.Email
+	}
+
+	saved, err := uc.notificationRepo.SetNotificationPreference(ctx, preference)
+	if err != nil {
+		logs.Errorf("UpdateNotificationPreference:: Error SetNotificationPreference repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return saved, nil
+}
+
+// getPreferences returns the preference of every user in userIDs for the kind, the default one when not saved
+func (uc *NotificationUsecase) getPreferences(ctx context.Context, userIDs []string, kind _model.NotificationKind) (map[string]*_model.NotificationPreference, error) {
+	saved, err := uc.notificationRepo.GetNotificationPreferences(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := make(map[string]*_model.NotificationPreference, len(userIDs))
+	for _, userID := range userIDs {
+		preferences[userID] = _model.DefaultNotificationPreference(userID, kind)
+	}
+	for _, preference := range saved {
+		if preference.Kind == kind {
+			preferences[preference.UserID] = preference
+		}
+	}
+	return preferences, nil
+}
+
+// personFromContext retrieves the caller and rejects service accounts, they receive no notifications
+func personFromContext(ctx context.Context) (*_projection.UserContext, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if userCtx.IsServiceAccount() {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Service accounts do not receive notifications")
+	}
+	return userCtx, nil
+}
+
+// notificationActor is the person whose action triggers the notification, nil for service accounts and background jobs
+func notificationActor(ctx context.Context) *string {
+	userCtx, err := userFromContext(ctx)
+	if err != nil || userCtx.IsServiceAccount() {
+		return nil
+	}
+	return &userCtx.UserID
+}
+
+func notificationMessage(user *_model.User, actorName string, notification *_model.Notification, task *_model.Task, excerpt string) mailer.Message {
+	var subject, action string
+	switch notification.Kind {
+	case _model.NotificationKindMentioned:
+		where := "the description of"
+		if notification.CommentID != nil {
+			where = "a comment on"
+		}
+		subject = fmt.Sprintf("%s mentioned you on %q", actorName, task.Title)
+		action = fmt.Sprintf("mentioned you in %s", where)
+	case _model.NotificationKindCommented:
+		subject = fmt.Sprintf("%s commented on %q", actorName, task.Title)
+		action = "commented on"
+	case _model.NotificationKindTaskMoved:
+		subject = fmt.Sprintf("%s moved %q", actorName, task.Title)
+		action = "moved"
+	default:
+		subject = fmt.Sprintf("%s updated %q", actorName, task.Title)
+		action = "updated"
+	}
+
+	return mailer.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body: fmt.Sprintf("Hi %s,\n\n%s %s the task %q:\n\n%s\n",
+			user.Name, actorName, action, task.Title, truncateExcerpt(excerpt)),
+	}
+}
+
+func truncateExcerpt(text string) string {
+	runes := []rune(text)
+	if len(runes) <= maxExcerptLength {
+		return text
+	}
+	return string(runes[:maxExcerptLength]) + "…"
+}
+
+// notificationsPageSize is the amount of notifications or mentions to return for the optional limit
+func notificationsPageSize(limit *int32) int {
+	if limit != nil && *limit > 0 {
+		return int(*limit)
+	}
+	return defaultNotificationsPageSize
+}
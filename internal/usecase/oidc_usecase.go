@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"time"
+
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_projection "bitbucket.org/edts/go-task-management/internal/model/projection"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"bitbucket.org/edts/go-task-management/pkg/oidc"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type OIDCUsecaseInterface interface {
+	BeginLogin(ctx context.Context) (*_projection.OIDCLoginRedirect, error)
+	CompleteLogin(ctx context.Context, code string, state string, flowToken string) (*_genModel.AuthResponse, error)
+}
+
+// oidcFlowTTL is how long a user has to log in at the identity provider
+const oidcFlowTTL = 10 * time.Minute
+
+type oidcFlowClaims struct {
+	Type         string `json:"type"`
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"codeVerifier"`
+	jwt.RegisteredClaims
+}
+
+type OIDCUsecase struct {
+	provider         *oidc.Provider
+	userRepo         _repo.UserRepositoryInterface
+	userIdentityRepo _repo.UserIdentityRepositoryInterface
+	userSessionRepo  _repo.UserSessionRepositoryInterface
+	invitationRepo   _repo.TeamInvitationRepositoryInterface
+	txRepo           _repo.TransactionRepositoryInterface
+}
+
+func NewOIDCUsecase(
+	provider *oidc.Provider,
+	userRepo _repo.UserRepositoryInterface,
+	userIdentityRepo _repo.UserIdentityRepositoryInterface,
+	userSessionRepo _repo.UserSessionRepositoryInterface,
+	invitationRepo _repo.TeamInvitationRepositoryInterface,
+	txRepo _repo.TransactionRepositoryInterface) OIDCUsecaseInterface {
+	return &OIDCUsecase{
+		provider:         provider,
+		userRepo:         userRepo,
+		userIdentityRepo: userIdentityRepo,
+		userSessionRepo:  userSessionRepo,
+		invitationRepo:   invitationRepo,
+		txRepo:           txRepo,
+	}
+}
+
+// BeginLogin starts the authorization code + PKCE flow
+func (uc *OIDCUsecase) BeginLogin(ctx context.Context) (*_projection.OIDCLoginRedirect, error) {
+	state, err := oidc.RandomString()
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, "Failed to start login")
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, "Failed to start login")
+	}
+	codeVerifier, err := oidc.RandomString()
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, "Failed to start login")
+	}
+
+	authURL, err := uc.provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallenge(codeVerifier))
+	if err != nil {
+		logs.Errorf("BeginLogin:: Error AuthCodeURL: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, "Identity provider is unavailable")
+	}
+
+	// The flow state is kept in a signed token instead of the database so any replica can handle the callback
+	expiresAt := time.Now().Add(oidcFlowTTL)
+	flowToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, oidcFlowClaims{
+		Type:         "oidc_flow",
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}).SignedString(jwtSecret)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, "Failed to start login")
+	}
+
+	return &_projection.OIDCLoginRedirect{
+		AuthURL:   authURL,
+		FlowToken: flowToken,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// CompleteLogin handles the provider callback and issues the same tokens as LoginUser
+func (uc *OIDCUsecase) CompleteLogin(ctx context.Context, code string, state string, flowToken string) (*_genModel.AuthResponse, error) {
+	flow := &oidcFlowClaims{}
+	_, err := jwt.ParseWithClaims(flowToken, flow, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}), jwt.WithExpirationRequired())
+	if err != nil || flow.Type != "oidc_flow" {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Login session is invalid or expired")
+	}
+	if code == "" || subtle.ConstantTimeCompare([]byte(state), []byte(flow.State)) != 1 {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid login state")
+	}
+
+	token, err := uc.provider.Exchange(ctx, code, flow.CodeVerifier)
+	if err != nil {
+		logs.Errorf("CompleteLogin:: Error Exchange: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusUnauthorized, "Failed to exchange authorization code")
+	}
+
+	claims, err := uc.provider.VerifyIDToken(ctx, token.IDToken, flow.Nonce)
+	if err != nil {
+		logs.Errorf("CompleteLogin:: Error VerifyIDToken: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusUnauthorized, "Invalid identity token")
+	}
+
+	user, err := uc.provisionUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return issueAuthResponse(ctx, uc.userSessionRepo, user)
+}
+
+// provisionUser resolves the user of an identity, linking it to an existing account by verified email
+// or creating the account just in time on the first login
+func (uc *OIDCUsecase) provisionUser(ctx context.Context, claims *oidc.IDTokenClaims) (*_model.User, error) {
+	var email *string
+	if claims.Email != "" && claims.IsEmailVerified() {
+		email = &claims.Email
+	}
+
+	identity, err := uc.userIdentityRepo.GetUserIdentity(ctx, uc.provider.Issuer(), claims.Subject)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Known identity, the sub claim is the stable key, the email may have changed at the provider
+	if identity != nil {
+		user, err := uc.userRepo.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusUnauthorized, "Linked user does not exist")
+		}
+		if err = uc.userIdentityRepo.TouchUserIdentity(ctx, identity.ID, email); err != nil {
+			logs.Errorf("provisionUser:: Error TouchUserIdentity repo: %v", err)
+		}
+		return user, nil
+	}
+
+	// Linking by email is only safe when the provider vouches for the address
+	if email == nil {
+		return nil, _customErr.NewGraphQLError(http.StatusForbidden, "Identity provider did not return a verified email")
+	}
+
+	user, err := uc.userRepo.GetUserByEmail(ctx, *email)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	// A new user joins the teams that invited the email like a registered one does,
+	// the invitee is not part of the workspaces of the inviting teams yet
+	err = uc.txRepo.WithTransaction(_repo.WithoutWorkspaceIsolation(ctx), func(ctx context.Context) error {
+		if user == nil {
+			name := claims.Name
+			if name == "" {
+				name = *email
+			}
+			// SSO only accounts have no password, password login is impossible for them
+			user, err = uc.userRepo.CreateUser(ctx, &_model.User{
+				Name:  name,
+				Email: *email,
+			})
+			if err != nil {
+				logs.Errorf("provisionUser:: Error CreateUser repo: %v", err)
+				return _customErr.NewGraphQLError(http.StatusInternalServerError, "Failed to provision user")
+			}
+			if err = acceptPendingInvitations(ctx, uc.invitationRepo, user.ID, user.Email); err != nil {
+				logs.Errorf("provisionUser:: Error accepting the pending invitations: %v", err)
+				return err
+			}
+			logs.Infof("provisionUser:: Provisioned user %s from issuer %s", user.ID, uc.provider.Issuer())
+		}
+
+		_, err = uc.userIdentityRepo.CreateUserIdentity(ctx, &_model.UserIdentity{
+			UserID:  user.ID,
+			Issuer:  uc.provider.Issuer(),
+			Subject: claims.Subject,
+			Email:   email,
+		})
+		if err != nil {
+			logs.Errorf("provisionUser:: Error CreateUserIdentity repo: %v", err)
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, "Failed to link identity")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
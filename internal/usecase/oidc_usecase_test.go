@@ -0,0 +1,271 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	"bitbucket.org/edts/go-task-management/pkg/oidc"
+	"bitbucket.org/edts/go-task-management/pkg/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// fakeUserRepo keeps users in memory, the methods the login does not use are left to the nil interface
+type fakeUserRepo struct {
+	_repo.UserRepositoryInterface
+	users map[string]*_model.User
+}
+
+func (r *fakeUserRepo) CreateUser(ctx context.Context, user *_model.User) (*_model.User, error) {
+	user.ID = "user-" + user.Email
+	r.users[user.ID] = user
+	return user, nil
+}
+
+func (r *fakeUserRepo) GetUserByID(ctx context.Context, id string) (*_model.User, error) {
+	if user, ok := r.users[id]; ok {
+		return user, nil
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (r *fakeUserRepo) GetUserByEmail(ctx context.Context, email string) (*_model.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+type fakeUserIdentityRepo struct {
+	identities []*_model.UserIdentity
+}
+
+func (r *fakeUserIdentityRepo) CreateUserIdentity(ctx context.Context, identity *_model.UserIdentity) (*_model.UserIdentity, error) {
+	r.identities = append(r.identities, identity)
+	return identity, nil
+}
+
+func (r *fakeUserIdentityRepo) GetUserIdentity(ctx context.Context, issuer string, subject string) (*_model.UserIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Issuer == issuer && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeUserIdentityRepo) TouchUserIdentity(ctx context.Context, id string, email *string) error {
+	return nil
+}
+
+type fakeUserSessionRepo struct {
+	_repo.UserSessionRepositoryInterface
+}
+
+func (r *fakeUserSessionRepo) CreateUserSession(ctx context.Context, userSession *_model.UserSession) (*_model.UserSession, error) {
+	return userSession, nil
+}
+
+// fakeInvitationRepo records the accepted invitations
+type fakeInvitationRepo struct {
+	_repo.TeamInvitationRepositoryInterface
+	pending  []*_model.TeamInvitation
+	accepted map[string]string
+}
+
+func (r *fakeInvitationRepo) GetPendingTeamInvitationsByEmail(ctx context.Context, email string) ([]*_model.TeamInvitation, error) {
+	var invitations []*_model.TeamInvitation
+	for _, invitation := range r.pending {
+		if invitation.Email == email {
+			invitations = append(invitations, invitation)
+		}
+	}
+	return invitations, nil
+}
+
+func (r *fakeInvitationRepo) AcceptTeamInvitation(ctx context.Context, id string, userID string) (string, error) {
+	for _, invitation := range r.pending {
+		if invitation.ID == id {
+			r.accepted[id] = userID
+			return invitation.TeamID, nil
+		}
+	}
+	return "", pgx.ErrNoRows
+}
+
+type fakeTxRepo struct{}
+
+func (fakeTxRepo) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type oidcTestEnv struct {
+	mock           *oidctest.Provider
+	usecase        OIDCUsecaseInterface
+	users          *fakeUserRepo
+	identities     *fakeUserIdentityRepo
+	invitationRepo *fakeInvitationRepo
+}
+
+func newOIDCTestEnv(t *testing.T) *oidcTestEnv {
+	t.Helper()
+
+	mock, err := oidctest.NewProvider("task-management")
+	if err != nil {
+		t.Fatalf("starting the mock provider: %v", err)
+	}
+	t.Cleanup(mock.Close)
+
+	env := &oidcTestEnv{
+		mock:       mock,
+		users:      &fakeUserRepo{users: make(map[string]*_model.User)},
+		identities: &fakeUserIdentityRepo{},
+		invitationRepo: &fakeInvitationRepo{
+			pending: []*_model.TeamInvitation{{
+				ID:        "invitation-1",
+				TeamID:    "team-1",
+				Email:     "jane@example.com",
+				Status:    _model.InvitationStatusPending,
+				ExpiresAt: time.Now().Add(time.Hour),
+			}},
+			accepted: make(map[string]string),
+		},
+	}
+	provider := oidc.NewProvider(oidc.Config{
+		IssuerURL:   mock.Issuer(),
+		ClientID:    "task-management",
+		RedirectURL: "http://localhost:8080/auth/oidc/callback",
+		HTTPClient:  mock.Client(),
+	})
+	env.usecase = NewOIDCUsecase(provider, env.users, env.identities, &fakeUserSessionRepo{}, env.invitationRepo, fakeTxRepo{})
+	return env
+}
+
+// login runs the whole flow the way the browser does, from the login redirect to the callback
+func (env *oidcTestEnv) login(t *testing.T) (string, string, string) {
+	t.Helper()
+
+	redirect, err := env.usecase.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	code, state, err := env.mock.Authorize(redirect.AuthURL)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	return code, state, redirect.FlowToken
+}
+
+func TestCompleteLoginProvisionsUserJustInTime(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	env.mock.SetClaims(jwt.MapClaims{"sub": "subject-1", "email": "jane@example.com", "email_verified": true, "name": "Jane"})
+
+	code, state, flowToken := env.login(t)
+	authResponse, err := env.usecase.CompleteLogin(context.Background(), code, state, flowToken)
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+
+	if authResponse.Token == "" || authResponse.RefreshToken == "" {
+		t.Fatal("expected an access and a refresh token")
+	}
+	if authResponse.User.Email != "jane@example.com" || authResponse.User.Name != "Jane" {
+		t.Fatalf("unexpected user %+v", authResponse.User)
+	}
+	if len(env.users.users) != 1 {
+		t.Fatalf("expected a single provisioned user, got %d", len(env.users.users))
+	}
+	if len(env.identities.identities) != 1 || env.identities.identities[0].Subject != "subject-1" {
+		t.Fatalf("expected the identity to be linked, got %+v", env.identities.identities)
+	}
+	if env.invitationRepo.accepted["invitation-1"] != authResponse.User.ID {
+		t.Fatal("expected the pending invitation of the email to be accepted")
+	}
+
+	// The second login finds the user through the identity
+	code, state, flowToken = env.login(t)
+	if _, err = env.usecase.CompleteLogin(context.Background(), code, state, flowToken); err != nil {
+		t.Fatalf("second CompleteLogin: %v", err)
+	}
+	if len(env.users.users) != 1 || len(env.identities.identities) != 1 {
+		t.Fatal("expected the second login to reuse the user and the identity")
+	}
+}
+
+func TestCompleteLoginLinksExistingUserByVerifiedEmail(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	env.users.users["user-1"] = &_model.User{ID: "user-1", Name: "Jane", Email: "jane@example.com"}
+	env.mock.SetClaims(jwt.MapClaims{"sub": "subject-1", "email": "jane@example.com", "email_verified": "true"})
+
+	code, state, flowToken := env.login(t)
+	authResponse, err := env.usecase.CompleteLogin(context.Background(), code, state, flowToken)
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+
+	if authResponse.User.ID != "user-1" || len(env.users.users) != 1 {
+		t.Fatalf("expected the existing user to be linked, got %+v", authResponse.User)
+	}
+	if len(env.invitationRepo.accepted) != 0 {
+		t.Fatal("expected invitations to be left to the existing user")
+	}
+}
+
+func TestCompleteLoginRejectsUnverifiedEmail(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	env.mock.SetClaims(jwt.MapClaims{"sub": "subject-1", "email": "jane@example.com", "email_verified": false})
+
+	code, state, flowToken := env.login(t)
+	_, err := env.usecase.CompleteLogin(context.Background(), code, state, flowToken)
+	assertStatus(t, err, http.StatusForbidden)
+	if len(env.users.users) != 0 {
+		t.Fatal("expected no user to be provisioned")
+	}
+}
+
+func TestCompleteLoginRejectsWrongState(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	env.mock.SetClaims(jwt.MapClaims{"sub": "subject-1", "email": "jane@example.com", "email_verified": true})
+
+	code, _, flowToken := env.login(t)
+	_, err := env.usecase.CompleteLogin(context.Background(), code, "forged-state", flowToken)
+	assertStatus(t, err, http.StatusBadRequest)
+}
+
+func TestCompleteLoginRejectsForgedFlowToken(t *testing.T) {
+	env := newOIDCTestEnv(t)
+
+	code, state, _ := env.login(t)
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, oidcFlowClaims{
+		Type:  "oidc_flow",
+		State: state,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}).SignedString([]byte("another-secret"))
+	if err != nil {
+		t.Fatalf("signing the flow token: %v", err)
+	}
+
+	_, err = env.usecase.CompleteLogin(context.Background(), code, state, forged)
+	assertStatus(t, err, http.StatusBadRequest)
+}
+
+func assertStatus(t *testing.T, err error, status int) {
+	t.Helper()
+
+	gqlErr, ok := err.(*gqlerror.Error)
+	if !ok {
+		t.Fatalf("expected a GraphQL error with status %d, got %v", status, err)
+	}
+	if gqlErr.Extensions["status"] != status {
+		t.Fatalf("expected status %d, got %v: %s", status, gqlErr.Extensions["status"], gqlErr.Message)
+	}
+}
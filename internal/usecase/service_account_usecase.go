@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"github.com/google/uuid"
+)
+
+type ServiceAccountUsecaseInterface interface {
+	CreateServiceAccount(ctx context.Context, input _genModel.CreateServiceAccountInput) (*_model.ServiceAccount, error)
+	DeleteServiceAccount(ctx context.Context, id string) (bool, error)
+	CreateServiceAccountToken(ctx context.Context, input _genModel.CreateServiceAccountTokenInput) (*_genModel.CreatedAccessToken, error)
+	GetServiceAccountsByTeam(ctx context.Context, teamID string) ([]*_model.ServiceAccount, error)
+	GetServiceAccountTokens(ctx context.Context, serviceAccountID string) ([]*_model.PersonalAccessToken, error)
+}
+
+type ServiceAccountUsecase struct {
+	serviceAccountRepo _repo.ServiceAccountRepositoryInterface
+	accessTokenRepo    _repo.PersonalAccessTokenRepositoryInterface
+	teamRepo           _repo.TeamRepositoryInterface
+	userTeamRepo       _repo.UserTeamRepositoryInterface
+}
+
+func NewServiceAccountUsecase(
+	serviceAccountRepo _repo.ServiceAccountRepositoryInterface,
+	accessTokenRepo _repo.PersonalAccessTokenRepositoryInterface,
+	teamRepo _repo.TeamRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface) ServiceAccountUsecaseInterface {
+	return &ServiceAccountUsecase{
+		serviceAccountRepo: serviceAccountRepo,
+		accessTokenRepo:    accessTokenRepo,
+		teamRepo:           teamRepo,
+		userTeamRepo:       userTeamRepo,
+	}
+}
+
+func (uc *ServiceAccountUsecase) CreateServiceAccount(ctx context.Context, input _genModel.CreateServiceAccountInput) (*_model.ServiceAccount, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Service accounts cannot spawn other service accounts
+	if userCtx.IsServiceAccount() {
+		return nil, _customErr.NewGraphQLError(http.StatusForbidden, "service accounts cannot create service accounts")
+	}
+
+	team, err := uc.teamRepo.GetTeamByID(ctx, input.TeamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, team.ID); err != nil {
+		return nil, err
+	}
+
+	createdBy, _ := uuid.Parse(userCtx.UserID)
+	serviceAccount := &_model.ServiceAccount{
+		TeamID:      team.ID,
+		Name:        input.Name,
+		Description: input.Description,
+		Base: _model.Base{
+			CreatedBy:  &createdBy,
+			ModifiedBy: &createdBy,
+		},
+	}
+
+	createdServiceAccount, err := uc.serviceAccountRepo.CreateServiceAccount(ctx, serviceAccount)
+	if err != nil {
+		logs.Errorf("CreateServiceAccount:: Error CreateServiceAccount repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, err.Error())
+	}
+
+	return createdServiceAccount, nil
+}
+
+func (uc *ServiceAccountUsecase) DeleteServiceAccount(ctx context.Context, id string) (bool, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	serviceAccount, err := uc.serviceAccountRepo.GetServiceAccountByID(ctx, id)
+	if err != nil {
+		return false, _customErr.NewGraphQLError(http.StatusNotFound, "Service account not found")
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, serviceAccount.TeamID); err != nil {
+		return false, err
+	}
+
+	if err = uc.serviceAccountRepo.DeleteServiceAccountByID(ctx, serviceAccount.ID); err != nil {
+		return false, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return true, nil
+}
+
+func (uc *ServiceAccountUsecase) CreateServiceAccountToken(ctx context.Context, input _genModel.CreateServiceAccountTokenInput) (*_genModel.CreatedAccessToken, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if userCtx.IsServiceAccount() {
+		return nil, _customErr.NewGraphQLError(http.StatusForbidden, "service accounts cannot create tokens")
+	}
+
+	serviceAccount, err := uc.serviceAccountRepo.GetServiceAccountByID(ctx, input.ServiceAccountID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Service account not found")
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, serviceAccount.TeamID); err != nil {
+		return nil, err
+	}
+
+	createdBy, _ := uuid.Parse(userCtx.UserID)
+	token := &_model.PersonalAccessToken{
+		Name:             input.Name,
+		Scopes:           input.Scopes,
+		ExpiresAt:        input.ExpiresAt,
+		ServiceAccountID: &serviceAccount.ID,
+		Base: _model.Base{
+			CreatedBy:  &createdBy,
+			ModifiedBy: &createdBy,
+		},
+	}
+
+	return issuePersonalAccessToken(ctx, uc.accessTokenRepo, userCtx, token)
+}
+
+func (uc *ServiceAccountUsecase) GetServiceAccountsByTeam(ctx context.Context, teamID string) ([]*_model.ServiceAccount, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, teamID); err != nil {
+		return nil, err
+	}
+
+	serviceAccounts, err := uc.serviceAccountRepo.GetServiceAccountsByTeamID(ctx, teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return serviceAccounts, nil
+}
+
+func (uc *ServiceAccountUsecase) GetServiceAccountTokens(ctx context.Context, serviceAccountID string) ([]*_model.PersonalAccessToken, error) {
+	tokens, err := uc.accessTokenRepo.GetPersonalAccessTokensByServiceAccountID(ctx, serviceAccountID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return tokens, nil
+}
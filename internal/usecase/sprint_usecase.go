@@ -0,0 +1,684 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_projection "bitbucket.org/edts/go-task-management/internal/model/projection"
+	_pubsub "bitbucket.org/edts/go-task-management/internal/pubsub"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type SprintUsecaseInterface interface {
+	CreateSprint(ctx context.Context, input _genModel.CreateSprintInput) (*_model.Sprint, error)
+	UpdateSprint(ctx context.Context, input _genModel.UpdateSprintInput) (*_model.Sprint, error)
+	DeleteSprint(ctx context.Context, id string) (bool, error)
+	GetSprintsByTeam(ctx context.Context, teamID string, state *_model.SprintState) ([]*_model.Sprint, error)
+	GetSprintByID(ctx context.Context, id string) (*_model.Sprint, error)
+	GetBacklog(ctx context.Context, teamID string) ([]*_model.Task, error)
+	PlanTasks(ctx context.Context, sprintID string, taskIDs []string) (*_model.Sprint, error)
+	MoveTasksToBacklog(ctx context.Context, taskIDs []string) ([]*_model.Task, error)
+	StartSprint(ctx context.Context, id string) (*_model.Sprint, error)
+	CompleteSprint(ctx context.Context, id string, carryOverTo *_model.SprintCarryOver) (*_model.Sprint, error)
+	GetSprintReport(ctx context.Context, id string) (*_genModel.SprintReport, error)
+}
+
+type SprintUsecase struct {
+	// Repo
+	sprintRepo   _repo.SprintRepositoryInterface
+	taskRepo     _repo.TaskRepositoryInterface
+	teamRepo     _repo.TeamRepositoryInterface
+	userTeamRepo _repo.UserTeamRepositoryInterface
+	txRepo       _repo.TransactionRepositoryInterface
+	// PubSub
+	taskPubSub _pubsub.TaskPubSubInterface
+}
+
+func NewSprintUsecase(
+	sprintRepo _repo.SprintRepositoryInterface,
+	taskRepo _repo.TaskRepositoryInterface,
+	teamRepo _repo.TeamRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface,
+	txRepo _repo.TransactionRepositoryInterface,
+	taskPubSub _pubsub.TaskPubSubInterface) SprintUsecaseInterface {
+	return &SprintUsecase{
+		sprintRepo:   sprintRepo,
+		taskRepo:     taskRepo,
+		teamRepo:     teamRepo,
+		userTeamRepo: userTeamRepo,
+		txRepo:       txRepo,
+		taskPubSub:   taskPubSub,
+	}
+}
+
+func (uc *SprintUsecase) CreateSprint(ctx context.Context, input _genModel.CreateSprintInput) (*_model.Sprint, error) {
+	logs.Infof("CreateSprint:: Starting with payload %v", input)
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, input.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	startDate, endDate, err := parseSprintDates(input.StartDate, input.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	sprint, err := uc.sprintRepo.CreateSprint(ctx, &_model.Sprint{
+		TeamID:    input.TeamID,
+		Name:      strings.TrimSpace(input.Name),
+		Goal:      input.Goal,
+		StartDate: startDate,
+		EndDate:   endDate,
+		State:     _model.SprintStatePlanned,
+		Base: _model.Base{
+			CreatedBy:  actorID(userCtx),
+			ModifiedBy: actorID(userCtx),
+		},
+	})
+	if err != nil {
+		logs.Errorf("CreateSprint:: Error CreateSprint repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	logs.Info("CreateSprint:: Finish CreateSprint")
+
+	return sprint, nil
+}
+
+// UpdateSprint changes the name, goal or dates of a sprint that is not completed yet
+func (uc *SprintUsecase) UpdateSprint(ctx context.Context, input _genModel.UpdateSprintInput) (*_model.Sprint, error) {
+	logs.Infof("UpdateSprint:: Starting with payload %v", input)
+	sprint, err := uc.sprintRepo.GetSprintByID(ctx, input.ID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Sprint not found")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, sprint.TeamID)
+	if err != nil {
+		return nil, err
+	}
+	if sprint.State == _model.SprintStateCompleted {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Completed sprint cannot be changed")
+	}
+
+	// Update only the fields that are provided
+	if input.Name != nil {
+		sprint.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Goal != nil {
+		sprint.Goal = input.Goal
+	}
+	startDate := sprint.StartDate.Format(time.RFC3339)
+	if input.StartDate != nil {
+		startDate = *input.StartDate
+	}
+	endDate := sprint.EndDate.Format(time.RFC3339)
+	if input.EndDate != nil {
+		endDate = *input.EndDate
+	}
+	sprint.StartDate, sprint.EndDate, err = parseSprintDates(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	sprint.ModifiedBy = actorID(userCtx)
+
+	updatedSprint, err := uc.sprintRepo.UpdateSprint(ctx, sprint)
+	if err != nil {
+		logs.Errorf("UpdateSprint:: Error UpdateSprint repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	logs.Info("UpdateSprint:: Finish UpdateSprint")
+
+	return updatedSprint, nil
+}
+
+// DeleteSprint removes a planned sprint, its tasks go back to the backlog
+func (uc *SprintUsecase) DeleteSprint(ctx context.Context, id string) (bool, error) {
+	logs.Infof("DeleteSprint:: Starting with sprint %s", id)
+	sprint, err := uc.sprintRepo.GetSprintByID(ctx, id)
+	if err != nil {
+		return false, _customErr.NewGraphQLError(http.StatusNotFound, "Sprint not found")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, sprint.TeamID)
+	if err != nil {
+		return false, err
+	}
+
+	var tasks []*_model.Task
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		sprint, err := uc.sprintRepo.LockSprint(ctx, id)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusNotFound, "Sprint not found")
+		}
+		if sprint.State != _model.SprintStatePlanned {
+			return _customErr.NewGraphQLError(http.StatusBadRequest, "Only planned sprints can be deleted")
+		}
+
+		sprintTasks, err := uc.sprintRepo.GetTasksBySprintIDs(ctx, []string{sprint.ID})
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if len(sprintTasks) > 0 {
+			tasks, err = uc.sprintRepo.SetTasksSprint(ctx, taskIDsOf(sprintTasks), nil, actorID(userCtx))
+			if err != nil {
+				return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+			}
+		}
+		if err = uc.sprintRepo.DeleteSprint(ctx, sprint.ID); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("DeleteSprint:: Error deleting the sprint: %v", err)
+		return false, err
+	}
+
+	uc.publishTasks(tasks)
+
+	logs.Info("DeleteSprint:: Finish DeleteSprint")
+
+	return true, nil
+}
+
+func (uc *SprintUsecase) GetSprintsByTeam(ctx context.Context, teamID string, state *_model.SprintState) ([]*_model.Sprint, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, teamID); err != nil {
+		return nil, err
+	}
+
+	sprints, err := uc.sprintRepo.GetSprintsByTeamID(ctx, teamID, state)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return sprints, nil
+}
+
+func (uc *SprintUsecase) GetSprintByID(ctx context.Context, id string) (*_model.Sprint, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sprint, err := uc.sprintRepo.GetSprintByID(ctx, id)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Sprint not found")
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, sprint.TeamID); err != nil {
+		return nil, err
+	}
+	return sprint, nil
+}
+
+func (uc *SprintUsecase) GetBacklog(ctx context.Context, teamID string) ([]*_model.Task, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, teamID); err != nil {
+		return nil, err
+	}
+
+	tasks, err := uc.sprintRepo.GetBacklogTasks(ctx, teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return tasks, nil
+}
+
+// PlanTasks moves the tasks into the sprint, tasks added to or taken from an active sprint are recorded as scope changes
+func (uc *SprintUsecase) PlanTasks(ctx context.Context, sprintID string, taskIDs []string) (*_model.Sprint, error) {
+	logs.Infof("PlanTasks:: Starting with sprint %s and tasks %v", sprintID, taskIDs)
+	sprint, err := uc.sprintRepo.GetSprintByID(ctx, sprintID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Sprint not found")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, sprint.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []*_model.Task
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		tasks, err = uc.moveTasks(ctx, userCtx, uniqueIDs(taskIDs), sprint)
+		return err
+	})
+	if err != nil {
+		logs.Errorf("PlanTasks:: Error planning the tasks: %v", err)
+		return nil, err
+	}
+
+	uc.publishTasks(tasks)
+
+	logs.Info("PlanTasks:: Finish PlanTasks")
+
+	return sprint, nil
+}
+
+// MoveTasksToBacklog takes the tasks out of their sprint
+func (uc *SprintUsecase) MoveTasksToBacklog(ctx context.Context, taskIDs []string) ([]*_model.Task, error) {
+	logs.Infof("MoveTasksToBacklog:: Starting with tasks %v", taskIDs)
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	taskIDs = uniqueIDs(taskIDs)
+	tasks, err := uc.taskRepo.GetTasksByIDs(ctx, taskIDs)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if len(tasks) != len(taskIDs) {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+	}
+	teamIDs := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		teamIDs = append(teamIDs, task.TeamID)
+	}
+	for _, teamID := range uniqueIDs(teamIDs) {
+		if _, err = ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, teamID); err != nil {
+			return nil, err
+		}
+	}
+
+	var movedTasks []*_model.Task
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		movedTasks, err = uc.moveTasks(ctx, userCtx, taskIDs, nil)
+		return err
+	})
+	if err != nil {
+		logs.Errorf("MoveTasksToBacklog:: Error moving the tasks: %v", err)
+		return nil, err
+	}
+
+	uc.publishTasks(movedTasks)
+
+	// Tasks already in the backlog are returned unchanged
+	tasks, err = uc.taskRepo.GetTasksByIDs(ctx, taskIDs)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	logs.Info("MoveTasksToBacklog:: Finish MoveTasksToBacklog")
+
+	return tasks, nil
+}
+
+// StartSprint activates a planned sprint, the tasks it holds at that moment are its commitment
+func (uc *SprintUsecase) StartSprint(ctx context.Context, id string) (*_model.Sprint, error) {
+	logs.Infof("StartSprint:: Starting with sprint %s", id)
+	sprint, err := uc.sprintRepo.GetSprintByID(ctx, id)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Sprint not found")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, sprint.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	var startedSprint *_model.Sprint
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		sprint, err := uc.sprintRepo.LockSprint(ctx, id)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusNotFound, "Sprint not found")
+		}
+		if sprint.State != _model.SprintStatePlanned {
+			return _customErr.NewGraphQLError(http.StatusBadRequest, "Only planned sprints can be started")
+		}
+
+		startedSprint, err = uc.sprintRepo.SetSprintState(ctx, sprint.ID, _model.SprintStateActive, actorID(userCtx))
+		if err != nil {
+			// The partial unique index allows one active sprint per team
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return _customErr.NewGraphQLError(http.StatusConflict, "Team already has an active sprint")
+			}
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+
+		tasks, err := uc.sprintRepo.GetTasksBySprintIDs(ctx, []string{sprint.ID})
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if err = uc.addSprintTaskEvents(ctx, userCtx, sprint.ID, taskIDsOf(tasks), _model.SprintTaskEventCommitted); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("StartSprint:: Error starting the sprint: %v", err)
+		return nil, err
+	}
+
+	logs.Info("StartSprint:: Finish StartSprint")
+
+	return startedSprint, nil
+}
+
+// CompleteSprint closes the active sprint, unfinished tasks move to the next planned sprint or to the backlog
+func (uc *SprintUsecase) CompleteSprint(ctx context.Context, id string, carryOverTo *_model.SprintCarryOver) (*_model.Sprint, error) {
+	logs.Infof("CompleteSprint:: Starting with sprint %s and carry over %v", id, carryOverTo)
+	sprint, err := uc.sprintRepo.GetSprintByID(ctx, id)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Sprint not found")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, sprint.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	carryOver := _model.SprintCarryOverNextSprint
+	if carryOverTo != nil {
+		carryOver = *carryOverTo
+	}
+
+	var completedSprint *_model.Sprint
+	var carriedTasks []*_model.Task
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		sprint, err := uc.sprintRepo.LockSprint(ctx, id)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusNotFound, "Sprint not found")
+		}
+		if sprint.State != _model.SprintStateActive {
+			return _customErr.NewGraphQLError(http.StatusBadRequest, "Only active sprints can be completed")
+		}
+
+		// Lock the tasks so their status does not change while they are split
+		sprintTasks, err := uc.sprintRepo.GetTasksBySprintIDs(ctx, []string{sprint.ID})
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if err = uc.taskRepo.LockTasks(ctx, taskIDsOf(sprintTasks)); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		sprintTasks, err = uc.sprintRepo.GetTasksBySprintIDs(ctx, []string{sprint.ID})
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+
+		var doneIDs, unfinishedIDs []string
+		for _, task := range sprintTasks {
+			if task.Status == _const.TASK_STATUS_DONE {
+				doneIDs = append(doneIDs, task.ID)
+			} else {
+				unfinishedIDs = append(unfinishedIDs, task.ID)
+			}
+		}
+		if err = uc.addSprintTaskEvents(ctx, userCtx, sprint.ID, doneIDs, _model.SprintTaskEventCompleted); err != nil {
+			return err
+		}
+		if err = uc.addSprintTaskEvents(ctx, userCtx, sprint.ID, unfinishedIDs, _model.SprintTaskEventCarriedOver); err != nil {
+			return err
+		}
+
+		if len(unfinishedIDs) > 0 {
+			var nextSprintID *string
+			if carryOver == _model.SprintCarryOverNextSprint {
+				nextSprint, err := uc.sprintRepo.GetNextPlannedSprint(ctx, sprint.TeamID)
+				if err != nil {
+					return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+				}
+				// A next sprint deleted meanwhile leaves the tasks in the backlog
+				if nextSprint != nil {
+					if nextSprint, err = uc.sprintRepo.LockSprint(ctx, nextSprint.ID); err == nil && nextSprint.State == _model.SprintStatePlanned {
+						nextSprintID = &nextSprint.ID
+					}
+				}
+			}
+
+			carriedTasks, err = uc.sprintRepo.SetTasksSprint(ctx, unfinishedIDs, nextSprintID, actorID(userCtx))
+			if err != nil {
+				return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+			}
+		}
+
+		completedSprint, err = uc.sprintRepo.SetSprintState(ctx, sprint.ID, _model.SprintStateCompleted, actorID(userCtx))
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("CompleteSprint:: Error completing the sprint: %v", err)
+		return nil, err
+	}
+
+	uc.publishTasks(carriedTasks)
+
+	logs.Info("CompleteSprint:: Finish CompleteSprint")
+
+	return completedSprint, nil
+}
+
+// GetSprintReport compares the commitment of the sprint with what got done and lists the scope changes,
+// while the sprint is active the completed tasks are the ones done so far
+func (uc *SprintUsecase) GetSprintReport(ctx context.Context, id string) (*_genModel.SprintReport, error) {
+	sprint, err := uc.GetSprintByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := uc.sprintRepo.GetSprintTaskEvents(ctx, sprint.ID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	report := &_genModel.SprintReport{
+		Sprint:       sprint,
+		Committed:    []*_model.Task{},
+		Completed:    []*_model.Task{},
+		CarriedOver:  []*_model.Task{},
+		Added:        []*_model.Task{},
+		Removed:      []*_model.Task{},
+		ScopeChanges: []*_genModel.SprintScopeChange{},
+	}
+
+	// A task moved in and out several times is listed once per list
+	seen := make(map[_model.SprintTaskEvent]map[string]bool)
+	appendOnce := func(list []*_model.Task, event *_model.SprintTask) []*_model.Task {
+		if seen[event.Event] == nil {
+			seen[event.Event] = make(map[string]bool)
+		}
+		if seen[event.Event][event.TaskID] {
+			return list
+		}
+		seen[event.Event][event.TaskID] = true
+		return append(list, event.Task)
+	}
+
+	for _, event := range events {
+		switch event.Event {
+		case _model.SprintTaskEventCommitted:
+			report.Committed = appendOnce(report.Committed, event)
+		case _model.SprintTaskEventCompleted:
+			report.Completed = appendOnce(report.Completed, event)
+		case _model.SprintTaskEventCarriedOver:
+			report.CarriedOver = appendOnce(report.CarriedOver, event)
+		case _model.SprintTaskEventAdded:
+			report.Added = appendOnce(report.Added, event)
+			report.ScopeChanges = append(report.ScopeChanges, &_genModel.SprintScopeChange{
+				Task:      event.Task,
+				Change:    _genModel.SprintScopeChangeTypeAdded,
+				ChangedAt: event.CreatedAt,
+			})
+		case _model.SprintTaskEventRemoved:
+			report.Removed = appendOnce(report.Removed, event)
+			report.ScopeChanges = append(report.ScopeChanges, &_genModel.SprintScopeChange{
+				Task:      event.Task,
+				Change:    _genModel.SprintScopeChangeTypeRemoved,
+				ChangedAt: event.CreatedAt,
+			})
+		}
+	}
+
+	if sprint.State == _model.SprintStateActive {
+		tasks, err := uc.sprintRepo.GetTasksBySprintIDs(ctx, []string{sprint.ID})
+		if err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		for _, task := range tasks {
+			if task.Status == _const.TASK_STATUS_DONE {
+				report.Completed = append(report.Completed, task)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// moveTasks plans the tasks into target, a nil target is the backlog. Every sprint involved is locked before
+// the tasks, in the order used by StartSprint and CompleteSprint, so scope changes cannot race with them
+func (uc *SprintUsecase) moveTasks(ctx context.Context, userCtx *_projection.UserContext, taskIDs []string, target *_model.Sprint) ([]*_model.Task, error) {
+	tasks, err := uc.taskRepo.GetTasksByIDs(ctx, taskIDs)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if len(tasks) != len(taskIDs) {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+	}
+
+	var sprintIDs []string
+	if target != nil {
+		sprintIDs = append(sprintIDs, target.ID)
+	}
+	for _, task := range tasks {
+		if task.SprintID != nil {
+			sprintIDs = append(sprintIDs, *task.SprintID)
+		}
+	}
+	sprintIDs = uniqueIDs(sprintIDs)
+	sort.Strings(sprintIDs)
+
+	sprints := make(map[string]*_model.Sprint)
+	for _, sprintID := range sprintIDs {
+		sprint, err := uc.sprintRepo.LockSprint(ctx, sprintID)
+		if err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusConflict, "Sprint changed meanwhile, please try again")
+		}
+		sprints[sprint.ID] = sprint
+	}
+	if target != nil {
+		target = sprints[target.ID]
+		if target.State == _model.SprintStateCompleted {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Tasks cannot be planned into a completed sprint")
+		}
+	}
+
+	// Read the tasks again under lock, a task planned meanwhile into a sprint that is not locked is a conflict
+	if err = uc.taskRepo.LockTasks(ctx, taskIDs); err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	tasks, err = uc.taskRepo.GetTasksByIDs(ctx, taskIDs)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	var movingIDs []string
+	removedIDs := make(map[string][]string)
+	for _, task := range tasks {
+		if target != nil && task.TeamID != target.TeamID {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Tasks must belong to the team of the sprint")
+		}
+		if task.SprintID == nil {
+			if target != nil {
+				movingIDs = append(movingIDs, task.ID)
+			}
+			continue
+		}
+
+		source, ok := sprints[*task.SprintID]
+		if !ok {
+			return nil, _customErr.NewGraphQLError(http.StatusConflict, "Sprint changed meanwhile, please try again")
+		}
+		if target != nil && source.ID == target.ID {
+			continue
+		}
+		if source.State == _model.SprintStateCompleted {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Tasks of a completed sprint cannot be moved")
+		}
+		movingIDs = append(movingIDs, task.ID)
+		if source.State == _model.SprintStateActive {
+			removedIDs[source.ID] = append(removedIDs[source.ID], task.ID)
+		}
+	}
+	if len(movingIDs) == 0 {
+		return nil, nil
+	}
+
+	var targetID *string
+	if target != nil {
+		targetID = &target.ID
+	}
+	movedTasks, err := uc.sprintRepo.SetTasksSprint(ctx, movingIDs, targetID, actorID(userCtx))
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Changes of an active sprint are scope changes
+	for sprintID, ids := range removedIDs {
+		if err = uc.addSprintTaskEvents(ctx, userCtx, sprintID, ids, _model.SprintTaskEventRemoved); err != nil {
+			return nil, err
+		}
+	}
+	if target != nil && target.State == _model.SprintStateActive {
+		if err = uc.addSprintTaskEvents(ctx, userCtx, target.ID, movingIDs, _model.SprintTaskEventAdded); err != nil {
+			return nil, err
+		}
+	}
+	return movedTasks, nil
+}
+
+func (uc *SprintUsecase) addSprintTaskEvents(ctx context.Context, userCtx *_projection.UserContext, sprintID string, taskIDs []string, event _model.SprintTaskEvent) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	if err := uc.sprintRepo.AddSprintTaskEvents(ctx, sprintID, taskIDs, event, actorID(userCtx)); err != nil {
+		return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return nil
+}
+
+// publishTasks publishes the tasks as updated once the change is saved
+func (uc *SprintUsecase) publishTasks(tasks []*_model.Task) {
+	for _, task := range tasks {
+		uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+	}
+}
+
+// parseSprintDates parses RFC3339 sprint dates, the sprint must end after it starts
+func parseSprintDates(start string, end string) (time.Time, time.Time, error) {
+	startDate, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return time.Time{}, time.Time{}, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid start date format")
+	}
+	endDate, err := time.Parse(time.RFC3339, end)
+	if err != nil {
+		return time.Time{}, time.Time{}, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid end date format")
+	}
+	if !endDate.After(startDate) {
+		return time.Time{}, time.Time{}, _customErr.NewGraphQLError(http.StatusBadRequest, "Sprint must end after it starts")
+	}
+	return startDate, endDate, nil
+}
+
+func taskIDsOf(tasks []*_model.Task) []string {
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
@@ -0,0 +1,383 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// bulkTaskFunc changes one task of a bulk operation inside its transaction. A client error is reported on the task
+// and leaves the other tasks untouched, any other error rolls the whole operation back
+type bulkTaskFunc func(ctx context.Context, task *_model.Task) error
+
+// BulkUpdateTasks applies the same patch to every task, omitted fields keep the values of each task
+func (uc *TaskUsecase) BulkUpdateTasks(ctx context.Context, ids []string, patch _genModel.BulkTaskPatch) ([]*_genModel.BulkTaskResult, error) {
+	logs.Infof("BulkUpdateTasks:: Starting with tasks %v and patch %v", ids, patch)
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var dueDate time.Time
+	if patch.DueDate != nil {
+		if dueDate, err = time.Parse(time.RFC3339, *patch.DueDate); err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid due date format")
+		}
+	}
+	addLabelIDs := uniqueIDs(patch.AddLabelIds)
+	removeLabelIDs := uniqueIDs(patch.RemoveLabelIds)
+	labelIDs := uniqueIDs(append(append([]string{}, addLabelIDs...), removeLabelIDs...))
+	updatesFields := patch.Priority != nil || patch.StoryPoints != nil || patch.DueDate != nil
+
+	// The labels are checked once per team
+	labelErrs := make(map[string]error)
+	results, err := uc.runBulk(ctx, ids, func(ctx context.Context, task *_model.Task) error {
+		if len(labelIDs) > 0 {
+			labelErr, checked := labelErrs[task.TeamID]
+			if !checked {
+				labelErr = uc.ensureTeamLabels(ctx, task.TeamID, labelIDs)
+				labelErrs[task.TeamID] = labelErr
+			}
+			if labelErr != nil {
+				return labelErr
+			}
+		}
+
+		if updatesFields {
+			if patch.Priority != nil {
+				task.Priority = *patch.Priority
+			}
+			if patch.StoryPoints != nil {
+				task.StoryPoints = patch.StoryPoints
+			}
+			if patch.DueDate != nil {
+				task.DueDate = dueDate
+			}
+			if _, err := uc.taskRepo.UpdateTaskById(ctx, task, nil); err != nil {
+				return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+			}
+		}
+		if len(addLabelIDs) > 0 {
+			if err := uc.labelRepo.AddTaskLabels(ctx, task.ID, addLabelIDs, actorID(userCtx)); err != nil {
+				return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+			}
+		}
+		if len(removeLabelIDs) > 0 {
+			if err := uc.labelRepo.RemoveTaskLabels(ctx, task.ID, removeLabelIDs); err != nil {
+				return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("BulkUpdateTasks:: Error updating the tasks: %v", err)
+		return nil, err
+	}
+
+	tasks := uc.completeBulkUpdate(ctx, results)
+	if updatesFields {
+		summary := updatedFieldsSummary(_genModel.UpdateTaskInput{Priority: patch.Priority, StoryPoints: patch.StoryPoints, DueDate: patch.DueDate})
+		for _, task := range tasks {
+			uc.watcherUsecase.NotifyWatchers(ctx, task, _model.NotificationKindTaskUpdated, nil, summary, nil)
+		}
+	}
+
+	logs.Info("BulkUpdateTasks:: Finish BulkUpdateTasks")
+
+	return results, nil
+}
+
+// BulkMoveTasks moves the tasks to the top of the status column of their team in the order of ids,
+// tasks already in the column keep their place
+func (uc *TaskUsecase) BulkMoveTasks(ctx context.Context, ids []string, status string) ([]*_genModel.BulkTaskResult, error) {
+	logs.Infof("BulkMoveTasks:: Starting with tasks %v and status %s", ids, status)
+	if !isTaskStatus(status) {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid task status")
+	}
+
+	previousStatus := make(map[string]string)
+	positions := make(map[string]int) // next position at the top of the column of each team
+	teams := make(map[string]*_model.Team)
+	var wipWarnings []string
+	results, err := uc.runBulk(ctx, ids, func(ctx context.Context, task *_model.Task) error {
+		if task.Status == status {
+			return nil
+		}
+		// A task waiting on unfinished tasks cannot be started
+		if status != _const.TASK_STATUS_TODO {
+			if err := uc.ensureTaskUnblocked(ctx, task.ID); err != nil {
+				return err
+			}
+		}
+
+		team, ok := teams[task.TeamID]
+		if !ok {
+			var err error
+			if team, err = uc.teamRepo.GetTeamByID(ctx, task.TeamID); err != nil {
+				return _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+			}
+			teams[task.TeamID] = team
+		}
+
+		column := _model.TaskColumn{TeamID: task.TeamID, Status: status}
+		newRank, err := rankAtPosition(ctx, uc.taskRepo, column, positions[task.TeamID], &task.ID)
+		if err != nil {
+			return err
+		}
+		wipWarning, err := uc.checkWipLimit(ctx, team, column, task.ID)
+		if err != nil {
+			return err
+		}
+
+		if _, err = uc.taskRepo.MoveTaskById(ctx, &_model.Task{ID: task.ID, Status: status, Rank: newRank}, nil); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if wipWarning != "" {
+			wipWarnings = append(wipWarnings, wipWarning)
+		}
+		previousStatus[task.ID] = task.Status
+		positions[task.TeamID]++
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("BulkMoveTasks:: Error moving the tasks: %v", err)
+		return nil, err
+	}
+
+	// The warning of a column is reported once however many tasks went over its limit
+	reported := make(map[string]bool)
+	for _, wipWarning := range wipWarnings {
+		if !reported[wipWarning] {
+			reported[wipWarning] = true
+			addWarning(ctx, "WIP_LIMIT_EXCEEDED", wipWarning)
+		}
+	}
+
+	for _, task := range uc.completeBulkUpdate(ctx, results) {
+		if from, moved := previousStatus[task.ID]; moved {
+			summary := fmt.Sprintf("Moved from %s to %s", from, task.Status)
+			uc.watcherUsecase.NotifyWatchers(ctx, task, _model.NotificationKindTaskMoved, nil, summary, nil)
+		}
+	}
+
+	logs.Info("BulkMoveTasks:: Finish BulkMoveTasks")
+
+	return results, nil
+}
+
+// BulkAssignTasks assigns every task to assignedTo, a nil assignedTo unassigns them
+func (uc *TaskUsecase) BulkAssignTasks(ctx context.Context, ids []string, assignedTo *string) ([]*_genModel.BulkTaskResult, error) {
+	logs.Infof("BulkAssignTasks:: Starting with tasks %v and assignee %v", ids, assignedTo)
+	summary := "Unassigned"
+	if assignedTo != nil {
+		assignedUser, err := uc.userRepo.GetUserByID(ctx, *assignedTo)
+		if err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Assigned user not found")
+		}
+		summary = fmt.Sprintf("Assigned to %s", assignedUser.Name)
+	}
+
+	// The new assignee starts watching the tasks
+	previousAssignee := make(map[string]*string)
+	results, err := uc.runBulk(ctx, ids, func(ctx context.Context, task *_model.Task) error {
+		if _, err := uc.taskRepo.AssignTask(ctx, &_model.Task{ID: task.ID, AssignedTo: assignedTo}, nil); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		previousAssignee[task.ID] = task.AssignedTo
+		if assignedTo == nil {
+			return nil
+		}
+		return uc.watcherUsecase.AddWatchers(ctx, task.ID, []string{*assignedTo})
+	})
+	if err != nil {
+		logs.Errorf("BulkAssignTasks:: Error assigning the tasks: %v", err)
+		return nil, err
+	}
+
+	for _, task := range uc.completeBulkUpdate(ctx, results) {
+		from := previousAssignee[task.ID]
+		if assignedTo == nil || from == nil || *from != *assignedTo {
+			uc.watcherUsecase.NotifyWatchers(ctx, task, _model.NotificationKindTaskUpdated, nil, summary, nil)
+		}
+	}
+
+	logs.Info("BulkAssignTasks:: Finish BulkAssignTasks")
+
+	return results, nil
+}
+
+// BulkDeleteTasks moves the tasks to the trash with their subtasks, like DeleteTaskById
+func (uc *TaskUsecase) BulkDeleteTasks(ctx context.Context, ids []string) ([]*_genModel.BulkTaskResult, error) {
+	logs.Infof("BulkDeleteTasks:: Starting with tasks %v", ids)
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// A subtask listed after its parent is already in the trash and comes back with it
+	var trashedTasks []*_model.Task
+	results, err := uc.runBulk(ctx, ids, func(ctx context.Context, task *_model.Task) error {
+		tasks, err := uc.taskRepo.TrashTask(ctx, task.ID, actorID(userCtx))
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		trashedTasks = append(trashedTasks, tasks...)
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("BulkDeleteTasks:: Error deleting the tasks: %v", err)
+		return nil, err
+	}
+
+	tasksByID := make(map[string]*_model.Task, len(trashedTasks))
+	for _, task := range trashedTasks {
+		tasksByID[task.ID] = task
+	}
+	for _, result := range results {
+		if result.Error == nil {
+			result.Task = tasksByID[result.ID]
+		}
+	}
+	uc.publishBatches(_model.TaskBatchTypeDeleted, trashedTasks)
+
+	logs.Info("BulkDeleteTasks:: Finish BulkDeleteTasks")
+
+	return results, nil
+}
+
+// runBulk applies apply to the tasks of ids in one transaction and reports the outcome of each task in the order of ids.
+// The tasks are locked first and the team of the tasks is checked once, a task of a team the caller cannot edit fails
+func (uc *TaskUsecase) runBulk(ctx context.Context, ids []string, apply bulkTaskFunc) ([]*_genModel.BulkTaskResult, error) {
+	ids = uniqueIDs(ids)
+
+	var results []*_genModel.BulkTaskResult
+	err := uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		results = make([]*_genModel.BulkTaskResult, 0, len(ids))
+		if err := uc.taskRepo.LockTasks(ctx, ids); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		tasks, err := uc.taskRepo.GetTasksByIDs(ctx, ids)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		tasksByID := make(map[string]*_model.Task, len(tasks))
+		for _, task := range tasks {
+			tasksByID[task.ID] = task
+		}
+
+		teamErrs := make(map[string]error)
+		for _, id := range ids {
+			result := &_genModel.BulkTaskResult{ID: id}
+			results = append(results, result)
+
+			task, ok := tasksByID[id]
+			if !ok {
+				result.Error = bulkTaskError(_customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found"))
+				continue
+			}
+
+			teamErr, checked := teamErrs[task.TeamID]
+			if !checked {
+				_, teamErr = ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, task.TeamID)
+				teamErrs[task.TeamID] = teamErr
+			}
+			err := teamErr
+			if err == nil {
+				err = apply(ctx, task)
+			}
+			if err != nil {
+				if result.Error = bulkTaskError(err); result.Error == nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// completeBulkUpdate loads the tasks changed by a bulk operation into their results and publishes them,
+// failures are only logged since the changes are already saved
+func (uc *TaskUsecase) completeBulkUpdate(ctx context.Context, results []*_genModel.BulkTaskResult) []*_model.Task {
+	var ids []string
+	for _, result := range results {
+		if result.Error == nil {
+			ids = append(ids, result.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tasks, err := uc.taskRepo.GetTasksByIDs(ctx, ids)
+	if err != nil {
+		logs.Errorf("completeBulkUpdate:: Error GetTasksByIDs repo: %v", err)
+		return nil
+	}
+	tasksByID := make(map[string]*_model.Task, len(tasks))
+	for _, task := range tasks {
+		tasksByID[task.ID] = task
+	}
+	for _, result := range results {
+		if result.Error == nil {
+			result.Task = tasksByID[result.ID]
+		}
+	}
+
+	uc.publishBatches(_model.TaskBatchTypeUpdated, tasks)
+	return tasks
+}
+
+// publishBatches publishes one event per team for the tasks changed by a bulk operation
+func (uc *TaskUsecase) publishBatches(batchType _model.TaskBatchType, tasks []*_model.Task) {
+	var teamIDs []string
+	tasksByTeam := make(map[string][]*_model.Task)
+	for _, task := range tasks {
+		if _, ok := tasksByTeam[task.TeamID]; !ok {
+			teamIDs = append(teamIDs, task.TeamID)
+		}
+		tasksByTeam[task.TeamID] = append(tasksByTeam[task.TeamID], task)
+	}
+
+	for _, teamID := range teamIDs {
+		uc.taskPubSub.PublishBatch(teamID, &_model.TaskBatch{Type: batchType, Tasks: tasksByTeam[teamID]})
+	}
+}
+
+// ensureTeamLabels rejects labels that are not in the palette of the team
+func (uc *TaskUsecase) ensureTeamLabels(ctx context.Context, teamID string, labelIDs []string) error {
+	count, err := uc.labelRepo.CountTeamLabels(ctx, teamID, labelIDs)
+	if err != nil {
+		return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if count != len(labelIDs) {
+		return _customErr.NewGraphQLError(http.StatusBadRequest, "Labels must belong to the team of the task")
+	}
+	return nil
+}
+
+// bulkTaskError turns a client error into the error reported on a task, nil for the errors that abort the operation
+func bulkTaskError(err error) *_genModel.BulkTaskError {
+	var gqlErr *gqlerror.Error
+	if !errors.As(err, &gqlErr) {
+		return nil
+	}
+	status, _ := gqlErr.Extensions["status"].(int)
+	if status < http.StatusBadRequest || status >= http.StatusInternalServerError {
+		return nil
+	}
+
+	code, _ := gqlErr.Extensions["code"].(string)
+	return &_genModel.BulkTaskError{Code: code, Message: gqlErr.Message}
+}
@@ -0,0 +1,221 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_pubsub "bitbucket.org/edts/go-task-management/internal/pubsub"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+)
+
+type TaskDependencyUsecaseInterface interface {
+	AddDependency(ctx context.Context, taskID string, blockedByTaskID string) (*_model.Task, error)
+	RemoveDependency(ctx context.Context, taskID string, blockedByTaskID string) (*_model.Task, error)
+	GetCriticalPath(ctx context.Context, teamID string) ([]*_model.Task, error)
+}
+
+type TaskDependencyUsecase struct {
+	// Repo
+	taskDependencyRepo _repo.TaskDependencyRepositoryInterface
+	taskRepo           _repo.TaskRepositoryInterface
+	txRepo             _repo.TransactionRepositoryInterface
+	// PubSub
+	taskPubSub _pubsub.TaskPubSubInterface
+}
+
+func NewTaskDependencyUsecase(
+	taskDependencyRepo _repo.TaskDependencyRepositoryInterface,
+	taskRepo _repo.TaskRepositoryInterface,
+	txRepo _repo.TransactionRepositoryInterface,
+	taskPubSub _pubsub.TaskPubSubInterface) TaskDependencyUsecaseInterface {
+	return &TaskDependencyUsecase{
+		taskDependencyRepo: taskDependencyRepo,
+		taskRepo:           taskRepo,
+		txRepo:             txRepo,
+		taskPubSub:         taskPubSub,
+	}
+}
+
+// AddDependency makes taskID wait on blockedByTaskID, both tasks must belong to the same team
+func (uc *TaskDependencyUsecase) AddDependency(ctx context.Context, taskID string, blockedByTaskID string) (*_model.Task, error) {
+	logs.Infof("AddDependency:: Starting with task %s blocked by %s", taskID, blockedByTaskID)
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if taskID == blockedByTaskID {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "A task cannot be blocked by itself")
+	}
+
+	task, blocker, err := uc.getDependencyTasks(ctx, taskID, blockedByTaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.taskDependencyRepo.LockTeamTaskDependencies(ctx, task.TeamID); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+
+		// The blocker must not already wait on the task, directly or through other tasks
+		isCycle, err := uc.taskDependencyRepo.IsTaskBlockedBy(ctx, blocker.ID, task.ID)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if isCycle {
+			return _customErr.NewGraphQLError(http.StatusBadRequest, "Dependency would create a cycle")
+		}
+
+		err = uc.taskDependencyRepo.CreateTaskDependency(ctx, &_model.TaskDependency{
+			TaskID:          task.ID,
+			BlockedByTaskID: blocker.ID,
+			Base: _model.Base{
+				CreatedBy: actorID(userCtx),
+			},
+		})
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("AddDependency:: Error adding the dependency: %v", err)
+		return nil, err
+	}
+
+	uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+	uc.taskPubSub.Publish(blocker.TeamID, _const.UPDATED, blocker)
+
+	logs.Info("AddDependency:: Finish AddDependency")
+
+	return task, nil
+}
+
+func (uc *TaskDependencyUsecase) RemoveDependency(ctx context.Context, taskID string, blockedByTaskID string) (*_model.Task, error) {
+	task, blocker, err := uc.getDependencyTasks(ctx, taskID, blockedByTaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	isRemoved, err := uc.taskDependencyRepo.DeleteTaskDependency(ctx, task.ID, blocker.ID)
+	if err != nil {
+		logs.Errorf("RemoveDependency:: Error DeleteTaskDependency repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if !isRemoved {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Dependency not found")
+	}
+
+	uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+	uc.taskPubSub.Publish(blocker.TeamID, _const.UPDATED, blocker)
+
+	return task, nil
+}
+
+// GetCriticalPath returns the longest chain of unfinished tasks of the team where each task waits on the previous one,
+// equally long chains are ranked by the due date of their last task, the latest wins
+func (uc *TaskDependencyUsecase) GetCriticalPath(ctx context.Context, teamID string) ([]*_model.Task, error) {
+	tasks, err := uc.taskRepo.GetTasksByTeam(ctx, teamID, nil, nil)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	dependencies, err := uc.taskDependencyRepo.GetTeamTaskDependencies(ctx, teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	return longestDependencyChain(tasks, dependencies), nil
+}
+
+// getDependencyTasks loads both ends of a dependency and checks they can be linked
+func (uc *TaskDependencyUsecase) getDependencyTasks(ctx context.Context, taskID string, blockedByTaskID string) (*_model.Task, *_model.Task, error) {
+	task, err := uc.taskRepo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+	}
+	if err = ensureTeamWritable(task.Team); err != nil {
+		return nil, nil, err
+	}
+
+	blocker, err := uc.taskRepo.GetTaskByID(ctx, blockedByTaskID)
+	if err != nil {
+		return nil, nil, _customErr.NewGraphQLError(http.StatusNotFound, "Blocking task not found")
+	}
+	if blocker.TeamID != task.TeamID {
+		return nil, nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Blocking task belongs to another team")
+	}
+	return task, blocker, nil
+}
+
+// longestDependencyChain walks the dependency graph of the unfinished tasks, done tasks no longer hold anything up
+func longestDependencyChain(tasks []*_model.Task, dependencies []*_model.TaskDependency) []*_model.Task {
+	openTasks := make(map[string]*_model.Task)
+	for _, task := range tasks {
+		if task.Status != _const.TASK_STATUS_DONE {
+			openTasks[task.ID] = task
+		}
+	}
+
+	blockers := make(map[string][]string)
+	for _, dependency := range dependencies {
+		if openTasks[dependency.TaskID] != nil && openTasks[dependency.BlockedByTaskID] != nil {
+			blockers[dependency.TaskID] = append(blockers[dependency.TaskID], dependency.BlockedByTaskID)
+		}
+	}
+
+	// chains[id] is the best chain ending at the task, visiting guards against a cycle left by bad data
+	chains := make(map[string][]*_model.Task)
+	visiting := make(map[string]bool)
+	var chainTo func(id string) []*_model.Task
+	chainTo = func(id string) []*_model.Task {
+		if chain, ok := chains[id]; ok {
+			return chain
+		}
+		if visiting[id] {
+			return nil
+		}
+		visiting[id] = true
+
+		var best []*_model.Task
+		for _, blockerID := range blockers[id] {
+			if chain := chainTo(blockerID); isLongerChain(chain, best) {
+				best = chain
+			}
+		}
+		chain := append(append([]*_model.Task{}, best...), openTasks[id])
+
+		visiting[id] = false
+		chains[id] = chain
+		return chain
+	}
+
+	// Walk the tasks in a stable order so ties always resolve the same way
+	ids := make([]string, 0, len(openTasks))
+	for id := range openTasks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	criticalPath := []*_model.Task{}
+	for _, id := range ids {
+		if chain := chainTo(id); isLongerChain(chain, criticalPath) {
+			criticalPath = chain
+		}
+	}
+	return criticalPath
+}
+
+// isLongerChain prefers the chain with more tasks, then the one finishing on the later due date
+func isLongerChain(chain []*_model.Task, than []*_model.Task) bool {
+	if len(chain) != len(than) {
+		return len(chain) > len(than)
+	}
+	if len(chain) == 0 {
+		return false
+	}
+	return chain[len(chain)-1].DueDate.After(than[len(than)-1].DueDate)
+}
@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_projection "bitbucket.org/edts/go-task-management/internal/model/projection"
+	_pubsub "bitbucket.org/edts/go-task-management/internal/pubsub"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+)
+
+// fakeTaskDependencyRepo walks the dependencies in memory the way the recursive query of the repository does
+type fakeTaskDependencyRepo struct {
+	_repo.TaskDependencyRepositoryInterface
+	blockers map[string][]string
+}
+
+func (r *fakeTaskDependencyRepo) LockTeamTaskDependencies(ctx context.Context, teamID string) error {
+	return nil
+}
+
+func (r *fakeTaskDependencyRepo) CreateTaskDependency(ctx context.Context, dependency *_model.TaskDependency) error {
+	r.blockers[dependency.TaskID] = append(r.blockers[dependency.TaskID], dependency.BlockedByTaskID)
+	return nil
+}
+
+func (r *fakeTaskDependencyRepo) IsTaskBlockedBy(ctx context.Context, taskID string, blockedByTaskID string) (bool, error) {
+	seen := map[string]bool{}
+	queue := append([]string{}, r.blockers[taskID]...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == blockedByTaskID {
+			return true, nil
+		}
+		if !seen[id] {
+			seen[id] = true
+			queue = append(queue, r.blockers[id]...)
+		}
+	}
+	return false, nil
+}
+
+type fakeTaskPubSub struct {
+	_pubsub.TaskPubSubInterface
+}
+
+func (fakeTaskPubSub) Publish(teamID, eventType string, task *_model.Task) {}
+
+func TestAddDependencyRejectsCycles(t *testing.T) {
+	tasks := &fakeTaskRepo{tasks: map[string]*_model.Task{}}
+	for _, id := range []string{"a", "b", "c", "d"} {
+		tasks.tasks[id] = &_model.Task{ID: id, TeamID: "team-a"}
+	}
+	tasks.tasks["other"] = &_model.Task{ID: "other", TeamID: "team-b"}
+	// a waits on b which waits on c
+	dependencies := &fakeTaskDependencyRepo{blockers: map[string][]string{"a": {"b"}, "b": {"c"}}}
+	uc := &TaskDependencyUsecase{
+		taskDependencyRepo: dependencies,
+		taskRepo:           tasks,
+		txRepo:             fakeTxRepo{},
+		taskPubSub:         fakeTaskPubSub{},
+	}
+	ctx := context.WithValue(context.Background(), "user", &_projection.UserContext{UserID: "user-1", Scopes: []string{}})
+
+	tests := []struct {
+		name      string
+		taskID    string
+		blockedBy string
+		status    int
+	}{
+		{name: "self dependency", taskID: "a", blockedBy: "a", status: http.StatusBadRequest},
+		{name: "direct cycle", taskID: "b", blockedBy: "a", status: http.StatusBadRequest},
+		{name: "cycle through another task", taskID: "c", blockedBy: "a", status: http.StatusBadRequest},
+		{name: "task of another team", taskID: "a", blockedBy: "other", status: http.StatusBadRequest},
+		{name: "unknown blocker", taskID: "a", blockedBy: "missing", status: http.StatusNotFound},
+		{name: "chain extended", taskID: "c", blockedBy: "d"},
+		{name: "shortcut along the chain", taskID: "a", blockedBy: "c"},
+		{name: "cycle through the new dependency", taskID: "d", blockedBy: "b", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := uc.AddDependency(ctx, tt.taskID, tt.blockedBy)
+			if tt.status != 0 {
+				assertStatus(t, err, tt.status)
+				return
+			}
+			if err != nil {
+				t.Fatalf("AddDependency: %v", err)
+			}
+		})
+	}
+}
+
+func TestLongestDependencyChainSurvivesCycles(t *testing.T) {
+	tasks := []*_model.Task{
+		{ID: "a", Status: _const.TASK_STATUS_TODO},
+		{ID: "b", Status: _const.TASK_STATUS_TODO},
+		{ID: "c", Status: _const.TASK_STATUS_TODO},
+		{ID: "done", Status: _const.TASK_STATUS_DONE},
+	}
+	// a and b wait on each other, a cycle left by bad data must not loop forever
+	dependencies := []*_model.TaskDependency{
+		{TaskID: "a", BlockedByTaskID: "b"},
+		{TaskID: "b", BlockedByTaskID: "a"},
+		{TaskID: "c", BlockedByTaskID: "b"},
+		{TaskID: "c", BlockedByTaskID: "done"},
+	}
+
+	chain := longestDependencyChain(tasks, dependencies)
+	if len(chain) != 2 {
+		t.Fatalf("expected a chain of 2 tasks, got %d", len(chain))
+	}
+	if chain[0].ID == chain[1].ID {
+		t.Fatalf("expected the chain to hold distinct tasks, got %s twice", chain[0].ID)
+	}
+	for _, task := range chain {
+		if task.ID == "done" {
+			t.Fatal("expected done tasks to be left out of the chain")
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetTaskByKey finds the task by its key such as ENG-142, or by a key it had before moving to another team
+func (uc *TaskUsecase) GetTaskByKey(ctx context.Context, key string) (*_model.Task, error) {
+	taskKey, ok := _model.ParseTaskKey(key)
+	if !ok {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid task key, expected the team key and the task number such as ENG-142")
+	}
+
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := uc.taskRepo.GetTasksByKeys(ctx, []_model.TaskKey{taskKey})
+	if err != nil {
+		logs.Errorf("GetTaskByKey:: Error GetTasksByKeys repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if len(tasks) == 0 {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, tasks[0].TeamID); err != nil {
+		return nil, err
+	}
+	return tasks[0], nil
+}
+
+// MoveTaskToTeam moves the task to another team at the top of the same status column, where it gets the next
+// task number. The current key stays an alias of the task. The caller must be able to edit both teams, the
+// assignee is kept only when they are a member of the new team and so are the watchers
+func (uc *TaskUsecase) MoveTaskToTeam(ctx context.Context, taskID string, teamID string, expectedVersion *int32) (*_model.Task, error) {
+	logs.Infof("MoveTaskToTeam:: Starting with task %s and team %s", taskID, teamID)
+	existingTask, err := uc.taskRepo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+	}
+	if existingTask.TeamID == teamID {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Task already belongs to this team")
+	}
+	if err = ensureTaskVersion(existingTask, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, existingTask.TeamID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, teamID); err != nil {
+		return nil, err
+	}
+	team, err := uc.teamRepo.GetTeamByID(ctx, teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+
+	// Subtasks stay in the team of their parent
+	subtasks, err := uc.taskRepo.GetSubtasksByParentIDs(ctx, []string{existingTask.ID})
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if len(subtasks) > 0 {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "A task with subtasks cannot move to another team, move its subtasks out first")
+	}
+
+	assignedTo := existingTask.AssignedTo
+	if assignedTo != nil {
+		isMember, err := uc.userTeamRepo.IsUserInTeam(ctx, *assignedTo, teamID)
+		if err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if !isMember {
+			assignedTo = nil
+			addWarning(ctx, "TASK_UNASSIGNED", "The assignee is not a member of the new team, the task was unassigned")
+		}
+	}
+
+	var movedTask *_model.Task
+	var wipWarning string
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.taskRepo.LockTasks(ctx, []string{existingTask.ID}); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+
+		// The task may have changed since it was checked, the key kept as alias is the one it has now
+		current, err := uc.taskRepo.GetTaskByID(ctx, existingTask.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+		}
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if current.TeamID != existingTask.TeamID || (expectedVersion != nil && current.Version != *expectedVersion) {
+			return taskConflictError(current)
+		}
+
+		column := _model.TaskColumn{TeamID: teamID, Status: existingTask.Status}
+		rank, err := rankAtPosition(ctx, uc.taskRepo, column, 0, nil)
+		if err != nil {
+			return err
+		}
+		if wipWarning, err = uc.checkWipLimit(ctx, team, column, existingTask.ID); err != nil {
+			return err
+		}
+
+		movedTask, err = uc.taskRepo.MoveTaskToTeam(ctx, &_model.Task{
+			ID:         existingTask.ID,
+			TeamID:     teamID,
+			Rank:       rank,
+			AssignedTo: assignedTo,
+		}, actorID(userCtx))
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("MoveTaskToTeam:: Error moving the task: %v", err)
+		return nil, err
+	}
+	movedTask.Team = team
+	if wipWarning != "" {
+		addWarning(ctx, "WIP_LIMIT_EXCEEDED", wipWarning)
+	}
+
+	// The task leaves the board of its previous team and shows up on the new one
+	uc.taskPubSub.Publish(existingTask.TeamID, _const.DELETED, existingTask)
+	uc.taskPubSub.Publish(teamID, _const.CREATED, movedTask)
+
+	previousKey := _model.TaskKey{TeamKey: existingTask.Team.Key, Number: existingTask.Number}
+	newKey := _model.TaskKey{TeamKey: team.Key, Number: movedTask.Number}
+	summary := fmt.Sprintf("Moved from %s to %s", previousKey, newKey)
+	uc.watcherUsecase.NotifyWatchers(ctx, movedTask, _model.NotificationKindTaskMoved, nil, summary, nil)
+
+	logs.Info("MoveTaskToTeam:: Finish MoveTaskToTeam")
+
+	return movedTask, nil
+}
@@ -0,0 +1,392 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_pubsub "bitbucket.org/edts/go-task-management/internal/pubsub"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"bitbucket.org/edts/go-task-management/pkg/rrule"
+)
+
+type TaskSeriesUsecaseInterface interface {
+	CreateTaskSeries(ctx context.Context, input _genModel.CreateTaskSeriesInput) (*_model.TaskSeries, error)
+	UpdateTaskSeries(ctx context.Context, input _genModel.UpdateTaskSeriesInput) (*_model.TaskSeries, error)
+	EndTaskSeries(ctx context.Context, id string) (*_model.TaskSeries, error)
+	GetTaskSeriesByTeam(ctx context.Context, teamID string) ([]*_model.TaskSeries, error)
+	GenerateTaskOccurrences(ctx context.Context) (int, error)
+}
+
+// taskSeriesBatchSize bounds the series a generator run goes through
+const taskSeriesBatchSize = 100
+
+type TaskSeriesUsecase struct {
+	// Repo
+	taskSeriesRepo _repo.TaskSeriesRepositoryInterface
+	taskRepo       _repo.TaskRepositoryInterface
+	teamRepo       _repo.TeamRepositoryInterface
+	userTeamRepo   _repo.UserTeamRepositoryInterface
+	txRepo         _repo.TransactionRepositoryInterface
+	// PubSub
+	taskPubSub _pubsub.TaskPubSubInterface
+}
+
+func NewTaskSeriesUsecase(
+	taskSeriesRepo _repo.TaskSeriesRepositoryInterface,
+	taskRepo _repo.TaskRepositoryInterface,
+	teamRepo _repo.TeamRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface,
+	txRepo _repo.TransactionRepositoryInterface,
+	taskPubSub _pubsub.TaskPubSubInterface) TaskSeriesUsecaseInterface {
+	return &TaskSeriesUsecase{
+		taskSeriesRepo: taskSeriesRepo,
+		taskRepo:       taskRepo,
+		teamRepo:       teamRepo,
+		userTeamRepo:   userTeamRepo,
+		txRepo:         txRepo,
+		taskPubSub:     taskPubSub,
+	}
+}
+
+// CreateTaskSeries saves the recurring task and creates the task of its first occurrence
+func (uc *TaskSeriesUsecase) CreateTaskSeries(ctx context.Context, input _genModel.CreateTaskSeriesInput) (*_model.TaskSeries, error) {
+	logs.Infof("CreateTaskSeries:: Starting with payload %v", input)
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, input.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := parseRecurrenceRule(input.Rrule)
+	if err != nil {
+		return nil, err
+	}
+	startAt, err := time.Parse(time.RFC3339, input.StartAt)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid start date format")
+	}
+	startAt = startAt.UTC()
+
+	// The start itself is an occurrence only when it matches the rule
+	firstOccurrence, ok := rule.Next(startAt, startAt.Add(-time.Nanosecond))
+	if !ok {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "The recurrence rule has no occurrence")
+	}
+
+	series := &_model.TaskSeries{
+		TeamID:           input.TeamID,
+		Title:            input.Title,
+		Description:      input.Description,
+		AssignedTo:       input.AssignedTo,
+		Priority:         _model.TaskPriorityNone,
+		StoryPoints:      input.StoryPoints,
+		OriginalEstimate: input.OriginalEstimate,
+		Rrule:            rule.String(),
+		StartAt:          startAt,
+		NextOccurrenceAt: &firstOccurrence,
+		Base: _model.Base{
+			CreatedBy:  actorID(userCtx),
+			ModifiedBy: actorID(userCtx),
+		},
+	}
+	if input.Priority != nil {
+		series.Priority = *input.Priority
+	}
+
+	var task *_model.Task
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		created, err := uc.taskSeriesRepo.CreateTaskSeries(ctx, series)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		series, task, err = uc.generateOccurrence(ctx, created, rule, time.Now().UTC())
+		return err
+	})
+	if err != nil {
+		logs.Errorf("CreateTaskSeries:: Error creating the series: %v", err)
+		return nil, err
+	}
+
+	// Publish taskCreated event
+	if task != nil {
+		uc.taskPubSub.Publish(task.TeamID, _const.CREATED, task)
+	}
+
+	logs.Info("CreateTaskSeries:: Finish CreateTaskSeries")
+
+	return series, nil
+}
+
+// UpdateTaskSeries edits the template and the rule of the series, the open tasks not edited on their own follow
+// the template when asked
+func (uc *TaskSeriesUsecase) UpdateTaskSeries(ctx context.Context, input _genModel.UpdateTaskSeriesInput) (*_model.TaskSeries, error) {
+	logs.Infof("UpdateTaskSeries:: Starting with payload %v", input)
+	existingSeries, err := uc.taskSeriesRepo.GetTaskSeriesByID(ctx, input.ID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task series not found")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, existingSeries.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rule *rrule.Rule
+	if input.Rrule != nil {
+		if rule, err = parseRecurrenceRule(*input.Rrule); err != nil {
+			return nil, err
+		}
+	}
+
+	var updatedSeries *_model.TaskSeries
+	var tasks []*_model.Task
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		// Lock the series so the generator does not advance it meanwhile
+		series, err := uc.taskSeriesRepo.LockTaskSeries(ctx, existingSeries.ID)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if series.EndedAt != nil {
+			return _customErr.NewGraphQLError(http.StatusBadRequest, "The task series has ended")
+		}
+
+		// Update only the fields that are provided
+		if input.Title != nil {
+			series.Title = *input.Title
+		}
+		if input.Description != nil {
+			series.Description = input.Description
+		}
+		if input.AssignedTo != nil {
+			series.AssignedTo = input.AssignedTo
+		}
+		if input.Priority != nil {
+			series.Priority = *input.Priority
+		}
+		if input.StoryPoints != nil {
+			series.StoryPoints = input.StoryPoints
+		}
+		if input.OriginalEstimate != nil {
+			series.OriginalEstimate = input.OriginalEstimate
+		}
+		if rule != nil {
+			// The new rule applies to the occurrences after the last generated one
+			after := series.StartAt.Add(-time.Nanosecond)
+			if series.LastOccurrenceAt != nil {
+				after = *series.LastOccurrenceAt
+			}
+			series.Rrule = rule.String()
+			series.NextOccurrenceAt = nil
+			if next, ok := rule.Next(series.StartAt, after); ok {
+				series.NextOccurrenceAt = &next
+			}
+		}
+		series.ModifiedBy = actorID(userCtx)
+
+		if updatedSeries, err = uc.taskSeriesRepo.UpdateTaskSeries(ctx, series); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if input.ApplyToOpenOccurrences {
+			if tasks, err = uc.taskSeriesRepo.UpdateOpenOccurrences(ctx, updatedSeries); err != nil {
+				return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("UpdateTaskSeries:: Error updating the series: %v", err)
+		return nil, err
+	}
+
+	// Publish taskUpdated events
+	for _, task := range tasks {
+		uc.taskPubSub.Publish(task.TeamID, _const.UPDATED, task)
+	}
+
+	logs.Info("UpdateTaskSeries:: Finish UpdateTaskSeries")
+
+	return updatedSeries, nil
+}
+
+// EndTaskSeries stops the series from generating tasks, the tasks already generated are kept
+func (uc *TaskSeriesUsecase) EndTaskSeries(ctx context.Context, id string) (*_model.TaskSeries, error) {
+	logs.Infof("EndTaskSeries:: Starting with series %s", id)
+	existingSeries, err := uc.taskSeriesRepo.GetTaskSeriesByID(ctx, id)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task series not found")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, existingSeries.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	var endedSeries *_model.TaskSeries
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		series, err := uc.taskSeriesRepo.LockTaskSeries(ctx, existingSeries.ID)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if series.EndedAt != nil {
+			endedSeries = series
+			return nil
+		}
+
+		endedAt := time.Now().UTC()
+		series.EndedAt = &endedAt
+		series.NextOccurrenceAt = nil
+		series.ModifiedBy = actorID(userCtx)
+		if endedSeries, err = uc.taskSeriesRepo.UpdateTaskSeries(ctx, series); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("EndTaskSeries:: Error ending the series: %v", err)
+		return nil, err
+	}
+
+	logs.Info("EndTaskSeries:: Finish EndTaskSeries")
+
+	return endedSeries, nil
+}
+
+func (uc *TaskSeriesUsecase) GetTaskSeriesByTeam(ctx context.Context, teamID string) ([]*_model.TaskSeries, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, teamID); err != nil {
+		return nil, err
+	}
+
+	seriesList, err := uc.taskSeriesRepo.GetTaskSeriesByTeamID(ctx, teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return seriesList, nil
+}
+
+// GenerateTaskOccurrences creates the tasks of the series whose occurrence is due or whose previous task is done,
+// run periodically by every replica. Each replica locks different series and an occurrence has a single task.
+// Every series is generated in a transaction of its own, a failing series is logged and skipped for the rest of the run
+func (uc *TaskSeriesUsecase) GenerateTaskOccurrences(ctx context.Context) (int, error) {
+	ctx = _repo.WithoutWorkspaceIsolation(ctx)
+	now := time.Now().UTC()
+
+	generated := 0
+	skipIDs := []string{}
+	for i := 0; i < taskSeriesBatchSize; i++ {
+		series, task, err := uc.generateDueOccurrence(ctx, now, skipIDs)
+		if err != nil && series == nil {
+			logs.Errorf("GenerateTaskOccurrences:: Error locking the due series: %v", err)
+			return generated, err
+		}
+		if err != nil {
+			logs.Errorf("GenerateTaskOccurrences:: Error generating the occurrence of series %s, skipping it: %v", series.ID, err)
+			skipIDs = append(skipIDs, series.ID)
+			continue
+		}
+		if series == nil {
+			break
+		}
+
+		if task != nil {
+			// Publish taskCreated event
+			uc.taskPubSub.Publish(task.TeamID, _const.CREATED, task)
+			generated++
+		}
+	}
+
+	if generated != 0 {
+		logs.Infof("GenerateTaskOccurrences:: Generated %d tasks", generated)
+	}
+	return generated, nil
+}
+
+// generateDueOccurrence locks the next due series left out of skipIDs and generates its occurrence,
+// a nil series means no series is due
+func (uc *TaskSeriesUsecase) generateDueOccurrence(ctx context.Context, now time.Time, skipIDs []string) (*_model.TaskSeries, *_model.Task, error) {
+	var series *_model.TaskSeries
+	var task *_model.Task
+	err := uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		seriesList, err := uc.taskSeriesRepo.LockDueTaskSeries(ctx, now, 1, skipIDs)
+		if err != nil || len(seriesList) == 0 {
+			return err
+		}
+		series = seriesList[0]
+
+		rule, err := rrule.Parse(series.Rrule)
+		if err != nil {
+			return fmt.Errorf("invalid rule %q: %w", series.Rrule, err)
+		}
+		_, task, err = uc.generateOccurrence(ctx, series, rule, now)
+		return err
+	})
+	return series, task, err
+}
+
+// generateOccurrence creates the task of the next occurrence of the locked series and schedules the one after.
+// Occurrences missed while no generator ran collapse into that task, a nil task means it already existed
+func (uc *TaskSeriesUsecase) generateOccurrence(ctx context.Context, series *_model.TaskSeries, rule *rrule.Rule, now time.Time) (*_model.TaskSeries, *_model.Task, error) {
+	occurrenceAt := *series.NextOccurrenceAt
+
+	// New tasks go on top of their column
+	column := _model.TaskColumn{TeamID: series.TeamID, Status: _const.TASK_STATUS_TODO}
+	taskRank, err := rankAtPosition(ctx, uc.taskRepo, column, 0, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	task, err := uc.taskSeriesRepo.CreateOccurrence(ctx, &_model.Task{
+		Title:            series.Title,
+		Description:      series.Description,
+		Status:           column.Status,
+		AssignedTo:       series.AssignedTo,
+		TeamID:           series.TeamID,
+		Priority:         series.Priority,
+		Rank:             taskRank,
+		DueDate:          occurrenceAt,
+		StoryPoints:      series.StoryPoints,
+		OriginalEstimate: series.OriginalEstimate,
+		SeriesID:         &series.ID,
+		OccurrenceAt:     &occurrenceAt,
+		Base: _model.Base{
+			CreatedBy:  series.ModifiedBy,
+			ModifiedBy: series.ModifiedBy,
+		},
+	})
+	if err != nil {
+		return nil, nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	series.LastOccurrenceAt = &occurrenceAt
+	series.NextOccurrenceAt = nil
+	if next, ok := rule.Next(series.StartAt, latest(occurrenceAt, now)); ok {
+		series.NextOccurrenceAt = &next
+	}
+	updatedSeries, err := uc.taskSeriesRepo.UpdateTaskSeries(ctx, series)
+	if err != nil {
+		return nil, nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return updatedSeries, task, nil
+}
+
+// parseRecurrenceRule reads an RRULE given by a client
+func parseRecurrenceRule(value string) (*rrule.Rule, error) {
+	rule, err := rrule.Parse(value)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, err.Error())
+	}
+	return rule, nil
+}
+
+func latest(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,653 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type TaskTemplateUsecaseInterface interface {
+	CreateTaskTemplate(ctx context.Context, input _genModel.CreateTaskTemplateInput) (*_model.TaskTemplate, error)
+	UpdateTaskTemplate(ctx context.Context, input _genModel.UpdateTaskTemplateInput) (*_model.TaskTemplate, error)
+	DeleteTaskTemplate(ctx context.Context, id string) (bool, error)
+	GetTaskTemplatesByTeam(ctx context.Context, teamID string) ([]*_model.TaskTemplate, error)
+	GetTaskTemplateByID(ctx context.Context, id string) (*_model.TaskTemplate, error)
+	CreateTaskFromTemplate(ctx context.Context, templateID string, overrides *_genModel.TaskTemplateOverridesInput) (*_model.Task, error)
+	ExportTaskTemplates(ctx context.Context, teamID string, ids []string) (string, error)
+	ImportTaskTemplates(ctx context.Context, teamID string, document string) ([]*_model.TaskTemplate, error)
+}
+
+// taskTemplateFileVersion is the version of the JSON document written by ExportTaskTemplates
+const taskTemplateFileVersion = 1
+
+// maxImportedTaskTemplates bounds the templates of a single import
+const maxImportedTaskTemplates = 100
+
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type TaskTemplateUsecase struct {
+	// Repo
+	taskTemplateRepo _repo.TaskTemplateRepositoryInterface
+	labelRepo        _repo.LabelRepositoryInterface
+	checklistRepo    _repo.ChecklistItemRepositoryInterface
+	userRepo         _repo.UserRepositoryInterface
+	teamRepo         _repo.TeamRepositoryInterface
+	userTeamRepo     _repo.UserTeamRepositoryInterface
+	txRepo           _repo.TransactionRepositoryInterface
+	// Usecase
+	taskUsecase TaskUsecaseInterface
+}
+
+func NewTaskTemplateUsecase(
+	taskTemplateRepo _repo.TaskTemplateRepositoryInterface,
+	labelRepo _repo.LabelRepositoryInterface,
+	checklistRepo _repo.ChecklistItemRepositoryInterface,
+	userRepo _repo.UserRepositoryInterface,
+	teamRepo _repo.TeamRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface,
+	txRepo _repo.TransactionRepositoryInterface,
+	taskUsecase TaskUsecaseInterface) TaskTemplateUsecaseInterface {
+	return &TaskTemplateUsecase{
+		taskTemplateRepo: taskTemplateRepo,
+		labelRepo:        labelRepo,
+		checklistRepo:    checklistRepo,
+		userRepo:         userRepo,
+		teamRepo:         teamRepo,
+		userTeamRepo:     userTeamRepo,
+		txRepo:           txRepo,
+		taskUsecase:      taskUsecase,
+	}
+}
+
+func (uc *TaskTemplateUsecase) CreateTaskTemplate(ctx context.Context, input _genModel.CreateTaskTemplateInput) (*_model.TaskTemplate, error) {
+	logs.Infof("CreateTaskTemplate:: Starting with payload %v", input)
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, input.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &_model.TaskTemplate{
+		TeamID:           input.TeamID,
+		Name:             strings.TrimSpace(input.Name),
+		TitlePattern:     input.TitlePattern,
+		Description:      input.Description,
+		Checklist:        input.Checklist,
+		LabelIDs:         uniqueIDs(input.LabelIds),
+		AssignedTo:       input.AssignedTo,
+		DueOffset:        input.DueOffset,
+		Priority:         _model.TaskPriorityNone,
+		StoryPoints:      input.StoryPoints,
+		OriginalEstimate: input.OriginalEstimate,
+		IsDefault:        input.IsDefault != nil && *input.IsDefault,
+		Base: _model.Base{
+			CreatedBy:  actorID(userCtx),
+			ModifiedBy: actorID(userCtx),
+		},
+	}
+	if input.Priority != nil {
+		template.Priority = *input.Priority
+	}
+	if err = uc.validateTemplate(ctx, template); err != nil {
+		return nil, err
+	}
+
+	var createdTemplate *_model.TaskTemplate
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		createdTemplate, err = uc.saveTemplate(ctx, template)
+		return err
+	})
+	if err != nil {
+		logs.Errorf("CreateTaskTemplate:: Error creating the template: %v", err)
+		return nil, err
+	}
+
+	logs.Info("CreateTaskTemplate:: Finish CreateTaskTemplate")
+
+	return createdTemplate, nil
+}
+
+func (uc *TaskTemplateUsecase) UpdateTaskTemplate(ctx context.Context, input _genModel.UpdateTaskTemplateInput) (*_model.TaskTemplate, error) {
+	logs.Infof("UpdateTaskTemplate:: Starting with payload %v", input)
+	template, err := uc.taskTemplateRepo.GetTaskTemplateByID(ctx, input.ID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task template not found")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, template.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update only the fields that are provided
+	if input.Name != nil {
+		template.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.TitlePattern != nil {
+		template.TitlePattern = *input.TitlePattern
+	}
+	if input.Description != nil {
+		template.Description = input.Description
+	}
+	if input.Checklist != nil {
+		template.Checklist = input.Checklist
+	}
+	if input.LabelIds != nil {
+		template.LabelIDs = uniqueIDs(input.LabelIds)
+	}
+	if input.AssignedTo != nil {
+		template.AssignedTo = input.AssignedTo
+	}
+	if input.DueOffset != nil {
+		template.DueOffset = input.DueOffset
+	}
+	if input.Priority != nil {
+		template.Priority = *input.Priority
+	}
+	if input.StoryPoints != nil {
+		template.StoryPoints = input.StoryPoints
+	}
+	if input.OriginalEstimate != nil {
+		template.OriginalEstimate = input.OriginalEstimate
+	}
+	if input.IsDefault != nil {
+		template.IsDefault = *input.IsDefault
+	}
+	template.ModifiedBy = actorID(userCtx)
+	if err = uc.validateTemplate(ctx, template); err != nil {
+		return nil, err
+	}
+
+	var updatedTemplate *_model.TaskTemplate
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		updatedTemplate, err = uc.saveTemplate(ctx, template)
+		return err
+	})
+	if err != nil {
+		logs.Errorf("UpdateTaskTemplate:: Error updating the template: %v", err)
+		return nil, err
+	}
+
+	logs.Info("UpdateTaskTemplate:: Finish UpdateTaskTemplate")
+
+	return updatedTemplate, nil
+}
+
+// DeleteTaskTemplate removes the template, the tasks created from it are kept
+func (uc *TaskTemplateUsecase) DeleteTaskTemplate(ctx context.Context, id string) (bool, error) {
+	logs.Infof("DeleteTaskTemplate:: Starting with template %s", id)
+	template, err := uc.taskTemplateRepo.GetTaskTemplateByID(ctx, id)
+	if err != nil {
+		return false, _customErr.NewGraphQLError(http.StatusNotFound, "Task template not found")
+	}
+	if _, err = ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, template.TeamID); err != nil {
+		return false, err
+	}
+
+	if err = uc.taskTemplateRepo.DeleteTaskTemplate(ctx, template.ID); err != nil {
+		logs.Errorf("DeleteTaskTemplate:: Error DeleteTaskTemplate repo: %v", err)
+		return false, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	logs.Info("DeleteTaskTemplate:: Finish DeleteTaskTemplate")
+
+	return true, nil
+}
+
+func (uc *TaskTemplateUsecase) GetTaskTemplatesByTeam(ctx context.Context, teamID string) ([]*_model.TaskTemplate, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, teamID); err != nil {
+		return nil, err
+	}
+
+	templates, err := uc.taskTemplateRepo.GetTaskTemplatesByTeamID(ctx, teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return templates, nil
+}
+
+func (uc *TaskTemplateUsecase) GetTaskTemplateByID(ctx context.Context, id string) (*_model.TaskTemplate, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := uc.taskTemplateRepo.GetTaskTemplateByID(ctx, id)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task template not found")
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, template.TeamID); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// CreateTaskFromTemplate creates a task through CreateTask from the template and the overrides,
+// the checklist and the labels of the template are added in the same transaction
+func (uc *TaskTemplateUsecase) CreateTaskFromTemplate(ctx context.Context, templateID string, overrides *_genModel.TaskTemplateOverridesInput) (*_model.Task, error) {
+	logs.Infof("CreateTaskFromTemplate:: Starting with template %s", templateID)
+	template, err := uc.taskTemplateRepo.GetTaskTemplateByID(ctx, templateID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task template not found")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, template.TeamID)
+	if err != nil {
+		return nil, err
+	}
+	team, err := uc.teamRepo.GetTeamByID(ctx, template.TeamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+
+	now := time.Now().UTC()
+	input := _genModel.CreateTaskInput{
+		Title:            expandTitlePattern(template.TitlePattern, team, now),
+		Description:      template.Description,
+		Status:           _const.TASK_STATUS_TODO,
+		AssignedTo:       template.AssignedTo,
+		TeamID:           template.TeamID,
+		Priority:         &template.Priority,
+		StoryPoints:      template.StoryPoints,
+		OriginalEstimate: template.OriginalEstimate,
+	}
+	if template.DueOffset != nil {
+		input.DueDate = now.Add(time.Duration(*template.DueOffset) * time.Second).Format(time.RFC3339)
+	}
+
+	if overrides != nil {
+		if overrides.Title != nil {
+			input.Title = *overrides.Title
+		}
+		if overrides.Description != nil {
+			input.Description = overrides.Description
+		}
+		if overrides.Status != nil {
+			input.Status = *overrides.Status
+		}
+		if overrides.AssignedTo != nil {
+			input.AssignedTo = overrides.AssignedTo
+		}
+		if overrides.ParentID != nil {
+			input.ParentID = overrides.ParentID
+		}
+		if overrides.Priority != nil {
+			input.Priority = overrides.Priority
+		}
+		if overrides.StoryPoints != nil {
+			input.StoryPoints = overrides.StoryPoints
+		}
+		if overrides.OriginalEstimate != nil {
+			input.OriginalEstimate = overrides.OriginalEstimate
+		}
+		if overrides.DueDate != nil {
+			input.DueDate = *overrides.DueDate
+		}
+	}
+	if input.DueDate == "" {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "A due date is required, the template has no due offset")
+	}
+	if !isTaskStatus(input.Status) {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid task status")
+	}
+
+	// The default assignee may have left the team since the template was saved
+	if input.AssignedTo != nil && input.AssignedTo == template.AssignedTo {
+		isMember, err := uc.userTeamRepo.IsUserInTeam(ctx, *input.AssignedTo, template.TeamID)
+		if err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if !isMember {
+			input.AssignedTo = nil
+			addWarning(ctx, "TEMPLATE_ASSIGNEE_DROPPED", "The default assignee of the template is no longer a member of the team, the task is unassigned")
+		}
+	}
+
+	task, err := uc.taskUsecase.CreateTaskWithSetup(ctx, input, func(ctx context.Context, task *_model.Task) error {
+		for _, text := range template.Checklist {
+			_, err := uc.checklistRepo.CreateChecklistItem(ctx, &_model.ChecklistItem{
+				TaskID: task.ID,
+				Text:   text,
+				Base: _model.Base{
+					CreatedBy:  actorID(userCtx),
+					ModifiedBy: actorID(userCtx),
+				},
+			})
+			if err != nil {
+				return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+			}
+		}
+		if len(template.LabelIDs) > 0 {
+			if err := uc.labelRepo.SetTaskLabels(ctx, task.ID, template.LabelIDs, actorID(userCtx)); err != nil {
+				return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("CreateTaskFromTemplate:: Error creating the task: %v", err)
+		return nil, err
+	}
+
+	logs.Info("CreateTaskFromTemplate:: Finish CreateTaskFromTemplate")
+
+	return task, nil
+}
+
+// ExportTaskTemplates writes the templates of the team, or the ones of ids, as a JSON document
+func (uc *TaskTemplateUsecase) ExportTaskTemplates(ctx context.Context, teamID string, ids []string) (string, error) {
+	templates, err := uc.GetTaskTemplatesByTeam(ctx, teamID)
+	if err != nil {
+		return "", err
+	}
+
+	labels, err := uc.labelRepo.GetLabelsByTeamID(ctx, teamID)
+	if err != nil {
+		return "", _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	labelMap := make(map[string]*_model.Label)
+	for _, label := range labels {
+		labelMap[label.ID] = label
+	}
+
+	var assigneeIDs []string
+	for _, template := range templates {
+		if template.AssignedTo != nil {
+			assigneeIDs = append(assigneeIDs, *template.AssignedTo)
+		}
+	}
+	emails := make(map[string]string)
+	if len(assigneeIDs) > 0 {
+		users, err := uc.userRepo.GetUsersByIDs(ctx, uniqueIDs(assigneeIDs))
+		if err != nil {
+			return "", _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		for _, user := range users {
+			emails[user.ID] = user.Email
+		}
+	}
+
+	selected := make(map[string]bool)
+	for _, id := range ids {
+		selected[id] = true
+	}
+
+	file := _model.TaskTemplateFile{
+		Version:   taskTemplateFileVersion,
+		Templates: []*_model.TaskTemplateFileEntry{},
+	}
+	for _, template := range templates {
+		if len(ids) > 0 && !selected[template.ID] {
+			continue
+		}
+
+		entry := &_model.TaskTemplateFileEntry{
+			Name:             template.Name,
+			TitlePattern:     template.TitlePattern,
+			Description:      template.Description,
+			Checklist:        template.Checklist,
+			DueOffset:        template.DueOffset,
+			Priority:         template.Priority,
+			StoryPoints:      template.StoryPoints,
+			OriginalEstimate: template.OriginalEstimate,
+		}
+		for _, labelID := range template.LabelIDs {
+			if label, ok := labelMap[labelID]; ok {
+				entry.Labels = append(entry.Labels, &_model.TaskTemplateFileLabel{Name: label.Name, Color: label.Color})
+			}
+		}
+		if template.AssignedTo != nil {
+			if email, ok := emails[*template.AssignedTo]; ok {
+				entry.AssigneeEmail = &email
+			}
+		}
+		file.Templates = append(file.Templates, entry)
+	}
+
+	document, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return "", _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return string(document), nil
+}
+
+// ImportTaskTemplates creates the templates of a document written by ExportTaskTemplates in the team.
+// Labels are matched by name and created when missing, an assignee is kept only when they are a member of the team
+func (uc *TaskTemplateUsecase) ImportTaskTemplates(ctx context.Context, teamID string, document string) ([]*_model.TaskTemplate, error) {
+	logs.Infof("ImportTaskTemplates:: Starting with team %s", teamID)
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	var file _model.TaskTemplateFile
+	if err = json.Unmarshal([]byte(document), &file); err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid template document: "+err.Error())
+	}
+	if file.Version != taskTemplateFileVersion {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, fmt.Sprintf("Unsupported template document version %d", file.Version))
+	}
+	if len(file.Templates) == 0 {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "The template document has no template")
+	}
+	if len(file.Templates) > maxImportedTaskTemplates {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, fmt.Sprintf("At most %d templates can be imported at once", maxImportedTaskTemplates))
+	}
+	for _, entry := range file.Templates {
+		if err = validateTemplateFileEntry(entry); err != nil {
+			return nil, err
+		}
+	}
+
+	labels, err := uc.labelRepo.GetLabelsByTeamID(ctx, teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	labelIDs := make(map[string]string)
+	for _, label := range labels {
+		labelIDs[strings.ToLower(label.Name)] = label.ID
+	}
+
+	var importedTemplates []*_model.TaskTemplate
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		for _, entry := range file.Templates {
+			template := &_model.TaskTemplate{
+				TeamID:           teamID,
+				Name:             strings.TrimSpace(entry.Name),
+				TitlePattern:     entry.TitlePattern,
+				Description:      entry.Description,
+				Checklist:        entry.Checklist,
+				DueOffset:        entry.DueOffset,
+				Priority:         entry.Priority,
+				StoryPoints:      entry.StoryPoints,
+				OriginalEstimate: entry.OriginalEstimate,
+				Base: _model.Base{
+					CreatedBy:  actorID(userCtx),
+					ModifiedBy: actorID(userCtx),
+				},
+			}
+			if template.Priority == "" {
+				template.Priority = _model.TaskPriorityNone
+			}
+
+			for _, fileLabel := range entry.Labels {
+				key := strings.ToLower(strings.TrimSpace(fileLabel.Name))
+				if _, ok := labelIDs[key]; !ok {
+					label, err := uc.labelRepo.CreateLabel(ctx, &_model.Label{
+						TeamID: teamID,
+						Name:   strings.TrimSpace(fileLabel.Name),
+						Color:  strings.ToLower(fileLabel.Color),
+						Base:   template.Base,
+					})
+					if err != nil {
+						return labelError(err)
+					}
+					labelIDs[key] = label.ID
+				}
+				template.LabelIDs = append(template.LabelIDs, labelIDs[key])
+			}
+			template.LabelIDs = uniqueIDs(template.LabelIDs)
+
+			if entry.AssigneeEmail != nil {
+				template.AssignedTo, err = uc.findTeamMember(ctx, teamID, *entry.AssigneeEmail)
+				if err != nil {
+					return err
+				}
+				if template.AssignedTo == nil {
+					addWarning(ctx, "TEMPLATE_ASSIGNEE_DROPPED", fmt.Sprintf("%s is not a member of the team, template %q has no default assignee", *entry.AssigneeEmail, template.Name))
+				}
+			}
+
+			createdTemplate, err := uc.saveTemplate(ctx, template)
+			if err != nil {
+				return err
+			}
+			importedTemplates = append(importedTemplates, createdTemplate)
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("ImportTaskTemplates:: Error importing the templates: %v", err)
+		return nil, err
+	}
+
+	logs.Info("ImportTaskTemplates:: Finish ImportTaskTemplates")
+
+	return importedTemplates, nil
+}
+
+// saveTemplate creates or updates the template with its labels, the team default moves to it when it is marked so
+func (uc *TaskTemplateUsecase) saveTemplate(ctx context.Context, template *_model.TaskTemplate) (*_model.TaskTemplate, error) {
+	if template.IsDefault {
+		if err := uc.taskTemplateRepo.ClearDefaultTaskTemplate(ctx, template.TeamID); err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	var saved *_model.TaskTemplate
+	var err error
+	if template.ID == "" {
+		saved, err = uc.taskTemplateRepo.CreateTaskTemplate(ctx, template)
+	} else {
+		saved, err = uc.taskTemplateRepo.UpdateTaskTemplate(ctx, template)
+	}
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, _customErr.NewGraphQLError(http.StatusConflict, fmt.Sprintf("A template named %q already exists in the team", template.Name))
+		}
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = uc.taskTemplateRepo.SetTaskTemplateLabels(ctx, saved.ID, template.LabelIDs); err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	saved.LabelIDs = template.LabelIDs
+	return saved, nil
+}
+
+// validateTemplate checks the labels come from the palette of the team and the default assignee is a member
+func (uc *TaskTemplateUsecase) validateTemplate(ctx context.Context, template *_model.TaskTemplate) error {
+	if len(template.LabelIDs) > 0 {
+		count, err := uc.labelRepo.CountTeamLabels(ctx, template.TeamID, template.LabelIDs)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if count != len(template.LabelIDs) {
+			return _customErr.NewGraphQLError(http.StatusBadRequest, "Labels must belong to the team of the template")
+		}
+	}
+
+	if template.AssignedTo != nil {
+		isMember, err := uc.userTeamRepo.IsUserInTeam(ctx, *template.AssignedTo, template.TeamID)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if !isMember {
+			return _customErr.NewGraphQLError(http.StatusBadRequest, "The default assignee must be a member of the team")
+		}
+	}
+	return nil
+}
+
+// findTeamMember returns the ID of the member of the team having the email, nil when there is none
+func (uc *TaskTemplateUsecase) findTeamMember(ctx context.Context, teamID string, email string) (*string, error) {
+	user, err := uc.userRepo.GetUserByEmail(ctx, strings.TrimSpace(email))
+	if err != nil || user == nil {
+		return nil, nil
+	}
+
+	isMember, err := uc.userTeamRepo.IsUserInTeam(ctx, user.ID, teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if !isMember {
+		return nil, nil
+	}
+	return &user.ID, nil
+}
+
+// validateTemplateFileEntry applies to an imported template the constraints of CreateTaskTemplateInput
+func validateTemplateFileEntry(entry *_model.TaskTemplateFileEntry) error {
+	invalid := func(message string) error {
+		return _customErr.NewGraphQLError(http.StatusBadRequest, fmt.Sprintf("Invalid template %q: %s", entry.Name, message))
+	}
+
+	if name := strings.TrimSpace(entry.Name); name == "" || len(name) > 100 {
+		return invalid("the name must have 1 to 100 characters")
+	}
+	if entry.TitlePattern == "" || len(entry.TitlePattern) > 255 {
+		return invalid("the title pattern must have 1 to 255 characters")
+	}
+	if len(entry.Checklist) > 50 {
+		return invalid("the checklist has more than 50 items")
+	}
+	for _, text := range entry.Checklist {
+		if text == "" || len(text) > 255 {
+			return invalid("checklist items must have 1 to 255 characters")
+		}
+	}
+	for _, label := range entry.Labels {
+		if name := strings.TrimSpace(label.Name); name == "" || len(name) > 50 {
+			return invalid("label names must have 1 to 50 characters")
+		}
+		if !labelColorPattern.MatchString(label.Color) {
+			return invalid("label colors must be hex colors such as #1f883d")
+		}
+	}
+	if entry.Priority != "" && !entry.Priority.IsValid() {
+		return invalid("unknown priority " + string(entry.Priority))
+	}
+	for _, value := range []*int32{entry.DueOffset, entry.StoryPoints, entry.OriginalEstimate} {
+		if value != nil && *value < 0 {
+			return invalid("the due offset and the estimates cannot be negative")
+		}
+	}
+	return nil
+}
+
+// expandTitlePattern fills the placeholders of a title pattern, unknown placeholders are left as they are
+func expandTitlePattern(pattern string, team *_model.Team, now time.Time) string {
+	year, week := now.ISOWeek()
+	return strings.NewReplacer(
+		"{date}", now.Format("2006-01-02"),
+		"{week}", fmt.Sprintf("%d-W%02d", year, week),
+		"{month}", now.Format("2006-01"),
+		"{team}", team.Name,
+	).Replace(pattern)
+}
@@ -2,59 +2,117 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	_config "bitbucket.org/edts/go-task-management/config"
 	_const "bitbucket.org/edts/go-task-management/internal/constant"
 	_model "bitbucket.org/edts/go-task-management/internal/model"
 	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_projection "bitbucket.org/edts/go-task-management/internal/model/projection"
 	_pubsub "bitbucket.org/edts/go-task-management/internal/pubsub"
 	_repo "bitbucket.org/edts/go-task-management/internal/repository"
 	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
 	_logger "bitbucket.org/edts/go-task-management/pkg/logger"
+	"bitbucket.org/edts/go-task-management/pkg/rank"
+	"github.com/jackc/pgx/v5"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 type TaskUsecaseInterface interface {
 	CreateTask(ctx context.Context, input _genModel.CreateTaskInput) (*_model.Task, error)
-	GetTasksByTeam(ctx context.Context, teamID string, status *string) ([]*_model.Task, error)
+	CreateTaskWithSetup(ctx context.Context, input _genModel.CreateTaskInput, setup TaskSetupFunc) (*_model.Task, error)
+	GetTasksByTeam(ctx context.Context, teamID string, status *string, labels *_model.LabelFilter) ([]*_model.Task, error)
 	GetTaskByID(ctx context.Context, taskID string) (*_model.Task, error)
+	GetTaskByKey(ctx context.Context, key string) (*_model.Task, error)
 	UpdateTaskById(ctx context.Context, input _genModel.UpdateTaskInput) (*_model.Task, error)
-	DeleteTaskById(ctx context.Context, taskID string) error
+	DeleteTaskById(ctx context.Context, taskID string, permanent bool) error
+	RestoreTask(ctx context.Context, taskID string) (*_model.Task, error)
+	GetTrash(ctx context.Context, teamID string) ([]*_model.Task, error)
+	PurgeTrash(ctx context.Context) (int, error)
 	MoveTaskByID(ctx context.Context, input _genModel.MoveTaskInput) (*_model.Task, error)
 	AssignTask(ctx context.Context, input _genModel.AssignTaskInput) (*_model.Task, error)
+	MoveTaskToTeam(ctx context.Context, taskID string, teamID string, expectedVersion *int32) (*_model.Task, error)
+	SetTaskParent(ctx context.Context, taskID string, parentID *string) (*_model.Task, error)
+	ReorderTask(ctx context.Context, taskID string, beforeID *string, afterID *string) (*_model.Task, error)
+	RebalanceTaskRanks(ctx context.Context) (int, error)
+	BulkUpdateTasks(ctx context.Context, ids []string, patch _genModel.BulkTaskPatch) ([]*_genModel.BulkTaskResult, error)
+	BulkMoveTasks(ctx context.Context, ids []string, status string) ([]*_genModel.BulkTaskResult, error)
+	BulkAssignTasks(ctx context.Context, ids []string, assignedTo *string) ([]*_genModel.BulkTaskResult, error)
+	BulkDeleteTasks(ctx context.Context, ids []string) ([]*_genModel.BulkTaskResult, error)
 
 	// Subscription triggered event
-	TaskCreatedEvent(ctx context.Context, teamID string) <-chan *_model.Task
-	TaskUpdatedEvent(ctx context.Context, teamID string) <-chan *_model.Task
-	TaskDeletedEvent(ctx context.Context, teamID string) <-chan *_genModel.DeletedTaskNotification
+	TaskCreatedEvent(ctx context.Context, teamID string) (<-chan *_model.Task, error)
+	TaskUpdatedEvent(ctx context.Context, teamID string) (<-chan *_model.Task, error)
+	TaskDeletedEvent(ctx context.Context, teamID string) (<-chan *_genModel.DeletedTaskNotification, error)
+	TasksBatchedEvent(ctx context.Context, teamID string) (<-chan *_model.TaskBatch, error)
 }
 
 var logs = _logger.GetContextLoggerf(nil)
 
+// TaskSetupFunc completes a task inside its creation transaction, before the created event is published
+type TaskSetupFunc func(ctx context.Context, task *_model.Task) error
+
+// defaultTaskRankMaxLength is used when the task section is missing from config
+const defaultTaskRankMaxLength = 16
+
+// defaultTaskTrashRetention is used when the task section is missing from config
+const defaultTaskTrashRetention = 30 * 24 * time.Hour
+
 type TaskUsecase struct {
 	// Repo
-	taskRepo _repo.TaskRepositoryInterface
-	userRepo _repo.UserRepositoryInterface
-	teamRepo _repo.TeamRepositoryInterface
+	taskRepo           _repo.TaskRepositoryInterface
+	userRepo           _repo.UserRepositoryInterface
+	teamRepo           _repo.TeamRepositoryInterface
+	userTeamRepo       _repo.UserTeamRepositoryInterface
+	taskDependencyRepo _repo.TaskDependencyRepositoryInterface
+	boardRepo          _repo.BoardRepositoryInterface
+	labelRepo          _repo.LabelRepositoryInterface
+	txRepo             _repo.TransactionRepositoryInterface
 	// PubSub
 	taskPubSub _pubsub.TaskPubSubInterface
+	// Usecase
+	mentionUsecase MentionUsecaseInterface
+	watcherUsecase WatcherUsecaseInterface
 }
 
 func NewTaskUsecase(
 	taskRepo _repo.TaskRepositoryInterface,
 	userRepo _repo.UserRepositoryInterface,
 	teamRepo _repo.TeamRepositoryInterface,
-	taskPubSub _pubsub.TaskPubSubInterface) TaskUsecaseInterface {
+	userTeamRepo _repo.UserTeamRepositoryInterface,
+	taskDependencyRepo _repo.TaskDependencyRepositoryInterface,
+	boardRepo _repo.BoardRepositoryInterface,
+	labelRepo _repo.LabelRepositoryInterface,
+	txRepo _repo.TransactionRepositoryInterface,
+	taskPubSub _pubsub.TaskPubSubInterface,
+	mentionUsecase MentionUsecaseInterface,
+	watcherUsecase WatcherUsecaseInterface) TaskUsecaseInterface {
 	return &TaskUsecase{
-		taskRepo:   taskRepo,
-		userRepo:   userRepo,
-		teamRepo:   teamRepo,
-		taskPubSub: taskPubSub,
+		taskRepo:           taskRepo,
+		userRepo:           userRepo,
+		teamRepo:           teamRepo,
+		userTeamRepo:       userTeamRepo,
+		taskDependencyRepo: taskDependencyRepo,
+		boardRepo:          boardRepo,
+		labelRepo:          labelRepo,
+		txRepo:             txRepo,
+		taskPubSub:         taskPubSub,
+		mentionUsecase:     mentionUsecase,
+		watcherUsecase:     watcherUsecase,
 	}
 }
 
 func (uc *TaskUsecase) CreateTask(ctx context.Context, input _genModel.CreateTaskInput) (*_model.Task, error) {
+	return uc.CreateTaskWithSetup(ctx, input, nil)
+}
+
+// CreateTaskWithSetup creates the task like CreateTask and runs setup in the same transaction, a failing setup
+// leaves no task behind
+func (uc *TaskUsecase) CreateTaskWithSetup(ctx context.Context, input _genModel.CreateTaskInput, setup TaskSetupFunc) (*_model.Task, error) {
 	logs.Infof("CreateTask:: Starting with payload %v", input)
 	// Convert string to time.Time
 	parsedDueDate, err := time.Parse(time.RFC3339, input.DueDate)
@@ -62,17 +120,81 @@ func (uc *TaskUsecase) CreateTask(ctx context.Context, input _genModel.CreateTas
 		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid due date format")
 	}
 
+	team, err := uc.teamRepo.GetTeamByID(ctx, input.TeamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+	if _, err = ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, team.ID); err != nil {
+		return nil, err
+	}
+
+	// A subtask stays in the team of its parent
+	if input.ParentID != nil {
+		parent, err := uc.taskRepo.GetTaskByID(ctx, *input.ParentID)
+		if err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Parent task not found")
+		}
+		if parent.TeamID != input.TeamID {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Parent task belongs to another team")
+		}
+	}
+
+	mentioned, err := uc.mentionUsecase.ResolveMentions(ctx, input.TeamID, input.Description)
+	if err != nil {
+		return nil, err
+	}
+
 	task := &_model.Task{
 		Title:       input.Title,
 		Description: input.Description,
 		Status:      input.Status,
 		AssignedTo:  input.AssignedTo,
 		TeamID:      input.TeamID,
+		ParentID:    input.ParentID,
+		Priority:    _model.TaskPriorityNone,
 		DueDate:     parsedDueDate,
+
+		StoryPoints:      input.StoryPoints,
+		OriginalEstimate: input.OriginalEstimate,
+	}
+	if input.Priority != nil {
+		task.Priority = *input.Priority
 	}
 
-	// Save to repo
-	createdTask, err := uc.taskRepo.CreateTask(ctx, task)
+	// New tasks go on top of their column
+	var createdTask *_model.Task
+	var added []string
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		task.Rank, err = rankAtPosition(ctx, uc.taskRepo, _model.TaskColumn{TeamID: task.TeamID, Status: task.Status}, 0, nil)
+		if err != nil {
+			return err
+		}
+
+		// Save to repo
+		createdTask, err = uc.taskRepo.CreateTask(ctx, task)
+		if err != nil {
+			return err
+		}
+
+		added, err = uc.mentionUsecase.SaveMentions(ctx, createdTask, nil, mentioned)
+		if err != nil {
+			return err
+		}
+
+		// The creator and the assignee watch the task from the start
+		watcherIDs := []string{}
+		if creator := notificationActor(ctx); creator != nil {
+			watcherIDs = append(watcherIDs, *creator)
+		}
+		if createdTask.AssignedTo != nil {
+			watcherIDs = append(watcherIDs, *createdTask.AssignedTo)
+		}
+		if err = uc.watcherUsecase.AddWatchers(ctx, createdTask.ID, watcherIDs); err != nil || setup == nil {
+			return err
+		}
+		return setup(ctx, createdTask)
+	})
 	if err != nil {
 		logs.Errorf("CreateTask:: Error CreateTask repo: %v", err)
 		return nil, err
@@ -91,14 +213,21 @@ func (uc *TaskUsecase) CreateTask(ctx context.Context, input _genModel.CreateTas
 
 	// Publish taskCreated event
 	uc.taskPubSub.Publish(input.TeamID, "created", createdTask)
+	if createdTask.Description != nil {
+		uc.mentionUsecase.NotifyMentions(ctx, createdTask, nil, added, *createdTask.Description)
+	}
 
 	logs.Info("CreateTask:: Finish CreateTask")
 
 	return createdTask, nil
 }
 
-func (uc *TaskUsecase) TaskCreatedEvent(ctx context.Context, teamID string) <-chan *_model.Task {
+func (uc *TaskUsecase) TaskCreatedEvent(ctx context.Context, teamID string) (<-chan *_model.Task, error) {
 	logs.Infof("TaskCreatedEvent:: Starting with variable teamId: %s", teamID)
+	if err := uc.ensureTeamVisible(ctx, teamID); err != nil {
+		return nil, err
+	}
+
 	taskChan := make(chan *_model.Task, 1)
 
 	go func() {
@@ -115,45 +244,41 @@ func (uc *TaskUsecase) TaskCreatedEvent(ctx context.Context, teamID string) <-ch
 
 	logs.Info("TaskCreatedEvent:: Finish subscribing taskCreated")
 
-	return taskChan
+	return taskChan, nil
 }
 
-func (uc *TaskUsecase) GetTasksByTeam(ctx context.Context, teamID string, status *string) ([]*_model.Task, error) {
-	logs.Infof("GetTasksByTeam:: Start fetching with variables teamId: %s and status: %v", teamID, status)
-
-	tasks, err := uc.taskRepo.GetTasksByTeam(ctx, teamID, status)
+func (uc *TaskUsecase) GetTasksByTeam(ctx context.Context, teamID string, status *string, labels *_model.LabelFilter) ([]*_model.Task, error) {
+	logs.Infof("GetTasksByTeam:: Start fetching with variables teamId: %s, status: %v and labels: %v", teamID, status, labels)
+	userCtx, err := userFromContext(ctx)
 	if err != nil {
-		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		return nil, err
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, teamID); err != nil {
+		return nil, err
 	}
 
-	var results []*_model.Task
-	for _, row := range tasks {
-		task := &_model.Task{
-			ID:          row.ID,
-			Title:       row.Title,
-			Description: row.Description,
-			Status:      row.Status,
-			DueDate:     row.DueDate,
-			AssignedTo:  row.AssignedTo,
-			TeamID:      row.TeamID,
-			Base: _model.Base{
-				CreatedAt:  row.CreatedAt,
-				ModifiedAt: row.ModifiedAt,
-			},
-		}
-		results = append(results, task)
+	tasks, err := uc.taskRepo.GetTasksByTeam(ctx, teamID, status, normalizeLabelFilter(labels))
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
 	}
 
 	logs.Info("GetTasksByTeam:: Finish fetching..")
 
-	return results, nil
+	return tasks, nil
 }
 
 func (uc *TaskUsecase) GetTaskByID(ctx context.Context, taskID string) (*_model.Task, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 	task, err := uc.taskRepo.GetTaskByID(ctx, taskID)
 	if err != nil {
 		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "task not found")
 	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, task.TeamID); err != nil {
+		return nil, err
+	}
 	return task, nil
 }
 
@@ -166,6 +291,12 @@ func (uc *TaskUsecase) UpdateTaskById(ctx context.Context, input _genModel.Updat
 		logs.Errorf("UpdateTaskById:: Error GetTaskByID repo %v", err)
 		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
 	}
+	if _, err = ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, existingTask.TeamID); err != nil {
+		return nil, err
+	}
+	if err = ensureTaskVersion(existingTask, input.ExpectedVersion); err != nil {
+		return nil, err
+	}
 
 	// Update only the fields that are provided
 	if input.Title != nil {
@@ -174,6 +305,15 @@ func (uc *TaskUsecase) UpdateTaskById(ctx context.Context, input _genModel.Updat
 	if input.Description != nil {
 		existingTask.Description = input.Description
 	}
+	if input.Priority != nil {
+		existingTask.Priority = *input.Priority
+	}
+	if input.StoryPoints != nil {
+		existingTask.StoryPoints = input.StoryPoints
+	}
+	if input.OriginalEstimate != nil {
+		existingTask.OriginalEstimate = input.OriginalEstimate
+	}
 
 	if input.DueDate != nil {
 		parsedDueDate, err := time.Parse(time.RFC3339, *input.DueDate)
@@ -183,68 +323,668 @@ func (uc *TaskUsecase) UpdateTaskById(ctx context.Context, input _genModel.Updat
 		existingTask.DueDate = parsedDueDate
 	}
 
-	// Save the updated task to the repository
-	updatedTask, err := uc.taskRepo.UpdateTaskById(ctx, existingTask)
+	var mentioned []string
+	if input.Description != nil {
+		mentioned, err = uc.mentionUsecase.ResolveMentions(ctx, existingTask.TeamID, input.Description)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Save the updated task to the repository, a new description replaces its mentions
+	var updatedTask *_model.Task
+	var added []string
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		updatedTask, err = uc.taskRepo.UpdateTaskById(ctx, existingTask, input.ExpectedVersion)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uc.taskConflict(ctx, existingTask.ID)
+		}
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if input.Description == nil {
+			return nil
+		}
+
+		added, err = uc.mentionUsecase.SaveMentions(ctx, updatedTask, nil, mentioned)
+		return err
+	})
 	if err != nil {
-		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		return nil, err
 	}
 
 	// Publish taskCreated event
 	uc.taskPubSub.Publish(existingTask.TeamID, "updated", updatedTask)
+	if input.Description != nil {
+		uc.mentionUsecase.NotifyMentions(ctx, updatedTask, nil, added, *input.Description)
+	}
+	uc.watcherUsecase.NotifyWatchers(ctx, updatedTask, _model.NotificationKindTaskUpdated, nil, updatedFieldsSummary(input), added)
 
 	logs.Info("UpdateTaskById:: Finish UpdateTaskById")
 
 	return updatedTask, nil
 }
 
-// DeleteTaskById implements TaskUsecaseInterface.
-func (uc *TaskUsecase) DeleteTaskById(ctx context.Context, taskID string) error {
+// DeleteTaskById moves the task to the trash of its team together with its subtasks.
+// A permanent deletion is reserved to the team admins and also applies to a task already in the trash
+func (uc *TaskUsecase) DeleteTaskById(ctx context.Context, taskID string, permanent bool) error {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if permanent {
+		return uc.deleteTaskPermanently(ctx, userCtx, taskID)
+	}
+
 	// Check if task exists
 	existingTask, err := uc.taskRepo.GetTaskByID(ctx, taskID)
 	if err != nil {
 		return _customErr.NewGraphQLError(http.StatusBadRequest, "Task Not Found")
 	}
+	if _, err = ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, existingTask.TeamID); err != nil {
+		return err
+	}
 
-	// Delete task from repository
-	err = uc.taskRepo.DeleteTaskById(ctx, existingTask.ID)
+	trashedTasks, err := uc.taskRepo.TrashTask(ctx, existingTask.ID, actorID(userCtx))
 	if err != nil {
 		return _customErr.NewGraphQLError(http.StatusBadRequest, err.Error())
 	}
 
 	// Publish taskDeleted event
-	uc.taskPubSub.Publish(existingTask.TeamID, "deleted", existingTask)
+	for _, task := range trashedTasks {
+		uc.taskPubSub.Publish(task.TeamID, _const.DELETED, task)
+	}
 
 	return nil
 }
 
+// deleteTaskPermanently hard deletes a live or trashed task, its subtasks are kept as top level tasks by the database
+func (uc *TaskUsecase) deleteTaskPermanently(ctx context.Context, userCtx *_projection.UserContext, taskID string) error {
+	existingTask, err := uc.taskRepo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		existingTask, err = uc.taskRepo.GetTrashedTaskByID(ctx, taskID)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusBadRequest, "Task Not Found")
+		}
+	}
+	team, err := uc.teamRepo.GetTeamByID(ctx, existingTask.TeamID)
+	if err != nil {
+		return _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+	if err = ensureTeamWritable(team); err != nil {
+		return err
+	}
+	role, err := getTeamRole(ctx, uc.userTeamRepo, userCtx, team.ID)
+	if err != nil {
+		return err
+	}
+	if !role.AtLeast(_model.TeamRoleAdmin) {
+		return _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: only a team admin can delete a task permanently")
+	}
+
+	subtasks, err := uc.taskRepo.GetSubtasksByParentIDs(ctx, []string{existingTask.ID})
+	if err != nil {
+		return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	err = uc.taskRepo.DeleteTaskById(ctx, existingTask.ID)
+	if err != nil {
+		return _customErr.NewGraphQLError(http.StatusBadRequest, err.Error())
+	}
+
+	uc.taskPubSub.Publish(existingTask.TeamID, _const.PURGED, existingTask)
+	// Subtasks left in the board of a task still in the trash did not show up there, they stay untouched
+	if existingTask.DeletedAt == nil {
+		for _, subtask := range subtasks {
+			subtask.ParentID = nil
+			uc.taskPubSub.Publish(subtask.TeamID, _const.UPDATED, subtask)
+		}
+	}
+
+	return nil
+}
+
+// RestoreTask takes the task out of the trash at the top of its column, with the subtasks deleted along with it.
+// A subtask cannot come back while its parent is still in the trash
+func (uc *TaskUsecase) RestoreTask(ctx context.Context, taskID string) (*_model.Task, error) {
+	logs.Infof("RestoreTask:: Starting with task %s", taskID)
+	trashedTask, err := uc.taskRepo.GetTrashedTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task not found in the trash")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, trashedTask.TeamID)
+	if err != nil {
+		return nil, err
+	}
+	if trashedTask.ParentID != nil {
+		if _, err = uc.taskRepo.GetTaskByID(ctx, *trashedTask.ParentID); err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Restore the parent task first")
+		}
+	}
+
+	var restoredTasks []*_model.Task
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		column := _model.TaskColumn{TeamID: trashedTask.TeamID, Status: trashedTask.Status}
+		newRank, err := rankAtPosition(ctx, uc.taskRepo, column, 0, nil)
+		if err != nil {
+			return err
+		}
+
+		restoredTasks, err = uc.taskRepo.RestoreTask(ctx, trashedTask.ID, newRank, actorID(userCtx))
+		if err != nil {
+			logs.Errorf("RestoreTask:: Error RestoreTask repo: %v", err)
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if len(restoredTasks) == 0 {
+			return _customErr.NewGraphQLError(http.StatusNotFound, "Task not found in the trash")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Boards add the restored tasks back like new ones, the task itself first so its subtasks find their parent
+	var restoredTask *_model.Task
+	for _, task := range restoredTasks {
+		if task.ID == trashedTask.ID {
+			restoredTask = task
+			uc.taskPubSub.Publish(task.TeamID, _const.CREATED, task)
+		}
+	}
+	for _, task := range restoredTasks {
+		if task.ID != trashedTask.ID {
+			uc.taskPubSub.Publish(task.TeamID, _const.CREATED, task)
+		}
+	}
+
+	logs.Info("RestoreTask:: Finish RestoreTask")
+
+	return restoredTask, nil
+}
+
+// GetTrash returns the tasks in the trash of the team, most recently deleted first
+func (uc *TaskUsecase) GetTrash(ctx context.Context, teamID string) ([]*_model.Task, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = uc.teamRepo.GetTeamByID(ctx, teamID); err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, teamID); err != nil {
+		return nil, err
+	}
+
+	tasks, err := uc.taskRepo.GetTrashedTasksByTeam(ctx, teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return tasks, nil
+}
+
+// PurgeTrash hard deletes the tasks kept in the trash longer than the retention period, run periodically by every replica
+func (uc *TaskUsecase) PurgeTrash(ctx context.Context) (int, error) {
+	deletedBefore := time.Now().Add(-getTaskTrashRetention())
+	tasks, err := uc.taskRepo.PurgeTrashedTasks(_repo.WithoutWorkspaceIsolation(ctx), deletedBefore)
+	if err != nil {
+		logs.Errorf("PurgeTrash:: Error PurgeTrashedTasks repo: %v", err)
+		return 0, err
+	}
+
+	for _, task := range tasks {
+		uc.taskPubSub.Publish(task.TeamID, _const.PURGED, task)
+	}
+
+	if len(tasks) != 0 {
+		logs.Infof("PurgeTrash:: Purged %d tasks", len(tasks))
+	}
+	return len(tasks), nil
+}
+
 // MoveTaskByID implements TaskUsecaseInterface.
 func (uc *TaskUsecase) MoveTaskByID(ctx context.Context, input _genModel.MoveTaskInput) (*_model.Task, error) {
-	updatedTask, err := uc.taskRepo.GetTaskByID(ctx, input.ID)
+	logs.Infof("MoveTaskByID:: Starting with payload %v", input)
+	existingTask, err := uc.taskRepo.GetTaskByID(ctx, input.ID)
 	if err != nil {
 		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Task Not Found")
 	}
+	if _, err = ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, existingTask.TeamID); err != nil {
+		return nil, err
+	}
+	if err = ensureTaskVersion(existingTask, input.ExpectedVersion); err != nil {
+		return nil, err
+	}
+	if !isTaskStatus(input.Status) {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid task status")
+	}
 
-	//TODO: update task status by task id
-	//1. Create new task entry
-	//2. Call task repo to update the status
-	//3. Return the updated task
+	// A task waiting on unfinished tasks cannot be started
+	if input.Status != existingTask.Status && input.Status != _const.TASK_STATUS_TODO {
+		if err = uc.ensureTaskUnblocked(ctx, existingTask.ID); err != nil {
+			return nil, err
+		}
+	}
 
-	return updatedTask, err
+	// The task keeps its place when it stays in its column without a target position,
+	// otherwise it takes a rank between its new neighbours, the top of the column by default
+	var movedTask *_model.Task
+	var wipWarning string
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		task := &_model.Task{
+			ID:     existingTask.ID,
+			Status: input.Status,
+			Rank:   existingTask.Rank,
+		}
+		if input.Status != existingTask.Status || input.Position != nil {
+			position := 0
+			if input.Position != nil && *input.Position > 0 {
+				position = int(*input.Position)
+			}
+
+			var err error
+			column := _model.TaskColumn{TeamID: existingTask.TeamID, Status: input.Status}
+			task.Rank, err = rankAtPosition(ctx, uc.taskRepo, column, position, &existingTask.ID)
+			if err != nil {
+				return err
+			}
+
+			if input.Status != existingTask.Status {
+				if wipWarning, err = uc.checkWipLimit(ctx, existingTask.Team, column, existingTask.ID); err != nil {
+					return err
+				}
+			}
+		}
+
+		var err error
+		movedTask, err = uc.taskRepo.MoveTaskById(ctx, task, input.ExpectedVersion)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uc.taskConflict(ctx, existingTask.ID)
+		}
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("MoveTaskByID:: Error moving the task: %v", err)
+		return nil, err
+	}
+	movedTask.Team = existingTask.Team
+	if wipWarning != "" {
+		addWarning(ctx, "WIP_LIMIT_EXCEEDED", wipWarning)
+	}
+
+	// Publish taskUpdated event
+	uc.taskPubSub.Publish(existingTask.TeamID, _const.UPDATED, movedTask)
+	if movedTask.Status != existingTask.Status {
+		summary := fmt.Sprintf("Moved from %s to %s", existingTask.Status, movedTask.Status)
+		uc.watcherUsecase.NotifyWatchers(ctx, movedTask, _model.NotificationKindTaskMoved, nil, summary, nil)
+	}
+
+	logs.Info("MoveTaskByID:: Finish MoveTaskByID")
+
+	return movedTask, nil
+}
+
+// checkWipLimit applies the WIP limit policy of the team to a task entering the column,
+// it returns the warning to report when the team only warns. It must run in the transaction moving the task,
+// the team stays locked until the move commits so concurrent moves cannot both squeeze under the limit.
+// The column is locked before the team like task creates do
+func (uc *TaskUsecase) checkWipLimit(ctx context.Context, team *_model.Team, column _model.TaskColumn, taskID string) (string, error) {
+	limits, err := uc.teamRepo.GetTeamWipLimits(ctx, []string{column.TeamID})
+	if err != nil {
+		return "", _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	for _, limit := range limits {
+		if limit.Status != column.Status {
+			continue
+		}
+
+		if err = uc.teamRepo.LockTeam(ctx, column.TeamID); err != nil {
+			return "", _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		count, err := uc.boardRepo.CountColumnTasks(ctx, column, taskID)
+		if err != nil {
+			return "", _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if count+1 <= int(limit.Limit) {
+			return "", nil
+		}
+
+		message := fmt.Sprintf("WIP limit of %d tasks exceeded in %s", limit.Limit, column.Status)
+		if team.WipLimitPolicy == _model.WipLimitPolicyReject {
+			return "", _customErr.NewGraphQLError(http.StatusConflict, message)
+		}
+		return message, nil
+	}
+	return "", nil
+}
+
+// ReorderTask places the task right below beforeID and right above afterID within its column,
+// only the rank of the task changes so the rest of the column is never rewritten
+func (uc *TaskUsecase) ReorderTask(ctx context.Context, taskID string, beforeID *string, afterID *string) (*_model.Task, error) {
+	logs.Infof("ReorderTask:: Starting with task %s between %v and %v", taskID, beforeID, afterID)
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if beforeID == nil && afterID == nil {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "beforeId or afterId is required")
+	}
+	if (beforeID != nil && *beforeID == taskID) || (afterID != nil && *afterID == taskID) {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "A task cannot be placed next to itself")
+	}
+
+	existingTask, err := uc.taskRepo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+	}
+	if _, err = ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, existingTask.TeamID); err != nil {
+		return nil, err
+	}
+	column := _model.TaskColumn{TeamID: existingTask.TeamID, Status: existingTask.Status}
+
+	var reorderedTask *_model.Task
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.taskRepo.LockTaskColumn(ctx, column); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+
+		// The anchors are read once the column is locked so their ranks cannot move anymore
+		var lower, upper string
+		if beforeID != nil {
+			before, err := uc.getColumnTask(ctx, column, *beforeID)
+			if err != nil {
+				return err
+			}
+			lower = before.Rank
+		}
+		if afterID != nil {
+			after, err := uc.getColumnTask(ctx, column, *afterID)
+			if err != nil {
+				return err
+			}
+			upper = after.Rank
+		}
+
+		// Fill the missing side with the neighbour of the given anchor
+		var err error
+		switch {
+		case afterID == nil:
+			upper, err = uc.taskRepo.GetAdjacentRank(ctx, column, lower, true, existingTask.ID)
+		case beforeID == nil:
+			lower, err = uc.taskRepo.GetAdjacentRank(ctx, column, upper, false, existingTask.ID)
+		case lower >= upper:
+			return _customErr.NewGraphQLError(http.StatusBadRequest, "beforeId must be above afterId in the column")
+		}
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+
+		newRank, err := rank.Between(lower, upper)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+
+		reorderedTask, err = uc.taskRepo.SetTaskRank(ctx, existingTask.ID, newRank, actorID(userCtx))
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("ReorderTask:: Error reordering the task: %v", err)
+		return nil, err
+	}
+
+	// Publish taskUpdated event
+	uc.taskPubSub.Publish(reorderedTask.TeamID, _const.UPDATED, reorderedTask)
+
+	logs.Info("ReorderTask:: Finish ReorderTask")
+
+	return reorderedTask, nil
+}
+
+// RebalanceTaskRanks spreads the ranks of the columns whose keys grew too long, run periodically by every replica.
+// The order of the tasks does not change so no event is published
+func (uc *TaskUsecase) RebalanceTaskRanks(ctx context.Context) (int, error) {
+	ctx = _repo.WithoutWorkspaceIsolation(ctx)
+	columns, err := uc.taskRepo.GetTaskColumnsWithLongRanks(ctx, getTaskRankMaxLength())
+	if err != nil {
+		logs.Errorf("RebalanceTaskRanks:: Error GetTaskColumnsWithLongRanks repo: %v", err)
+		return 0, err
+	}
+
+	rebalanced := 0
+	for _, column := range columns {
+		err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+			if err := uc.taskRepo.LockTaskColumn(ctx, *column); err != nil {
+				return err
+			}
+			ids, err := uc.taskRepo.GetColumnTaskIDs(ctx, *column)
+			if err != nil {
+				return err
+			}
+			return uc.taskRepo.SetTaskRanks(ctx, ids, rank.Spread(len(ids)))
+		})
+		if err != nil {
+			logs.Errorf("RebalanceTaskRanks:: Error rebalancing column %s of team %s: %v", column.Status, column.TeamID, err)
+			continue
+		}
+		rebalanced++
+	}
+
+	if rebalanced != 0 {
+		logs.Infof("RebalanceTaskRanks:: Rebalanced %d columns", rebalanced)
+	}
+	return rebalanced, nil
+}
+
+// rankAtPosition locks the column and returns a rank for a task dropped at the zero based position,
+// excludeID is the task being moved so it does not count in the column
+func rankAtPosition(ctx context.Context, taskRepo _repo.TaskRepositoryInterface, column _model.TaskColumn, position int, excludeID *string) (string, error) {
+	if err := taskRepo.LockTaskColumn(ctx, column); err != nil {
+		return "", _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	lower, upper, err := taskRepo.GetColumnRankGap(ctx, column, position, excludeID)
+	if err != nil {
+		return "", _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	newRank, err := rank.Between(lower, upper)
+	if err != nil {
+		return "", _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return newRank, nil
+}
+
+// getColumnTask loads a task used as reorder anchor, it must sit in the given column
+func (uc *TaskUsecase) getColumnTask(ctx context.Context, column _model.TaskColumn, taskID string) (*_model.Task, error) {
+	task, err := uc.taskRepo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Anchor task not found")
+	}
+	if task.TeamID != column.TeamID || task.Status != column.Status {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Anchor task belongs to another column")
+	}
+	return task, nil
+}
+
+func getTaskTrashRetention() time.Duration {
+	if retention := _config.AppConfigInstance.Task.TrashRetention; retention > 0 {
+		return retention
+	}
+	return defaultTaskTrashRetention
+}
+
+func getTaskRankMaxLength() int {
+	if maxLength := _config.AppConfigInstance.Task.RankMaxLength; maxLength > 0 {
+		return maxLength
+	}
+	return defaultTaskRankMaxLength
+}
+
+// taskState is the current state of a task sent along a CONFLICT error, fields are named like in the schema
+type taskState struct {
+	ID               string              `json:"id"`
+	Title            string              `json:"title"`
+	Description      *string             `json:"description"`
+	Status           string              `json:"status"`
+	AssignedTo       *string             `json:"assignedTo"`
+	DueDate          time.Time           `json:"dueDate"`
+	Priority         _model.TaskPriority `json:"priority"`
+	StoryPoints      *int32              `json:"storyPoints"`
+	OriginalEstimate *int32              `json:"originalEstimate"`
+	Rank             string              `json:"rank"`
+	ParentID         *string             `json:"parentId"`
+	Version          int32               `json:"version"`
+	ModifiedAt       time.Time           `json:"modifiedAt"`
+}
+
+// ensureTaskVersion rejects a change made on a stale copy of the task, a nil expectedVersion skips the check
+func ensureTaskVersion(task *_model.Task, expectedVersion *int32) error {
+	if expectedVersion != nil && *expectedVersion != task.Version {
+		return taskConflictError(task)
+	}
+	return nil
+}
+
+// taskConflict reports a write that lost the race against another change of the task since its version was checked
+func (uc *TaskUsecase) taskConflict(ctx context.Context, taskID string) error {
+	current, err := uc.taskRepo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+	}
+	return taskConflictError(current)
+}
+
+func taskConflictError(task *_model.Task) error {
+	return _customErr.NewConflictError(
+		fmt.Sprintf("Task was changed by someone else, its current version is %d", task.Version),
+		&taskState{
+			ID:               task.ID,
+			Title:            task.Title,
+			Description:      task.Description,
+			Status:           task.Status,
+			AssignedTo:       task.AssignedTo,
+			DueDate:          task.DueDate,
+			Priority:         task.Priority,
+			StoryPoints:      task.StoryPoints,
+			OriginalEstimate: task.OriginalEstimate,
+			Rank:             task.Rank,
+			ParentID:         task.ParentID,
+			Version:          task.Version,
+			ModifiedAt:       task.ModifiedAt,
+		},
+	)
+}
+
+// ensureTaskUnblocked rejects a task that still waits on tasks which are not done
+func (uc *TaskUsecase) ensureTaskUnblocked(ctx context.Context, taskID string) error {
+	dependencies, err := uc.taskDependencyRepo.GetBlockingTasks(ctx, []string{taskID})
+	if err != nil {
+		return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	var openBlockers []string
+	for _, dependency := range dependencies {
+		if dependency.BlockedBy.Status != _const.TASK_STATUS_DONE {
+			openBlockers = append(openBlockers, dependency.BlockedBy.Title)
+		}
+	}
+	if len(openBlockers) > 0 {
+		return _customErr.NewGraphQLError(http.StatusConflict, "Task is blocked by unfinished tasks: "+strings.Join(openBlockers, ", "))
+	}
+	return nil
+}
+
+// normalizeLabelFilter drops the empty lists so they do not filter and removes duplicates,
+// the "all" filter compares the number of matching labels with the length of its list
+func normalizeLabelFilter(filter *_model.LabelFilter) *_model.LabelFilter {
+	if filter == nil {
+		return nil
+	}
+	return &_model.LabelFilter{
+		Any:  uniqueIDs(filter.Any),
+		All:  uniqueIDs(filter.All),
+		None: uniqueIDs(filter.None),
+	}
+}
+
+// updatedFieldsSummary describes the fields changed by the input for the watchers of the task
+func updatedFieldsSummary(input _genModel.UpdateTaskInput) string {
+	var fields []string
+	if input.Title != nil {
+		fields = append(fields, "title")
+	}
+	if input.Description != nil {
+		fields = append(fields, "description")
+	}
+	if input.Priority != nil {
+		fields = append(fields, "priority")
+	}
+	if input.DueDate != nil {
+		fields = append(fields, "due date")
+	}
+	if input.StoryPoints != nil {
+		fields = append(fields, "story points")
+	}
+	if input.OriginalEstimate != nil {
+		fields = append(fields, "original estimate")
+	}
+	if len(fields) == 0 {
+		return "Updated"
+	}
+	return "Changed the " + strings.Join(fields, ", ")
+}
+
+// uniqueIDs removes the duplicated ids keeping the first occurrence, an empty list becomes nil
+func uniqueIDs(ids []string) []string {
+	var unique []string
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// isTaskStatus reports whether status is one of the statuses the tasks table accepts
+func isTaskStatus(status string) bool {
+	switch status {
+	case _const.TASK_STATUS_TODO, _const.TASK_STATUS_IN_PROGRESS, _const.TASK_STATUS_DONE:
+		return true
+	}
+	return false
 }
 
 // AssignTask implements TaskUsecaseInterface.
 func (uc *TaskUsecase) AssignTask(ctx context.Context, input _genModel.AssignTaskInput) (*_model.Task, error) {
-	_, err := uc.taskRepo.GetTaskByID(ctx, input.ID)
+	existingTask, err := uc.taskRepo.GetTaskByID(ctx, input.ID)
 	if err != nil {
 		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Task Not Found")
 	}
+	if _, err = ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, existingTask.TeamID); err != nil {
+		return nil, err
+	}
+	if err = ensureTaskVersion(existingTask, input.ExpectedVersion); err != nil {
+		return nil, err
+	}
 
 	// Retrieve the assigned user based on ID
+	summary := "Unassigned"
 	if input.AssignedTo != nil {
-		_, err = uc.userRepo.GetUserByID(ctx, *input.AssignedTo)
+		assignedUser, err := uc.userRepo.GetUserByID(ctx, *input.AssignedTo)
 		if err != nil {
 			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Assigned user not found")
 		}
+		summary = fmt.Sprintf("Assigned to %s", assignedUser.Name)
 	}
 
 	task := &_model.Task{
@@ -252,16 +992,103 @@ func (uc *TaskUsecase) AssignTask(ctx context.Context, input _genModel.AssignTas
 		AssignedTo: input.AssignedTo,
 	}
 
-	updatedTask, err := uc.taskRepo.AssignTask(ctx, task)
+	// The new assignee starts watching the task
+	var updatedTask *_model.Task
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		updatedTask, err = uc.taskRepo.AssignTask(ctx, task, input.ExpectedVersion)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uc.taskConflict(ctx, task.ID)
+		}
+		if err != nil {
+			return gqlerror.Errorf(err.Error())
+		}
+		if input.AssignedTo == nil {
+			return nil
+		}
+		return uc.watcherUsecase.AddWatchers(ctx, task.ID, []string{*input.AssignedTo})
+	})
 	if err != nil {
-		return nil, gqlerror.Errorf(err.Error())
+		return nil, err
+	}
+
+	if input.AssignedTo == nil || existingTask.AssignedTo == nil || *existingTask.AssignedTo != *input.AssignedTo {
+		uc.watcherUsecase.NotifyWatchers(ctx, updatedTask, _model.NotificationKindTaskUpdated, nil, summary, nil)
 	}
 
 	return updatedTask, err
 
 }
 
-func (uc *TaskUsecase) TaskUpdatedEvent(ctx context.Context, teamID string) <-chan *_model.Task {
+// SetTaskParent moves the task under parentID at any depth, a nil parentID makes it a top level task again
+func (uc *TaskUsecase) SetTaskParent(ctx context.Context, taskID string, parentID *string) (*_model.Task, error) {
+	logs.Infof("SetTaskParent:: Starting with task %s and parent %v", taskID, parentID)
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var updatedTask *_model.Task
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		// Lock both tasks so two concurrent moves cannot close a cycle
+		lockIDs := []string{taskID}
+		if parentID != nil {
+			lockIDs = append(lockIDs, *parentID)
+		}
+		if err := uc.taskRepo.LockTasks(ctx, lockIDs); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+
+		task, err := uc.taskRepo.GetTaskByID(ctx, taskID)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+		}
+		if _, err = ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, task.TeamID); err != nil {
+			return err
+		}
+
+		if parentID != nil {
+			parent, err := uc.taskRepo.GetTaskByID(ctx, *parentID)
+			if err != nil {
+				return _customErr.NewGraphQLError(http.StatusNotFound, "Parent task not found")
+			}
+			if parent.TeamID != task.TeamID {
+				return _customErr.NewGraphQLError(http.StatusBadRequest, "Parent task belongs to another team")
+			}
+
+			// The task cannot end up below itself
+			isCycle, err := uc.taskRepo.IsTaskAncestor(ctx, task.ID, parent.ID)
+			if err != nil {
+				return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+			}
+			if isCycle {
+				return _customErr.NewGraphQLError(http.StatusBadRequest, "A task cannot be a subtask of itself or of its own subtasks")
+			}
+		}
+
+		updatedTask, err = uc.taskRepo.SetTaskParent(ctx, task.ID, parentID, actorID(userCtx))
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		logs.Errorf("SetTaskParent:: Error setting the parent: %v", err)
+		return nil, err
+	}
+
+	uc.taskPubSub.Publish(updatedTask.TeamID, _const.UPDATED, updatedTask)
+
+	logs.Info("SetTaskParent:: Finish SetTaskParent")
+
+	return updatedTask, nil
+}
+
+func (uc *TaskUsecase) TaskUpdatedEvent(ctx context.Context, teamID string) (<-chan *_model.Task, error) {
+	if err := uc.ensureTeamVisible(ctx, teamID); err != nil {
+		return nil, err
+	}
+
 	taskChan := make(chan *_model.Task, 1)
 
 	go func() {
@@ -276,10 +1103,14 @@ func (uc *TaskUsecase) TaskUpdatedEvent(ctx context.Context, teamID string) <-ch
 		close(taskChan)
 	}()
 
-	return taskChan
+	return taskChan, nil
 }
 
-func (uc *TaskUsecase) TaskDeletedEvent(ctx context.Context, teamID string) <-chan *_genModel.DeletedTaskNotification {
+func (uc *TaskUsecase) TaskDeletedEvent(ctx context.Context, teamID string) (<-chan *_genModel.DeletedTaskNotification, error) {
+	if err := uc.ensureTeamVisible(ctx, teamID); err != nil {
+		return nil, err
+	}
+
 	taskChan := make(chan *_genModel.DeletedTaskNotification, 1) // Create a channel for DeletedTaskNotification
 
 	go func() {
@@ -287,11 +1118,12 @@ func (uc *TaskUsecase) TaskDeletedEvent(ctx context.Context, teamID string) <-ch
 		defer uc.taskPubSub.Unsubscribe(teamID, eventChan)
 
 		for event := range eventChan {
-			if event.Type == _const.DELETED {
+			if event.Type == _const.DELETED || event.Type == _const.PURGED {
 				// Map the Task to DeletedTaskNotification (adjust according to your actual model)
 				deletedTaskNotification := &_genModel.DeletedTaskNotification{
-					TaskID:  event.Task.ID,
-					Deleted: true,
+					TaskID:    event.Task.ID,
+					Deleted:   true,
+					Permanent: event.Type == _const.PURGED,
 				}
 
 				taskChan <- deletedTaskNotification // Send the notification to the channel
@@ -301,5 +1133,36 @@ func (uc *TaskUsecase) TaskDeletedEvent(ctx context.Context, teamID string) <-ch
 		close(taskChan)
 	}()
 
-	return taskChan
+	return taskChan, nil
+}
+
+func (uc *TaskUsecase) TasksBatchedEvent(ctx context.Context, teamID string) (<-chan *_model.TaskBatch, error) {
+	if err := uc.ensureTeamVisible(ctx, teamID); err != nil {
+		return nil, err
+	}
+
+	batchChan := make(chan *_model.TaskBatch, 1)
+
+	go func() {
+		eventChan := uc.taskPubSub.Subscribe(teamID)
+		defer uc.taskPubSub.Unsubscribe(teamID, eventChan)
+
+		for event := range eventChan {
+			if event.Type == _const.BATCH {
+				batchChan <- event.Batch
+			}
+		}
+		close(batchChan)
+	}()
+
+	return batchChan, nil
+}
+
+// ensureTeamVisible stops subscriptions to teams outside of the workspace of the request,
+// events are delivered from memory and never go through the row level security policies
+func (uc *TaskUsecase) ensureTeamVisible(ctx context.Context, teamID string) error {
+	if _, err := uc.teamRepo.GetTeamByID(ctx, teamID); err != nil {
+		return _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+	return nil
 }
@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_projection "bitbucket.org/edts/go-task-management/internal/model/projection"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// fakeTaskRepo keeps tasks in memory, the methods the tests do not use are left to the nil interface
+type fakeTaskRepo struct {
+	_repo.TaskRepositoryInterface
+	tasks map[string]*_model.Task
+	// keys maps the task keys to the task ids
+	keys   map[string]string
+	racing bool
+}
+
+func (r *fakeTaskRepo) GetTaskByID(ctx context.Context, id string) (*_model.Task, error) {
+	if task, ok := r.tasks[id]; ok {
+		copied := *task
+		return &copied, nil
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (r *fakeTaskRepo) GetTasksByKeys(ctx context.Context, keys []_model.TaskKey) ([]*_model.Task, error) {
+	tasks := []*_model.Task{}
+	for _, key := range keys {
+		if task, ok := r.tasks[r.keys[key.String()]]; ok {
+			copied := *task
+			tasks = append(tasks, &copied)
+		}
+	}
+	return tasks, nil
+}
+
+func (r *fakeTaskRepo) GetTasksByTeam(ctx context.Context, teamID string, status *string, labels *_model.LabelFilter) ([]*_model.Task, error) {
+	tasks := []*_model.Task{}
+	for _, task := range r.tasks {
+		if task.TeamID == teamID {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+// UpdateTaskById applies the version check of the repository, racing lands another write right before it
+func (r *fakeTaskRepo) UpdateTaskById(ctx context.Context, task *_model.Task, expectedVersion *int32) (*_model.Task, error) {
+	stored := r.tasks[task.ID]
+	if r.racing {
+		stored.Version++
+	}
+	if expectedVersion != nil && *expectedVersion != stored.Version {
+		return nil, pgx.ErrNoRows
+	}
+
+	updated := *task
+	updated.Version = stored.Version + 1
+	r.tasks[task.ID] = &updated
+	return &updated, nil
+}
+
+type fakeWatcherUsecase struct {
+	WatcherUsecaseInterface
+	notified []*_model.Task
+}
+
+func (uc *fakeWatcherUsecase) NotifyWatchers(ctx context.Context, task *_model.Task, kind _model.NotificationKind, commentID *string, excerpt string, skip []string) {
+	uc.notified = append(uc.notified, task)
+}
+
+type fakeTeamRepo struct {
+	_repo.TeamRepositoryInterface
+	teams map[string]*_model.Team
+}
+
+func (r *fakeTeamRepo) GetTeamByID(ctx context.Context, id string) (*_model.Team, error) {
+	if team, ok := r.teams[id]; ok {
+		return team, nil
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func newTaskTestUsecase() *TaskUsecase {
+	tasks := &fakeTaskRepo{tasks: map[string]*_model.Task{
+		"task-a": {ID: "task-a", TeamID: "team-a", Number: 1, Title: "Own task", Version: 1},
+		"task-b": {ID: "task-b", TeamID: "team-b", Number: 1, Title: "Other task", Version: 1},
+	}, keys: map[string]string{"ENG-1": "task-a", "OPS-1": "task-b"}}
+	teams := &fakeTeamRepo{teams: map[string]*_model.Team{
+		"team-a": {ID: "team-a"},
+		"team-b": {ID: "team-b"},
+	}}
+	return &TaskUsecase{
+		taskRepo:       tasks,
+		teamRepo:       teams,
+		txRepo:         fakeTxRepo{},
+		taskPubSub:     fakeTaskPubSub{},
+		watcherUsecase: &fakeWatcherUsecase{},
+	}
+}
+
+// serviceAccountContext authenticates the request with a token of a service account owned by the team
+func serviceAccountContext(teamID string) context.Context {
+	return context.WithValue(context.Background(), "user", &_projection.UserContext{
+		UserID:           "service-account-1",
+		ServiceAccountID: "service-account-1",
+		TeamID:           teamID,
+		Scopes:           []string{},
+	})
+}
+
+func TestTeamBoundTokenCannotReachAnotherTeam(t *testing.T) {
+	uc := newTaskTestUsecase()
+	ctx := serviceAccountContext("team-a")
+	title := "Renamed"
+
+	_, err := uc.GetTaskByID(ctx, "task-b")
+	assertStatus(t, err, http.StatusForbidden)
+
+	_, err = uc.GetTaskByKey(ctx, "OPS-1")
+	assertStatus(t, err, http.StatusForbidden)
+
+	_, err = uc.GetTasksByTeam(ctx, "team-b", nil, nil)
+	assertStatus(t, err, http.StatusForbidden)
+
+	_, err = uc.UpdateTaskById(ctx, _genModel.UpdateTaskInput{ID: "task-b", Title: &title})
+	assertStatus(t, err, http.StatusForbidden)
+
+	err = uc.DeleteTaskById(ctx, "task-b", false)
+	assertStatus(t, err, http.StatusForbidden)
+}
+
+func TestTeamBoundTokenReadsItsOwnTeam(t *testing.T) {
+	uc := newTaskTestUsecase()
+	ctx := serviceAccountContext("team-a")
+
+	task, err := uc.GetTaskByID(ctx, "task-a")
+	if err != nil {
+		t.Fatalf("GetTaskByID: %v", err)
+	}
+	if task.ID != "task-a" {
+		t.Fatalf("expected task-a, got %s", task.ID)
+	}
+
+	tasks, err := uc.GetTasksByTeam(ctx, "team-a", nil, nil)
+	if err != nil {
+		t.Fatalf("GetTasksByTeam: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("expected 1 task, got %d", len(tasks))
+	}
+}
+
+func TestEnsureTaskVersion(t *testing.T) {
+	task := &_model.Task{ID: "task-a", Title: "Own task", Version: 3}
+	stale, current := int32(2), int32(3)
+
+	if err := ensureTaskVersion(task, nil); err != nil {
+		t.Fatalf("expected no version to skip the check, got %v", err)
+	}
+	if err := ensureTaskVersion(task, &current); err != nil {
+		t.Fatalf("expected the current version to pass, got %v", err)
+	}
+
+	err := ensureTaskVersion(task, &stale)
+	assertStatus(t, err, http.StatusConflict)
+	assertCurrentVersion(t, err, 3)
+}
+
+func TestUpdateTaskRejectsStaleVersion(t *testing.T) {
+	uc := newTaskTestUsecase()
+	ctx := serviceAccountContext("team-a")
+	title := "Renamed"
+	stale := int32(0)
+
+	_, err := uc.UpdateTaskById(ctx, _genModel.UpdateTaskInput{ID: "task-a", Title: &title, ExpectedVersion: &stale})
+	assertStatus(t, err, http.StatusConflict)
+	assertCurrentVersion(t, err, 1)
+
+	if task := uc.taskRepo.(*fakeTaskRepo).tasks["task-a"]; task.Title != "Own task" {
+		t.Fatalf("expected the stale update to be dropped, the title is %q", task.Title)
+	}
+}
+
+func TestUpdateTaskLosingTheRace(t *testing.T) {
+	uc := newTaskTestUsecase()
+	ctx := serviceAccountContext("team-a")
+	title := "Renamed"
+	version := int32(1)
+
+	// The version matches when the task is loaded but another write lands before the update
+	uc.taskRepo.(*fakeTaskRepo).racing = true
+	_, err := uc.UpdateTaskById(ctx, _genModel.UpdateTaskInput{ID: "task-a", Title: &title, ExpectedVersion: &version})
+	assertStatus(t, err, http.StatusConflict)
+	assertCurrentVersion(t, err, 2)
+}
+
+func TestUpdateTaskWithCurrentVersion(t *testing.T) {
+	uc := newTaskTestUsecase()
+	ctx := serviceAccountContext("team-a")
+	title := "Renamed"
+	version := int32(1)
+
+	task, err := uc.UpdateTaskById(ctx, _genModel.UpdateTaskInput{ID: "task-a", Title: &title, ExpectedVersion: &version})
+	if err != nil {
+		t.Fatalf("UpdateTaskById: %v", err)
+	}
+	if task.Title != title || task.Version != 2 {
+		t.Fatalf("expected %q at version 2, got %q at version %d", title, task.Title, task.Version)
+	}
+	if notified := uc.watcherUsecase.(*fakeWatcherUsecase).notified; len(notified) != 1 || notified[0].Version != 2 {
+		t.Fatal("expected the watchers to be notified with the updated task")
+	}
+}
+
+// assertCurrentVersion checks the conflict carries the task as currently stored
+func assertCurrentVersion(t *testing.T, err error, version int32) {
+	t.Helper()
+
+	gqlErr, ok := err.(*gqlerror.Error)
+	if !ok {
+		t.Fatalf("expected a GraphQL error, got %v", err)
+	}
+	current, ok := gqlErr.Extensions["current"].(*taskState)
+	if !ok {
+		t.Fatalf("expected the current task in the conflict, got %v", gqlErr.Extensions["current"])
+	}
+	if current.Version != version {
+		t.Fatalf("expected the current version %d, got %d", version, current.Version)
+	}
+}
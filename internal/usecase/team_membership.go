@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	_const "bitbucket.org/edts/go-task-management/internal/constant"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_projection "bitbucket.org/edts/go-task-management/internal/model/projection"
+	_pubsub "bitbucket.org/edts/go-task-management/internal/pubsub"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+)
+
+// teamMembership holds the membership rules shared by the team and user usecases:
+// removals never drop the last owner and the tasks of departing members follow the team reassign policy
+type teamMembership struct {
+	userRepo      _repo.UserRepositoryInterface
+	userTeamRepo  _repo.UserTeamRepositoryInterface
+	taskRepo      _repo.TaskRepositoryInterface
+	workspaceRepo _repo.WorkspaceRepositoryInterface
+	taskPubSub    _pubsub.TaskPubSubInterface
+}
+
+// replaceMembers makes userIDs the exact member list of the team, must run inside a transaction.
+// Members kept keep their role, new members join as MEMBER
+func (m *teamMembership) replaceMembers(ctx context.Context, userCtx *_projection.UserContext, team *_model.Team, userIDs []string) ([]*_model.Task, error) {
+	roles, err := m.userTeamRepo.GetTeamMemberRoles(ctx, team.ID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if err = m.ensureCanManage(userCtx, roles); err != nil {
+		return nil, err
+	}
+
+	desired := make(map[string]bool, len(userIDs))
+	var added []string
+	for _, userID := range userIDs {
+		if desired[userID] {
+			continue
+		}
+		desired[userID] = true
+		if _, ok := roles[userID]; !ok {
+			added = append(added, userID)
+		}
+	}
+
+	if len(added) != 0 {
+		users, err := m.userRepo.GetUsersByIDs(ctx, added)
+		if err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if len(users) != len(added) {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "User Not Found")
+		}
+		if err = m.ensureWorkspaceMembers(ctx, team.WorkspaceID, added); err != nil {
+			return nil, err
+		}
+		if _, err = m.userTeamRepo.InsertUserTeams(ctx, &added, team); err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	var removed []string
+	for userID := range roles {
+		if !desired[userID] {
+			removed = append(removed, userID)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	return m.removeMembers(ctx, userCtx, team, roles, removed, nil)
+}
+
+// removeMember removes a single member, reassignTo overrides the team policy for their tasks.
+// Must run inside a transaction
+func (m *teamMembership) removeMember(ctx context.Context, userCtx *_projection.UserContext, team *_model.Team, userID string, reassignTo *string) ([]*_model.Task, error) {
+	roles, err := m.userTeamRepo.GetTeamMemberRoles(ctx, team.ID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if _, ok := roles[userID]; !ok {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "User is not a member of this team")
+	}
+
+	// Leaving only requires being a member, removing someone else requires managing the team
+	if userID != userCtx.UserID || userCtx.IsServiceAccount() {
+		if err = m.ensureCanManage(userCtx, roles); err != nil {
+			return nil, err
+		}
+	}
+
+	return m.removeMembers(ctx, userCtx, team, roles, []string{userID}, reassignTo)
+}
+
+func (m *teamMembership) removeMembers(
+	ctx context.Context,
+	userCtx *_projection.UserContext,
+	team *_model.Team,
+	roles map[string]_model.TeamRole,
+	userIDs []string,
+	reassignTo *string) ([]*_model.Task, error) {
+	departing := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		departing[userID] = true
+	}
+
+	// A member can only be removed by someone holding at least their role, leaving is always allowed
+	callerRole := roles[userCtx.UserID]
+	if userCtx.IsWorkspaceAdmin() {
+		callerRole = _model.TeamRoleOwner
+	}
+	var remainingOwners []string
+	ownerRemoved := false
+	for userID, role := range roles {
+		if !departing[userID] {
+			if role == _model.TeamRoleOwner {
+				remainingOwners = append(remainingOwners, userID)
+			}
+			continue
+		}
+		if userID != userCtx.UserID && !callerRole.AtLeast(role) {
+			return nil, _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: cannot remove a member with a higher role than your own")
+		}
+		if role == _model.TeamRoleOwner {
+			ownerRemoved = true
+		}
+	}
+	if ownerRemoved && len(remainingOwners) == 0 {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "The last owner cannot leave the team, transfer the ownership first")
+	}
+
+	assignee := reassignTo
+	if assignee != nil {
+		if _, ok := roles[*assignee]; !ok || departing[*assignee] {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Tasks can only be reassigned to a remaining member")
+		}
+	} else if team.TaskReassignPolicy == _model.TaskReassignPolicyReassignToOwner {
+		assignee = pickOwner(team, remainingOwners)
+	}
+
+	tasks, err := m.taskRepo.ReassignTeamTasks(ctx, team.ID, userIDs, assignee, actorID(userCtx))
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = m.userTeamRepo.DeleteUserTeams(ctx, team.ID, userIDs); err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	return tasks, nil
+}
+
+// ensureCanManage allows team admins, owners and workspace admins to change the members, service accounts never can
+func (m *teamMembership) ensureCanManage(userCtx *_projection.UserContext, roles map[string]_model.TeamRole) error {
+	if userCtx.IsWorkspaceAdmin() {
+		return nil
+	}
+	role, ok := roles[userCtx.UserID]
+	if userCtx.IsServiceAccount() || !ok || !role.AtLeast(_model.TeamRoleAdmin) {
+		return _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: only team admins can manage members")
+	}
+	return nil
+}
+
+// ensureWorkspaceMembers rejects users from outside the workspace of the team
+func (m *teamMembership) ensureWorkspaceMembers(ctx context.Context, workspaceID string, userIDs []string) error {
+	unique := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		unique[userID] = true
+	}
+	if len(unique) == 0 {
+		return nil
+	}
+
+	count, err := m.workspaceRepo.CountWorkspaceMembers(ctx, workspaceID, userIDs)
+	if err != nil {
+		return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if count != len(unique) {
+		return _customErr.NewGraphQLError(http.StatusBadRequest, "Team members must belong to the workspace")
+	}
+	return nil
+}
+
+// publishReassigned notifies subscribers once the transaction that reassigned the tasks is committed
+func (m *teamMembership) publishReassigned(teamID string, tasks []*_model.Task) {
+	for _, task := range tasks {
+		m.taskPubSub.Publish(teamID, _const.UPDATED, task)
+	}
+}
+
+// pickOwner prefers the team creator so reassignment is predictable when a team has several owners
+func pickOwner(team *_model.Team, owners []string) *string {
+	if len(owners) == 0 {
+		return nil
+	}
+	sort.Strings(owners)
+	for _, owner := range owners {
+		if team.CreatedBy != nil && team.CreatedBy.String() == owner {
+			return &owner
+		}
+	}
+	return &owners[0]
+}
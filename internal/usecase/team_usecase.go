@@ -1,47 +1,118 @@
 package usecase
 
 import (
+	_config "bitbucket.org/edts/go-task-management/config"
 	_model "bitbucket.org/edts/go-task-management/internal/model"
 	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
 	_projection "bitbucket.org/edts/go-task-management/internal/model/projection"
+	_pubsub "bitbucket.org/edts/go-task-management/internal/pubsub"
 	_repo "bitbucket.org/edts/go-task-management/internal/repository"
 	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
 	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type TeamUsecaseInterface interface {
 	CreateTeam(ctx context.Context, input _genModel.CreateTeamInput) (*_model.Team, error)
 	UpdateTeam(ctx context.Context, input _genModel.UpdateTeamInput) (*_model.Team, error)
-	GetTeamsByUser(ctx context.Context) ([]*_genModel.TeamSummary, error)
+	GetTeamsByUser(ctx context.Context, includeArchived bool) ([]*_genModel.TeamSummary, error)
+	RemoveTeamMember(ctx context.Context, teamID string, userID string, reassignTo *string) (*_model.Team, error)
+	LeaveTeam(ctx context.Context, teamID string) (bool, error)
+	ArchiveTeam(ctx context.Context, teamID string) (*_model.Team, error)
+	UnarchiveTeam(ctx context.Context, teamID string) (*_model.Team, error)
+	DeleteTeam(ctx context.Context, teamID string) (*_model.Team, error)
+	RestoreTeam(ctx context.Context, teamID string) (*_model.Team, error)
+	TransferTeamOwnership(ctx context.Context, teamID string, userID string) (*_model.Team, error)
+	PurgeDeletedTeams(ctx context.Context) (int, error)
 }
 
+// defaultTeamDeletionGracePeriod is used when the team section is missing from config
+const defaultTeamDeletionGracePeriod = 30 * 24 * time.Hour
+
 type TeamUsecase struct {
 	teamRepo     _repo.TeamRepositoryInterface
 	userRepo     _repo.UserRepositoryInterface
 	userTeamRepo _repo.UserTeamRepositoryInterface
+	txRepo       _repo.TransactionRepositoryInterface
+	membership   *teamMembership
 }
 
-func NewTeamUsecase(teamRepo _repo.TeamRepositoryInterface, userRepo _repo.UserRepositoryInterface, userTeamRepo _repo.UserTeamRepositoryInterface) TeamUsecaseInterface {
+func NewTeamUsecase(
+	teamRepo _repo.TeamRepositoryInterface,
+	userRepo _repo.UserRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface,
+	taskRepo _repo.TaskRepositoryInterface,
+	workspaceRepo _repo.WorkspaceRepositoryInterface,
+	txRepo _repo.TransactionRepositoryInterface,
+	taskPubSub _pubsub.TaskPubSubInterface) TeamUsecaseInterface {
 	return &TeamUsecase{
 		teamRepo:     teamRepo,
 		userRepo:     userRepo,
 		userTeamRepo: userTeamRepo,
+		txRepo:       txRepo,
+		membership: &teamMembership{
+			userRepo:      userRepo,
+			userTeamRepo:  userTeamRepo,
+			taskRepo:      taskRepo,
+			workspaceRepo: workspaceRepo,
+			taskPubSub:    taskPubSub,
+		},
 	}
 }
 
 func (uc *TeamUsecase) CreateTeam(ctx context.Context, input _genModel.CreateTeamInput) (*_model.Team, error) {
+	userCtx, err := workspaceUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	team := &_model.Team{
-		Name:        input.Name,
-		Description: input.Description,
+		Name:               input.Name,
+		Description:        input.Description,
+		WorkspaceID:        userCtx.WorkspaceID,
+		TaskReassignPolicy: _model.TaskReassignPolicyUnassign,
+		WipLimitPolicy:     _model.WipLimitPolicyWarn,
+	}
+	if input.TaskReassignPolicy != nil {
+		team.TaskReassignPolicy = *input.TaskReassignPolicy
+	}
+	if input.WipLimitPolicy != nil {
+		team.WipLimitPolicy = *input.WipLimitPolicy
 	}
+	team.CreatedBy = actorID(userCtx)
+	team.ModifiedBy = team.CreatedBy
 
-	// Save to repo
+	if err = uc.membership.ensureWorkspaceMembers(ctx, userCtx.WorkspaceID, input.Assignee); err != nil {
+		return nil, err
+	}
+
+	if team.Key, err = uc.teamKey(ctx, input.Key, input.Name); err != nil {
+		return nil, err
+	}
+
+	// Save to repo, a team created meanwhile may have taken the key
 	createdTeam, err := uc.teamRepo.CreateTeam(ctx, team)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, teamKeyTakenError(team.Key)
+		}
 		return nil, err
 	}
 
+	// The creator owns the team, inserted before the assignees so the role is kept
+	if !userCtx.IsServiceAccount() {
+		if err = uc.userTeamRepo.InsertUserTeam(ctx, userCtx.UserID, createdTeam.ID, _model.TeamRoleOwner); err != nil {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, err.Error())
+		}
+	}
+
 	// Insert Team Assignee
 	team, err = uc.userTeamRepo.InsertUserTeams(ctx, &input.Assignee, createdTeam)
 	if err != nil {
@@ -51,35 +122,152 @@ func (uc *TeamUsecase) CreateTeam(ctx context.Context, input _genModel.CreateTea
 	return createdTeam, nil
 }
 
+// teamKey checks the key requested for a new team, or derives one from the first letters of its name,
+// numbered when another team of the workspace already has it
+func (uc *TeamUsecase) teamKey(ctx context.Context, requested *string, name string) (string, error) {
+	if requested != nil {
+		key := strings.ToUpper(strings.TrimSpace(*requested))
+		if !_model.TeamKeyPattern.MatchString(key) {
+			return "", _customErr.NewGraphQLError(http.StatusBadRequest, "Team key must be 2 to 10 letters or digits starting with a letter")
+		}
+		exists, err := uc.teamRepo.TeamKeyExists(ctx, key)
+		if err != nil {
+			return "", _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if exists {
+			return "", teamKeyTakenError(key)
+		}
+		return key, nil
+	}
+
+	base := teamKeyFromName(name)
+	key := base
+	for i := 2; ; i++ {
+		exists, err := uc.teamRepo.TeamKeyExists(ctx, key)
+		if err != nil {
+			return "", _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if !exists {
+			return key, nil
+		}
+		key = fmt.Sprintf("%s%d", base, i)
+	}
+}
+
+// teamKeyFromName keeps the first three letters of the name, TEAM when it has less than two
+func teamKeyFromName(name string) string {
+	var letters strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if r >= 'A' && r <= 'Z' {
+			letters.WriteRune(r)
+		}
+	}
+
+	key := letters.String()
+	if len(key) < 2 {
+		return "TEAM"
+	}
+	if len(key) > 3 {
+		key = key[:3]
+	}
+	return key
+}
+
+func teamKeyTakenError(key string) error {
+	return _customErr.NewGraphQLError(http.StatusConflict, fmt.Sprintf("Team key %s is already used in the workspace", key))
+}
+
 func (uc *TeamUsecase) UpdateTeam(ctx context.Context, input _genModel.UpdateTeamInput) (*_model.Team, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	team := &_model.Team{
 		ID:          input.ID,
 		Name:        input.Name,
 		Description: input.Description,
 	}
+	if input.TaskReassignPolicy != nil {
+		team.TaskReassignPolicy = *input.TaskReassignPolicy
+	}
+	if input.WipLimitPolicy != nil {
+		team.WipLimitPolicy = *input.WipLimitPolicy
+	}
+	team.ModifiedBy = actorID(userCtx)
 
-	// Save to repo
-	updatedTeam, err := uc.teamRepo.UpdateTeam(ctx, team)
+	wipLimits, err := toWipLimits(team.ID, input.WipLimits)
 	if err != nil {
 		return nil, err
 	}
 
-	team, err = uc.userTeamRepo.InsertUserTeams(ctx, &input.Assignee, updatedTeam)
+	// The team and its member list change together
+	var updatedTeam *_model.Team
+	var reassignedTasks []*_model.Task
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		role, err := getTeamRole(ctx, uc.userTeamRepo, userCtx, team.ID)
+		if err != nil {
+			return err
+		}
+		if !role.AtLeast(_model.TeamRoleAdmin) {
+			return _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: only team admins can update the team")
+		}
+
+		existingTeam, err := uc.teamRepo.GetTeamByID(ctx, team.ID)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+		}
+		if err = ensureTeamWritable(existingTeam); err != nil {
+			return err
+		}
+		if input.ExpectedVersion != nil && *input.ExpectedVersion != existingTeam.Version {
+			return teamConflictError(existingTeam)
+		}
+
+		// Save to repo
+		updatedTeam, err = uc.teamRepo.UpdateTeam(ctx, team, input.ExpectedVersion)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Changed by another request since the version was checked
+			current, err := uc.teamRepo.GetTeamByID(ctx, team.ID)
+			if err != nil {
+				return _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+			}
+			return teamConflictError(current)
+		}
+		if err != nil {
+			return err
+		}
+
+		// An omitted WIP limit list leaves the limits untouched
+		if input.WipLimits != nil {
+			if err = uc.teamRepo.SetTeamWipLimits(ctx, updatedTeam.ID, wipLimits, team.ModifiedBy); err != nil {
+				return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+			}
+		}
+
+		// An omitted assignee list leaves the members untouched
+		if input.Assignee != nil {
+			reassignedTasks, err = uc.membership.replaceMembers(ctx, userCtx, updatedTeam, input.Assignee)
+		}
+		return err
+	})
 	if err != nil {
-		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, err.Error())
+		return nil, err
 	}
 
+	uc.membership.publishReassigned(updatedTeam.ID, reassignedTasks)
+
 	return updatedTeam, nil
 }
 
-func (uc *TeamUsecase) GetTeamsByUser(ctx context.Context) ([]*_genModel.TeamSummary, error) {
+func (uc *TeamUsecase) GetTeamsByUser(ctx context.Context, includeArchived bool) ([]*_genModel.TeamSummary, error) {
 	// Retrieve the user context data
-	userCtx, ok := ctx.Value("user").(*_projection.UserContext)
-	if !ok {
-		return nil, _customErr.NewGraphQLError(http.StatusUnauthorized, "unauthorized: user context is missing or invalid")
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 	// Fetch teams by user ID
-	teams, err := uc.teamRepo.GetTeamsByUserID(ctx, userCtx.UserID)
+	teams, err := uc.teamRepo.GetTeamsByUserID(ctx, userCtx.UserID, includeArchived)
 	if err != nil {
 		return nil, err
 	}
@@ -94,3 +282,230 @@ func (uc *TeamUsecase) GetTeamsByUser(ctx context.Context) ([]*_genModel.TeamSum
 
 	return results, nil
 }
+
+func (uc *TeamUsecase) RemoveTeamMember(ctx context.Context, teamID string, userID string, reassignTo *string) (*_model.Team, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := uc.teamRepo.GetTeamByID(ctx, teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+	if err = ensureTeamWritable(team); err != nil {
+		return nil, err
+	}
+
+	var reassignedTasks []*_model.Task
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		reassignedTasks, err = uc.membership.removeMember(ctx, userCtx, team, userID, reassignTo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.membership.publishReassigned(team.ID, reassignedTasks)
+
+	return team, nil
+}
+
+func (uc *TeamUsecase) LeaveTeam(ctx context.Context, teamID string) (bool, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	if userCtx.IsServiceAccount() {
+		return false, _customErr.NewGraphQLError(http.StatusForbidden, "service accounts cannot leave their team")
+	}
+
+	team, err := uc.teamRepo.GetTeamByID(ctx, teamID)
+	if err != nil {
+		return false, _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+
+	var reassignedTasks []*_model.Task
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		reassignedTasks, err = uc.membership.removeMember(ctx, userCtx, team, userCtx.UserID, nil)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	uc.membership.publishReassigned(team.ID, reassignedTasks)
+
+	return true, nil
+}
+
+func (uc *TeamUsecase) ArchiveTeam(ctx context.Context, teamID string) (*_model.Team, error) {
+	return uc.setTeamArchived(ctx, teamID, true)
+}
+
+func (uc *TeamUsecase) UnarchiveTeam(ctx context.Context, teamID string) (*_model.Team, error) {
+	return uc.setTeamArchived(ctx, teamID, false)
+}
+
+func (uc *TeamUsecase) setTeamArchived(ctx context.Context, teamID string, archived bool) (*_model.Team, error) {
+	userCtx, err := uc.requireTeamRole(ctx, teamID, _model.TeamRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := uc.teamRepo.SetTeamArchived(ctx, teamID, archived, actorID(userCtx))
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+	return team, nil
+}
+
+func (uc *TeamUsecase) DeleteTeam(ctx context.Context, teamID string) (*_model.Team, error) {
+	userCtx, err := uc.requireTeamRole(ctx, teamID, _model.TeamRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := uc.teamRepo.SoftDeleteTeam(ctx, teamID, actorID(userCtx))
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+	return team, nil
+}
+
+func (uc *TeamUsecase) RestoreTeam(ctx context.Context, teamID string) (*_model.Team, error) {
+	userCtx, err := uc.requireTeamRole(ctx, teamID, _model.TeamRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	deletedAfter := time.Now().Add(-getTeamDeletionGracePeriod())
+	team, err := uc.teamRepo.RestoreTeam(ctx, teamID, deletedAfter, actorID(userCtx))
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Deleted team not found or grace period is over")
+	}
+	return team, nil
+}
+
+func (uc *TeamUsecase) TransferTeamOwnership(ctx context.Context, teamID string, userID string) (*_model.Team, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if userID == userCtx.UserID {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "You already own this team")
+	}
+
+	team, err := uc.teamRepo.GetTeamByID(ctx, teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		roles, err := uc.userTeamRepo.GetTeamMemberRoles(ctx, team.ID)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if userCtx.IsServiceAccount() || roles[userCtx.UserID] != _model.TeamRoleOwner {
+			return _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: only the team owner can transfer the ownership")
+		}
+		if _, ok := roles[userID]; !ok {
+			return _customErr.NewGraphQLError(http.StatusBadRequest, "The new owner must be a member of this team")
+		}
+
+		if err = uc.userTeamRepo.UpdateUserTeamRole(ctx, userID, team.ID, _model.TeamRoleOwner); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if err = uc.userTeamRepo.UpdateUserTeamRole(ctx, userCtx.UserID, team.ID, _model.TeamRoleAdmin); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+// PurgeDeletedTeams hard deletes the teams whose grace period is over, run periodically by every replica
+func (uc *TeamUsecase) PurgeDeletedTeams(ctx context.Context) (int, error) {
+	deletedBefore := time.Now().Add(-getTeamDeletionGracePeriod())
+	teamIDs, err := uc.teamRepo.PurgeDeletedTeams(_repo.WithoutWorkspaceIsolation(ctx), deletedBefore)
+	if err != nil {
+		logs.Errorf("PurgeDeletedTeams:: Error PurgeDeletedTeams repo: %v", err)
+		return 0, err
+	}
+
+	if len(teamIDs) != 0 {
+		logs.Infof("PurgeDeletedTeams:: Purged teams %v", teamIDs)
+	}
+	return len(teamIDs), nil
+}
+
+// requireTeamRole rejects callers holding less than the given role, deleted teams still have their members
+func (uc *TeamUsecase) requireTeamRole(ctx context.Context, teamID string, role _model.TeamRole) (*_projection.UserContext, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	callerRole, err := getTeamRole(ctx, uc.userTeamRepo, userCtx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if !callerRole.AtLeast(role) {
+		return nil, _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: requires the "+strings.ToLower(string(role))+" role")
+	}
+	return userCtx, nil
+}
+
+// toWipLimits validates the WIP limits of a team, each status can be limited once
+func toWipLimits(teamID string, inputs []*_genModel.WipLimitInput) ([]*_model.WipLimit, error) {
+	limits := make([]*_model.WipLimit, 0, len(inputs))
+	seen := make(map[string]bool, len(inputs))
+	for _, input := range inputs {
+		if !isTaskStatus(input.Status) {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid task status "+input.Status)
+		}
+		if seen[input.Status] {
+			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Duplicated WIP limit for "+input.Status)
+		}
+		seen[input.Status] = true
+		limits = append(limits, &_model.WipLimit{TeamID: teamID, Status: input.Status, Limit: input.Limit})
+	}
+	return limits, nil
+}
+
+// teamState is the current state of a team sent along a CONFLICT error, fields are named like in the schema
+type teamState struct {
+	ID                 string                    `json:"id"`
+	Name               string                    `json:"name"`
+	Description        *string                   `json:"description"`
+	TaskReassignPolicy _model.TaskReassignPolicy `json:"taskReassignPolicy"`
+	WipLimitPolicy     _model.WipLimitPolicy     `json:"wipLimitPolicy"`
+	Version            int32                     `json:"version"`
+	ModifiedAt         time.Time                 `json:"modifiedAt"`
+}
+
+func teamConflictError(team *_model.Team) error {
+	return _customErr.NewConflictError(
+		fmt.Sprintf("Team was changed by someone else, its current version is %d", team.Version),
+		&teamState{
+			ID:                 team.ID,
+			Name:               team.Name,
+			Description:        team.Description,
+			TaskReassignPolicy: team.TaskReassignPolicy,
+			WipLimitPolicy:     team.WipLimitPolicy,
+			Version:            team.Version,
+			ModifiedAt:         team.ModifiedAt,
+		},
+	)
+}
+
+func getTeamDeletionGracePeriod() time.Duration {
+	if gracePeriod := _config.AppConfigInstance.Team.DeletionGracePeriod; gracePeriod > 0 {
+		return gracePeriod
+	}
+	return defaultTeamDeletionGracePeriod
+}
@@ -1,23 +1,253 @@
 package usecase
 
 import (
+	"context"
+	"net/http"
+
+	_config "bitbucket.org/edts/go-task-management/config"
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_projection "bitbucket.org/edts/go-task-management/internal/model/projection"
 	_pubsub "bitbucket.org/edts/go-task-management/internal/pubsub"
 	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	"bitbucket.org/edts/go-task-management/pkg/blobstore"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"bitbucket.org/edts/go-task-management/pkg/mailer"
+	"bitbucket.org/edts/go-task-management/pkg/oidc"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/google/uuid"
 )
 
 type Usecase struct {
-	TaskUsecase TaskUsecaseInterface
-	AuthUsecase AuthUsecaseInterface
-	TeamUsecase TeamUsecaseInterface
-	UserUsecase UserUsecaseInterface
+	TaskUsecase           TaskUsecaseInterface
+	AuthUsecase           AuthUsecaseInterface
+	TeamUsecase           TeamUsecaseInterface
+	UserUsecase           UserUsecaseInterface
+	AccessTokenUsecase    AccessTokenUsecaseInterface
+	ServiceAccountUsecase ServiceAccountUsecaseInterface
+	OIDCUsecase           OIDCUsecaseInterface
+	InvitationUsecase     InvitationUsecaseInterface
+	WorkspaceUsecase      WorkspaceUsecaseInterface
+	ChecklistUsecase      ChecklistUsecaseInterface
+	TaskDependencyUsecase TaskDependencyUsecaseInterface
+	LabelUsecase          LabelUsecaseInterface
+	BoardUsecase          BoardUsecaseInterface
+	SprintUsecase         SprintUsecaseInterface
+	MilestoneUsecase      MilestoneUsecaseInterface
+	WorklogUsecase        WorklogUsecaseInterface
+	TaskSeriesUsecase     TaskSeriesUsecaseInterface
+	TaskTemplateUsecase   TaskTemplateUsecaseInterface
+	AttachmentUsecase     AttachmentUsecaseInterface
+	CommentUsecase        CommentUsecaseInterface
+	MentionUsecase        MentionUsecaseInterface
+	NotificationUsecase   NotificationUsecaseInterface
+	WatcherUsecase        WatcherUsecaseInterface
 }
 
 // NewUsecase Usecase dependency injection here
 func NewUsecase(repo *_repo.Repository, pubsub *_pubsub.PubSub) *Usecase {
+	mail := newMailer()
+	notificationUsecase := NewNotificationUsecase(repo.NotificationRepo, repo.UserRepo, mail)
+	watcherUsecase := NewWatcherUsecase(repo.WatcherRepo, repo.TaskRepo, repo.UserTeamRepo, notificationUsecase)
+	mentionUsecase := NewMentionUsecase(repo.MentionRepo, repo.UserTeamRepo, repo.TaskRepo, watcherUsecase, notificationUsecase)
+	taskUsecase := NewTaskUsecase(repo.TaskRepo, repo.UserRepo, repo.TeamRepo, repo.UserTeamRepo, repo.TaskDependencyRepo, repo.BoardRepo, repo.LabelRepo, repo.TransactionRepo, pubsub.TaskPubSub, mentionUsecase, watcherUsecase)
+
 	return &Usecase{
-		TaskUsecase: NewTaskUsecase(repo.TaskRepo, repo.UserRepo, repo.TeamRepo, pubsub.TaskPubSub),
-		AuthUsecase: NewAuthUsecase(repo.UserRepo, repo.UserSessionRepo),
-		TeamUsecase: NewTeamUsecase(repo.TeamRepo, repo.UserRepo, repo.UserTeamRepo),
-		UserUsecase: NewUserUsecase(repo.UserRepo, repo.TeamRepo, repo.UserTeamRepo),
+		TaskUsecase:           taskUsecase,
+		AuthUsecase:           NewAuthUsecase(repo.UserRepo, repo.UserSessionRepo, repo.InvitationRepo, repo.TransactionRepo),
+		TeamUsecase:           NewTeamUsecase(repo.TeamRepo, repo.UserRepo, repo.UserTeamRepo, repo.TaskRepo, repo.WorkspaceRepo, repo.TransactionRepo, pubsub.TaskPubSub),
+		UserUsecase:           NewUserUsecase(repo.UserRepo, repo.TeamRepo, repo.UserTeamRepo, repo.TaskRepo, repo.WorkspaceRepo, repo.TransactionRepo, pubsub.TaskPubSub),
+		AccessTokenUsecase:    NewAccessTokenUsecase(repo.AccessTokenRepo, repo.ServiceAccountRepo, repo.UserTeamRepo),
+		ServiceAccountUsecase: NewServiceAccountUsecase(repo.ServiceAccountRepo, repo.AccessTokenRepo, repo.TeamRepo, repo.UserTeamRepo),
+		OIDCUsecase:           NewOIDCUsecase(newOIDCProvider(), repo.UserRepo, repo.UserIdentityRepo, repo.UserSessionRepo, repo.InvitationRepo, repo.TransactionRepo),
+		InvitationUsecase:     NewInvitationUsecase(repo.InvitationRepo, repo.TeamRepo, repo.UserRepo, repo.UserTeamRepo, mail),
+		WorkspaceUsecase:      NewWorkspaceUsecase(repo.WorkspaceRepo, repo.TeamRepo, repo.UserRepo, repo.UserTeamRepo, repo.TaskRepo, repo.TransactionRepo, pubsub.TaskPubSub),
+		ChecklistUsecase:      NewChecklistUsecase(repo.ChecklistItemRepo, repo.TaskRepo, repo.TransactionRepo, pubsub.TaskPubSub),
+		TaskDependencyUsecase: NewTaskDependencyUsecase(repo.TaskDependencyRepo, repo.TaskRepo, repo.TransactionRepo, pubsub.TaskPubSub),
+		LabelUsecase:          NewLabelUsecase(repo.LabelRepo, repo.TaskRepo, repo.TeamRepo, repo.UserTeamRepo, repo.TransactionRepo, pubsub.TaskPubSub),
+		BoardUsecase:          NewBoardUsecase(repo.BoardRepo, repo.TeamRepo, repo.UserRepo, repo.UserTeamRepo, repo.LabelRepo),
+		SprintUsecase:         NewSprintUsecase(repo.SprintRepo, repo.TaskRepo, repo.TeamRepo, repo.UserTeamRepo, repo.TransactionRepo, pubsub.TaskPubSub),
+		MilestoneUsecase:      NewMilestoneUsecase(repo.MilestoneRepo, repo.TaskRepo, repo.TeamRepo, repo.UserTeamRepo, repo.TransactionRepo, pubsub.TaskPubSub),
+		WorklogUsecase:        NewWorklogUsecase(repo.WorklogRepo, repo.TaskRepo, repo.TeamRepo, repo.UserRepo, repo.UserTeamRepo),
+		TaskSeriesUsecase:     NewTaskSeriesUsecase(repo.TaskSeriesRepo, repo.TaskRepo, repo.TeamRepo, repo.UserTeamRepo, repo.TransactionRepo, pubsub.TaskPubSub),
+		TaskTemplateUsecase:   NewTaskTemplateUsecase(repo.TaskTemplateRepo, repo.LabelRepo, repo.ChecklistItemRepo, repo.UserRepo, repo.TeamRepo, repo.UserTeamRepo, repo.TransactionRepo, taskUsecase),
+		AttachmentUsecase:     NewAttachmentUsecase(repo.AttachmentRepo, repo.TaskRepo, repo.TeamRepo, repo.UserTeamRepo, repo.TransactionRepo, pubsub.TaskPubSub, newBlobStore()),
+		CommentUsecase:        NewCommentUsecase(repo.CommentRepo, repo.TaskRepo, repo.TeamRepo, repo.UserTeamRepo, repo.TransactionRepo, pubsub.TaskPubSub, mentionUsecase, watcherUsecase),
+		MentionUsecase:        mentionUsecase,
+		NotificationUsecase:   notificationUsecase,
+		WatcherUsecase:        watcherUsecase,
+	}
+}
+
+// newOIDCProvider builds the single sign-on provider from config, discovery happens on first login
+func newOIDCProvider() *oidc.Provider {
+	cfg := _config.AppConfigInstance.OIDC
+	return oidc.NewProvider(oidc.Config{
+		IssuerURL:    cfg.IssuerURL,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       cfg.Scopes,
+	})
+}
+
+// newMailer builds the configured mailer, emails are only logged unless smtp is configured
+func newMailer() mailer.Mailer {
+	cfg := _config.AppConfigInstance.Mail
+	if cfg.Driver == "smtp" {
+		return mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     cfg.Host,
+			Port:     cfg.Port,
+			Username: cfg.Username,
+			Password: cfg.Password,
+			From:     cfg.From,
+		})
+	}
+	return mailer.NewLogMailer()
+}
+
+// newBlobStore builds the configured store of the attachments, files are kept on the local disk unless s3 is configured
+func newBlobStore() blobstore.BlobStore {
+	cfg := _config.AppConfigInstance.Attachment
+	if cfg.Driver == "s3" {
+		return blobstore.NewS3Store(blobstore.S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			PathStyle: cfg.S3.PathStyle,
+		})
+	}
+
+	root := cfg.LocalDir
+	if root == "" {
+		root = "./data/attachments"
+	}
+	return blobstore.NewLocalStore(root)
+}
+
+// userFromContext retrieves the caller stored in the context by the auth directive
+func userFromContext(ctx context.Context) (*_projection.UserContext, error) {
+	userCtx, ok := ctx.Value("user").(*_projection.UserContext)
+	if !ok {
+		return nil, _customErr.NewGraphQLError(http.StatusUnauthorized, "unauthorized: user context is missing or invalid")
+	}
+	return userCtx, nil
+}
+
+// workspaceUserFromContext retrieves the caller and requires a workspace to be selected for the request
+func workspaceUserFromContext(ctx context.Context) (*_projection.UserContext, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if userCtx.WorkspaceID == "" {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "A workspace must be selected with the X-Workspace-ID header")
+	}
+	return userCtx, nil
+}
+
+// ensureTeamMember rejects callers that do not belong to the team,
+// a service account only belongs to the team that owns it
+func ensureTeamMember(ctx context.Context, userTeamRepo _repo.UserTeamRepositoryInterface, userCtx *_projection.UserContext, teamID string) error {
+	if userCtx.IsServiceAccount() {
+		if userCtx.TeamID != teamID {
+			return _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: not a member of this team")
+		}
+		return nil
+	}
+	// Workspace admins oversee every team of the workspace, teams of other workspaces are not visible
+	if userCtx.IsWorkspaceAdmin() {
+		return nil
+	}
+
+	isMember, err := userTeamRepo.IsUserInTeam(ctx, userCtx.UserID, teamID)
+	if err != nil {
+		return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if !isMember {
+		return _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: not a member of this team")
+	}
+	return nil
+}
+
+// getTeamRole returns the caller role in the team and rejects non members,
+// a service account acts as a member of the team that owns it and a workspace admin as an owner
+func getTeamRole(ctx context.Context, userTeamRepo _repo.UserTeamRepositoryInterface, userCtx *_projection.UserContext, teamID string) (_model.TeamRole, error) {
+	if userCtx.IsServiceAccount() {
+		if userCtx.TeamID != teamID {
+			return "", _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: not a member of this team")
+		}
+		return _model.TeamRoleMember, nil
+	}
+	if userCtx.IsWorkspaceAdmin() {
+		return _model.TeamRoleOwner, nil
+	}
+
+	role, err := userTeamRepo.GetUserTeamRole(ctx, userCtx.UserID, teamID)
+	if err != nil {
+		return "", _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if role == nil {
+		return "", _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: not a member of this team")
+	}
+	return *role, nil
+}
+
+// ensureTeamWritable rejects changes to an archived team
+func ensureTeamWritable(team *_model.Team) error {
+	if team != nil && team.ArchivedAt != nil {
+		return _customErr.NewGraphQLError(http.StatusBadRequest, "Team is archived and read-only")
+	}
+	return nil
+}
+
+// ensureTeamEditable lets team members change the content of teams that are not archived
+func ensureTeamEditable(ctx context.Context, teamRepo _repo.TeamRepositoryInterface, userTeamRepo _repo.UserTeamRepositoryInterface, teamID string) (*_projection.UserContext, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := teamRepo.GetTeamByID(ctx, teamID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Team Not Found")
+	}
+	if err = ensureTeamWritable(team); err != nil {
+		return nil, err
+	}
+	if err = ensureTeamMember(ctx, userTeamRepo, userCtx, teamID); err != nil {
+		return nil, err
+	}
+	return userCtx, nil
+}
+
+// actorID is the caller recorded in created_by and modified_by columns
+func actorID(userCtx *_projection.UserContext) *uuid.UUID {
+	id, err := uuid.Parse(userCtx.UserID)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+// warning is a problem that did not stop the operation, reported in the "warnings" extension of the response
+type warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// addWarning appends a warning to the GraphQL response, outside of a GraphQL operation it is only logged
+func addWarning(ctx context.Context, code string, message string) {
+	logs.Infof("addWarning:: %s: %s", code, message)
+	if !graphql.HasOperationContext(ctx) {
+		return
+	}
+
+	warnings, _ := graphql.GetExtension(ctx, "warnings").(*[]warning)
+	if warnings == nil {
+		warnings = &[]warning{}
+		graphql.RegisterExtension(ctx, "warnings", warnings)
 	}
+	*warnings = append(*warnings, warning{Code: code, Message: message})
 }
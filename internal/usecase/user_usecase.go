@@ -3,10 +3,9 @@ package usecase
 import (
 	_model "bitbucket.org/edts/go-task-management/internal/model"
 	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_pubsub "bitbucket.org/edts/go-task-management/internal/pubsub"
 	_repo "bitbucket.org/edts/go-task-management/internal/repository"
-	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
 	"context"
-	"net/http"
 )
 
 type UserUsecaseInterface interface {
@@ -19,13 +18,30 @@ type UserUsecase struct {
 	userRepo     _repo.UserRepositoryInterface
 	teamRepo     _repo.TeamRepositoryInterface
 	userTeamRepo _repo.UserTeamRepositoryInterface
+	txRepo       _repo.TransactionRepositoryInterface
+	membership   *teamMembership
 }
 
-func NewUserUsecase(userRepo _repo.UserRepositoryInterface, teamRepo _repo.TeamRepositoryInterface, userTeamRepo _repo.UserTeamRepositoryInterface) UserUsecaseInterface {
+func NewUserUsecase(
+	userRepo _repo.UserRepositoryInterface,
+	teamRepo _repo.TeamRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface,
+	taskRepo _repo.TaskRepositoryInterface,
+	workspaceRepo _repo.WorkspaceRepositoryInterface,
+	txRepo _repo.TransactionRepositoryInterface,
+	taskPubSub _pubsub.TaskPubSubInterface) UserUsecaseInterface {
 	return &UserUsecase{
 		userRepo:     userRepo,
 		teamRepo:     teamRepo,
 		userTeamRepo: userTeamRepo,
+		txRepo:       txRepo,
+		membership: &teamMembership{
+			userRepo:      userRepo,
+			userTeamRepo:  userTeamRepo,
+			taskRepo:      taskRepo,
+			workspaceRepo: workspaceRepo,
+			taskPubSub:    taskPubSub,
+		},
 	}
 }
 
@@ -62,44 +78,31 @@ func (uc *UserUsecase) GetAssigneeByTeam(ctx context.Context, teamID string) ([]
 }
 
 func (uc *UserUsecase) AssignUserToTeam(ctx context.Context, input _genModel.AssignUserToTeamInput) (*_model.Team, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get the team by ID
 	team, err := uc.teamRepo.GetTeamByID(ctx, input.TeamID)
 	if err != nil {
 		return nil, err
 	}
-
-	// Get the users by IDs
-	users := make([]string, len(input.UserID))
-	for i, userID := range input.UserID {
-		user, err := uc.userRepo.GetUserByID(ctx, userID)
-		if err != nil {
-			return nil, err
-		}
-		users[i] = user.ID
+	if err = ensureTeamWritable(team); err != nil {
+		return nil, err
 	}
 
-	// Check user_teams is exist or not by teamId
-	existsTeam, err := uc.userTeamRepo.ExistUserTeamsByTeamId(ctx, team.ID)
+	// Replace the user-team relationships, departing members hand over their tasks
+	var reassignedTasks []*_model.Task
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		reassignedTasks, err = uc.membership.replaceMembers(ctx, userCtx, team, input.UserID)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	//Delete user_teams by team id before inserting new one
-	if existsTeam {
-		err = uc.userTeamRepo.DeleteUserTeamsByTeamId(ctx, team.ID)
-		if err != nil {
-			return nil, _customErr.NewGraphQLError(http.StatusBadRequest, err.Error())
-		}
-	}
-
-	// Save the user-team relationships
-	if len(users) != 0 {
-		team, err = uc.userTeamRepo.InsertUserTeams(ctx, &users, team)
-		if err != nil {
-
-			return nil, err
-		}
-	}
+	uc.membership.publishReassigned(team.ID, reassignedTasks)
 
 	return team, nil
 }
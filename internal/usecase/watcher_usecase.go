@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_projection "bitbucket.org/edts/go-task-management/internal/model/projection"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+)
+
+type WatcherUsecaseInterface interface {
+	WatchTask(ctx context.Context, taskID string) (*_model.Task, error)
+	UnwatchTask(ctx context.Context, taskID string) (*_model.Task, error)
+	AddWatchers(ctx context.Context, taskID string, userIDs []string) error
+	NotifyWatchers(ctx context.Context, task *_model.Task, kind _model.NotificationKind, commentID *string, excerpt string, skip []string)
+}
+
+type WatcherUsecase struct {
+	// Repo
+	watcherRepo  _repo.WatcherRepositoryInterface
+	taskRepo     _repo.TaskRepositoryInterface
+	userTeamRepo _repo.UserTeamRepositoryInterface
+	// Usecase
+	notificationUsecase NotificationUsecaseInterface
+}
+
+func NewWatcherUsecase(
+	watcherRepo _repo.WatcherRepositoryInterface,
+	taskRepo _repo.TaskRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface,
+	notificationUsecase NotificationUsecaseInterface) WatcherUsecaseInterface {
+	return &WatcherUsecase{
+		watcherRepo:         watcherRepo,
+		taskRepo:            taskRepo,
+		userTeamRepo:        userTeamRepo,
+		notificationUsecase: notificationUsecase,
+	}
+}
+
+func (uc *WatcherUsecase) WatchTask(ctx context.Context, taskID string) (*_model.Task, error) {
+	logs.Infof("WatchTask:: Starting with task %s", taskID)
+	task, userCtx, err := uc.getWatchableTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = uc.watcherRepo.AddWatchers(ctx, task.ID, []string{userCtx.UserID}); err != nil {
+		logs.Errorf("WatchTask:: Error AddWatchers repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return task, nil
+}
+
+// UnwatchTask stops the notifications of the task for the caller, until they get involved in it again
+func (uc *WatcherUsecase) UnwatchTask(ctx context.Context, taskID string) (*_model.Task, error) {
+	logs.Infof("UnwatchTask:: Starting with task %s", taskID)
+	task, userCtx, err := uc.getWatchableTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = uc.watcherRepo.RemoveWatcher(ctx, task.ID, userCtx.UserID); err != nil {
+		logs.Errorf("UnwatchTask:: Error RemoveWatcher repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return task, nil
+}
+
+// AddWatchers makes the users involved in the task watch it, meant to run in the transaction of the change
+func (uc *WatcherUsecase) AddWatchers(ctx context.Context, taskID string, userIDs []string) error {
+	userIDs = uniqueIDs(userIDs)
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	if err := uc.watcherRepo.AddWatchers(ctx, taskID, userIDs); err != nil {
+		logs.Errorf("AddWatchers:: Error AddWatchers repo: %v", err)
+		return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return nil
+}
+
+// NotifyWatchers notifies the watchers of the task once the change is committed, except the ones in skip
+// who already received a more specific notification about it
+func (uc *WatcherUsecase) NotifyWatchers(ctx context.Context, task *_model.Task, kind _model.NotificationKind, commentID *string, excerpt string, skip []string) {
+	watcherIDs, err := uc.watcherRepo.GetWatcherIDsOfTeamMembers(ctx, task.ID)
+	if err != nil {
+		logs.Errorf("NotifyWatchers:: Error GetWatcherIDsOfTeamMembers repo: %v", err)
+		return
+	}
+
+	skipped := make(map[string]bool, len(skip))
+	for _, userID := range skip {
+		skipped[userID] = true
+	}
+	var recipients []string
+	for _, userID := range watcherIDs {
+		if !skipped[userID] {
+			recipients = append(recipients, userID)
+		}
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	notification := &_model.Notification{
+		Kind:      kind,
+		TaskID:    task.ID,
+		CommentID: commentID,
+		ActorID:   notificationActor(ctx),
+	}
+	uc.notificationUsecase.Notify(ctx, recipients, notification, task, excerpt)
+}
+
+// getWatchableTask returns the task when the caller is a person of its team
+func (uc *WatcherUsecase) getWatchableTask(ctx context.Context, taskID string) (*_model.Task, *_projection.UserContext, error) {
+	userCtx, err := personFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	task, err := uc.taskRepo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+	}
+	if err = ensureTeamMember(ctx, uc.userTeamRepo, userCtx, task.TeamID); err != nil {
+		return nil, nil, err
+	}
+	return task, userCtx, nil
+}
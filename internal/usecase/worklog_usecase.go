@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_genModel "bitbucket.org/edts/go-task-management/internal/model/_generated"
+	_projection "bitbucket.org/edts/go-task-management/internal/model/projection"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type WorklogUsecaseInterface interface {
+	LogWork(ctx context.Context, input _genModel.LogWorkInput) (*_model.Worklog, error)
+	DeleteWorklog(ctx context.Context, id string) (bool, error)
+	StartTimer(ctx context.Context, taskID string, note *string) (*_model.Worklog, error)
+	StopTimer(ctx context.Context) (*_model.Worklog, error)
+	GetRunningTimer(ctx context.Context) (*_model.Worklog, error)
+	GetTimesheet(ctx context.Context, userID *string, from string, to string) (*_genModel.Timesheet, error)
+}
+
+type WorklogUsecase struct {
+	// Repo
+	worklogRepo  _repo.WorklogRepositoryInterface
+	taskRepo     _repo.TaskRepositoryInterface
+	teamRepo     _repo.TeamRepositoryInterface
+	userRepo     _repo.UserRepositoryInterface
+	userTeamRepo _repo.UserTeamRepositoryInterface
+}
+
+func NewWorklogUsecase(
+	worklogRepo _repo.WorklogRepositoryInterface,
+	taskRepo _repo.TaskRepositoryInterface,
+	teamRepo _repo.TeamRepositoryInterface,
+	userRepo _repo.UserRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface) WorklogUsecaseInterface {
+	return &WorklogUsecase{
+		worklogRepo:  worklogRepo,
+		taskRepo:     taskRepo,
+		teamRepo:     teamRepo,
+		userRepo:     userRepo,
+		userTeamRepo: userTeamRepo,
+	}
+}
+
+// LogWork records time already spent on a task
+func (uc *WorklogUsecase) LogWork(ctx context.Context, input _genModel.LogWorkInput) (*_model.Worklog, error) {
+	logs.Infof("LogWork:: Starting with payload %v", input)
+	userCtx, err := uc.ensureTaskWorker(ctx, input.TaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	startedAt, err := time.Parse(time.RFC3339, input.StartedAt)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid start date format")
+	}
+
+	worklog, err := uc.worklogRepo.CreateWorklog(ctx, &_model.Worklog{
+		TaskID:    input.TaskID,
+		UserID:    userCtx.UserID,
+		StartedAt: startedAt,
+		Duration:  &input.Duration,
+		Note:      input.Note,
+	})
+	if err != nil {
+		logs.Errorf("LogWork:: Error CreateWorklog repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	logs.Info("LogWork:: Finish LogWork")
+
+	return worklog, nil
+}
+
+// DeleteWorklog removes a worklog of the caller, workspace admins can remove any worklog
+func (uc *WorklogUsecase) DeleteWorklog(ctx context.Context, id string) (bool, error) {
+	logs.Infof("DeleteWorklog:: Starting with worklog %s", id)
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	worklog, err := uc.worklogRepo.GetWorklogByID(ctx, id)
+	if err != nil {
+		return false, _customErr.NewGraphQLError(http.StatusNotFound, "Worklog not found")
+	}
+	if worklog.UserID != userCtx.UserID && !userCtx.IsWorkspaceAdmin() {
+		return false, _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: worklog belongs to another user")
+	}
+
+	if err = uc.worklogRepo.DeleteWorklog(ctx, worklog.ID); err != nil {
+		logs.Errorf("DeleteWorklog:: Error DeleteWorklog repo: %v", err)
+		return false, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	logs.Info("DeleteWorklog:: Finish DeleteWorklog")
+
+	return true, nil
+}
+
+// StartTimer starts a timer of the caller on the task, a user runs one timer at a time
+func (uc *WorklogUsecase) StartTimer(ctx context.Context, taskID string, note *string) (*_model.Worklog, error) {
+	logs.Infof("StartTimer:: Starting with task %s", taskID)
+	userCtx, err := uc.ensureTaskWorker(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	worklog, err := uc.worklogRepo.CreateWorklog(ctx, &_model.Worklog{
+		TaskID:    taskID,
+		UserID:    userCtx.UserID,
+		StartedAt: time.Now().UTC(),
+		Note:      note,
+	})
+	if err != nil {
+		// The partial unique index allows one running timer per user
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, _customErr.NewGraphQLError(http.StatusConflict, "A timer is already running, stop it first")
+		}
+		logs.Errorf("StartTimer:: Error CreateWorklog repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	logs.Info("StartTimer:: Finish StartTimer")
+
+	return worklog, nil
+}
+
+// StopTimer stops the running timer of the caller and logs the time elapsed
+func (uc *WorklogUsecase) StopTimer(ctx context.Context) (*_model.Worklog, error) {
+	logs.Info("StopTimer:: Starting")
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	worklog, err := uc.worklogRepo.StopRunningWorklog(ctx, userCtx.UserID)
+	if err != nil {
+		logs.Errorf("StopTimer:: Error StopRunningWorklog repo: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if worklog == nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "No timer is running")
+	}
+
+	logs.Info("StopTimer:: Finish StopTimer")
+
+	return worklog, nil
+}
+
+func (uc *WorklogUsecase) GetRunningTimer(ctx context.Context) (*_model.Worklog, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	worklog, err := uc.worklogRepo.GetRunningWorklog(ctx, userCtx.UserID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return worklog, nil
+}
+
+// GetTimesheet lists the worklogs of the user started in [from, to), the caller reads their own timesheet
+// unless a workspace admin asks for another user
+func (uc *WorklogUsecase) GetTimesheet(ctx context.Context, userID *string, from string, to string) (*_genModel.Timesheet, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	timesheetUserID := userCtx.UserID
+	if userID != nil && *userID != userCtx.UserID {
+		if !userCtx.IsWorkspaceAdmin() {
+			return nil, _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: only workspace admins can read the timesheet of another user")
+		}
+		timesheetUserID = *userID
+	}
+
+	fromDate, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid from date format")
+	}
+	toDate, err := time.Parse(time.RFC3339, to)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid to date format")
+	}
+	if !toDate.After(fromDate) {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "The to date must be after the from date")
+	}
+
+	user, err := uc.userRepo.GetUserByID(ctx, timesheetUserID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "User not found")
+	}
+
+	worklogs, err := uc.worklogRepo.GetUserWorklogs(ctx, user.ID, fromDate, toDate)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	timesheet := &_genModel.Timesheet{
+		User:    user,
+		From:    fromDate,
+		To:      toDate,
+		Entries: []*_model.Worklog{},
+	}
+	for _, worklog := range worklogs {
+		timesheet.TotalDuration += *worklog.Duration
+		timesheet.Entries = append(timesheet.Entries, worklog)
+	}
+	return timesheet, nil
+}
+
+// ensureTaskWorker lets members of the task team log time on it, service accounts do not log time
+func (uc *WorklogUsecase) ensureTaskWorker(ctx context.Context, taskID string) (*_projection.UserContext, error) {
+	task, err := uc.taskRepo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "Task Not Found")
+	}
+	userCtx, err := ensureTeamEditable(ctx, uc.teamRepo, uc.userTeamRepo, task.TeamID)
+	if err != nil {
+		return nil, err
+	}
+	if userCtx.IsServiceAccount() {
+		return nil, _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: service accounts cannot log time")
+	}
+	return userCtx, nil
+}
+
+// ComputeTimeSpent sums the duration of the stopped worklogs
+func ComputeTimeSpent(worklogs []*_model.Worklog) int32 {
+	var spent int32
+	for _, worklog := range worklogs {
+		if worklog.Duration != nil {
+			spent += *worklog.Duration
+		}
+	}
+	return spent
+}
+
+// ComputeRemainingEstimate is the part of the original estimate not spent yet, nil for a task without estimate
+func ComputeRemainingEstimate(task *_model.Task, worklogs []*_model.Worklog) *int32 {
+	if task.OriginalEstimate == nil {
+		return nil
+	}
+	remaining := max(*task.OriginalEstimate-ComputeTimeSpent(worklogs), 0)
+	return &remaining
+}
@@ -0,0 +1,274 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	_model "bitbucket.org/edts/go-task-management/internal/model"
+	_projection "bitbucket.org/edts/go-task-management/internal/model/projection"
+	_pubsub "bitbucket.org/edts/go-task-management/internal/pubsub"
+	_repo "bitbucket.org/edts/go-task-management/internal/repository"
+	_customErr "bitbucket.org/edts/go-task-management/pkg/errors"
+	"github.com/jackc/pgx/v5"
+)
+
+type WorkspaceUsecaseInterface interface {
+	ResolveWorkspace(ctx context.Context, userCtx *_projection.UserContext, requestedWorkspaceID string) error
+	CreateWorkspace(ctx context.Context, name string) (*_model.Workspace, error)
+	GetWorkspaces(ctx context.Context) ([]*_model.Workspace, error)
+	GetWorkspaceMembers(ctx context.Context) ([]*_model.WorkspaceMember, error)
+	AddWorkspaceMember(ctx context.Context, email string, role _model.WorkspaceRole) (*_model.WorkspaceMember, error)
+	RemoveWorkspaceMember(ctx context.Context, userID string) (bool, error)
+}
+
+type WorkspaceUsecase struct {
+	workspaceRepo _repo.WorkspaceRepositoryInterface
+	teamRepo      _repo.TeamRepositoryInterface
+	userRepo      _repo.UserRepositoryInterface
+	txRepo        _repo.TransactionRepositoryInterface
+	membership    *teamMembership
+}
+
+func NewWorkspaceUsecase(
+	workspaceRepo _repo.WorkspaceRepositoryInterface,
+	teamRepo _repo.TeamRepositoryInterface,
+	userRepo _repo.UserRepositoryInterface,
+	userTeamRepo _repo.UserTeamRepositoryInterface,
+	taskRepo _repo.TaskRepositoryInterface,
+	txRepo _repo.TransactionRepositoryInterface,
+	taskPubSub _pubsub.TaskPubSubInterface) WorkspaceUsecaseInterface {
+	return &WorkspaceUsecase{
+		workspaceRepo: workspaceRepo,
+		teamRepo:      teamRepo,
+		userRepo:      userRepo,
+		txRepo:        txRepo,
+		membership: &teamMembership{
+			userRepo:      userRepo,
+			userTeamRepo:  userTeamRepo,
+			taskRepo:      taskRepo,
+			workspaceRepo: workspaceRepo,
+			taskPubSub:    taskPubSub,
+		},
+	}
+}
+
+// ResolveWorkspace selects the workspace of the request and scopes every following query to it.
+// Service accounts always act in the workspace of their team, users pick one with the X-Workspace-ID header
+// and fall back to their only workspace. When none can be picked the request sees no workspace data
+func (uc *WorkspaceUsecase) ResolveWorkspace(ctx context.Context, userCtx *_projection.UserContext, requestedWorkspaceID string) error {
+	if userCtx.IsServiceAccount() {
+		workspaceID, err := uc.workspaceRepo.GetWorkspaceIDByTeamID(ctx, userCtx.TeamID)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusUnauthorized, "unauthorized: service account team not found")
+		}
+		if requestedWorkspaceID != "" && requestedWorkspaceID != workspaceID {
+			return _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: not a member of this workspace")
+		}
+		userCtx.WorkspaceID = workspaceID
+		userCtx.WorkspaceRole = _model.WorkspaceRoleMember
+	} else if requestedWorkspaceID != "" {
+		role, err := uc.workspaceRepo.GetWorkspaceMemberRole(ctx, requestedWorkspaceID, userCtx.UserID)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid workspace")
+		}
+		if role == nil {
+			return _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: not a member of this workspace")
+		}
+		userCtx.WorkspaceID = requestedWorkspaceID
+		userCtx.WorkspaceRole = *role
+	} else {
+		workspaces, err := uc.workspaceRepo.GetWorkspacesByUserID(ctx, userCtx.UserID)
+		if err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		if len(workspaces) == 1 {
+			userCtx.WorkspaceID = workspaces[0].ID
+			userCtx.WorkspaceRole = workspaces[0].Role
+		}
+	}
+
+	_repo.ScopeRequestToWorkspace(ctx, userCtx.WorkspaceID)
+	return nil
+}
+
+func (uc *WorkspaceUsecase) CreateWorkspace(ctx context.Context, name string) (*_model.Workspace, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if userCtx.IsServiceAccount() {
+		return nil, _customErr.NewGraphQLError(http.StatusForbidden, "service accounts cannot create workspaces")
+	}
+
+	workspace := &_model.Workspace{
+		Name: strings.TrimSpace(name),
+		Role: _model.WorkspaceRoleAdmin,
+		Base: _model.Base{
+			CreatedBy:  actorID(userCtx),
+			ModifiedBy: actorID(userCtx),
+		},
+	}
+
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		if workspace, err = uc.workspaceRepo.CreateWorkspace(ctx, workspace); err != nil {
+			return err
+		}
+		_, err = uc.workspaceRepo.UpsertWorkspaceMember(ctx, &_model.WorkspaceMember{
+			WorkspaceID: workspace.ID,
+			UserID:      userCtx.UserID,
+			Role:        _model.WorkspaceRoleAdmin,
+		})
+		return err
+	})
+	if err != nil {
+		logs.Errorf("CreateWorkspace:: Error creating workspace: %v", err)
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	return workspace, nil
+}
+
+func (uc *WorkspaceUsecase) GetWorkspaces(ctx context.Context) ([]*_model.Workspace, error) {
+	userCtx, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Service accounts only see the workspace of their team
+	if userCtx.IsServiceAccount() {
+		return []*_model.Workspace{}, nil
+	}
+
+	workspaces, err := uc.workspaceRepo.GetWorkspacesByUserID(ctx, userCtx.UserID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return workspaces, nil
+}
+
+func (uc *WorkspaceUsecase) GetWorkspaceMembers(ctx context.Context) ([]*_model.WorkspaceMember, error) {
+	userCtx, err := workspaceUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := uc.workspaceRepo.GetWorkspaceMembers(ctx, userCtx.WorkspaceID)
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	return members, nil
+}
+
+func (uc *WorkspaceUsecase) AddWorkspaceMember(ctx context.Context, email string, role _model.WorkspaceRole) (*_model.WorkspaceMember, error) {
+	userCtx, err := uc.requireWorkspaceAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !role.IsValid() {
+		return nil, _customErr.NewGraphQLError(http.StatusBadRequest, "Invalid workspace role")
+	}
+
+	user, err := uc.userRepo.GetUserByEmail(ctx, strings.TrimSpace(email))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, _customErr.NewGraphQLError(http.StatusNotFound, "User Not Found, invite them to a team instead")
+	}
+	if err != nil {
+		return nil, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	var member *_model.WorkspaceMember
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		if role != _model.WorkspaceRoleAdmin {
+			if err := uc.ensureAdminRemains(ctx, userCtx.WorkspaceID, user.ID); err != nil {
+				return err
+			}
+		}
+
+		member, err = uc.workspaceRepo.UpsertWorkspaceMember(ctx, &_model.WorkspaceMember{
+			WorkspaceID: userCtx.WorkspaceID,
+			UserID:      user.ID,
+			Role:        role,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+func (uc *WorkspaceUsecase) RemoveWorkspaceMember(ctx context.Context, userID string) (bool, error) {
+	userCtx, err := uc.requireWorkspaceAdmin(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	teams, err := uc.teamRepo.GetTeamsByUserID(ctx, userID, true)
+	if err != nil {
+		return false, _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+
+	// The user leaves every team of the workspace, their tasks follow the policy of each team
+	reassignedTasks := make(map[string][]*_model.Task)
+	err = uc.txRepo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.ensureAdminRemains(ctx, userCtx.WorkspaceID, userID); err != nil {
+			return err
+		}
+
+		for _, summary := range teams {
+			tasks, err := uc.membership.removeMember(ctx, userCtx, summary.Team, userID, nil)
+			if err != nil {
+				return err
+			}
+			reassignedTasks[summary.Team.ID] = tasks
+		}
+
+		if err := uc.workspaceRepo.DeleteWorkspaceMember(ctx, userCtx.WorkspaceID, userID); err != nil {
+			return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for teamID, tasks := range reassignedTasks {
+		uc.membership.publishReassigned(teamID, tasks)
+	}
+
+	return true, nil
+}
+
+func (uc *WorkspaceUsecase) requireWorkspaceAdmin(ctx context.Context) (*_projection.UserContext, error) {
+	userCtx, err := workspaceUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !userCtx.IsWorkspaceAdmin() {
+		return nil, _customErr.NewGraphQLError(http.StatusForbidden, "forbidden: only workspace admins can manage members")
+	}
+	return userCtx, nil
+}
+
+// ensureAdminRemains rejects removing or demoting the last admin of the workspace
+func (uc *WorkspaceUsecase) ensureAdminRemains(ctx context.Context, workspaceID string, userID string) error {
+	role, err := uc.workspaceRepo.GetWorkspaceMemberRole(ctx, workspaceID, userID)
+	if err != nil {
+		return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if role == nil || *role != _model.WorkspaceRoleAdmin {
+		return nil
+	}
+
+	admins, err := uc.workspaceRepo.CountWorkspaceAdmins(ctx, workspaceID)
+	if err != nil {
+		return _customErr.NewGraphQLError(http.StatusInternalServerError, err.Error())
+	}
+	if admins <= 1 {
+		return _customErr.NewGraphQLError(http.StatusBadRequest, "A workspace must keep at least one admin")
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// Package blobstore keeps the content of uploaded files behind a BlobStore,
+// on the local filesystem or in an S3 compatible bucket
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("blobstore: blob not found")
+
+var ErrInvalidKey = errors.New("blobstore: invalid key")
+
+// BlobStore saves blobs under slash separated keys such as "team/task/blob"
+type BlobStore interface {
+	// Put writes size bytes of body under key, replacing any previous blob
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the blob, ErrNotFound when there is none
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob, deleting a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// URLSigner is implemented by stores serving downloads themselves, such as presigned S3 URLs.
+// Downloads from other stores go through the API
+type URLSigner interface {
+	SignedURL(ctx context.Context, key string, filename string, ttl time.Duration) (string, error)
+}
+
+// validateKey rejects keys that could escape the store root or the bucket prefix
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
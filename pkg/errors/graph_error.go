@@ -7,11 +7,16 @@ import (
 
 // Map HTTP status to GraphQL error codes
 var statusToCode = map[int]string{
-	http.StatusBadRequest:          "BAD_REQUEST",
-	http.StatusUnauthorized:        "UNAUTHORIZED",
-	http.StatusForbidden:           "FORBIDDEN",
-	http.StatusNotFound:            "NOT_FOUND",
-	http.StatusInternalServerError: "INTERNAL_SERVER_ERROR",
+	http.StatusBadRequest:            "BAD_REQUEST",
+	http.StatusUnauthorized:          "UNAUTHORIZED",
+	http.StatusForbidden:             "FORBIDDEN",
+	http.StatusNotFound:              "NOT_FOUND",
+	http.StatusConflict:              "CONFLICT",
+	http.StatusUnprocessableEntity:   "UNPROCESSABLE_ENTITY",
+	http.StatusRequestEntityTooLarge: "PAYLOAD_TOO_LARGE",
+	http.StatusUnsupportedMediaType:  "UNSUPPORTED_MEDIA_TYPE",
+	http.StatusTooManyRequests:       "RATE_LIMITED",
+	http.StatusInternalServerError:   "INTERNAL_SERVER_ERROR",
 }
 
 // NewGraphQLError creates a new GraphQL error with a mapped code
@@ -29,3 +34,11 @@ func NewGraphQLError(status int, message string) *gqlerror.Error {
 		},
 	}
 }
+
+// NewConflictError creates a CONFLICT error carrying the current server state in the "current" extension,
+// for the client to merge its changes into
+func NewConflictError(message string, current interface{}) *gqlerror.Error {
+	err := NewGraphQLError(http.StatusConflict, message)
+	err.Extensions["current"] = current
+	return err
+}
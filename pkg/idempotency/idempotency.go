@@ -0,0 +1,110 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// pendingTimeout bounds how long a reserved key waits for its response, a key left pending
+// by a crashed request can be reserved again afterwards
+const pendingTimeout = time.Minute
+
+// ErrKeyReused is returned when a key is sent again with a different request
+var ErrKeyReused = errors.New("idempotency key reused with a different request")
+
+// ErrKeyInProgress is returned when a key is sent again while its first request is still running
+var ErrKeyInProgress = errors.New("idempotency key in progress")
+
+// ErrKeyLost is returned when the request completing or releasing a key no longer holds it,
+// another request took it over after the pending timeout
+var ErrKeyLost = errors.New("idempotency key taken over by another request")
+
+// Record is the state kept for a key, Response stays nil until the first request completes.
+// Owner is the token of the request holding the key, only that request completes or releases it
+type Record struct {
+	Owner       string
+	Fingerprint string
+	Response    []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Store keeps the records, implementations must make Reserve atomic per key
+type Store interface {
+	// Reserve claims the key for the fingerprint on behalf of owner and returns nil, or returns the record already holding the key
+	Reserve(ctx context.Context, key string, fingerprint string, owner string, now time.Time, ttl time.Duration) (*Record, error)
+	// Complete saves the response of the request holding the key, ErrKeyLost when owner no longer holds it
+	Complete(ctx context.Context, key string, owner string, response []byte) error
+	// Release frees the key, for a request that failed to be retried with it. ErrKeyLost when owner no longer holds it
+	Release(ctx context.Context, key string, owner string) error
+}
+
+// Keeper replays the responses of the requests sent again with the same key until the TTL expires
+type Keeper struct {
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+func NewKeeper(store Store, ttl time.Duration) *Keeper {
+	return &Keeper{
+		store: store,
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+// Begin reserves the key for the request, it returns the stored response when the same request
+// already completed with the key. Otherwise it returns the owner token the caller passes to Complete or Release
+// once the request ran
+func (k *Keeper) Begin(ctx context.Context, key string, fingerprint string) ([]byte, string, error) {
+	owner, err := newOwner()
+	if err != nil {
+		return nil, "", err
+	}
+
+	record, err := k.store.Reserve(ctx, key, fingerprint, owner, k.now(), k.ttl)
+	if err != nil {
+		return nil, "", err
+	}
+	if record == nil {
+		return nil, owner, nil
+	}
+	if record.Fingerprint != fingerprint {
+		return nil, "", ErrKeyReused
+	}
+	if record.Response == nil {
+		return nil, "", ErrKeyInProgress
+	}
+	return record.Response, "", nil
+}
+
+// Complete saves the response replayed to the next requests sent with the key
+func (k *Keeper) Complete(ctx context.Context, key string, owner string, response []byte) error {
+	return k.store.Complete(ctx, key, owner, response)
+}
+
+// Release frees the key so the request can be retried with it
+func (k *Keeper) Release(ctx context.Context, key string, owner string) error {
+	return k.store.Release(ctx, key, owner)
+}
+
+// newOwner generates the token telling the request holding a key apart from the one taking it over
+func newOwner() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// available tells whether a record no longer holds its key
+func (r *Record) available(now time.Time) bool {
+	if !now.Before(r.ExpiresAt) {
+		return true
+	}
+	return r.Response == nil && now.Sub(r.CreatedAt) > pendingTimeout
+}
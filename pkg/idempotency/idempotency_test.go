@@ -0,0 +1,116 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestKeeper returns a keeper on a memory store with a clock the test moves forward
+func newTestKeeper() (*Keeper, *time.Time) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	keeper := NewKeeper(NewMemoryStore(), time.Hour)
+	keeper.now = func() time.Time { return now }
+	return keeper, &now
+}
+
+func begin(t *testing.T, keeper *Keeper, fingerprint string) string {
+	t.Helper()
+
+	stored, owner, err := keeper.Begin(context.Background(), "user-1:key", fingerprint)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if stored != nil || owner == "" {
+		t.Fatalf("expected the key to be reserved, got response %q and owner %q", stored, owner)
+	}
+	return owner
+}
+
+func TestKeeperReplaysTheCompletedResponse(t *testing.T) {
+	keeper, _ := newTestKeeper()
+	ctx := context.Background()
+
+	owner := begin(t, keeper, "create-task")
+	if err := keeper.Complete(ctx, "user-1:key", owner, []byte(`{"data":{}}`)); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	stored, _, err := keeper.Begin(ctx, "user-1:key", "create-task")
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if string(stored) != `{"data":{}}` {
+		t.Fatalf("expected the stored response, got %q", stored)
+	}
+}
+
+func TestKeeperRejectsConcurrentAndReusedKeys(t *testing.T) {
+	keeper, _ := newTestKeeper()
+	ctx := context.Background()
+	begin(t, keeper, "create-task")
+
+	if _, _, err := keeper.Begin(ctx, "user-1:key", "create-task"); !errors.Is(err, ErrKeyInProgress) {
+		t.Fatalf("expected ErrKeyInProgress, got %v", err)
+	}
+	if _, _, err := keeper.Begin(ctx, "user-1:key", "delete-task"); !errors.Is(err, ErrKeyReused) {
+		t.Fatalf("expected ErrKeyReused, got %v", err)
+	}
+}
+
+func TestKeeperReleasedKeyIsFreeAgain(t *testing.T) {
+	keeper, _ := newTestKeeper()
+
+	owner := begin(t, keeper, "create-task")
+	if err := keeper.Release(context.Background(), "user-1:key", owner); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	begin(t, keeper, "create-task")
+}
+
+func TestKeeperTakeOverAfterThePendingTimeout(t *testing.T) {
+	keeper, now := newTestKeeper()
+	ctx := context.Background()
+
+	stale := begin(t, keeper, "create-task")
+	*now = now.Add(pendingTimeout + time.Second)
+	current := begin(t, keeper, "create-task")
+
+	// The request that was taken over can neither release nor complete the key of the new one
+	if err := keeper.Release(ctx, "user-1:key", stale); !errors.Is(err, ErrKeyLost) {
+		t.Fatalf("expected ErrKeyLost on release, got %v", err)
+	}
+	if err := keeper.Complete(ctx, "user-1:key", stale, []byte(`{"stale":true}`)); !errors.Is(err, ErrKeyLost) {
+		t.Fatalf("expected ErrKeyLost on complete, got %v", err)
+	}
+	if _, _, err := keeper.Begin(ctx, "user-1:key", "create-task"); !errors.Is(err, ErrKeyInProgress) {
+		t.Fatalf("expected the new request to still hold the key, got %v", err)
+	}
+
+	if err := keeper.Complete(ctx, "user-1:key", current, []byte(`{"current":true}`)); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	stored, _, err := keeper.Begin(ctx, "user-1:key", "create-task")
+	if err != nil || string(stored) != `{"current":true}` {
+		t.Fatalf("expected the response of the new request, got %q %v", stored, err)
+	}
+}
+
+func TestKeeperCompletedKeyIsNotTakenOver(t *testing.T) {
+	keeper, now := newTestKeeper()
+	ctx := context.Background()
+
+	owner := begin(t, keeper, "create-task")
+	if err := keeper.Complete(ctx, "user-1:key", owner, []byte(`{}`)); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	*now = now.Add(pendingTimeout + time.Second)
+	if stored, _, err := keeper.Begin(ctx, "user-1:key", "create-task"); err != nil || stored == nil {
+		t.Fatalf("expected the response to be replayed until the TTL, got %q %v", stored, err)
+	}
+
+	*now = now.Add(time.Hour)
+	begin(t, keeper, "create-task")
+}
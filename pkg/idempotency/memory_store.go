@@ -0,0 +1,81 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps records in process, keys are per replica
+type MemoryStore struct {
+	mu        sync.Mutex
+	records   map[string]*Record
+	lastSweep time.Time
+}
+
+// sweepInterval bounds how often expired records are evicted
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[string]*Record),
+	}
+}
+
+func (s *MemoryStore) Reserve(ctx context.Context, key string, fingerprint string, owner string, now time.Time, ttl time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	if record, ok := s.records[key]; ok && !record.available(now) {
+		existing := *record
+		return &existing, nil
+	}
+
+	s.records[key] = &Record{
+		Owner:       owner,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, key string, owner string, response []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok || record.Owner != owner {
+		return ErrKeyLost
+	}
+	record.Response = response
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok || record.Owner != owner {
+		return ErrKeyLost
+	}
+	delete(s.records, key)
+	return nil
+}
+
+// sweep drops the expired records
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
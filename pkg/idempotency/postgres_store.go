@@ -0,0 +1,102 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore keeps records in app.idempotency_keys, keys are shared by every replica
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{
+		pool: pool,
+	}
+}
+
+func (s *PostgresStore) Reserve(ctx context.Context, key string, fingerprint string, owner string, now time.Time, ttl time.Duration) (*Record, error) {
+	// Claim the key unless a live record holds it, expired and abandoned records are taken over
+	reserveQuery := `
+		INSERT INTO app.idempotency_keys (key, owner, fingerprint, created_at, expires_at)
+		VALUES (@key, @owner, @fingerprint, @now, @expires_at)
+		ON CONFLICT (key) DO UPDATE
+		SET owner = EXCLUDED.owner,
+			fingerprint = EXCLUDED.fingerprint,
+			response = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE app.idempotency_keys.expires_at <= @now
+			OR (app.idempotency_keys.response IS NULL AND app.idempotency_keys.created_at < @pending_before)
+		RETURNING key
+	`
+	var reserved string
+	err := s.pool.QueryRow(ctx, reserveQuery, pgx.NamedArgs{
+		"key":            key,
+		"owner":          owner,
+		"fingerprint":    fingerprint,
+		"now":            now,
+		"expires_at":     now.Add(ttl),
+		"pending_before": now.Add(-pendingTimeout),
+	}).Scan(&reserved)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	var record Record
+	selectQuery := `SELECT owner, fingerprint, response, created_at, expires_at FROM app.idempotency_keys WHERE key = @key`
+	err = s.pool.QueryRow(ctx, selectQuery, pgx.NamedArgs{"key": key}).Scan(&record.Owner, &record.Fingerprint, &record.Response, &record.CreatedAt, &record.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Released in the meantime, the key is free again
+		return s.Reserve(ctx, key, fingerprint, owner, now, ttl)
+	} else if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *PostgresStore) Complete(ctx context.Context, key string, owner string, response []byte) error {
+	query := `UPDATE app.idempotency_keys SET response = @response WHERE key = @key AND owner = @owner`
+	tag, err := s.pool.Exec(ctx, query, pgx.NamedArgs{
+		"key":      key,
+		"owner":    owner,
+		"response": response,
+	})
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrKeyLost
+	}
+	return nil
+}
+
+func (s *PostgresStore) Release(ctx context.Context, key string, owner string) error {
+	query := `DELETE FROM app.idempotency_keys WHERE key = @key AND owner = @owner`
+	tag, err := s.pool.Exec(ctx, query, pgx.NamedArgs{
+		"key":   key,
+		"owner": owner,
+	})
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrKeyLost
+	}
+	return nil
+}
+
+// Purge removes the expired records
+func (s *PostgresStore) Purge(ctx context.Context) error {
+	query := `DELETE FROM app.idempotency_keys WHERE expires_at <= @now`
+	_, err := s.pool.Exec(ctx, query, pgx.NamedArgs{"now": time.Now()})
+	return err
+}
@@ -0,0 +1,204 @@
+// Package oidctest serves a minimal OpenID Connect provider over httptest, for testing the single sign-on flow
+// without a real identity provider
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeyID is the kid of the signing key published in the JWKS
+const KeyID = "oidctest-key"
+
+// Provider issues ID tokens signed with its own RSA key. Its discovery document, JWKS and token endpoint
+// are served by an httptest server, the login at the authorization endpoint is played by Authorize
+type Provider struct {
+	*httptest.Server
+	ClientID string
+	Key      *rsa.PrivateKey
+
+	mu sync.Mutex
+	// claims are added to the ID tokens issued from now on, see SetClaims
+	claims jwt.MapClaims
+	codes  map[string]authorization
+}
+
+type authorization struct {
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+}
+
+// NewProvider starts the provider, the caller must Close it
+func NewProvider(clientID string) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Provider{
+		ClientID: clientID,
+		Key:      key,
+		claims:   jwt.MapClaims{},
+		codes:    make(map[string]authorization),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	mux.HandleFunc("POST /token", p.token)
+	p.Server = httptest.NewServer(mux)
+	return p, nil
+}
+
+// Issuer is the URL relying parties are configured with
+func (p *Provider) Issuer() string {
+	return p.URL
+}
+
+// SetClaims replaces the identity claims of the ID tokens issued from now on
+func (p *Provider) SetClaims(claims jwt.MapClaims) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.claims = claims
+}
+
+// Authorize plays the user logging in at the authorization endpoint,
+// it returns the code and the state the provider redirects back with
+func (p *Provider) Authorize(authURL string) (code string, state string, err error) {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	if !strings.HasPrefix(authURL, p.URL+"/authorize") {
+		return "", "", errors.New("oidctest: not an authorization URL of this provider")
+	}
+
+	params := parsed.Query()
+	if params.Get("response_type") != "code" || params.Get("client_id") != p.ClientID {
+		return "", "", errors.New("oidctest: invalid authorization request")
+	}
+	if params.Get("code_challenge_method") != "S256" || params.Get("code_challenge") == "" {
+		return "", "", errors.New("oidctest: PKCE is required")
+	}
+
+	code = randomString()
+	p.mu.Lock()
+	p.codes[code] = authorization{
+		nonce:         params.Get("nonce"),
+		codeChallenge: params.Get("code_challenge"),
+		redirectURI:   params.Get("redirect_uri"),
+	}
+	p.mu.Unlock()
+	return code, params.Get("state"), nil
+}
+
+// SignIDToken signs the claims with the key of the provider
+func (p *Provider) SignIDToken(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = KeyID
+	return token.SignedString(p.Key)
+}
+
+// IDTokenClaims returns valid registered claims for the given nonce, tests override some of them to break the token
+func (p *Provider) IDTokenClaims(nonce string) jwt.MapClaims {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   p.Issuer(),
+		"aud":   p.ClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": nonce,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for name, value := range p.claims {
+		claims[name] = value
+	}
+	return claims
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 p.Issuer(),
+		"authorization_endpoint": p.URL + "/authorize",
+		"token_endpoint":         p.URL + "/token",
+		"jwks_uri":               p.URL + "/jwks",
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kid": KeyID,
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(p.Key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.Key.E)).Bytes()),
+		}},
+	})
+}
+
+// token redeems a code once, the PKCE verifier must match the challenge of the authorization
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	p.mu.Lock()
+	auth, ok := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+
+	if !ok || r.PostForm.Get("client_id") != p.ClientID || r.PostForm.Get("redirect_uri") != auth.redirectURI ||
+		codeChallenge(r.PostForm.Get("code_verifier")) != auth.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	idToken, err := p.SignIDToken(p.IDTokenClaims(auth.nonce))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+		"expires_in":   300,
+	})
+}
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString() string {
+	raw := make([]byte, 16)
+	_, _ = rand.Read(raw)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
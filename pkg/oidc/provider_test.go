@@ -0,0 +1,248 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"bitbucket.org/edts/go-task-management/pkg/oidc"
+	"bitbucket.org/edts/go-task-management/pkg/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID    = "task-management"
+	testRedirectURL = "http://localhost:8080/auth/oidc/callback"
+)
+
+func newTestProvider(t *testing.T) (*oidctest.Provider, *oidc.Provider) {
+	t.Helper()
+
+	mock, err := oidctest.NewProvider(testClientID)
+	if err != nil {
+		t.Fatalf("starting the mock provider: %v", err)
+	}
+	t.Cleanup(mock.Close)
+
+	provider := oidc.NewProvider(oidc.Config{
+		IssuerURL:   mock.Issuer(),
+		ClientID:    testClientID,
+		RedirectURL: testRedirectURL,
+		HTTPClient:  mock.Client(),
+	})
+	return mock, provider
+}
+
+func TestAuthCodeURLUsesDiscoveredEndpoint(t *testing.T) {
+	mock, provider := newTestProvider(t)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", "nonce-1", oidc.CodeChallenge("verifier"))
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	if !strings.HasPrefix(authURL, mock.URL+"/authorize?") {
+		t.Fatalf("expected the discovered authorization endpoint, got %s", authURL)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parsing %s: %v", authURL, err)
+	}
+	params := parsed.Query()
+	expected := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        oidc.CodeChallenge("verifier"),
+		"code_challenge_method": "S256",
+	}
+	for name, value := range expected {
+		if params.Get(name) != value {
+			t.Errorf("%s: expected %q, got %q", name, value, params.Get(name))
+		}
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"issuer":"https://evil.example","authorization_endpoint":"https://evil.example/authorize",` +
+			`"token_endpoint":"https://evil.example/token","jwks_uri":"https://evil.example/jwks"}`))
+	}))
+	defer server.Close()
+
+	provider := oidc.NewProvider(oidc.Config{IssuerURL: server.URL, ClientID: testClientID, HTTPClient: server.Client()})
+	if _, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "challenge"); err == nil {
+		t.Fatal("expected the discovery document of another issuer to be rejected")
+	}
+}
+
+func TestExchangeRedeemsCodeWithVerifier(t *testing.T) {
+	mock, provider := newTestProvider(t)
+	mock.SetClaims(jwt.MapClaims{"sub": "user-1", "email": "jane@example.com", "email_verified": true})
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce-1", oidc.CodeChallenge("verifier"))
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code, _, err := mock.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+
+	token, err := provider.Exchange(ctx, code, "verifier")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, token.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if claims.Subject != "user-1" || claims.Email != "jane@example.com" || !claims.IsEmailVerified() {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+
+	// Codes are single use
+	if _, err = provider.Exchange(ctx, code, "verifier"); err == nil {
+		t.Fatal("expected a redeemed code to be rejected")
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	mock, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", oidc.CodeChallenge("verifier"))
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code, _, err := mock.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+
+	if _, err = provider.Exchange(ctx, code, "another-verifier"); err == nil {
+		t.Fatal("expected the exchange to fail when the PKCE verifier does not match")
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	mock, provider := newTestProvider(t)
+	mock.SetClaims(jwt.MapClaims{"sub": "user-1"})
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating a key: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   func() (string, error)
+		nonce   string
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			token: func() (string, error) { return mock.SignIDToken(mock.IDTokenClaims("nonce-1")) },
+			nonce: "nonce-1",
+		},
+		{
+			name:    "nonce mismatch",
+			token:   func() (string, error) { return mock.SignIDToken(mock.IDTokenClaims("nonce-1")) },
+			nonce:   "nonce-2",
+			wantErr: true,
+		},
+		{
+			name: "other audience",
+			token: func() (string, error) {
+				claims := mock.IDTokenClaims("nonce-1")
+				claims["aud"] = "another-client"
+				return mock.SignIDToken(claims)
+			},
+			nonce:   "nonce-1",
+			wantErr: true,
+		},
+		{
+			name: "other issuer",
+			token: func() (string, error) {
+				claims := mock.IDTokenClaims("nonce-1")
+				claims["iss"] = "https://evil.example"
+				return mock.SignIDToken(claims)
+			},
+			nonce:   "nonce-1",
+			wantErr: true,
+		},
+		{
+			name: "expired",
+			token: func() (string, error) {
+				claims := mock.IDTokenClaims("nonce-1")
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+				return mock.SignIDToken(claims)
+			},
+			nonce:   "nonce-1",
+			wantErr: true,
+		},
+		{
+			name: "missing subject",
+			token: func() (string, error) {
+				claims := mock.IDTokenClaims("nonce-1")
+				delete(claims, "sub")
+				return mock.SignIDToken(claims)
+			},
+			nonce:   "nonce-1",
+			wantErr: true,
+		},
+		{
+			name: "signed by another key",
+			token: func() (string, error) {
+				token := jwt.NewWithClaims(jwt.SigningMethodRS256, mock.IDTokenClaims("nonce-1"))
+				token.Header["kid"] = oidctest.KeyID
+				return token.SignedString(otherKey)
+			},
+			nonce:   "nonce-1",
+			wantErr: true,
+		},
+		{
+			name: "symmetric algorithm",
+			token: func() (string, error) {
+				return jwt.NewWithClaims(jwt.SigningMethodHS256, mock.IDTokenClaims("nonce-1")).SignedString([]byte("secret"))
+			},
+			nonce:   "nonce-1",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawToken, err := tt.token()
+			if err != nil {
+				t.Fatalf("signing the token: %v", err)
+			}
+
+			claims, err := provider.VerifyIDToken(context.Background(), rawToken, tt.nonce)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got claims %+v", claims)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyIDToken: %v", err)
+			}
+			if claims.Subject != "user-1" {
+				t.Fatalf("expected subject user-1, got %q", claims.Subject)
+			}
+		})
+	}
+}
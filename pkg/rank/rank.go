@@ -0,0 +1,130 @@
+// Package rank generates lexicographic sort keys, a key can always be generated between two others
+// so reordering an item only rewrites the key of that item.
+//
+// Keys are base 62 fractions without their leading "0.", they never end with the zero digit
+// which keeps room below every key. Keys compare with plain byte ordering (COLLATE "C").
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const base = uint64(len(digits))
+
+var (
+	ErrInvalidKey   = errors.New("rank: invalid key")
+	ErrInvalidRange = errors.New("rank: lower key must sort before upper key")
+)
+
+// Between returns a key sorting after lower and before upper,
+// an empty lower means the start of the list and an empty upper its end
+func Between(lower string, upper string) (string, error) {
+	if !isValid(lower) || !isValid(upper) {
+		return "", ErrInvalidKey
+	}
+	if upper != "" && lower >= upper {
+		return "", ErrInvalidRange
+	}
+	return midpoint(lower, upper), nil
+}
+
+// Spread returns n evenly spaced keys in ascending order, used to rebalance a list whose keys grew long
+func Spread(n int) []string {
+	if n <= 0 {
+		return nil
+	}
+
+	// Use one more digit than needed to count the items so every gap can take many inserts
+	length := 1
+	for capacity := base; capacity <= uint64(n); capacity *= base {
+		length++
+	}
+	length++
+
+	space := uint64(1)
+	for i := 0; i < length; i++ {
+		space *= base
+	}
+	step := space / uint64(n+1)
+
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = encode(step*uint64(i+1), length)
+	}
+	return keys
+}
+
+// midpoint follows the fractional indexing algorithm, upper is empty when unbounded
+func midpoint(lower string, upper string) string {
+	if upper != "" {
+		// Keep the common prefix, lower is padded with zeros
+		n := 0
+		for n < len(upper) && digitAt(lower, n) == upper[n] {
+			n++
+		}
+		if n > 0 {
+			return upper[:n] + midpoint(suffix(lower, n), upper[n:])
+		}
+	}
+
+	digitLower := 0
+	if lower != "" {
+		digitLower = strings.IndexByte(digits, lower[0])
+	}
+	digitUpper := int(base)
+	if upper != "" {
+		digitUpper = strings.IndexByte(digits, upper[0])
+	}
+
+	if digitUpper-digitLower > 1 {
+		return string(digits[(digitLower+digitUpper+1)/2])
+	}
+
+	// The first digits are consecutive
+	if len(upper) > 1 {
+		return upper[:1]
+	}
+	return string(digits[digitLower]) + midpoint(suffix(lower, 1), "")
+}
+
+// encode writes value as a key of the given length and drops the trailing zeros
+func encode(value uint64, length int) string {
+	key := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		key[i] = digits[value%base]
+		value /= base
+	}
+	return strings.TrimRight(string(key), digits[:1])
+}
+
+func digitAt(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return digits[0]
+}
+
+func suffix(key string, i int) string {
+	if i < len(key) {
+		return key[i:]
+	}
+	return ""
+}
+
+func isValid(key string) bool {
+	if key == "" {
+		return true
+	}
+	if key[len(key)-1] == digits[0] {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+package rank
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name  string
+		lower string
+		upper string
+		want  string
+		err   error
+	}{
+		{name: "empty list", lower: "", upper: "", want: "V"},
+		{name: "before the first key", lower: "", upper: "V", want: "G"},
+		{name: "after the last key", lower: "V", upper: "", want: "l"},
+		{name: "wide gap", lower: "A", upper: "C", want: "B"},
+		{name: "consecutive digits", lower: "A", upper: "B", want: "AV"},
+		{name: "common prefix", lower: "AB", upper: "AD", want: "AC"},
+		{name: "upper longer than lower", lower: "A", upper: "AB", want: "A6"},
+		{name: "upper with more digits", lower: "A", upper: "BC", want: "B"},
+		{name: "last digit", lower: "z", upper: "", want: "zV"},
+		{name: "invalid digit", lower: "A-", upper: "", err: ErrInvalidKey},
+		{name: "trailing zero", lower: "A0", upper: "", err: ErrInvalidKey},
+		{name: "equal keys", lower: "A", upper: "A", err: ErrInvalidRange},
+		{name: "reversed keys", lower: "B", upper: "A", err: ErrInvalidRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Between(tt.lower, tt.upper)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestBetweenKeepsOrder inserts at random positions and checks the keys stay valid and strictly ordered
+func TestBetweenKeepsOrder(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	keys := []string{}
+	for i := 0; i < 2000; i++ {
+		position := random.Intn(len(keys) + 1)
+		lower, upper := "", ""
+		if position > 0 {
+			lower = keys[position-1]
+		}
+		if position < len(keys) {
+			upper = keys[position]
+		}
+
+		key, err := Between(lower, upper)
+		if err != nil {
+			t.Fatalf("Between(%q, %q): %v", lower, upper, err)
+		}
+		if !isValid(key) || key <= lower || (upper != "" && key >= upper) {
+			t.Fatalf("Between(%q, %q) returned %q", lower, upper, key)
+		}
+		keys = append(keys[:position], append([]string{key}, keys[position:]...)...)
+	}
+}
+
+func TestBetweenRepeatedAtTheTop(t *testing.T) {
+	upper := ""
+	for i := 0; i < 500; i++ {
+		key, err := Between("", upper)
+		if err != nil {
+			t.Fatalf("Between(\"\", %q): %v", upper, err)
+		}
+		if upper != "" && key >= upper {
+			t.Fatalf("expected %q to sort before %q", key, upper)
+		}
+		upper = key
+	}
+}
+
+func TestSpread(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 61, 62, 1000} {
+		keys := Spread(n)
+		if len(keys) != n {
+			t.Fatalf("Spread(%d) returned %d keys", n, len(keys))
+		}
+		if !sort.StringsAreSorted(keys) {
+			t.Fatalf("Spread(%d) keys are not sorted", n)
+		}
+		for i, key := range keys {
+			if key == "" || !isValid(key) {
+				t.Fatalf("Spread(%d) returned invalid key %q", n, key)
+			}
+			if i > 0 && keys[i-1] == key {
+				t.Fatalf("Spread(%d) returned %q twice", n, key)
+			}
+		}
+		// Every gap, including the ones at both ends, still takes a new key
+		for i := 0; i <= len(keys); i++ {
+			lower, upper := "", ""
+			if i > 0 {
+				lower = keys[i-1]
+			}
+			if i < len(keys) {
+				upper = keys[i]
+			}
+			if _, err := Between(lower, upper); err != nil {
+				t.Fatalf("Spread(%d) leaves no room between %q and %q: %v", n, lower, upper, err)
+			}
+		}
+	}
+}
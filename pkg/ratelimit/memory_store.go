@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps buckets in process, limits are per replica
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+// memoryBucket remembers when the bucket is full again under its own rule, the zero time when it never refills
+type memoryBucket struct {
+	*bucket
+	fullAt time.Time
+}
+
+// sweepInterval bounds how often idle buckets are evicted
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*memoryBucket),
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{bucket: newBucket(rule, now)}
+		s.buckets[key] = b
+	}
+	result := b.take(rule, now)
+
+	b.fullAt = time.Time{}
+	if rule.Rate > 0 {
+		b.fullAt = now.Add(time.Duration((float64(rule.Burst) - b.tokens) / rule.Rate * float64(time.Second)))
+	}
+	return result, nil
+}
+
+// sweep drops buckets that have been refilled completely, they are equal to a new bucket
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if !b.fullAt.IsZero() && now.After(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore keeps buckets in app.rate_limit_buckets, limits are shared by every replica
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{
+		pool: pool,
+	}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Make sure the row exists so it can be locked, concurrent requests wait on the row lock
+	insertQuery := `
+		INSERT INTO app.rate_limit_buckets (key, tokens, updated_at)
+		VALUES (@key, @tokens, @updated_at)
+		ON CONFLICT (key) DO NOTHING
+	`
+	if _, err = tx.Exec(ctx, insertQuery, pgx.NamedArgs{
+		"key":        key,
+		"tokens":     float64(rule.Burst),
+		"updated_at": now,
+	}); err != nil {
+		return Result{}, err
+	}
+
+	var b bucket
+	selectQuery := `SELECT tokens, updated_at FROM app.rate_limit_buckets WHERE key = @key FOR UPDATE`
+	err = tx.QueryRow(ctx, selectQuery, pgx.NamedArgs{"key": key}).Scan(&b.tokens, &b.updatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		b = *newBucket(rule, now)
+	} else if err != nil {
+		return Result{}, err
+	}
+
+	result := b.take(rule, now)
+
+	updateQuery := `UPDATE app.rate_limit_buckets SET tokens = @tokens, updated_at = @updated_at WHERE key = @key`
+	if _, err = tx.Exec(ctx, updateQuery, pgx.NamedArgs{
+		"key":        key,
+		"tokens":     b.tokens,
+		"updated_at": b.updatedAt,
+	}); err != nil {
+		return Result{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+// Purge removes buckets idle for longer than the given duration
+func (s *PostgresStore) Purge(ctx context.Context, idleFor time.Duration) error {
+	query := `DELETE FROM app.rate_limit_buckets WHERE updated_at < @before`
+	_, err := s.pool.Exec(ctx, query, pgx.NamedArgs{"before": time.Now().Add(-idleFor)})
+	return err
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Rule configures a token bucket, Rate tokens are refilled every second up to Burst tokens
+type Rule struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute builds a rule refilling the given amount of requests every minute
+func PerMinute(requests float64, burst int) Rule {
+	return Rule{Rate: requests / 60, Burst: burst}
+}
+
+// Result of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets state, implementations must make Take atomic per key
+type Store interface {
+	Take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error)
+}
+
+// Limiter applies the named rules on top of a pluggable store
+type Limiter struct {
+	store Store
+	rules map[string]Rule
+}
+
+func NewLimiter(store Store, rules map[string]Rule) *Limiter {
+	return &Limiter{
+		store: store,
+		rules: rules,
+	}
+}
+
+// Allow takes a token of the named bucket for the given key (e.g. the client IP),
+// buckets without a configured rule are never limited
+func (l *Limiter) Allow(ctx context.Context, bucket string, key string) (Result, error) {
+	rule, ok := l.rules[bucket]
+	if !ok || rule.Burst <= 0 {
+		return Result{Allowed: true}, nil
+	}
+	return l.store.Take(ctx, bucket+":"+key, rule, time.Now())
+}
+
+// bucket is the token bucket state shared by the store implementations
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// take refills the bucket for the elapsed time then consumes a token if one is available
+func (b *bucket) take(rule Rule, now time.Time) Result {
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(rule.Burst), b.tokens+elapsed*rule.Rate)
+	}
+	b.updatedAt = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return Result{Allowed: true}
+	}
+
+	retryAfter := time.Duration(math.MaxInt64)
+	if rule.Rate > 0 {
+		retryAfter = time.Duration((1 - b.tokens) / rule.Rate * float64(time.Second))
+	}
+	return Result{Allowed: false, RetryAfter: retryAfter}
+}
+
+func newBucket(rule Rule, now time.Time) *bucket {
+	return &bucket{tokens: float64(rule.Burst), updatedAt: now}
+}
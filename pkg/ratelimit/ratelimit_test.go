@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	store := NewMemoryStore()
+	rule := PerMinute(60, 2) // one token per second, two at most
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name       string
+		at         time.Duration
+		allowed    bool
+		retryAfter time.Duration
+	}{
+		{name: "first token of the burst", at: 0, allowed: true},
+		{name: "second token of the burst", at: 0, allowed: true},
+		{name: "burst exhausted", at: 0, allowed: false, retryAfter: time.Second},
+		{name: "half refilled", at: 500 * time.Millisecond, allowed: false, retryAfter: 500 * time.Millisecond},
+		{name: "one token refilled", at: time.Second, allowed: true},
+		{name: "refill is capped by the burst", at: time.Hour, allowed: true},
+		{name: "second token after the long pause", at: time.Hour, allowed: true},
+		{name: "nothing left after the long pause", at: time.Hour, allowed: false, retryAfter: time.Second},
+	}
+
+	for _, step := range steps {
+		result, err := store.Take(context.Background(), "login:203.0.113.7", rule, now.Add(step.at))
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if result.Allowed != step.allowed {
+			t.Fatalf("%s: expected allowed %v, got %v", step.name, step.allowed, result.Allowed)
+		}
+		if result.RetryAfter != step.retryAfter {
+			t.Fatalf("%s: expected retry after %s, got %s", step.name, step.retryAfter, result.RetryAfter)
+		}
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	store := NewMemoryStore()
+	rule := PerMinute(1, 1)
+	now := time.Now()
+
+	if result, _ := store.Take(context.Background(), "login:a", rule, now); !result.Allowed {
+		t.Fatal("expected the first request of a to be allowed")
+	}
+	if result, _ := store.Take(context.Background(), "login:a", rule, now); result.Allowed {
+		t.Fatal("expected the second request of a to be limited")
+	}
+	if result, _ := store.Take(context.Background(), "login:b", rule, now); !result.Allowed {
+		t.Fatal("expected b to have its own bucket")
+	}
+}
+
+func TestMemoryStoreSweepsFullBuckets(t *testing.T) {
+	store := NewMemoryStore()
+	slow := PerMinute(0.5, 1)
+	fast := PerMinute(600, 1)
+	now := time.Now()
+
+	store.Take(context.Background(), "slow", slow, now)
+	store.Take(context.Background(), "fast", fast, now)
+
+	// The fast bucket is full again after 100ms, the slow one needs two minutes
+	store.Take(context.Background(), "other", fast, now.Add(sweepInterval))
+	if _, ok := store.buckets["fast"]; ok {
+		t.Fatal("expected the refilled fast bucket to be swept")
+	}
+	if _, ok := store.buckets["slow"]; !ok {
+		t.Fatal("expected the slow bucket to be kept until it is refilled")
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), map[string]Rule{
+		"login":    PerMinute(1, 1),
+		"disabled": PerMinute(1, 0),
+	})
+
+	for _, bucket := range []string{"unknown", "disabled"} {
+		for i := 0; i < 3; i++ {
+			if result, err := limiter.Allow(context.Background(), bucket, "203.0.113.7"); err != nil || !result.Allowed {
+				t.Fatalf("expected bucket %s to never be limited", bucket)
+			}
+		}
+	}
+
+	if result, _ := limiter.Allow(context.Background(), "login", "203.0.113.7"); !result.Allowed {
+		t.Fatal("expected the first login to be allowed")
+	}
+	if result, _ := limiter.Allow(context.Background(), "login", "203.0.113.7"); result.Allowed {
+		t.Fatal("expected the second login to be limited")
+	}
+	if result, _ := limiter.Allow(context.Background(), "login", "198.51.100.1"); !result.Allowed {
+		t.Fatal("expected another client to be allowed")
+	}
+}
@@ -0,0 +1,350 @@
+// Package rrule reads the subset of iCalendar recurrence rules (RFC 5545) used by recurring tasks:
+// daily, weekly on days and monthly by day, with an interval and a COUNT or UNTIL end condition.
+//
+// Occurrences keep the clock time and the location of the start of the series. A start that does not
+// match the rule is not an occurrence itself, the first occurrence is the first match on or after it.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// maxPeriods bounds the search for the next occurrence, a rule matching nothing never loops forever
+const maxPeriods = 50000
+
+const untilLayout = "20060102T150405Z"
+
+var ErrInvalidRule = errors.New("rrule: invalid rule")
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// WeekdayNum is a BYDAY entry, N is the ordinal inside the month (2TU, -1FR) and zero for every such day
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	Count      int
+	Until      *time.Time
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=10", an "RRULE:" prefix is accepted
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty rule", ErrInvalidRule)
+	}
+
+	rule := &Rule{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %s is repeated", ErrInvalidRule, name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			rule.Freq = Frequency(value)
+			if rule.Freq != Daily && rule.Freq != Weekly && rule.Freq != Monthly {
+				return nil, fmt.Errorf("%w: unsupported frequency %s", ErrInvalidRule, value)
+			}
+		case "INTERVAL":
+			rule.Interval, err = parsePositive(name, value)
+		case "COUNT":
+			rule.Count, err = parsePositive(name, value)
+		case "UNTIL":
+			rule.Until, err = parseUntil(value)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseByMonthDay(value)
+		case "WKST":
+			if value != "MO" {
+				return nil, fmt.Errorf("%w: weeks start on Monday", ErrInvalidRule)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %s", ErrInvalidRule, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rule.validate(); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (r *Rule) validate() error {
+	if r.Freq == "" {
+		return fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if r.Count > 0 && r.Until != nil {
+		return fmt.Errorf("%w: COUNT and UNTIL cannot be combined", ErrInvalidRule)
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq != Monthly {
+		return fmt.Errorf("%w: BYMONTHDAY needs a monthly frequency", ErrInvalidRule)
+	}
+	if len(r.ByMonthDay) > 0 && len(r.ByDay) > 0 {
+		return fmt.Errorf("%w: BYDAY and BYMONTHDAY cannot be combined", ErrInvalidRule)
+	}
+	for _, day := range r.ByDay {
+		if day.N != 0 && r.Freq != Monthly {
+			return fmt.Errorf("%w: a BYDAY ordinal needs a monthly frequency", ErrInvalidRule)
+		}
+	}
+	return nil
+}
+
+// String formats the rule back in its canonical form
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = formatWeekday(day)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence of the series started at dtstart strictly after the given time,
+// false once the series has ended
+func (r *Rule) Next(dtstart time.Time, after time.Time) (time.Time, bool) {
+	count := 0
+	for period := 0; period < maxPeriods; period++ {
+		for _, occurrence := range r.candidates(dtstart, period) {
+			if occurrence.Before(dtstart) {
+				continue
+			}
+			count++
+			if r.Count > 0 && count > r.Count {
+				return time.Time{}, false
+			}
+			if r.Until != nil && occurrence.After(*r.Until) {
+				return time.Time{}, false
+			}
+			if occurrence.After(after) {
+				return occurrence, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// candidates lists in order the occurrences of the nth period of the series
+func (r *Rule) candidates(dtstart time.Time, period int) []time.Time {
+	step := period * r.Interval
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		day := date(dtstart).AddDate(0, 0, step)
+		if r.matchesWeekday(day.Weekday()) {
+			days = append(days, day)
+		}
+	case Weekly:
+		start := date(dtstart)
+		monday := start.AddDate(0, 0, -((int(start.Weekday())+6)%7)).AddDate(0, 0, 7*step)
+		for offset := 0; offset < 7; offset++ {
+			day := monday.AddDate(0, 0, offset)
+			if len(r.ByDay) == 0 && day.Weekday() == dtstart.Weekday() || len(r.ByDay) > 0 && r.matchesWeekday(day.Weekday()) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		first := time.Date(dtstart.Year(), dtstart.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, step, 0)
+		days = r.monthDays(first, dtstart.Day())
+	}
+
+	occurrences := make([]time.Time, len(days))
+	for i, day := range days {
+		occurrences[i] = time.Date(day.Year(), day.Month(), day.Day(),
+			dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, dtstart.Location())
+	}
+	return occurrences
+}
+
+// monthDays lists the matching days of the month starting at first, months lacking a day are skipped
+func (r *Rule) monthDays(first time.Time, startDay int) []time.Time {
+	length := first.AddDate(0, 1, -1).Day()
+	matches := map[int]bool{}
+
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, day := range r.ByMonthDay {
+			if day < 0 {
+				day = length + day + 1
+			}
+			if day >= 1 && day <= length {
+				matches[day] = true
+			}
+		}
+	case len(r.ByDay) > 0:
+		for _, weekday := range r.ByDay {
+			// Day of the month of the first such weekday
+			firstDay := 1 + (int(weekday.Weekday)-int(first.Weekday())+7)%7
+			var days []int
+			for day := firstDay; day <= length; day += 7 {
+				days = append(days, day)
+			}
+			switch {
+			case weekday.N == 0:
+				for _, day := range days {
+					matches[day] = true
+				}
+			case weekday.N > 0 && weekday.N <= len(days):
+				matches[days[weekday.N-1]] = true
+			case weekday.N < 0 && -weekday.N <= len(days):
+				matches[days[len(days)+weekday.N]] = true
+			}
+		}
+	default:
+		if startDay <= length {
+			matches[startDay] = true
+		}
+	}
+
+	days := make([]time.Time, 0, len(matches))
+	for day := range matches {
+		days = append(days, first.AddDate(0, 0, day-1))
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+func (r *Rule) matchesWeekday(weekday time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, day := range r.ByDay {
+		if day.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// date drops the clock of t, days are counted in UTC to stay clear of daylight saving shifts
+func date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func parsePositive(name string, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%w: %s must be a positive number", ErrInvalidRule, name)
+	}
+	return n, nil
+}
+
+// parseUntil accepts a UTC date-time or a date, a date includes its whole day
+func parseUntil(value string) (*time.Time, error) {
+	if until, err := time.Parse(untilLayout, value); err == nil {
+		return &until, nil
+	}
+	day, err := time.Parse("20060102", value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: UNTIL must be a UTC date-time such as 20261231T235959Z", ErrInvalidRule)
+	}
+	until := day.Add(24*time.Hour - time.Second)
+	return &until, nil
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("%w: invalid BYDAY value %q", ErrInvalidRule, item)
+		}
+		weekday, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid BYDAY value %q", ErrInvalidRule, item)
+		}
+		day := WeekdayNum{Weekday: weekday}
+		if ordinal := item[:len(item)-2]; ordinal != "" {
+			n, err := strconv.Atoi(ordinal)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("%w: invalid BYDAY ordinal %q", ErrInvalidRule, item)
+			}
+			day.N = n
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func parseByMonthDay(value string) ([]int, error) {
+	var days []int
+	for _, item := range strings.Split(value, ",") {
+		day, err := strconv.Atoi(item)
+		if err != nil || day == 0 || day < -31 || day > 31 {
+			return nil, fmt.Errorf("%w: invalid BYMONTHDAY value %q", ErrInvalidRule, item)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func formatWeekday(day WeekdayNum) string {
+	for name, weekday := range weekdays {
+		if weekday == day.Weekday {
+			if day.N != 0 {
+				return strconv.Itoa(day.N) + name
+			}
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,241 @@
+package rrule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func at(value string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// occurrences lists up to limit occurrences of the series from its start
+func occurrences(rule *Rule, dtstart time.Time, limit int) []time.Time {
+	var list []time.Time
+	after := dtstart.Add(-time.Second)
+	for len(list) < limit {
+		next, ok := rule.Next(dtstart, after)
+		if !ok {
+			break
+		}
+		list = append(list, next)
+		after = next
+	}
+	return list
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart string
+		want    []string
+	}{
+		{
+			name:    "daily with a count",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: "2026-01-01 09:00",
+			want:    []string{"2026-01-01 09:00", "2026-01-02 09:00", "2026-01-03 09:00"},
+		},
+		{
+			name:    "daily every other day on weekdays",
+			rule:    "FREQ=DAILY;INTERVAL=2;BYDAY=MO,WE,FR",
+			dtstart: "2026-01-05 09:00",
+			want:    []string{"2026-01-05 09:00", "2026-01-07 09:00", "2026-01-09 09:00", "2026-01-19 09:00"},
+		},
+		{
+			name:    "daily until a date-time, the end is included",
+			rule:    "FREQ=DAILY;UNTIL=20260103T090000Z",
+			dtstart: "2026-01-01 09:00",
+			want:    []string{"2026-01-01 09:00", "2026-01-02 09:00", "2026-01-03 09:00"},
+		},
+		{
+			name:    "daily until a date, the whole day is included",
+			rule:    "FREQ=DAILY;UNTIL=20260102",
+			dtstart: "2026-01-01 23:30",
+			want:    []string{"2026-01-01 23:30", "2026-01-02 23:30"},
+		},
+		{
+			name:    "weekly on the day of the start",
+			rule:    "FREQ=WEEKLY;INTERVAL=2",
+			dtstart: "2026-01-07 14:00",
+			want:    []string{"2026-01-07 14:00", "2026-01-21 14:00", "2026-02-04 14:00"},
+		},
+		{
+			name:    "weekly by day with a count",
+			rule:    "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=4",
+			dtstart: "2026-01-01 10:00",
+			want:    []string{"2026-01-01 10:00", "2026-01-05 10:00", "2026-01-08 10:00", "2026-01-12 10:00"},
+		},
+		{
+			name:    "weekly until a date",
+			rule:    "FREQ=WEEKLY;BYDAY=TU;UNTIL=20260120T000000Z",
+			dtstart: "2026-01-01 10:00",
+			want:    []string{"2026-01-06 10:00", "2026-01-13 10:00"},
+		},
+		{
+			// RFC 5545 counts DTSTART as the first occurrence, the series only counts the matches of the rule
+			name:    "start not matching the rule is not an occurrence",
+			rule:    "FREQ=WEEKLY;BYDAY=MO;COUNT=2",
+			dtstart: "2026-01-01 10:00",
+			want:    []string{"2026-01-05 10:00", "2026-01-12 10:00"},
+		},
+		{
+			name:    "monthly on the day of the start skips the months lacking it",
+			rule:    "FREQ=MONTHLY",
+			dtstart: "2026-01-31 08:00",
+			want:    []string{"2026-01-31 08:00", "2026-03-31 08:00", "2026-05-31 08:00"},
+		},
+		{
+			name:    "monthly on the 31st",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=31;COUNT=4",
+			dtstart: "2026-01-01 08:00",
+			want:    []string{"2026-01-31 08:00", "2026-03-31 08:00", "2026-05-31 08:00", "2026-07-31 08:00"},
+		},
+		{
+			name:    "monthly on the last day",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-1",
+			dtstart: "2026-01-15 08:00",
+			want:    []string{"2026-01-31 08:00", "2026-02-28 08:00", "2026-03-31 08:00", "2026-04-30 08:00"},
+		},
+		{
+			name:    "monthly on several days",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=1,15;UNTIL=20260215T235959Z",
+			dtstart: "2026-01-10 08:00",
+			want:    []string{"2026-01-15 08:00", "2026-02-01 08:00", "2026-02-15 08:00"},
+		},
+		{
+			name:    "monthly on the second tuesday",
+			rule:    "FREQ=MONTHLY;BYDAY=2TU",
+			dtstart: "2026-01-01 09:00",
+			want:    []string{"2026-01-13 09:00", "2026-02-10 09:00", "2026-03-10 09:00"},
+		},
+		{
+			name:    "monthly on the last friday every other month",
+			rule:    "FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR",
+			dtstart: "2026-01-01 17:00",
+			want:    []string{"2026-01-30 17:00", "2026-03-27 17:00", "2026-05-29 17:00"},
+		},
+		{
+			name:    "monthly on every monday",
+			rule:    "FREQ=MONTHLY;BYDAY=MO;COUNT=5",
+			dtstart: "2026-02-01 09:00",
+			want:    []string{"2026-02-02 09:00", "2026-02-09 09:00", "2026-02-16 09:00", "2026-02-23 09:00", "2026-03-02 09:00"},
+		},
+		{
+			name:    "until before the first match",
+			rule:    "FREQ=WEEKLY;BYDAY=FR;UNTIL=20260101T000000Z",
+			dtstart: "2026-01-01 09:00",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+
+			// A series with an end must stop right after its last occurrence, the others go on
+			got := occurrences(rule, at(tt.dtstart), len(tt.want)+1)
+			if rule.Count == 0 && rule.Until == nil {
+				got = got[:len(tt.want)]
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d occurrences, got %v", len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				if !got[i].Equal(at(want)) {
+					t.Fatalf("occurrence %d: expected %s, got %s", i, want, got[i].Format("2006-01-02 15:04"))
+				}
+			}
+		})
+	}
+}
+
+func TestNextAfterAMissedPeriod(t *testing.T) {
+	rule, err := Parse("FREQ=DAILY;COUNT=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dtstart := at("2026-01-01 09:00")
+
+	// Occurrences before the given time are skipped but still counted
+	next, ok := rule.Next(dtstart, at("2026-01-05 12:00"))
+	if !ok || !next.Equal(at("2026-01-06 09:00")) {
+		t.Fatalf("expected 2026-01-06 09:00, got %s %v", next, ok)
+	}
+	if _, ok = rule.Next(dtstart, at("2026-01-10 09:00")); ok {
+		t.Fatal("expected the series to end after its tenth occurrence")
+	}
+}
+
+func TestNextKeepsTheLocationOfTheStart(t *testing.T) {
+	location := time.FixedZone("UTC+7", 7*60*60)
+	rule, err := Parse("FREQ=DAILY")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dtstart := time.Date(2026, 1, 1, 8, 30, 0, 0, location)
+	next, ok := rule.Next(dtstart, dtstart)
+	if !ok || !next.Equal(time.Date(2026, 1, 2, 8, 30, 0, 0, location)) {
+		t.Fatalf("expected 2026-01-02 08:30 UTC+7, got %s", next)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{rule: "FREQ=DAILY", want: "FREQ=DAILY"},
+		{rule: "RRULE:freq=weekly;byday=mo,th;interval=2", want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH"},
+		{rule: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3", want: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3"},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=1,-1;WKST=MO", want: "FREQ=MONTHLY;BYMONTHDAY=1,-1"},
+		{rule: "FREQ=DAILY;UNTIL=20261231", want: "FREQ=DAILY;UNTIL=20261231T235959Z"},
+	}
+
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.rule, err)
+		}
+		if got := rule.String(); got != tt.want {
+			t.Fatalf("Parse(%q): expected %s, got %s", tt.rule, tt.want, got)
+		}
+	}
+}
+
+func TestParseRejectsInvalidRules(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"COUNT=3",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;INTERVAL=-1",
+		"FREQ=DAILY;COUNT=2;UNTIL=20260101",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYDAY=MO;BYMONTHDAY=1",
+		"FREQ=DAILY;WKST=SU",
+		"FREQ=DAILY;BYHOUR=9",
+		"FREQ=DAILY;COUNT",
+	} {
+		if _, err := Parse(rule); !errors.Is(err, ErrInvalidRule) {
+			t.Fatalf("expected %q to be rejected, got %v", rule, err)
+		}
+	}
+}